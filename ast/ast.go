@@ -1,7 +1,11 @@
 // Package ast defines the abstract syntax tree representation of Risor code.
 package ast
 
-import "github.com/risor-io/risor/token"
+import (
+	"strings"
+
+	"github.com/risor-io/risor/token"
+)
 
 // Node represents a portion of the syntax tree. All nodes have a token, which is
 // the token that begins the node. A Node may be an Expression, in which case
@@ -40,3 +44,31 @@ type Expression interface {
 	// ExpressionNode signals that this Node is an expression.
 	ExpressionNode()
 }
+
+// TypeAnnotation is an optional type hint attached to a function parameter,
+// a function return value, or a variable. Annotations are recorded for use by
+// tooling such as the static checker and are ignored by the compiler.
+type TypeAnnotation struct {
+	// the token of the first type name
+	token token.Token
+
+	// names holds one or more type names. More than one name indicates a
+	// union, as in "int | nil".
+	names []string
+}
+
+// NewTypeAnnotation creates a new TypeAnnotation node.
+func NewTypeAnnotation(token token.Token, names []string) *TypeAnnotation {
+	return &TypeAnnotation{token: token, names: names}
+}
+
+func (t *TypeAnnotation) IsExpression() bool { return false }
+
+func (t *TypeAnnotation) Token() token.Token { return t.token }
+
+func (t *TypeAnnotation) Literal() string { return t.token.Literal }
+
+// Names returns the type names in this annotation.
+func (t *TypeAnnotation) Names() []string { return t.names }
+
+func (t *TypeAnnotation) String() string { return strings.Join(t.names, " | ") }
@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"fmt"
 	"strings"
 
 	"github.com/risor-io/risor/token"
@@ -66,6 +67,33 @@ func (p *Prefix) String() string {
 	return out.String()
 }
 
+// Spread is an expression that expands an iterable into the surrounding call
+// arguments or list literal, or a map into the surrounding map literal.
+// Examples include "f(...args)" and "[1, ...rest]".
+type Spread struct {
+	token token.Token
+
+	// value is the iterable or map being expanded
+	value Expression
+}
+
+// NewSpread creates a new Spread node.
+func NewSpread(token token.Token, value Expression) *Spread {
+	return &Spread{token: token, value: value}
+}
+
+func (s *Spread) ExpressionNode() {}
+
+func (s *Spread) IsExpression() bool { return true }
+
+func (s *Spread) Token() token.Token { return s.token }
+
+func (s *Spread) Literal() string { return s.token.Literal }
+
+func (s *Spread) Value() Expression { return s.value }
+
+func (s *Spread) String() string { return "..." + s.value.String() }
+
 // Infix is an operator expression where the operator is between the operands.
 // Examples include "x + y" and "5 - 1".
 type Infix struct {
@@ -199,9 +227,10 @@ func (t *Ternary) String() string {
 
 // Call is an expression node that describes the invocation of a function.
 type Call struct {
-	token     token.Token // the '(' token
-	function  Expression  // the function being called
-	arguments []Node      // the arguments supplied to the call
+	token     token.Token   // the '(' token
+	function  Expression    // the function being called
+	arguments []Node        // the arguments supplied to the call
+	keywords  []*KeywordArg // the keyword arguments supplied to the call
 }
 
 // NewCall creates a new Call node.
@@ -209,6 +238,11 @@ func NewCall(token token.Token, function Expression, arguments []Node) *Call {
 	return &Call{token: token, function: function, arguments: arguments}
 }
 
+// NewCallWithKeywords creates a new Call node that includes keyword arguments.
+func NewCallWithKeywords(token token.Token, function Expression, arguments []Node, keywords []*KeywordArg) *Call {
+	return &Call{token: token, function: function, arguments: arguments, keywords: keywords}
+}
+
 func (c *Call) ExpressionNode() {}
 
 func (c *Call) IsExpression() bool { return true }
@@ -221,12 +255,18 @@ func (c *Call) Function() Expression { return c.function }
 
 func (c *Call) Arguments() []Node { return c.arguments }
 
+// Keywords returns the keyword arguments supplied to the call, in source order.
+func (c *Call) Keywords() []*KeywordArg { return c.keywords }
+
 func (c *Call) String() string {
 	var out bytes.Buffer
 	args := make([]string, 0)
 	for _, a := range c.arguments {
 		args = append(args, a.String())
 	}
+	for _, k := range c.keywords {
+		args = append(args, k.String())
+	}
 	out.WriteString(c.function.String())
 	out.WriteString("(")
 	out.WriteString(strings.Join(args, ", "))
@@ -234,6 +274,30 @@ func (c *Call) String() string {
 	return out.String()
 }
 
+// KeywordArg is a named argument supplied to a call, as in "f(timeout=5)".
+type KeywordArg struct {
+	token token.Token // the name token
+	name  *Ident
+	value Expression
+}
+
+// NewKeywordArg creates a new KeywordArg node.
+func NewKeywordArg(name *Ident, value Expression) *KeywordArg {
+	return &KeywordArg{token: name.Token(), name: name, value: value}
+}
+
+func (k *KeywordArg) IsExpression() bool { return false }
+
+func (k *KeywordArg) Token() token.Token { return k.token }
+
+func (k *KeywordArg) Literal() string { return k.token.Literal }
+
+func (k *KeywordArg) Name() *Ident { return k.name }
+
+func (k *KeywordArg) Value() Expression { return k.value }
+
+func (k *KeywordArg) String() string { return k.name.value + "=" + k.value.String() }
+
 // GetAttr is an expression node that describes the access of an attribute on
 // an object.
 type GetAttr struct {
@@ -263,10 +327,18 @@ func (e *GetAttr) Object() Expression { return e.object }
 
 func (e *GetAttr) Name() string { return e.attribute.value }
 
+// IsOptional returns true if the attribute is accessed with "?.", which
+// evaluates to nil if the object is nil or the attribute is missing.
+func (e *GetAttr) IsOptional() bool { return e.token.Type == token.QUESTION_PERIOD }
+
 func (e *GetAttr) String() string {
 	var out bytes.Buffer
 	out.WriteString(e.object.String())
-	out.WriteString(".")
+	if e.IsOptional() {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
 	out.WriteString(e.attribute.value)
 	return out.String()
 }
@@ -332,10 +404,18 @@ func (c *ObjectCall) Object() Expression { return c.object }
 
 func (c *ObjectCall) Call() Expression { return c.call }
 
+// IsOptional returns true if the method is accessed with "?.", in which case
+// the call evaluates to nil if the object is nil or the method is missing.
+func (c *ObjectCall) IsOptional() bool { return c.token.Type == token.QUESTION_PERIOD }
+
 func (c *ObjectCall) String() string {
 	var out bytes.Buffer
 	out.WriteString(c.object.String())
-	out.WriteString(".")
+	if c.IsOptional() {
+		out.WriteString("?.")
+	} else {
+		out.WriteString(".")
+	}
 	out.WriteString(c.call.String())
 	return out.String()
 }
@@ -368,10 +448,17 @@ func (i *Index) Left() Expression { return i.left }
 
 func (i *Index) Index() Expression { return i.index }
 
+// IsOptional returns true if the index is accessed with "?.[", which
+// evaluates to nil if the container is nil or the key is missing.
+func (i *Index) IsOptional() bool { return i.token.Type == token.QUESTION_PERIOD }
+
 func (i *Index) String() string {
 	var out bytes.Buffer
 	out.WriteString("(")
 	out.WriteString(i.left.String())
+	if i.IsOptional() {
+		out.WriteString("?.")
+	}
 	out.WriteString("[")
 	out.WriteString(i.index.String())
 	out.WriteString("])")
@@ -533,6 +620,122 @@ func (s *Switch) String() string {
 	return out.String()
 }
 
+// MatchCase is a single case within a match expression. A case matches if
+// any of its patterns match the value and its optional guard is truthy.
+type MatchCase struct {
+	// the "case" or "default" token
+	token token.Token
+
+	// Default branch?
+	isDefault bool
+
+	// alternative patterns to match against
+	patterns []Expression
+
+	// optional condition that must also be truthy
+	guard Expression
+
+	// the code to execute if there is a match
+	block *Block
+}
+
+// NewMatchCase creates a new MatchCase node.
+func NewMatchCase(token token.Token, patterns []Expression, guard Expression, block *Block) *MatchCase {
+	return &MatchCase{token: token, patterns: patterns, guard: guard, block: block}
+}
+
+// NewMatchDefaultCase represents the default case within a match expression.
+func NewMatchDefaultCase(token token.Token, block *Block) *MatchCase {
+	return &MatchCase{token: token, isDefault: true, block: block}
+}
+
+func (c *MatchCase) ExpressionNode() {}
+
+func (c *MatchCase) IsExpression() bool { return true }
+
+func (c *MatchCase) Token() token.Token { return c.token }
+
+func (c *MatchCase) Literal() string { return c.token.Literal }
+
+func (c *MatchCase) IsDefault() bool { return c.isDefault }
+
+func (c *MatchCase) Patterns() []Expression { return c.patterns }
+
+func (c *MatchCase) Guard() Expression { return c.guard }
+
+func (c *MatchCase) Block() *Block { return c.block }
+
+func (c *MatchCase) String() string {
+	var out bytes.Buffer
+	if c.isDefault {
+		out.WriteString("default")
+	} else {
+		out.WriteString("case ")
+		tmp := []string{}
+		for _, pattern := range c.patterns {
+			tmp = append(tmp, pattern.String())
+		}
+		out.WriteString(strings.Join(tmp, ", "))
+		if c.guard != nil {
+			out.WriteString(" if ")
+			out.WriteString(c.guard.String())
+		}
+	}
+	out.WriteString(":\n")
+	if c.block != nil {
+		for i, stmt := range c.block.statements {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString("\t" + stmt.String())
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// Match is an expression node that compares a value against a series of
+// patterns, which may destructure the value and bind variables.
+type Match struct {
+	// token containing "match"
+	token token.Token
+
+	// the expression to match on
+	value Expression
+
+	// match cases
+	cases []*MatchCase
+}
+
+// NewMatch creates a new Match node.
+func NewMatch(token token.Token, value Expression, cases []*MatchCase) *Match {
+	return &Match{token: token, value: value, cases: cases}
+}
+
+func (m *Match) ExpressionNode() {}
+
+func (m *Match) IsExpression() bool { return true }
+
+func (m *Match) Token() token.Token { return m.token }
+
+func (m *Match) Literal() string { return m.token.Literal }
+
+func (m *Match) Value() Expression { return m.value }
+
+func (m *Match) Cases() []*MatchCase { return m.cases }
+
+func (m *Match) String() string {
+	var out bytes.Buffer
+	out.WriteString("match ")
+	out.WriteString(m.value.String())
+	out.WriteString(" {\n")
+	for _, c := range m.cases {
+		out.WriteString(c.String())
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
 // In is an expression node that checks whether a value is present in a container.
 type In struct {
 	token token.Token
@@ -660,3 +863,165 @@ func (r *Receive) String() string {
 	out.WriteString(r.channel.String())
 	return out.String()
 }
+
+// ComprehensionClause is a "for" clause within a comprehension, along with
+// any "if" conditions that follow it.
+type ComprehensionClause struct {
+	// the "for" token
+	token token.Token
+
+	// the loop variables. A single variable receives each value; with two
+	// variables, the first receives the key or index.
+	names []*Ident
+
+	// the container being iterated over
+	iterable Expression
+
+	// conditions that must all be truthy for an item to be included
+	conditions []Expression
+}
+
+// NewComprehensionClause creates a new ComprehensionClause node.
+func NewComprehensionClause(token token.Token, names []*Ident, iterable Expression, conditions []Expression) *ComprehensionClause {
+	return &ComprehensionClause{token: token, names: names, iterable: iterable, conditions: conditions}
+}
+
+func (c *ComprehensionClause) Token() token.Token { return c.token }
+
+func (c *ComprehensionClause) Literal() string { return c.token.Literal }
+
+func (c *ComprehensionClause) Names() []*Ident { return c.names }
+
+func (c *ComprehensionClause) Iterable() Expression { return c.iterable }
+
+func (c *ComprehensionClause) Conditions() []Expression { return c.conditions }
+
+func (c *ComprehensionClause) String() string {
+	var out bytes.Buffer
+	names := make([]string, 0, len(c.names))
+	for _, name := range c.names {
+		names = append(names, name.String())
+	}
+	out.WriteString("for ")
+	out.WriteString(strings.Join(names, ", "))
+	out.WriteString(" in ")
+	out.WriteString(c.iterable.String())
+	for _, cond := range c.conditions {
+		out.WriteString(" if ")
+		out.WriteString(cond.String())
+	}
+	return out.String()
+}
+
+func comprehensionString(clauses []*ComprehensionClause) string {
+	parts := make([]string, 0, len(clauses))
+	for _, clause := range clauses {
+		parts = append(parts, clause.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+// ListComprehension is an expression node that builds a list by evaluating
+// an element expression for each item produced by its clauses.
+type ListComprehension struct {
+	// the '[' token
+	token token.Token
+
+	// the expression evaluated for each item
+	element Expression
+
+	// the "for" clauses, outermost first
+	clauses []*ComprehensionClause
+}
+
+// NewListComprehension creates a new ListComprehension node.
+func NewListComprehension(token token.Token, element Expression, clauses []*ComprehensionClause) *ListComprehension {
+	return &ListComprehension{token: token, element: element, clauses: clauses}
+}
+
+func (l *ListComprehension) ExpressionNode() {}
+
+func (l *ListComprehension) IsExpression() bool { return true }
+
+func (l *ListComprehension) Token() token.Token { return l.token }
+
+func (l *ListComprehension) Literal() string { return l.token.Literal }
+
+func (l *ListComprehension) Element() Expression { return l.element }
+
+func (l *ListComprehension) Clauses() []*ComprehensionClause { return l.clauses }
+
+func (l *ListComprehension) String() string {
+	return fmt.Sprintf("[%s %s]", l.element.String(), comprehensionString(l.clauses))
+}
+
+// MapComprehension is an expression node that builds a map by evaluating a
+// key and value expression for each item produced by its clauses.
+type MapComprehension struct {
+	// the '{' token
+	token token.Token
+
+	// the expressions evaluated for each item
+	key   Expression
+	value Expression
+
+	// the "for" clauses, outermost first
+	clauses []*ComprehensionClause
+}
+
+// NewMapComprehension creates a new MapComprehension node.
+func NewMapComprehension(token token.Token, key, value Expression, clauses []*ComprehensionClause) *MapComprehension {
+	return &MapComprehension{token: token, key: key, value: value, clauses: clauses}
+}
+
+func (m *MapComprehension) ExpressionNode() {}
+
+func (m *MapComprehension) IsExpression() bool { return true }
+
+func (m *MapComprehension) Token() token.Token { return m.token }
+
+func (m *MapComprehension) Literal() string { return m.token.Literal }
+
+func (m *MapComprehension) Key() Expression { return m.key }
+
+func (m *MapComprehension) Value() Expression { return m.value }
+
+func (m *MapComprehension) Clauses() []*ComprehensionClause { return m.clauses }
+
+func (m *MapComprehension) String() string {
+	return fmt.Sprintf("{%s: %s %s}", m.key.String(), m.value.String(), comprehensionString(m.clauses))
+}
+
+// SetComprehension is an expression node that builds a set by evaluating an
+// element expression for each item produced by its clauses.
+type SetComprehension struct {
+	// the '{' token
+	token token.Token
+
+	// the expression evaluated for each item
+	element Expression
+
+	// the "for" clauses, outermost first
+	clauses []*ComprehensionClause
+}
+
+// NewSetComprehension creates a new SetComprehension node.
+func NewSetComprehension(token token.Token, element Expression, clauses []*ComprehensionClause) *SetComprehension {
+	return &SetComprehension{token: token, element: element, clauses: clauses}
+}
+
+func (s *SetComprehension) ExpressionNode() {}
+
+func (s *SetComprehension) IsExpression() bool { return true }
+
+func (s *SetComprehension) Token() token.Token { return s.token }
+
+func (s *SetComprehension) Literal() string { return s.token.Literal }
+
+func (s *SetComprehension) Element() Expression { return s.element }
+
+func (s *SetComprehension) Clauses() []*ComprehensionClause { return s.clauses }
+
+func (s *SetComprehension) String() string {
+	return fmt.Sprintf("{%s %s}", s.element.String(), comprehensionString(s.clauses))
+}
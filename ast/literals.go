@@ -113,8 +113,18 @@ type Func struct {
 	// defaults holds any default values for arguments which aren't specified.
 	defaults map[string]Expression
 
+	// restParam optionally names a parameter that collects any extra
+	// arguments into a list, as in "func(a, ...rest)".
+	restParam *Ident
+
 	// body contains the set of statements within the function.
 	body *Block
+
+	// paramTypes holds optional type annotations keyed by parameter name.
+	paramTypes map[string]*TypeAnnotation
+
+	// returnType is the optional annotation of the return value.
+	returnType *TypeAnnotation
 }
 
 // NewFunc creates a new Func node.
@@ -128,6 +138,19 @@ func NewFunc(token token.Token, name *Ident, parameters []*Ident, defaults map[s
 	}
 }
 
+// NewVariadicFunc creates a new Func node that collects extra arguments into
+// the given rest parameter.
+func NewVariadicFunc(token token.Token, name *Ident, parameters []*Ident, defaults map[string]Expression, restParam *Ident, body *Block) *Func {
+	return &Func{
+		token:      token,
+		name:       name,
+		parameters: parameters,
+		defaults:   defaults,
+		restParam:  restParam,
+		body:       body,
+	}
+}
+
 func (f *Func) ExpressionNode() {}
 
 func (f *Func) IsExpression() bool { return f.name == nil }
@@ -150,13 +173,34 @@ func (f *Func) ParameterNames() []string {
 
 func (f *Func) Defaults() map[string]Expression { return f.defaults }
 
+func (f *Func) RestParameter() *Ident { return f.restParam }
+
 func (f *Func) Body() *Block { return f.body }
 
+// Annotate records the optional type annotations of the function parameters
+// and return value. The function is returned for convenience.
+func (f *Func) Annotate(paramTypes map[string]*TypeAnnotation, returnType *TypeAnnotation) *Func {
+	f.paramTypes = paramTypes
+	f.returnType = returnType
+	return f
+}
+
+// ParameterType returns the type annotation of the named parameter, or nil
+// if the parameter is not annotated.
+func (f *Func) ParameterType(name string) *TypeAnnotation { return f.paramTypes[name] }
+
+// ReturnType returns the type annotation of the return value, or nil if the
+// function is not annotated.
+func (f *Func) ReturnType() *TypeAnnotation { return f.returnType }
+
 func (f *Func) String() string {
 	var out bytes.Buffer
 	params := make([]string, 0)
 	for _, p := range f.parameters {
-		params = append(params, p.value)
+		params = append(params, f.annotatedParam(p.value))
+	}
+	if f.restParam != nil {
+		params = append(params, "..."+f.annotatedParam(f.restParam.value))
 	}
 	out.WriteString(f.Literal())
 	if f.name != nil {
@@ -164,12 +208,23 @@ func (f *Func) String() string {
 	}
 	out.WriteString("(")
 	out.WriteString(strings.Join(params, ", "))
-	out.WriteString(") { ")
+	out.WriteString(")")
+	if f.returnType != nil {
+		out.WriteString(" -> " + f.returnType.String())
+	}
+	out.WriteString(" { ")
 	out.WriteString(f.body.String())
 	out.WriteString(" }")
 	return out.String()
 }
 
+func (f *Func) annotatedParam(name string) string {
+	if t, ok := f.paramTypes[name]; ok {
+		return name + ": " + t.String()
+	}
+	return name
+}
+
 // String is an expression node that holds a string literal.
 type String struct {
 	// Token is the token
@@ -250,6 +305,7 @@ func (l *List) String() string {
 type Map struct {
 	token token.Token               // the '{' token
 	items map[Expression]Expression // items in the map
+	order []Expression              // keys and spreads in source order
 }
 
 // NewMap creates a new Map node.
@@ -257,6 +313,13 @@ func NewMap(token token.Token, items map[Expression]Expression) *Map {
 	return &Map{token: token, items: items}
 }
 
+// NewOrderedMap creates a new Map node that remembers the source order of its
+// entries. Each element of order is either a key in items or a *Spread whose
+// value is merged into the map at that position.
+func NewOrderedMap(token token.Token, items map[Expression]Expression, order []Expression) *Map {
+	return &Map{token: token, items: items, order: order}
+}
+
 func (m *Map) ExpressionNode() {}
 
 func (m *Map) IsExpression() bool { return true }
@@ -267,11 +330,25 @@ func (m *Map) Literal() string { return m.token.Literal }
 
 func (m *Map) Items() map[Expression]Expression { return m.items }
 
+// Order returns the keys and spreads of the map in source order. It is nil
+// for maps that were not created with NewOrderedMap.
+func (m *Map) Order() []Expression { return m.order }
+
 func (m *Map) String() string {
 	var out bytes.Buffer
 	pairs := make([]string, 0)
-	for key, value := range m.items {
-		pairs = append(pairs, key.String()+":"+value.String())
+	if m.order != nil {
+		for _, key := range m.order {
+			if spread, ok := key.(*Spread); ok {
+				pairs = append(pairs, spread.String())
+			} else {
+				pairs = append(pairs, key.String()+":"+m.items[key].String())
+			}
+		}
+	} else {
+		for key, value := range m.items {
+			pairs = append(pairs, key.String()+":"+value.String())
+		}
 	}
 	out.WriteString("{")
 	out.WriteString(strings.Join(pairs, ", "))
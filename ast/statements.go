@@ -21,6 +21,9 @@ type Var struct {
 
 	// isWalrus is true if this is a ":=" statement.
 	isWalrus bool
+
+	// typ is the optional type annotation of the variable.
+	typ *TypeAnnotation
 }
 
 // NewVar creates a new Var node.
@@ -45,6 +48,16 @@ func (s *Var) Value() (string, Expression) { return s.name.value, s.value }
 
 func (s *Var) IsWalrus() bool { return s.isWalrus }
 
+// Annotate records the optional type annotation of the variable. The
+// statement is returned for convenience.
+func (s *Var) Annotate(typ *TypeAnnotation) *Var {
+	s.typ = typ
+	return s
+}
+
+// Type returns the type annotation of the variable, or nil if there is none.
+func (s *Var) Type() *TypeAnnotation { return s.typ }
+
 func (s *Var) String() string {
 	var out bytes.Buffer
 	if s.isWalrus {
@@ -54,6 +67,9 @@ func (s *Var) String() string {
 	}
 	out.WriteString(s.Literal() + " ")
 	out.WriteString(s.name.Literal())
+	if s.typ != nil {
+		out.WriteString(": " + s.typ.String())
+	}
 	out.WriteString(" = ")
 	if s.value != nil {
 		out.WriteString(s.value.String())
@@ -109,6 +125,90 @@ func (s *MultiVar) String() string {
 	return out.String()
 }
 
+// Destructure is a statement that assigns variables by unpacking a value into
+// one or more targets. A target is a name, a list of targets such as
+// [a, [b, c]] which may end with a rest target like ...rest, a set of names
+// such as {name, age}, or a map such as {name: n, address: {city}} whose
+// values are targets. For example:
+//
+//	first, ...rest := items
+//	{name, tags: [tag]} = person
+type Destructure struct {
+	token    token.Token
+	targets  []Expression // targets being assigned
+	value    Expression   // value being unpacked
+	isWalrus bool         // isWalrus is true if this is a ":=" statement.
+}
+
+func NewDestructure(token token.Token, targets []Expression, value Expression, isWalrus bool) *Destructure {
+	return &Destructure{token: token, targets: targets, value: value, isWalrus: isWalrus}
+}
+
+func (s *Destructure) StatementNode() {}
+
+func (s *Destructure) IsExpression() bool { return false }
+
+func (s *Destructure) Token() token.Token { return s.token }
+
+func (s *Destructure) Literal() string { return s.token.Literal }
+
+func (s *Destructure) Value() ([]Expression, Expression) { return s.targets, s.value }
+
+func (s *Destructure) IsWalrus() bool { return s.isWalrus }
+
+// Names returns the names of the variables assigned by the statement, in the
+// order they appear.
+func (s *Destructure) Names() []string { return TargetNames(s.targets) }
+
+func (s *Destructure) String() string {
+	targets := make([]string, 0, len(s.targets))
+	for _, target := range s.targets {
+		targets = append(targets, target.String())
+	}
+	operator := " = "
+	if s.isWalrus {
+		operator = " := "
+	}
+	return strings.Join(targets, ", ") + operator + s.value.String()
+}
+
+// TargetNames returns the names of the variables assigned by the given
+// destructuring targets, in the order they appear. The name "_" is omitted.
+func TargetNames(targets []Expression) []string {
+	var names []string
+	for _, target := range targets {
+		names = appendTargetNames(names, target)
+	}
+	return names
+}
+
+// appendTargetNames appends the names of the variables assigned by a
+// destructuring target to the given slice.
+func appendTargetNames(names []string, target Expression) []string {
+	switch target := target.(type) {
+	case *Ident:
+		if target.value != "_" {
+			names = append(names, target.value)
+		}
+	case *Spread:
+		names = appendTargetNames(names, target.Value())
+	case *List:
+		for _, item := range target.Items() {
+			names = appendTargetNames(names, item)
+		}
+	case *Set:
+		for _, item := range target.Items() {
+			names = appendTargetNames(names, item)
+		}
+	case *Map:
+		items := target.Items()
+		for _, key := range target.Order() {
+			names = appendTargetNames(names, items[key])
+		}
+	}
+	return names
+}
+
 // Const is a statement that defines a named constant.
 type Const struct {
 	// the "const" token
@@ -119,6 +219,9 @@ type Const struct {
 
 	// value of the constant
 	value Expression
+
+	// typ is the optional type annotation of the constant.
+	typ *TypeAnnotation
 }
 
 // NewConst creates a new Const node.
@@ -136,10 +239,23 @@ func (c *Const) Literal() string { return c.token.Literal }
 
 func (c *Const) Value() (string, Expression) { return c.name.value, c.value }
 
+// Annotate records the optional type annotation of the constant. The
+// statement is returned for convenience.
+func (c *Const) Annotate(typ *TypeAnnotation) *Const {
+	c.typ = typ
+	return c
+}
+
+// Type returns the type annotation of the constant, or nil if there is none.
+func (c *Const) Type() *TypeAnnotation { return c.typ }
+
 func (c *Const) String() string {
 	var out bytes.Buffer
 	out.WriteString(c.Literal() + " ")
 	out.WriteString(c.name.Literal())
+	if c.typ != nil {
+		out.WriteString(": " + c.typ.String())
+	}
 	out.WriteString(" = ")
 	if c.value != nil {
 		out.WriteString(c.value.String())
@@ -154,6 +270,9 @@ type Control struct {
 
 	// optional value, for return statements
 	value Expression
+
+	// optional label of the loop targeted by a break or continue
+	label *Ident
 }
 
 // NewControl creates a new Control node.
@@ -161,6 +280,12 @@ func NewControl(token token.Token, value Expression) *Control {
 	return &Control{token: token, value: value}
 }
 
+// NewLabeledControl creates a new Control node that targets the loop with
+// the given label.
+func NewLabeledControl(token token.Token, label *Ident) *Control {
+	return &Control{token: token, label: label}
+}
+
 func (c *Control) StatementNode() {}
 
 func (c *Control) IsExpression() bool { return false }
@@ -171,6 +296,10 @@ func (c *Control) Literal() string { return c.token.Literal }
 
 func (c *Control) Value() Expression { return c.value }
 
+// Label returns the label of the targeted loop, or nil if the statement
+// targets the innermost loop.
+func (c *Control) Label() *Ident { return c.label }
+
 func (c *Control) IsReturn() bool {
 	return c.token.Type == token.RETURN
 }
@@ -178,6 +307,9 @@ func (c *Control) IsReturn() bool {
 func (c *Control) String() string {
 	var out bytes.Buffer
 	out.WriteString(c.Literal())
+	if c.label != nil {
+		out.WriteString(" " + c.label.value)
+	}
 	if c.value != nil {
 		out.WriteString(" " + c.value.String())
 	}
@@ -217,6 +349,40 @@ func (r *Return) String() string {
 	return out.String()
 }
 
+// Yield is a statement that suspends a generator function and produces a
+// value from it. A function containing a yield statement is a generator.
+type Yield struct {
+	// "yield"
+	token token.Token
+
+	// optional value
+	value Expression
+}
+
+// NewYield creates a new Yield node.
+func NewYield(token token.Token, value Expression) *Yield {
+	return &Yield{token: token, value: value}
+}
+
+func (y *Yield) StatementNode() {}
+
+func (y *Yield) IsExpression() bool { return false }
+
+func (y *Yield) Token() token.Token { return y.token }
+
+func (y *Yield) Literal() string { return y.token.Literal }
+
+func (y *Yield) Value() Expression { return y.value }
+
+func (y *Yield) String() string {
+	var out bytes.Buffer
+	out.WriteString(y.Literal())
+	if y.value != nil {
+		out.WriteString(" " + y.value.String())
+	}
+	return out.String()
+}
+
 // Block is a node that holds a sequence of statements. This is used to
 // represent the body of a function, loop, or a conditional.
 type Block struct {
@@ -277,6 +443,9 @@ type For struct {
 	// Statement which is executed after each execution of the block
 	// (and only if the block was executed).
 	post Node
+
+	// optional label that break and continue statements may refer to
+	label *Ident
 }
 
 // NewSimpleFor creates a new For node with no condition, init, or post.
@@ -306,7 +475,7 @@ func (f *For) IsIteratorLoop() bool {
 		return false
 	}
 	switch f.condition.(type) {
-	case *Var, *MultiVar:
+	case *Var, *MultiVar, *Destructure:
 		// The only case where var and multi-var assignments are supported are
 		// when an iterator is being used to define the loop. The assignment AST
 		// node currently comes through as the loop "condition" in the AST. For
@@ -314,6 +483,7 @@ func (f *For) IsIteratorLoop() bool {
 		//   for i := range x {}
 		//   for i, j := range x {}
 		//   for i, j := myiterator {}
+		//   for i, [a, b] := range x {}
 		return true
 	}
 	return false
@@ -327,8 +497,20 @@ func (f *For) Init() Node { return f.init }
 
 func (f *For) Post() Node { return f.post }
 
+// Label returns the label of the loop, or nil if it is not labeled.
+func (f *For) Label() *Ident { return f.label }
+
+// WithLabel sets the label of the loop and returns the loop.
+func (f *For) WithLabel(label *Ident) *For {
+	f.label = label
+	return f
+}
+
 func (f *For) String() string {
 	var out bytes.Buffer
+	if f.label != nil {
+		out.WriteString(f.label.value + ": ")
+	}
 	// Simple for {} loop
 	if f.IsSimpleLoop() {
 		out.WriteString("for { ")
@@ -362,11 +544,18 @@ type ForIn struct {
 	// variable is the identifier that holds the current iteration value
 	variable *Ident
 
+	// targets receive the current iteration value instead of variable when
+	// the loop destructures it. Two targets receive the key and the value.
+	targets []Expression
+
 	// iterable is the expression that provides the values to iterate over
 	iterable Node
 
 	// consequence contains the statements that make up the loop body.
 	consequence *Block
+
+	// optional label that break and continue statements may refer to
+	label *Ident
 }
 
 // NewForIn creates a new ForIn node.
@@ -374,6 +563,12 @@ func NewForIn(token token.Token, variable *Ident, iterable Node, consequence *Bl
 	return &ForIn{token: token, variable: variable, iterable: iterable, consequence: consequence}
 }
 
+// NewForInDestructure creates a new ForIn node that destructures each value
+// into the given targets, as in "for k, {id, name} in items".
+func NewForInDestructure(token token.Token, targets []Expression, iterable Node, consequence *Block) *ForIn {
+	return &ForIn{token: token, targets: targets, iterable: iterable, consequence: consequence}
+}
+
 func (f *ForIn) StatementNode() {}
 
 func (f *ForIn) IsExpression() bool { return false }
@@ -382,16 +577,41 @@ func (f *ForIn) Token() token.Token { return f.token }
 
 func (f *ForIn) Literal() string { return f.token.Literal }
 
+// Variable returns the loop variable, or nil if the loop destructures its
+// values into targets instead.
 func (f *ForIn) Variable() *Ident { return f.variable }
 
+// Targets returns the destructuring targets of the loop, if any.
+func (f *ForIn) Targets() []Expression { return f.targets }
+
 func (f *ForIn) Iterable() Node { return f.iterable }
 
 func (f *ForIn) Consequence() *Block { return f.consequence }
 
+// Label returns the label of the loop, or nil if it is not labeled.
+func (f *ForIn) Label() *Ident { return f.label }
+
+// WithLabel sets the label of the loop and returns the loop.
+func (f *ForIn) WithLabel(label *Ident) *ForIn {
+	f.label = label
+	return f
+}
+
 func (f *ForIn) String() string {
 	var out bytes.Buffer
+	if f.label != nil {
+		out.WriteString(f.label.value + ": ")
+	}
 	out.WriteString("for ")
-	out.WriteString(f.variable.String())
+	if f.variable != nil {
+		out.WriteString(f.variable.String())
+	} else {
+		targets := make([]string, 0, len(f.targets))
+		for _, target := range f.targets {
+			targets = append(targets, target.String())
+		}
+		out.WriteString(strings.Join(targets, ", "))
+	}
 	out.WriteString(" in ")
 	out.WriteString(f.iterable.String())
 	out.WriteString(" ")
@@ -704,3 +924,258 @@ func (s *Send) Value() Expression { return s.value }
 func (s *Send) String() string {
 	return fmt.Sprintf("%s <- %s", s.channel.String(), s.value.String())
 }
+
+// Struct is a statement that declares a named struct type with a fixed set of
+// fields. Each field may have an optional default value.
+type Struct struct {
+	// the "struct" token
+	token token.Token
+
+	// name of the struct type
+	name *Ident
+
+	// names of the struct fields, in declaration order
+	fields []*Ident
+
+	// default values for the fields, parallel to fields. Nil if the field
+	// has no default.
+	defaults []Expression
+}
+
+// NewStruct creates a new Struct node.
+func NewStruct(token token.Token, name *Ident, fields []*Ident, defaults []Expression) *Struct {
+	return &Struct{token: token, name: name, fields: fields, defaults: defaults}
+}
+
+func (s *Struct) StatementNode() {}
+
+func (s *Struct) IsExpression() bool { return false }
+
+func (s *Struct) Token() token.Token { return s.token }
+
+func (s *Struct) Literal() string { return s.token.Literal }
+
+func (s *Struct) Name() *Ident { return s.name }
+
+func (s *Struct) Fields() []*Ident { return s.fields }
+
+func (s *Struct) Defaults() []Expression { return s.defaults }
+
+func (s *Struct) String() string {
+	var out bytes.Buffer
+	out.WriteString(s.Literal() + " ")
+	out.WriteString(s.name.Literal())
+	out.WriteString(" {")
+	for i, field := range s.fields {
+		if i > 0 {
+			out.WriteString(",")
+		}
+		out.WriteString(" ")
+		out.WriteString(field.Literal())
+		if def := s.defaults[i]; def != nil {
+			out.WriteString(" = ")
+			out.WriteString(def.String())
+		}
+	}
+	if len(s.fields) > 0 {
+		out.WriteString(" ")
+	}
+	out.WriteString("}")
+	return out.String()
+}
+
+// Try is a statement that runs a block of code and handles errors that occur
+// within it. It has an optional catch block, which receives the error, and an
+// optional finally block, which always runs. At least one must be present.
+type Try struct {
+	// the "try" token
+	token token.Token
+
+	// the block of code to try
+	body *Block
+
+	// optional name of the variable the caught error is assigned to
+	catchIdent *Ident
+
+	// optional block that runs when an error occurs
+	catchBlock *Block
+
+	// optional block that runs after the try and catch blocks
+	finallyBlock *Block
+}
+
+// NewTry creates a new Try node.
+func NewTry(token token.Token, body *Block, catchIdent *Ident, catchBlock, finallyBlock *Block) *Try {
+	return &Try{
+		token:        token,
+		body:         body,
+		catchIdent:   catchIdent,
+		catchBlock:   catchBlock,
+		finallyBlock: finallyBlock,
+	}
+}
+
+func (t *Try) StatementNode() {}
+
+func (t *Try) IsExpression() bool { return false }
+
+func (t *Try) Token() token.Token { return t.token }
+
+func (t *Try) Literal() string { return t.token.Literal }
+
+func (t *Try) Body() *Block { return t.body }
+
+func (t *Try) CatchIdent() *Ident { return t.catchIdent }
+
+func (t *Try) CatchBlock() *Block { return t.catchBlock }
+
+func (t *Try) FinallyBlock() *Block { return t.finallyBlock }
+
+func (t *Try) String() string {
+	var out bytes.Buffer
+	out.WriteString("try ")
+	out.WriteString(t.body.String())
+	if t.catchBlock != nil {
+		out.WriteString(" catch ")
+		if t.catchIdent != nil {
+			out.WriteString(t.catchIdent.Literal() + " ")
+		}
+		out.WriteString(t.catchBlock.String())
+	}
+	if t.finallyBlock != nil {
+		out.WriteString(" finally ")
+		out.WriteString(t.finallyBlock.String())
+	}
+	return out.String()
+}
+
+// SelectCase is a single case within a select statement. It is either a send
+// to a channel, a receive from a channel, or the default case.
+type SelectCase struct {
+	// the "case" or "default" token
+	token token.Token
+
+	// Default branch?
+	isDefault bool
+
+	// the send operation, for send cases
+	send *Send
+
+	// the receive operation, for receive cases
+	receive *Receive
+
+	// optional names the received value and "ok" flag are assigned to
+	valueIdent *Ident
+	okIdent    *Ident
+
+	// the code to execute if this case is selected
+	block *Block
+}
+
+// NewSelectSendCase creates a new SelectCase node for a channel send.
+func NewSelectSendCase(token token.Token, send *Send, block *Block) *SelectCase {
+	return &SelectCase{token: token, send: send, block: block}
+}
+
+// NewSelectReceiveCase creates a new SelectCase node for a channel receive.
+// The value and ok identifiers are optional.
+func NewSelectReceiveCase(token token.Token, receive *Receive, valueIdent, okIdent *Ident, block *Block) *SelectCase {
+	return &SelectCase{
+		token:      token,
+		receive:    receive,
+		valueIdent: valueIdent,
+		okIdent:    okIdent,
+		block:      block,
+	}
+}
+
+// NewSelectDefaultCase creates a new SelectCase node for the default case.
+func NewSelectDefaultCase(token token.Token, block *Block) *SelectCase {
+	return &SelectCase{token: token, isDefault: true, block: block}
+}
+
+func (c *SelectCase) StatementNode() {}
+
+func (c *SelectCase) IsExpression() bool { return false }
+
+func (c *SelectCase) Token() token.Token { return c.token }
+
+func (c *SelectCase) Literal() string { return c.token.Literal }
+
+func (c *SelectCase) IsDefault() bool { return c.isDefault }
+
+func (c *SelectCase) Send() *Send { return c.send }
+
+func (c *SelectCase) Receive() *Receive { return c.receive }
+
+func (c *SelectCase) ValueIdent() *Ident { return c.valueIdent }
+
+func (c *SelectCase) OkIdent() *Ident { return c.okIdent }
+
+func (c *SelectCase) Block() *Block { return c.block }
+
+func (c *SelectCase) String() string {
+	var out bytes.Buffer
+	switch {
+	case c.isDefault:
+		out.WriteString("default")
+	case c.send != nil:
+		out.WriteString("case " + c.send.String())
+	default:
+		out.WriteString("case ")
+		if c.valueIdent != nil {
+			out.WriteString(c.valueIdent.Literal())
+			if c.okIdent != nil {
+				out.WriteString(", " + c.okIdent.Literal())
+			}
+			out.WriteString(" := ")
+		}
+		out.WriteString(c.receive.String())
+	}
+	out.WriteString(":\n")
+	if c.block != nil {
+		for i, stmt := range c.block.statements {
+			if i > 0 {
+				out.WriteString("\n")
+			}
+			out.WriteString("\t" + stmt.String())
+		}
+	}
+	out.WriteString("\n")
+	return out.String()
+}
+
+// Select is a statement that waits on multiple channel operations and runs
+// the case of the first one that is ready.
+type Select struct {
+	// the "select" token
+	token token.Token
+
+	// select cases
+	cases []*SelectCase
+}
+
+// NewSelect creates a new Select node.
+func NewSelect(token token.Token, cases []*SelectCase) *Select {
+	return &Select{token: token, cases: cases}
+}
+
+func (s *Select) StatementNode() {}
+
+func (s *Select) IsExpression() bool { return false }
+
+func (s *Select) Token() token.Token { return s.token }
+
+func (s *Select) Literal() string { return s.token.Literal }
+
+func (s *Select) Cases() []*SelectCase { return s.cases }
+
+func (s *Select) String() string {
+	var out bytes.Buffer
+	out.WriteString("select {\n")
+	for _, c := range s.cases {
+		out.WriteString(c.String())
+	}
+	out.WriteString("}")
+	return out.String()
+}
@@ -44,3 +44,77 @@ func BenchmarkRisor_Fibonacci35(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkRisor_Loop(b *testing.B) {
+	script := `
+    func sum(n) {
+        total := 0
+        for i := 0; i < n; i++ {
+            if i % 3 == 0 {
+                total += i * 2
+            }
+        }
+        return total
+    }
+    sum(1000000)
+    `
+
+	ctx := context.Background()
+
+	ast, err := parser.Parse(ctx, script)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := compiler.Compile(ast)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := vm.Run(ctx, code)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if result.Interface().(int64) != 333333666666 {
+			b.Fatalf("unexpected result: %v", result)
+		}
+	}
+}
+
+func BenchmarkRisor_MethodCalls(b *testing.B) {
+	script := `
+    counts := {}
+    for i in range(10000) {
+        line := "INFO request handled path=/api/items status=200"
+        parts := line.split(" ")
+        level := parts[0].to_lower()
+        counts[level] = counts.get(level, 0) + 1
+    }
+    counts["info"]
+    `
+
+	ctx := context.Background()
+
+	ast, err := parser.Parse(ctx, script)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	code, err := compiler.Compile(ast)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result, err := vm.Run(ctx, code)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if result.Interface().(int64) != 10000 {
+			b.Fatalf("unexpected result: %v", result)
+		}
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"fmt"
 	"hash"
 	"io"
+	"math"
+	"math/big"
 	"sort"
 	"strconv"
 	"unicode"
@@ -25,6 +27,16 @@ func Len(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("len", 1, args); err != nil {
 		return err
 	}
+	if hook, ok := object.GetHook(args[0], object.HookLen); ok {
+		result, err := object.CallHook(ctx, hook, args[0])
+		if err != nil {
+			return object.NewError(err)
+		}
+		if _, ok := result.(*object.Int); !ok {
+			return object.TypeErrorf("type error: %s must return an int (got %s)", object.HookLen, result.Type())
+		}
+		return result
+	}
 	switch arg := args[0].(type) {
 	case object.Container:
 		return arg.Len()
@@ -81,6 +93,9 @@ func Set(ctx context.Context, args ...object.Object) object.Object {
 	for {
 		val, ok := iter.Next(ctx)
 		if !ok {
+			if err := object.IteratorErr(iter); err != nil {
+				return object.NewError(err)
+			}
 			break
 		}
 		if res := set.Add(val); object.IsError(res) {
@@ -109,6 +124,11 @@ func List(ctx context.Context, args ...object.Object) object.Object {
 		return object.NewList(arr)
 	}
 	arg := args[0]
+	if result, ok, err := object.CallIterHook(ctx, arg); err != nil {
+		return object.NewError(err)
+	} else if ok {
+		arg = result
+	}
 	iter, err := object.AsIterator(arg)
 	if err != nil {
 		return err
@@ -117,6 +137,9 @@ func List(ctx context.Context, args ...object.Object) object.Object {
 	for {
 		val, ok := iter.Next(ctx)
 		if !ok {
+			if err := object.IteratorErr(iter); err != nil {
+				return object.NewError(err)
+			}
 			break
 		}
 		items = append(items, val)
@@ -155,6 +178,9 @@ func Map(ctx context.Context, args ...object.Object) object.Object {
 	}
 	for {
 		if _, ok := iter.Next(ctx); !ok {
+			if err := object.IteratorErr(iter); err != nil {
+				return object.NewError(err)
+			}
 			break
 		}
 		entry, _ := iter.Entry()
@@ -177,6 +203,11 @@ func String(ctx context.Context, args ...object.Object) object.Object {
 		return object.NewString("")
 	}
 	arg := args[0]
+	if s, ok, err := object.CallStrHook(ctx, arg); err != nil {
+		return object.NewError(err)
+	} else if ok {
+		return object.NewString(s)
+	}
 	switch arg := arg.(type) {
 	case *object.Buffer:
 		return object.NewString(arg.Value().String())
@@ -376,6 +407,9 @@ func Any(ctx context.Context, args ...object.Object) object.Object {
 		for {
 			val, ok := arg.Next(ctx)
 			if !ok {
+				if err := object.IteratorErr(arg); err != nil {
+					return object.NewError(err)
+				}
 				break
 			}
 			if val.IsTruthy() {
@@ -432,6 +466,9 @@ func All(ctx context.Context, args ...object.Object) object.Object {
 		for {
 			val, ok := arg.Next(ctx)
 			if !ok {
+				if err := object.IteratorErr(arg); err != nil {
+					return object.NewError(err)
+				}
 				break
 			}
 			if !val.IsTruthy() {
@@ -501,7 +538,7 @@ func Sorted(ctx context.Context, args ...object.Object) object.Object {
 			return object.TypeErrorf(sortErr.Error())
 		}
 	} else {
-		if err := object.Sort(resultItems); err != nil {
+		if err := object.Sort(ctx, resultItems); err != nil {
 			return err
 		}
 	}
@@ -592,6 +629,9 @@ func iterKeys(ctx context.Context, iter object.Iterator) object.Object {
 	var keys []object.Object
 	for {
 		if _, ok := iter.Next(ctx); !ok {
+			if err := object.IteratorErr(iter); err != nil {
+				return object.NewError(err)
+			}
 			break
 		}
 		entry, _ := iter.Entry()
@@ -638,6 +678,17 @@ func Int(ctx context.Context, args ...object.Object) object.Object {
 		return object.NewInt(int64(obj.Value()))
 	case *object.Float:
 		return object.NewInt(int64(obj.Value()))
+	case *object.BigInt:
+		if !obj.Value().IsInt64() {
+			return object.Errorf("value error: int() argument is out of range (%s given)", obj.String())
+		}
+		return object.NewInt(obj.Value().Int64())
+	case *object.Decimal:
+		truncated := obj.Int()
+		if !truncated.IsInt64() {
+			return object.Errorf("value error: int() argument is out of range (%s given)", obj.String())
+		}
+		return object.NewInt(truncated.Int64())
 	case *object.String:
 		if i, err := strconv.ParseInt(obj.Value(), 0, 64); err == nil {
 			return object.NewInt(i)
@@ -662,6 +713,9 @@ func Float(ctx context.Context, args ...object.Object) object.Object {
 		return object.NewFloat(float64(obj.Value()))
 	case *object.Float:
 		return obj
+	case *object.BigInt, *object.Decimal:
+		f, _ := object.AsFloat(obj)
+		return object.NewFloat(f)
 	case *object.String:
 		if f, err := strconv.ParseFloat(obj.Value(), 64); err == nil {
 			return object.NewFloat(f)
@@ -672,6 +726,71 @@ func Float(ctx context.Context, args ...object.Object) object.Object {
 	}
 }
 
+func BigInt(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("bigint", 0, 1, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return object.NewBigInt(new(big.Int))
+	}
+	switch obj := args[0].(type) {
+	case *object.BigInt:
+		return obj
+	case *object.Int:
+		return object.NewBigInt(big.NewInt(obj.Value()))
+	case *object.Byte:
+		return object.NewBigInt(big.NewInt(int64(obj.Value())))
+	case *object.Float:
+		value := obj.Value()
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			return object.Errorf("value error: bigint() argument must be finite (%v given)", value)
+		}
+		i, _ := big.NewFloat(value).Int(nil)
+		return object.NewBigInt(i)
+	case *object.Decimal:
+		return object.NewBigInt(obj.Int())
+	case *object.String:
+		if i, ok := new(big.Int).SetString(obj.Value(), 0); ok {
+			return object.NewBigInt(i)
+		}
+		return object.Errorf("value error: invalid literal for bigint(): %q", obj.Value())
+	default:
+		return object.TypeErrorf("type error: bigint() unsupported argument (%s given)", args[0].Type())
+	}
+}
+
+func Decimal(ctx context.Context, args ...object.Object) object.Object {
+	if err := arg.RequireRange("decimal", 0, 1, args); err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		return object.NewDecimal(new(big.Int), 0)
+	}
+	switch obj := args[0].(type) {
+	case *object.Decimal:
+		return obj
+	case *object.Int:
+		return object.NewDecimal(big.NewInt(obj.Value()), 0)
+	case *object.Byte:
+		return object.NewDecimal(big.NewInt(int64(obj.Value())), 0)
+	case *object.BigInt:
+		return object.NewDecimal(obj.Value(), 0)
+	case *object.Float:
+		d, err := object.NewDecimalFromFloat(obj.Value())
+		if err != nil {
+			return object.Errorf("value error: decimal() argument must be finite (%v given)", obj.Value())
+		}
+		return d
+	case *object.String:
+		if d, err := object.ParseDecimal(obj.Value()); err == nil {
+			return d
+		}
+		return object.Errorf("value error: invalid literal for decimal(): %q", obj.Value())
+	default:
+		return object.TypeErrorf("type error: decimal() unsupported argument (%s given)", args[0].Type())
+	}
+}
+
 func Ord(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("ord", 1, args); err != nil {
 		return err
@@ -962,11 +1081,57 @@ func IsHashable(ctx context.Context, args ...object.Object) object.Object {
 	return object.NewBool(ok)
 }
 
+// signatures describe the parameters and return types of the builtins. They
+// are used by the static checker to validate calls without running any code.
+var signatures = map[string]string{
+	"all":         "(items) -> bool",
+	"any":         "(items) -> bool",
+	"assert":      "(value, message?) -> nil",
+	"bigint":      "(value?) -> bigint",
+	"bool":        "(value?) -> bool",
+	"buffer":      "(value?) -> buffer",
+	"byte_slice":  "(value?) -> byte_slice",
+	"byte":        "(value?) -> byte",
+	"call":        "(fn, ...args)",
+	"chan":        "(size?: int) -> channel",
+	"chr":         "(code: int) -> string",
+	"chunk":       "(items: list, size: int) -> list",
+	"close":       "(ch: channel) -> nil",
+	"coalesce":    "(...values)",
+	"decimal":     "(value?) -> decimal",
+	"decode":      "(data, encoding: string)",
+	"delete":      "(container, key)",
+	"encode":      "(data, encoding: string)",
+	"error":       "(message: string | error, ...args) -> error",
+	"float_slice": "(value?) -> float_slice",
+	"float":       "(value?) -> float",
+	"getattr":     "(obj, name: string, default?)",
+	"hash":        "(data, algorithm?: string) -> byte_slice",
+	"int":         "(value?) -> int",
+	"is_hashable": "(value) -> bool",
+	"iter":        "(container)",
+	"keys":        "(container) -> list",
+	"len":         "(container) -> int",
+	"list":        "(items?) -> list",
+	"make":        "(typ, size?: int)",
+	"map":         "(items?) -> map",
+	"ord":         "(char: string) -> int",
+	"reversed":    "(items)",
+	"set":         "(items?) -> set",
+	"sorted":      "(items, key?) -> list",
+	"spawn":       "(fn, ...args) -> thread",
+	"sprintf":     "(format: string, ...args) -> string",
+	"string":      "(value?) -> string",
+	"try":         "(...funcs)",
+	"type":        "(value) -> string",
+}
+
 func Builtins() map[string]object.Object {
-	return map[string]object.Object{
+	builtins := map[string]*object.Builtin{
 		"all":         object.NewBuiltin("all", All),
 		"any":         object.NewBuiltin("any", Any),
 		"assert":      object.NewBuiltin("assert", Assert),
+		"bigint":      object.NewBuiltin("bigint", BigInt),
 		"bool":        object.NewBuiltin("bool", Bool),
 		"buffer":      object.NewBuiltin("buffer", Buffer),
 		"byte_slice":  object.NewBuiltin("byte_slice", ByteSlice),
@@ -977,6 +1142,7 @@ func Builtins() map[string]object.Object {
 		"chunk":       object.NewBuiltin("chunk", Chunk),
 		"close":       object.NewBuiltin("close", Close),
 		"coalesce":    object.NewBuiltin("coalesce", Coalesce),
+		"decimal":     object.NewBuiltin("decimal", Decimal),
 		"decode":      object.NewBuiltin("decode", Decode),
 		"delete":      object.NewBuiltin("delete", Delete),
 		"encode":      object.NewBuiltin("encode", Encode),
@@ -1003,4 +1169,12 @@ func Builtins() map[string]object.Object {
 		"try":         object.NewBuiltin("try", Try),
 		"type":        object.NewBuiltin("type", Type),
 	}
+	result := make(map[string]object.Object, len(builtins))
+	for name, b := range builtins {
+		if sig, ok := signatures[name]; ok {
+			b.WithSignature(object.MustParseSignature(sig))
+		}
+		result[name] = b
+	}
+	return result
 }
@@ -299,11 +299,11 @@ func decodeJSON(ctx context.Context, obj object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	var result interface{}
-	if err := json.Unmarshal([]byte(data), &result); err != nil {
-		return object.NewError(err)
+	result, decodeErr := object.FromJSON(data)
+	if decodeErr != nil {
+		return object.NewError(decodeErr)
 	}
-	return object.FromGoType(result)
+	return result
 }
 
 func decodeCsv(ctx context.Context, obj object.Object) object.Object {
@@ -0,0 +1,834 @@
+// Package check implements a static checker for Risor programs. The checker
+// infers the types of expressions and uses optional type annotations, along
+// with the signatures attached to builtins, to report type mismatches, calls
+// with the wrong number of arguments, and calls to unknown module functions,
+// all without running any code.
+package check
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/token"
+)
+
+// Diagnostic describes a problem found by the checker.
+type Diagnostic struct {
+	// Message describes the problem.
+	Message string
+
+	// Position is the location in the source code of the problem.
+	Position token.Position
+}
+
+func (d Diagnostic) String() string {
+	location := fmt.Sprintf("%d:%d", d.Position.LineNumber(), d.Position.ColumnNumber())
+	if d.Position.File != "" {
+		location = d.Position.File + ":" + location
+	}
+	return fmt.Sprintf("%s: %s", location, d.Message)
+}
+
+// Option is a configuration function for the checker.
+type Option func(*checker)
+
+// WithGlobals makes the given global variables known to the checker. The
+// signatures of builtins are used to check calls and modules are used to
+// detect calls to functions that do not exist.
+func WithGlobals(globals map[string]any) Option {
+	return func(c *checker) {
+		for name, value := range globals {
+			c.globals.declare(name, globalSymbol(name, value))
+		}
+	}
+}
+
+// WithFilename sets the name of the file being checked, which is included in
+// the position of each diagnostic.
+func WithFilename(filename string) Option {
+	return func(c *checker) {
+		c.filename = filename
+	}
+}
+
+// Check analyzes the program and returns the problems found, ordered by
+// their position in the source code.
+func Check(program *ast.Program, options ...Option) []Diagnostic {
+	c := &checker{
+		globals: newScope(nil),
+		structs: map[string]bool{},
+	}
+	for _, opt := range options {
+		opt(c)
+	}
+	c.block(program.Statements(), newScope(c.globals))
+	sort.SliceStable(c.diagnostics, func(i, j int) bool {
+		a, b := c.diagnostics[i].Position, c.diagnostics[j].Position
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return c.diagnostics
+}
+
+// function describes a value known to be a callable function.
+type function struct {
+	name string
+	sig  *object.Signature
+}
+
+// symbol holds what is known about a named value.
+type symbol struct {
+	types     typeSet
+	declared  typeSet // set if the variable has a type annotation
+	annotated bool
+	fn        *function
+	module    *object.Module
+}
+
+type scope struct {
+	parent  *scope
+	symbols map[string]*symbol
+}
+
+func newScope(parent *scope) *scope {
+	return &scope{parent: parent, symbols: map[string]*symbol{}}
+}
+
+func (s *scope) declare(name string, sym *symbol) {
+	s.symbols[name] = sym
+}
+
+func (s *scope) lookup(name string) (*symbol, bool) {
+	for current := s; current != nil; current = current.parent {
+		if sym, ok := current.symbols[name]; ok {
+			return sym, true
+		}
+	}
+	return nil, false
+}
+
+// funcContext tracks the function whose body is being checked.
+type funcContext struct {
+	name        string
+	returns     typeSet // the annotated return types, if any
+	annotated   bool
+	inferred    typeSet
+	hasReturn   bool
+	isGenerator bool
+}
+
+func (f *funcContext) addReturn(types typeSet) {
+	if !f.hasReturn {
+		f.inferred = types
+		f.hasReturn = true
+		return
+	}
+	f.inferred = union(f.inferred, types)
+}
+
+type checker struct {
+	filename    string
+	globals     *scope
+	structs     map[string]bool
+	fn          *funcContext
+	diagnostics []Diagnostic
+}
+
+func globalSymbol(name string, value any) *symbol {
+	switch value := value.(type) {
+	case *object.Module:
+		return &symbol{types: types(object.MODULE), module: value}
+	case *object.Builtin:
+		return &symbol{
+			types: types(object.BUILTIN),
+			fn:    &function{name: name, sig: value.Signature()},
+		}
+	case object.Object:
+		return &symbol{types: types(value.Type())}
+	default:
+		return &symbol{}
+	}
+}
+
+func (c *checker) errorf(tok token.Token, format string, args ...any) {
+	position := tok.StartPosition
+	if position.File == "" {
+		position.File = c.filename
+	}
+	c.diagnostics = append(c.diagnostics, Diagnostic{
+		Message:  fmt.Sprintf(format, args...),
+		Position: position,
+	})
+}
+
+// block checks a sequence of statements in the given scope and returns the
+// types of the final statement, if it is an expression.
+func (c *checker) block(statements []ast.Node, s *scope) typeSet {
+	// Named functions and structs may be referenced before their definition,
+	// for example from within the body of another function.
+	for _, stmt := range statements {
+		switch stmt := stmt.(type) {
+		case *ast.Func:
+			if stmt.Name() != nil {
+				s.declare(stmt.Name().Literal(), &symbol{
+					types: types(object.FUNCTION),
+					fn:    &function{name: stmt.Name().Literal(), sig: c.signature(stmt)},
+				})
+			}
+		case *ast.Struct:
+			c.structs[stmt.Name().Literal()] = true
+			s.declare(stmt.Name().Literal(), &symbol{types: types(object.STRUCT_TYPE)})
+		}
+	}
+	var result typeSet
+	for _, stmt := range statements {
+		result = c.node(stmt, s)
+		if !stmt.IsExpression() {
+			result = nil
+		}
+	}
+	return result
+}
+
+// node checks the given node and returns the types it may evaluate to.
+func (c *checker) node(n ast.Node, s *scope) typeSet {
+	switch n := n.(type) {
+	case nil:
+		return nil
+	case *ast.Int:
+		return types(object.INT)
+	case *ast.Float:
+		return types(object.FLOAT)
+	case *ast.Bool:
+		return types(object.BOOL)
+	case *ast.Nil:
+		return types(object.NIL)
+	case *ast.String:
+		for _, expr := range n.TemplateExpressions() {
+			c.node(expr, s)
+		}
+		return types(object.STRING)
+	case *ast.List:
+		for _, item := range n.Items() {
+			c.node(item, s)
+		}
+		return types(object.LIST)
+	case *ast.Map:
+		for key, value := range n.Items() {
+			c.node(key, s)
+			c.node(value, s)
+		}
+		return types(object.MAP)
+	case *ast.Set:
+		for _, item := range n.Items() {
+			c.node(item, s)
+		}
+		return types(object.SET)
+	case *ast.Ident:
+		if sym, ok := s.lookup(n.Literal()); ok {
+			return sym.types
+		}
+		return nil
+	case *ast.Prefix:
+		right := c.node(n.Right(), s)
+		switch n.Operator() {
+		case "!", "not":
+			return types(object.BOOL)
+		case "-":
+			if right.isOnly(object.INT, object.FLOAT) {
+				return right
+			}
+		}
+		return nil
+	case *ast.Infix:
+		return c.infix(n.Operator(), c.node(n.Left(), s), c.node(n.Right(), s))
+	case *ast.In:
+		c.node(n.Left(), s)
+		c.node(n.Right(), s)
+		return types(object.BOOL)
+	case *ast.NotIn:
+		c.node(n.Left(), s)
+		c.node(n.Right(), s)
+		return types(object.BOOL)
+	case *ast.Ternary:
+		c.node(n.Condition(), s)
+		return union(c.node(n.IfTrue(), s), c.node(n.IfFalse(), s))
+	case *ast.If:
+		c.node(n.Condition(), s)
+		c.node(n.Consequence(), s)
+		c.node(n.Alternative(), s)
+		return nil
+	case *ast.Block:
+		if n == nil {
+			return nil
+		}
+		return c.block(n.Statements(), newScope(s))
+	case *ast.Func:
+		c.function(n, s)
+		return types(object.FUNCTION)
+	case *ast.Call:
+		return c.call(n, s, true)
+	case *ast.ObjectCall:
+		return c.objectCall(n, s, true)
+	case *ast.GetAttr:
+		c.node(n.Object(), s)
+		return nil
+	case *ast.Index:
+		c.node(n.Left(), s)
+		c.node(n.Index(), s)
+		return nil
+	case *ast.Slice:
+		c.node(n.Left(), s)
+		c.node(n.FromIndex(), s)
+		c.node(n.ToIndex(), s)
+		return nil
+	case *ast.Pipe:
+		// Each call in a pipe receives an additional argument, so arities
+		// are not checked
+		for _, expr := range n.Expressions() {
+			switch expr := expr.(type) {
+			case *ast.Call:
+				c.call(expr, s, false)
+			case *ast.ObjectCall:
+				c.objectCall(expr, s, false)
+			default:
+				c.node(expr, s)
+			}
+		}
+		return nil
+	case *ast.Spread:
+		c.node(n.Value(), s)
+		return nil
+	case *ast.Range:
+		c.node(n.Container(), s)
+		return nil
+	case *ast.Receive:
+		c.node(n.Channel(), s)
+		return nil
+	case *ast.Switch:
+		c.node(n.Value(), s)
+		for _, choice := range n.Choices() {
+			for _, expr := range choice.Expressions() {
+				c.node(expr, s)
+			}
+			c.node(choice.Block(), s)
+		}
+		return nil
+	case *ast.Match:
+		c.node(n.Value(), s)
+		for _, matchCase := range n.Cases() {
+			// Patterns may bind names, so they are not checked as expressions
+			caseScope := newScope(s)
+			c.node(matchCase.Guard(), caseScope)
+			c.node(matchCase.Block(), caseScope)
+		}
+		return nil
+	case *ast.ListComprehension:
+		inner := c.clauses(n.Clauses(), s)
+		c.node(n.Element(), inner)
+		return types(object.LIST)
+	case *ast.MapComprehension:
+		inner := c.clauses(n.Clauses(), s)
+		c.node(n.Key(), inner)
+		c.node(n.Value(), inner)
+		return types(object.MAP)
+	case *ast.SetComprehension:
+		inner := c.clauses(n.Clauses(), s)
+		c.node(n.Element(), inner)
+		return types(object.SET)
+	case *ast.Var:
+		name, value := n.Value()
+		c.declare(name, n.Type(), value, s)
+		return nil
+	case *ast.MultiVar:
+		names, value := n.Value()
+		c.node(value, s)
+		for _, name := range names {
+			c.assignUnknown(name, s)
+		}
+		return nil
+	case *ast.Destructure:
+		_, value := n.Value()
+		c.node(value, s)
+		for _, name := range n.Names() {
+			c.assignUnknown(name, s)
+		}
+		return nil
+	case *ast.Const:
+		name, value := n.Value()
+		c.declare(name, n.Type(), value, s)
+		return nil
+	case *ast.Assign:
+		c.assign(n, s)
+		return nil
+	case *ast.SetAttr:
+		c.node(n.Object(), s)
+		c.node(n.Value(), s)
+		return nil
+	case *ast.Return:
+		c.returnValue(n.Token(), n.Value(), s)
+		return nil
+	case *ast.Yield:
+		c.node(n.Value(), s)
+		if c.fn != nil {
+			c.fn.isGenerator = true
+		}
+		return nil
+	case *ast.Control:
+		c.node(n.Value(), s)
+		return nil
+	case *ast.For:
+		inner := newScope(s)
+		c.node(n.Init(), inner)
+		c.node(n.Condition(), inner)
+		c.node(n.Post(), inner)
+		c.node(n.Consequence(), inner)
+		return nil
+	case *ast.ForIn:
+		inner := newScope(s)
+		c.node(n.Iterable(), s)
+		if n.Variable() != nil {
+			inner.declare(n.Variable().Literal(), &symbol{})
+		}
+		for _, name := range ast.TargetNames(n.Targets()) {
+			inner.declare(name, &symbol{})
+		}
+		c.node(n.Consequence(), inner)
+		return nil
+	case *ast.Import:
+		s.declare(n.ModuleName(), &symbol{types: types(object.MODULE)})
+		return nil
+	case *ast.FromImport:
+		for _, imp := range n.Imports() {
+			s.declare(imp.ModuleName(), &symbol{})
+		}
+		return nil
+	case *ast.Struct:
+		for _, value := range n.Defaults() {
+			c.node(value, s)
+		}
+		return nil
+	case *ast.Try:
+		c.node(n.Body(), s)
+		catchScope := newScope(s)
+		if n.CatchIdent() != nil {
+			catchScope.declare(n.CatchIdent().Literal(), &symbol{types: types(object.ERROR)})
+		}
+		c.node(n.CatchBlock(), catchScope)
+		c.node(n.FinallyBlock(), s)
+		return nil
+	case *ast.Select:
+		for _, selectCase := range n.Cases() {
+			caseScope := newScope(s)
+			if send := selectCase.Send(); send != nil {
+				c.node(send, s)
+			}
+			if receive := selectCase.Receive(); receive != nil {
+				c.node(receive, s)
+			}
+			for _, ident := range []*ast.Ident{selectCase.ValueIdent(), selectCase.OkIdent()} {
+				if ident != nil {
+					caseScope.declare(ident.Literal(), &symbol{})
+				}
+			}
+			c.node(selectCase.Block(), caseScope)
+		}
+		return nil
+	case *ast.Go:
+		c.node(n.Call(), s)
+		return nil
+	case *ast.Defer:
+		c.node(n.Call(), s)
+		return nil
+	case *ast.Send:
+		c.node(n.Channel(), s)
+		c.node(n.Value(), s)
+		return nil
+	}
+	return nil
+}
+
+// infix returns the types resulting from a binary operation.
+func (c *checker) infix(operator string, left, right typeSet) typeSet {
+	switch operator {
+	case "==", "!=", "<", ">", "<=", ">=":
+		return types(object.BOOL)
+	case "+", "-", "*", "/", "%", "**":
+		if left.is(object.INT) && right.is(object.INT) {
+			return types(object.INT)
+		}
+		if left.isOnly(object.INT, object.FLOAT) && right.isOnly(object.INT, object.FLOAT) {
+			return types(object.FLOAT)
+		}
+		if operator == "+" {
+			for _, typ := range []object.Type{object.STRING, object.LIST} {
+				if left.is(typ) && right.is(typ) {
+					return types(typ)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (c *checker) clauses(clauses []*ast.ComprehensionClause, s *scope) *scope {
+	inner := newScope(s)
+	for _, clause := range clauses {
+		c.node(clause.Iterable(), inner)
+		for _, name := range clause.Names() {
+			inner.declare(name.Literal(), &symbol{})
+		}
+		for _, cond := range clause.Conditions() {
+			c.node(cond, inner)
+		}
+	}
+	return inner
+}
+
+// annotation converts a type annotation to a typeSet, reporting any type
+// names that are not recognized.
+func (c *checker) annotation(annotation *ast.TypeAnnotation) typeSet {
+	names := annotationTypes(annotation)
+	for i, name := range names {
+		if c.structs[string(name)] {
+			names[i] = object.STRUCT
+		} else if !typeNames[name] {
+			c.errorf(annotation.Token(), "type error: unknown type %q", name)
+			return nil
+		}
+	}
+	return newTypeSet(names)
+}
+
+// signature returns the signature of a Risor function, built from its
+// parameters and any type annotations.
+func (c *checker) signature(fn *ast.Func) *object.Signature {
+	sig := &object.Signature{}
+	defaults := fn.Defaults()
+	for _, param := range fn.Parameters() {
+		name := param.Literal()
+		_, hasDefault := defaults[name]
+		sig.Params = append(sig.Params, object.Param{
+			Name:     name,
+			Types:    c.annotation(fn.ParameterType(name)),
+			Optional: hasDefault,
+		})
+	}
+	if rest := fn.RestParameter(); rest != nil {
+		sig.Params = append(sig.Params, object.Param{
+			Name:     rest.Literal(),
+			Variadic: true,
+		})
+	}
+	sig.Returns = c.annotation(fn.ReturnType())
+	return sig
+}
+
+// function checks the body of a Risor function and returns a description
+// of it. Unless the return type is annotated, it is inferred from the body.
+func (c *checker) function(fn *ast.Func, s *scope) *function {
+	name := "function"
+	var sig *object.Signature
+	if fn.Name() != nil {
+		name = fn.Name().Literal()
+		// Reuse the signature of the predeclared function, if any, so that
+		// the return types inferred below are visible to callers
+		if sym, ok := s.symbols[name]; ok && sym.fn != nil {
+			sig = sym.fn.sig
+		}
+	}
+	if sig == nil {
+		sig = c.signature(fn)
+	}
+	result := &function{name: name, sig: sig}
+	if fn.Name() != nil {
+		s.declare(name, &symbol{types: types(object.FUNCTION), fn: result})
+	}
+
+	inner := newScope(s)
+	for i, param := range fn.Parameters() {
+		expected := newTypeSet(sig.Params[i].Types)
+		if value, ok := fn.Defaults()[param.Literal()]; ok {
+			if actual := c.node(value, s); !compatible(expected, actual) {
+				c.errorf(value.Token(), "type error: %s() parameter %s expected %s (%s given as default)",
+					name, param.Literal(), expected, actual)
+			}
+		}
+		inner.declare(param.Literal(), &symbol{
+			types:     expected,
+			declared:  expected,
+			annotated: expected.isKnown(),
+		})
+	}
+	if rest := fn.RestParameter(); rest != nil {
+		inner.declare(rest.Literal(), &symbol{types: types(object.LIST)})
+	}
+
+	outer := c.fn
+	c.fn = &funcContext{
+		name:      name,
+		returns:   newTypeSet(sig.Returns),
+		annotated: len(sig.Returns) > 0,
+	}
+	statements := fn.Body().Statements()
+	tail := c.block(statements, inner)
+	var last ast.Node
+	if len(statements) > 0 {
+		last = statements[len(statements)-1]
+	}
+	switch {
+	case last != nil && isReturn(last):
+		// Already recorded when the return statement was checked
+	case last != nil && last.IsExpression():
+		c.fn.addReturn(tail)
+		c.checkReturn(last.Token(), tail)
+	default:
+		c.fn.addReturn(types(object.NIL))
+	}
+	if !c.fn.annotated {
+		if c.fn.isGenerator {
+			sig.Returns = []object.Type{object.GENERATOR}
+		} else {
+			sig.Returns = c.fn.inferred
+		}
+	}
+	c.fn = outer
+	return result
+}
+
+func isReturn(n ast.Node) bool {
+	_, ok := n.(*ast.Return)
+	return ok
+}
+
+func (c *checker) returnValue(tok token.Token, value ast.Expression, s *scope) {
+	result := types(object.NIL)
+	if value != nil {
+		result = c.node(value, s)
+	}
+	if c.fn == nil {
+		return
+	}
+	c.fn.addReturn(result)
+	c.checkReturn(tok, result)
+}
+
+func (c *checker) checkReturn(tok token.Token, result typeSet) {
+	if c.fn == nil || !c.fn.annotated || c.fn.isGenerator {
+		return
+	}
+	if !compatible(c.fn.returns, result) {
+		c.errorf(tok, "type error: %s() must return %s (%s returned)",
+			c.fn.name, c.fn.returns, result)
+	}
+}
+
+// declare adds a new variable to the scope, checking its value against the
+// type annotation, if there is one.
+func (c *checker) declare(name string, annotation *ast.TypeAnnotation, value ast.Expression, s *scope) {
+	var actual typeSet
+	var fn *function
+	switch value := value.(type) {
+	case *ast.Func:
+		fn = c.function(value, s)
+		if value.Name() == nil {
+			fn.name = name
+		}
+		actual = types(object.FUNCTION)
+	case *ast.Ident:
+		if sym, ok := s.lookup(value.Literal()); ok {
+			fn = sym.fn
+		}
+		actual = c.node(value, s)
+	default:
+		actual = c.node(value, s)
+	}
+	sym := &symbol{types: actual, fn: fn}
+	if annotation != nil {
+		declared := c.annotation(annotation)
+		if !compatible(declared, actual) {
+			c.errorf(value.Token(), "type error: cannot assign %s to %s (declared as %s)",
+				actual, name, declared)
+		}
+		if declared.isKnown() {
+			sym.types = declared
+			sym.declared = declared
+			sym.annotated = true
+		}
+	}
+	s.declare(name, sym)
+}
+
+// assignUnknown records that a value of unknown type was assigned to the
+// named variable, declaring it if necessary.
+func (c *checker) assignUnknown(name string, s *scope) {
+	if sym, ok := s.lookup(name); ok {
+		if !sym.annotated {
+			sym.types = nil
+			sym.fn = nil
+		}
+		return
+	}
+	s.declare(name, &symbol{})
+}
+
+func (c *checker) assign(a *ast.Assign, s *scope) {
+	if index := a.Index(); index != nil {
+		c.node(index, s)
+		c.node(a.Value(), s)
+		return
+	}
+	value := c.node(a.Value(), s)
+	sym, ok := s.lookup(a.Name())
+	if !ok {
+		return
+	}
+	if op := a.Operator(); op != "=" {
+		value = c.infix(op[:len(op)-1], sym.types, value)
+	}
+	if sym.annotated {
+		if !compatible(sym.declared, value) {
+			c.errorf(a.Value().Token(), "type error: cannot assign %s to %s (declared as %s)",
+				value, a.Name(), sym.declared)
+		}
+		return
+	}
+	sym.types = union(sym.types, value)
+	sym.fn = nil
+}
+
+// call checks a call to a function. Arities are only checked if checkArity
+// is true.
+func (c *checker) call(call *ast.Call, s *scope, checkArity bool) typeSet {
+	var fn *function
+	switch callee := call.Function().(type) {
+	case *ast.Ident:
+		if sym, ok := s.lookup(callee.Literal()); ok {
+			if !callable(sym.types) {
+				c.errorf(callee.Token(), "type error: %s is not callable (%s)", callee.Literal(), sym.types)
+			}
+			fn = sym.fn
+			if sym.types.is(object.STRUCT_TYPE) {
+				c.arguments(call, s)
+				return types(object.STRUCT)
+			}
+		}
+	case *ast.Func:
+		fn = c.function(callee, s)
+	default:
+		c.node(callee, s)
+	}
+	return c.checkCall(call, fn, s, checkArity)
+}
+
+// objectCall checks a method call. Calls to functions in known modules are
+// checked the same way as other calls.
+func (c *checker) objectCall(n *ast.ObjectCall, s *scope, checkArity bool) typeSet {
+	objectTypes := c.node(n.Object(), s)
+	call, ok := n.Call().(*ast.Call)
+	if !ok {
+		c.node(n.Call(), s)
+		return nil
+	}
+	ident, isIdent := call.Function().(*ast.Ident)
+	moduleIdent, isModule := n.Object().(*ast.Ident)
+	if !isIdent || !isModule || !objectTypes.is(object.MODULE) {
+		c.arguments(call, s)
+		return nil
+	}
+	sym, ok := s.lookup(moduleIdent.Literal())
+	if !ok || sym.module == nil {
+		c.arguments(call, s)
+		return nil
+	}
+	name := fmt.Sprintf("%s.%s", moduleIdent.Literal(), ident.Literal())
+	attr, found := sym.module.GetAttr(ident.Literal())
+	if !found {
+		if !n.IsOptional() {
+			c.errorf(ident.Token(), "name error: unknown function %s()", name)
+		}
+		c.arguments(call, s)
+		return nil
+	}
+	var fn *function
+	if builtin, ok := attr.(*object.Builtin); ok {
+		fn = &function{name: name, sig: builtin.Signature()}
+	}
+	return c.checkCall(call, fn, s, checkArity)
+}
+
+// arguments checks the arguments of a call and returns their types.
+func (c *checker) arguments(call *ast.Call, s *scope) ([]typeSet, bool) {
+	var args []typeSet
+	var hasSpread bool
+	for _, arg := range call.Arguments() {
+		if _, ok := arg.(*ast.Spread); ok {
+			hasSpread = true
+		}
+		args = append(args, c.node(arg, s))
+	}
+	for _, kwarg := range call.Keywords() {
+		c.node(kwarg.Value(), s)
+	}
+	return args, hasSpread
+}
+
+func (c *checker) checkCall(call *ast.Call, fn *function, s *scope, checkArity bool) typeSet {
+	args, hasSpread := c.arguments(call, s)
+	if fn == nil || fn.sig == nil {
+		return nil
+	}
+	sig := fn.sig
+	tok := call.Function().Token()
+	if checkArity && !hasSpread && len(call.Keywords()) == 0 {
+		nArgs := len(args)
+		minArgs, maxArgs := sig.MinArgs(), sig.MaxArgs()
+		switch {
+		case minArgs == maxArgs && nArgs != minArgs:
+			c.errorf(tok, "args error: %s() takes exactly %d %s (%d given)",
+				fn.name, minArgs, pluralize("argument", minArgs), nArgs)
+		case nArgs < minArgs:
+			c.errorf(tok, "args error: %s() takes at least %d %s (%d given)",
+				fn.name, minArgs, pluralize("argument", minArgs), nArgs)
+		case maxArgs >= 0 && nArgs > maxArgs:
+			c.errorf(tok, "args error: %s() takes at most %d %s (%d given)",
+				fn.name, maxArgs, pluralize("argument", maxArgs), nArgs)
+		}
+	}
+	if checkArity && !hasSpread {
+		for i, actual := range args {
+			param, ok := paramAt(sig, i)
+			if !ok {
+				break
+			}
+			expected := newTypeSet(param.Types)
+			if !compatible(expected, actual) {
+				c.errorf(call.Arguments()[i].Token(), "type error: %s() argument %d (%s) expected %s (%s given)",
+					fn.name, i+1, param.Name, expected, actual)
+			}
+		}
+	}
+	return newTypeSet(sig.Returns)
+}
+
+// paramAt returns the parameter that receives the argument at index i.
+func paramAt(sig *object.Signature, i int) (object.Param, bool) {
+	if i < len(sig.Params) && !sig.Params[i].Variadic {
+		return sig.Params[i], true
+	}
+	if n := len(sig.Params); n > 0 && sig.Params[n-1].Variadic {
+		return sig.Params[n-1], true
+	}
+	return object.Param{}, false
+}
+
+func pluralize(s string, count int) string {
+	if count == 1 {
+		return s
+	}
+	return s + "s"
+}
@@ -0,0 +1,162 @@
+package check
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/builtins"
+	modMath "github.com/risor-io/risor/modules/math"
+	modStrings "github.com/risor-io/risor/modules/strings"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func testGlobals() map[string]any {
+	globals := map[string]any{
+		"math":    modMath.Module(),
+		"strings": modStrings.Module(),
+	}
+	for name, value := range builtins.Builtins() {
+		globals[name] = value
+	}
+	return globals
+}
+
+func checkSource(t *testing.T, source string) []string {
+	t.Helper()
+	program, err := parser.Parse(context.Background(), source)
+	require.Nil(t, err)
+	var messages []string
+	for _, d := range Check(program, WithGlobals(testGlobals())) {
+		messages = append(messages, d.String())
+	}
+	return messages
+}
+
+func TestCheckValidPrograms(t *testing.T) {
+	tests := []string{
+		`func f(x: int, y: list) -> map { return {x: y} }; f(1, [2])`,
+		`func f(x: float) -> float { x * 2 }; f(1)`,
+		`var x: int | nil = nil; x = 3`,
+		`const name: string = "abc"`,
+		`func f(a, b=2, ...rest) { a }; f(1); f(1, 2, 3, 4)`,
+		`strings.split("a,b", ","); math.sqrt(4)`,
+		`func f(x: any) { x }; f(1); f("a")`,
+		`func apply(fn: func, x) { fn(x) }; apply(len, "a"); apply(func(v) { v }, 1)`,
+		`x := 1; x = "a"; len(x)`,
+		`items := [1]; len(...items)`,
+		`struct Point { x, y }; func f(p: Point) -> int { 1 }; f(Point(1, 2))`,
+		`func g() { yield 1 }; for v in g() { v }`,
+		`func f(x: int) -> int { if x > 0 { return 1 }; return 0 }`,
+		`"a" | strings.to_upper`,
+		`["a", "b"] | strings.join(",")`,
+		`strings?.missing()`,
+		`[a, {name}] := [1, {name: "x"}]; len(name); a + 1`,
+		`for k, {id} in [{id: 1}] { len(k); id }`,
+	}
+	for _, source := range tests {
+		t.Run(source, func(t *testing.T) {
+			require.Empty(t, checkSource(t, source))
+		})
+	}
+}
+
+func TestCheckDiagnostics(t *testing.T) {
+	tests := []struct {
+		source   string
+		expected []string
+	}{
+		{
+			`var x: int = "a"`,
+			[]string{`1:14: type error: cannot assign string to x (declared as int)`},
+		},
+		{
+			`var x: int = 1; x = [1]`,
+			[]string{`1:21: type error: cannot assign list to x (declared as int)`},
+		},
+		{
+			`const x: string | nil = 1.5`,
+			[]string{`1:25: type error: cannot assign float to x (declared as string | nil)`},
+		},
+		{
+			`func f(x: int, y: list) { x }; f("a", [])`,
+			[]string{`1:34: type error: f() argument 1 (x) expected int (string given)`},
+		},
+		{
+			`func f(x: int) -> string { return x }`,
+			[]string{`1:28: type error: f() must return string (int returned)`},
+		},
+		{
+			`func f() -> int { "a" }`,
+			[]string{`1:19: type error: f() must return int (string returned)`},
+		},
+		{
+			`func f(a, b=1) { a }; f(); f(1, 2, 3)`,
+			[]string{
+				`1:23: args error: f() takes at least 1 argument (0 given)`,
+				`1:28: args error: f() takes at most 2 arguments (3 given)`,
+			},
+		},
+		{
+			`len(1, 2)`,
+			[]string{`1:1: args error: len() takes exactly 1 argument (2 given)`},
+		},
+		{
+			`chr("a")`,
+			[]string{`1:5: type error: chr() argument 1 (code) expected int (string given)`},
+		},
+		{
+			`strings.splitt("a,b", ",")`,
+			[]string{`1:9: name error: unknown function strings.splitt()`},
+		},
+		{
+			`strings.split("a,b")`,
+			[]string{`1:9: args error: strings.split() takes exactly 2 arguments (1 given)`},
+		},
+		{
+			`math.sqrt("4")`,
+			[]string{`1:11: type error: math.sqrt() argument 1 (x) expected int | float (string given)`},
+		},
+		{
+			`func f() { 1 }; func g(x: string) { x }; g(f())`,
+			[]string{`1:45: type error: g() argument 1 (x) expected string (int given)`},
+		},
+		{
+			`g := func(a, b) { a }; g(1)`,
+			[]string{`1:24: args error: g() takes exactly 2 arguments (1 given)`},
+		},
+		{
+			`func f(x: widget) { x }`,
+			[]string{`1:11: type error: unknown type "widget"`},
+		},
+		{
+			`func f(x: int = "a") { x }`,
+			[]string{`1:17: type error: f() parameter x expected int (string given as default)`},
+		},
+		{
+			`x := 1; x()`,
+			[]string{`1:9: type error: x is not callable (int)`},
+		},
+		{
+			`func outer() { inner(1) }; func inner() { 1 }`,
+			[]string{`1:16: args error: inner() takes exactly 0 arguments (1 given)`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			require.Equal(t, tt.expected, checkSource(t, tt.source))
+		})
+	}
+}
+
+func TestDiagnosticString(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "\nlen()", parser.WithFilename("main.risor"))
+	require.Nil(t, err)
+	diagnostics := Check(program, WithFilename("main.risor"), WithGlobals(map[string]any{
+		"len": object.NewBuiltin("len", nil).WithSignature(object.MustParseSignature("(x) -> int")),
+	}))
+	require.Len(t, diagnostics, 1)
+	require.Equal(t, "main.risor:2:1: args error: len() takes exactly 1 argument (0 given)",
+		diagnostics[0].String())
+}
@@ -0,0 +1,175 @@
+package check
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/object"
+)
+
+// funcType is the pseudo-type used by the "func" annotation. It accepts any
+// callable value: Risor functions, builtins and partials.
+const funcType object.Type = "func"
+
+// anyType is the annotation that accepts a value of any type.
+const anyType object.Type = "any"
+
+// typeNames holds the type names that may be used in annotations.
+var typeNames = map[object.Type]bool{
+	anyType:              true,
+	funcType:             true,
+	object.BIGINT:        true,
+	object.BOOL:          true,
+	object.BUFFER:        true,
+	object.BUILTIN:       true,
+	object.BYTE:          true,
+	object.BYTE_SLICE:    true,
+	object.CHANNEL:       true,
+	object.COLOR:         true,
+	object.COMPLEX:       true,
+	object.COMPLEX_SLICE: true,
+	object.DECIMAL:       true,
+	object.DIR_ENTRY:     true,
+	object.ERROR:         true,
+	object.FILE:          true,
+	object.FILE_INFO:     true,
+	object.FLOAT:         true,
+	object.FLOAT_SLICE:   true,
+	object.FUNCTION:      true,
+	object.GENERATOR:     true,
+	object.INT:           true,
+	object.LIST:          true,
+	object.MAP:           true,
+	object.MODULE:        true,
+	object.NIL:           true,
+	object.PARTIAL:       true,
+	object.RESULT:        true,
+	object.SET:           true,
+	object.STRING:        true,
+	object.STRUCT:        true,
+	object.STRUCT_TYPE:   true,
+	object.THREAD:        true,
+	object.TIME:          true,
+}
+
+// typeSet holds the possible types of a value. A nil typeSet means that the
+// type is unknown, in which case the value is compatible with any type.
+type typeSet []object.Type
+
+func types(types ...object.Type) typeSet {
+	return typeSet(types)
+}
+
+// newTypeSet converts the given type names to a typeSet. The "any" type
+// makes the whole set unknown.
+func newTypeSet(names []object.Type) typeSet {
+	if len(names) == 0 {
+		return nil
+	}
+	var result typeSet
+	for _, name := range names {
+		if name == anyType {
+			return nil
+		}
+		if !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// annotationTypes returns the type names within an annotation.
+func annotationTypes(annotation *ast.TypeAnnotation) []object.Type {
+	if annotation == nil {
+		return nil
+	}
+	var names []object.Type
+	for _, name := range annotation.Names() {
+		names = append(names, object.Type(name))
+	}
+	return names
+}
+
+func (t typeSet) isKnown() bool {
+	return t != nil
+}
+
+func (t typeSet) is(typ object.Type) bool {
+	return len(t) == 1 && t[0] == typ
+}
+
+// isOnly returns true if every type in the set is one of the given types.
+func (t typeSet) isOnly(allowed ...object.Type) bool {
+	if !t.isKnown() {
+		return false
+	}
+	for _, typ := range t {
+		if !slices.Contains(allowed, typ) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t typeSet) String() string {
+	if !t.isKnown() {
+		return string(anyType)
+	}
+	names := make([]string, 0, len(t))
+	for _, typ := range t {
+		names = append(names, string(typ))
+	}
+	return strings.Join(names, " | ")
+}
+
+// union returns the set of types that may be held by either a or b.
+func union(a, b typeSet) typeSet {
+	if !a.isKnown() || !b.isKnown() {
+		return nil
+	}
+	result := slices.Clone(a)
+	for _, typ := range b {
+		if !slices.Contains(result, typ) {
+			result = append(result, typ)
+		}
+	}
+	return result
+}
+
+// compatible returns true if a value with the actual types may be used where
+// the expected types are required. Values of unknown type are always
+// compatible, since the checker only reports definite mismatches.
+func compatible(expected, actual typeSet) bool {
+	if !expected.isKnown() || !actual.isKnown() {
+		return true
+	}
+	for _, a := range actual {
+		for _, e := range expected {
+			if accepts(e, a) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func accepts(expected, actual object.Type) bool {
+	if expected == actual {
+		return true
+	}
+	switch expected {
+	case funcType:
+		return actual == object.FUNCTION || actual == object.BUILTIN || actual == object.PARTIAL
+	case object.FLOAT:
+		// Integers are accepted wherever floats are, as with most builtins
+		return actual == object.INT
+	}
+	return false
+}
+
+// callable returns false if no type in the set can be called.
+func callable(t typeSet) bool {
+	return !t.isOnly(object.BOOL, object.BYTE, object.FLOAT, object.INT,
+		object.LIST, object.MAP, object.NIL, object.SET, object.STRING)
+}
@@ -18,9 +18,10 @@ type ExportedFunc struct {
 }
 
 type FuncReturn struct {
-	Type     string
-	NewFunc  string
-	CastFunc string
+	Type      string
+	NewFunc   string
+	CastFunc  string
+	RisorType string
 }
 
 type FuncParam struct {
@@ -30,6 +31,28 @@ type FuncParam struct {
 	CastFunc     string
 	CastMaxValue string
 	CastMinValue string
+	RisorType    string
+}
+
+// Signature returns the Risor signature of the function, in the form parsed
+// by [object.ParseSignature].
+func (f ExportedFunc) Signature() string {
+	params := make([]string, 0, len(f.Params))
+	for _, p := range f.Params {
+		if p.RisorType == "" {
+			params = append(params, p.Name)
+		} else {
+			params = append(params, p.Name+": "+p.RisorType)
+		}
+	}
+	sig := "(" + strings.Join(params, ", ") + ")"
+	if f.Return == nil {
+		return sig + " -> nil"
+	}
+	if f.Return.RisorType != "" {
+		return sig + " -> " + f.Return.RisorType
+	}
+	return sig
 }
 
 func (m *Module) parseFuncDecl(decl *ast.FuncDecl) error {
@@ -193,26 +216,26 @@ func (m *Module) parseParamType(typeName string) (FuncParam, error) {
 	case "any", "interface{}":
 		return FuncParam{}, fmt.Errorf("type 'any' is not allowed, use 'object.Object' instead")
 	case "string":
-		return FuncParam{ReadFunc: "AsString"}, nil
+		return FuncParam{ReadFunc: "AsString", RisorType: "string"}, nil
 	case "[]string":
-		return FuncParam{ReadFunc: "AsStringSlice"}, nil
+		return FuncParam{ReadFunc: "AsStringSlice", RisorType: "list"}, nil
 	case "[]byte":
 		return FuncParam{ReadFunc: "AsBytes"}, nil
 	case "bool":
-		return FuncParam{ReadFunc: "AsBool"}, nil
+		return FuncParam{ReadFunc: "AsBool", RisorType: "bool"}, nil
 	case "int64":
-		return FuncParam{ReadFunc: "AsInt"}, nil
+		return FuncParam{ReadFunc: "AsInt", RisorType: "int | byte"}, nil
 	case "int32":
 		m.addImport("math")
-		return FuncParam{ReadFunc: "AsInt", CastFunc: "int32", CastMaxValue: "math.MaxInt32", CastMinValue: "math.MinInt32"}, nil
+		return FuncParam{ReadFunc: "AsInt", CastFunc: "int32", CastMaxValue: "math.MaxInt32", CastMinValue: "math.MinInt32", RisorType: "int | byte"}, nil
 	case "int":
 		m.addImport("math")
-		return FuncParam{ReadFunc: "AsInt", CastFunc: "int", CastMaxValue: "math.MaxInt", CastMinValue: "math.MinInt"}, nil
+		return FuncParam{ReadFunc: "AsInt", CastFunc: "int", CastMaxValue: "math.MaxInt", CastMinValue: "math.MinInt", RisorType: "int | byte"}, nil
 	case "float64":
-		return FuncParam{ReadFunc: "AsFloat"}, nil
+		return FuncParam{ReadFunc: "AsFloat", RisorType: "int | float | byte"}, nil
 	case "float32":
 		m.addImport("math")
-		return FuncParam{ReadFunc: "AsFloat", CastFunc: "float32", CastMaxValue: "math.MaxFloat32"}, nil
+		return FuncParam{ReadFunc: "AsFloat", CastFunc: "float32", CastMaxValue: "math.MaxFloat32", RisorType: "int | float | byte"}, nil
 	default:
 		return FuncParam{}, fmt.Errorf("unsupported parameter type: %q", typeName)
 	}
@@ -239,21 +262,21 @@ func (m *Module) parseReturnType(typeName string) (FuncReturn, error) {
 	case "any", "interface{}":
 		return FuncReturn{}, fmt.Errorf("type 'any' is not allowed, use 'object.Object' instead")
 	case "string":
-		return FuncReturn{NewFunc: "NewString"}, nil
+		return FuncReturn{NewFunc: "NewString", RisorType: "string"}, nil
 	case "[]string":
-		return FuncReturn{NewFunc: "NewStringList"}, nil
+		return FuncReturn{NewFunc: "NewStringList", RisorType: "list"}, nil
 	case "[]byte":
-		return FuncReturn{NewFunc: "NewByteSlice"}, nil
+		return FuncReturn{NewFunc: "NewByteSlice", RisorType: "byte_slice"}, nil
 	case "bool":
-		return FuncReturn{NewFunc: "NewBool"}, nil
+		return FuncReturn{NewFunc: "NewBool", RisorType: "bool"}, nil
 	case "int64":
-		return FuncReturn{NewFunc: "NewInt"}, nil
+		return FuncReturn{NewFunc: "NewInt", RisorType: "int"}, nil
 	case "int", "int32":
-		return FuncReturn{NewFunc: "NewInt", CastFunc: "int64"}, nil
+		return FuncReturn{NewFunc: "NewInt", CastFunc: "int64", RisorType: "int"}, nil
 	case "float64":
-		return FuncReturn{NewFunc: "NewFloat"}, nil
+		return FuncReturn{NewFunc: "NewFloat", RisorType: "float"}, nil
 	case "float32":
-		return FuncReturn{NewFunc: "NewFloat", CastFunc: "float64"}, nil
+		return FuncReturn{NewFunc: "NewFloat", CastFunc: "float64", RisorType: "float"}, nil
 	default:
 		return FuncReturn{}, fmt.Errorf("unsupported return type: %q", typeName)
 	}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/importer"
+	"github.com/spf13/cobra"
+)
+
+const buildExample = `  risor build ./path/to/script.risor
+
+  risor build ./path/to/script.risor -o script.rsc
+
+  risor script.rsc`
+
+var buildCmd = &cobra.Command{
+	Use:     "build",
+	Short:   "Compile a Risor script and the modules it imports",
+	Example: buildExample,
+	Args:    cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		processGlobalFlags()
+		path := args[0]
+		source, err := os.ReadFile(path)
+		if err != nil {
+			fatal(err)
+		}
+
+		// Compile the script then gather the modules it imports
+		opts := append(getRisorOptions(), risor.WithFilename(path))
+		main, err := compileRisorCode(ctx, string(source), path, opts)
+		if err != nil {
+			fatal(err)
+		}
+		bundle, err := importer.NewBundle(ctx, main, getImporter(opts))
+		if err != nil {
+			fatal(err)
+		}
+		data, err := importer.MarshalBundle(bundle)
+		if err != nil {
+			fatal(err)
+		}
+
+		// By default, the output is written next to the script
+		output := cmd.Flag("output").Value.String()
+		if output == "" {
+			output = strings.TrimSuffix(path, filepath.Ext(path)) + ".rsc"
+		}
+		if err := os.WriteFile(output, data, 0o644); err != nil {
+			fatal(err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(buildCmd)
+	buildCmd.Flags().StringP("output", "o", "", "Path of the compiled output")
+}
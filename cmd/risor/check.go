@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/check"
+	"github.com/risor-io/risor/parser"
+	"github.com/spf13/cobra"
+)
+
+const checkExample = `  risor check -c "func f(x: int) { x }; f(\"a\")"
+
+  risor check ./path/to/script.risor`
+
+var checkCmd = &cobra.Command{
+	Use:     "check",
+	Short:   "Check Risor code for type errors without running it",
+	Example: checkExample,
+	Args:    cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		ctx := context.Background()
+		processGlobalFlags()
+		opts := getRisorOptions()
+		code, err := getRisorCode(cmd, args)
+		if err != nil {
+			fatal(err)
+		}
+		var filename string
+		if len(args) > 0 {
+			filename = args[0]
+		}
+
+		ast, err := parser.Parse(ctx, code, parser.WithFilename(filename))
+		if err != nil {
+			fatal(err)
+		}
+		cfg := risor.NewConfig(opts...)
+		diagnostics := check.Check(ast,
+			check.WithFilename(filename),
+			check.WithGlobals(cfg.Globals()))
+
+		// Print each problem found and exit non-zero if there were any
+		for _, d := range diagnostics {
+			fmt.Println(d.String())
+		}
+		if len(diagnostics) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(checkCmd)
+}
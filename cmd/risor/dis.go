@@ -17,7 +17,9 @@ const disExample = `  risor dis -c "a := 1 + 2"
 
   risor dis ./path/to/script.risor
 
-  risor dis ./path/to/script.risor --func myfunc`
+  risor dis ./path/to/script.risor --func myfunc
+
+  risor dis ./path/to/script.risor --no-optimize`
 
 var disCmd = &cobra.Command{
 	Use:     "dis",
@@ -6,23 +6,43 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/require"
 )
 
-func TestDisassembly(t *testing.T) {
+func captureDisassembly(args []string) string {
 	// Capture stdout
 	old := os.Stdout
 	r, w, _ := os.Pipe()
 	os.Stdout = w
 	defer func() { os.Stdout = old }()
 
-	disCmd.Run(disCmd, []string{"fixtures/ex1.risor"})
+	disCmd.Run(disCmd, args)
 
 	w.Close()
 
 	var buf bytes.Buffer
 	_, _ = buf.ReadFrom(r)
-	capturedOutput := buf.String()
+	return buf.String()
+}
+
+func TestDisassembly(t *testing.T) {
+	capturedOutput := captureDisassembly([]string{"fixtures/ex1.risor"})
+	expected := `
++--------+------------+----------+------+
+| OFFSET |   OPCODE   | OPERANDS | INFO |
++--------+------------+----------+------+
+|      0 | LOAD_CONST |        0 | 7    |
++--------+------------+----------+------+
+`
+	require.Equal(t, strings.TrimPrefix(expected, "\n"), capturedOutput)
+}
+
+func TestDisassemblyWithoutOptimization(t *testing.T) {
+	viper.Set("no-optimize", true)
+	defer viper.Set("no-optimize", false)
+
+	capturedOutput := captureDisassembly([]string{"fixtures/ex1.risor"})
 	expected := `
 +--------+------------+----------+------+
 | OFFSET |   OPCODE   | OPERANDS | INFO |
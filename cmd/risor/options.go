@@ -1,11 +1,14 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"io"
 	"os"
 
 	"github.com/risor-io/risor"
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/importer"
 	"github.com/risor-io/risor/modules/aws"
 	"github.com/risor-io/risor/modules/bcrypt"
 	"github.com/risor-io/risor/modules/cli"
@@ -35,6 +38,8 @@ import (
 	"github.com/risor-io/risor/modules/uuid"
 	"github.com/risor-io/risor/modules/vault"
 	"github.com/risor-io/risor/modules/yaml"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/parser"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -112,12 +117,87 @@ func getRisorOptions() []risor.Option {
 		risor.WithListenersAllowed(),
 		getGlobals(),
 	}
-	if modulesDir := viper.GetString("modules"); modulesDir != "" {
-		opts = append(opts, risor.WithLocalImporter(modulesDir))
+	if imp := getImporter(opts); imp != nil {
+		opts = append(opts, risor.WithImporter(imp))
+	}
+	if viper.GetBool("no-optimize") {
+		opts = append(opts, risor.WithoutOptimization())
 	}
 	return opts
 }
 
+// Returns an importer for the modules in the modules directory, or nil if no
+// directory is set. The global names are taken from the given options.
+func getImporter(opts []risor.Option) importer.Importer {
+	modulesDir := viper.GetString("modules")
+	if modulesDir == "" {
+		return nil
+	}
+	cfg := risor.NewConfig(opts...)
+	return importer.NewLocalImporter(importer.LocalImporterOptions{
+		GlobalNames: cfg.GlobalNames(),
+		SourceDir:   modulesDir,
+		Extensions:  []string{".risor", ".rsr"},
+		Cache:       getCodeCache(),
+	})
+}
+
+// Returns the cache for compiled code, or nil if caching is disabled or the
+// user's cache directory can't be determined.
+func getCodeCache() *importer.CodeCache {
+	if viper.GetBool("no-cache") {
+		return nil
+	}
+	dir, err := importer.DefaultCacheDir()
+	if err != nil {
+		return nil
+	}
+	return importer.NewCodeCache(dir)
+}
+
+// Compiles the given source code, reusing the result of an earlier compile
+// from the cache if there is one.
+func compileRisorCode(ctx context.Context, source, filename string, opts []risor.Option) (*compiler.Code, error) {
+	cfg := risor.NewConfig(opts...)
+	if cache := getCodeCache(); cache != nil {
+		return cache.Compile(ctx, source, importer.CompileOptions{
+			Filename:            filename,
+			GlobalNames:         cfg.GlobalNames(),
+			WithoutOptimization: viper.GetBool("no-optimize"),
+		})
+	}
+	var parserOpts []parser.Option
+	if filename != "" {
+		parserOpts = append(parserOpts, parser.WithFilename(filename))
+	}
+	ast, err := parser.Parse(ctx, source, parserOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return compiler.Compile(ast, cfg.CompilerOpts()...)
+}
+
+// Evaluates the given code, which is either source code or a bundle written
+// by the build command. Modules that aren't in a bundle are imported from the
+// modules directory as usual.
+func evalRisorCode(ctx context.Context, code, filename string, opts []risor.Option) (object.Object, error) {
+	var main *compiler.Code
+	if data := []byte(code); importer.IsBundle(data) {
+		bundle, err := importer.UnmarshalBundle(data)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, risor.WithImporter(bundle.Importer(getImporter(opts))))
+		main = bundle.Main
+	} else {
+		var err error
+		if main, err = compileRisorCode(ctx, code, filename, opts); err != nil {
+			return nil, err
+		}
+	}
+	return risor.EvalCode(ctx, main, opts...)
+}
+
 func shouldRunRepl(cmd *cobra.Command, args []string) bool {
 	if viper.GetBool("no-repl") || viper.GetBool("stdin") {
 		return false
@@ -217,7 +217,7 @@ func getEvaluator(cfg *risor.Config) func(ctx context.Context, source string) (o
 			} else {
 				color.Magenta(errStr)
 			}
-		case *object.Int, *object.Float, *object.Bool:
+		case *object.Int, *object.Float, *object.BigInt, *object.Decimal, *object.Bool:
 			color.Yellow(result.Inspect())
 		case *object.String:
 			color.Green(result.Inspect())
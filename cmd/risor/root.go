@@ -39,6 +39,8 @@ func init() {
 	rootCmd.PersistentFlags().StringArrayP("mount", "m", []string{}, "Mount a filesystem")
 	rootCmd.PersistentFlags().Bool("no-default-globals", false, "Disable the default globals")
 	rootCmd.PersistentFlags().String("modules", ".", "Path to library modules")
+	rootCmd.PersistentFlags().Bool("no-optimize", false, "Disable compiler optimizations")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Disable the cache of compiled code")
 	rootCmd.PersistentFlags().BoolP("help", "h", false, "Help for Risor")
 
 	viper.BindPFlag("code", rootCmd.PersistentFlags().Lookup("code"))
@@ -49,6 +51,8 @@ func init() {
 	viper.BindPFlag("mount", rootCmd.PersistentFlags().Lookup("mount"))
 	viper.BindPFlag("no-default-globals", rootCmd.PersistentFlags().Lookup("no-default-globals"))
 	viper.BindPFlag("modules", rootCmd.PersistentFlags().Lookup("modules"))
+	viper.BindPFlag("no-optimize", rootCmd.PersistentFlags().Lookup("no-optimize"))
+	viper.BindPFlag("no-cache", rootCmd.PersistentFlags().Lookup("no-cache"))
 	viper.BindPFlag("help", rootCmd.PersistentFlags().Lookup("help"))
 
 	// Root command flags
@@ -122,7 +126,7 @@ var rootCmd = &cobra.Command{
 			if entry.IsDir() {
 				// hacky way to add a trailing / on Linux, or trailing \ on Windows
 				name = strings.TrimSuffix(filepath.Join(name, "x"), "x")
-			} else if !strings.HasSuffix(name, ".risor") {
+			} else if !strings.HasSuffix(name, ".risor") && !strings.HasSuffix(name, ".rsc") {
 				continue
 			}
 			files = append(files, filepath.Join(path, name))
@@ -175,7 +179,8 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
-		// Read the provided code (from flags, stdin, or a file)
+		// Read the provided code (from flags, stdin, or a file). This may be
+		// source code or a bundle of compiled code written by "risor build".
 		code, err := getRisorCode(cmd, args)
 		if err != nil {
 			fatal(err)
@@ -184,10 +189,12 @@ var rootCmd = &cobra.Command{
 		// Execute the code
 		start := time.Now()
 		evalOpts := getRisorOptions()
+		var filename string
 		if len(args) > 0 {
-			evalOpts = append(evalOpts, risor.WithFilename(args[0]))
+			filename = args[0]
+			evalOpts = append(evalOpts, risor.WithFilename(filename))
 		}
-		result, err := risor.Eval(ctx, code, evalOpts...)
+		result, err := evalRisorCode(ctx, code, filename, evalOpts)
 		if err != nil {
 			errMsg := err.Error()
 			if friendlyErr, ok := err.(errz.FriendlyError); ok {
@@ -0,0 +1,667 @@
+package compiler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"runtime/debug"
+	"sort"
+	"sync"
+
+	"github.com/risor-io/risor/op"
+)
+
+// The binary bytecode format begins with a header that holds the magic bytes,
+// the format version, the version of Risor that wrote the data and a SHA-256
+// hash of the content that follows the header. The content consists of:
+//
+//  1. A table of all strings, which are referenced elsewhere by index
+//  2. A pool of constants shared by all code objects, without duplicates
+//  3. The symbol table
+//  4. The code objects, as ordered by Code.Flatten
+//
+// Integers, including instructions, are encoded as varints.
+
+// BytecodeMagic identifies data in the binary bytecode format.
+const BytecodeMagic = "RSC\x00"
+
+// BytecodeVersion is the version of the binary bytecode format. It must be
+// incremented whenever the encoding or the instruction set changes.
+const BytecodeVersion uint16 = 1
+
+// Types of the entries in the constant pool
+const (
+	constNil byte = iota
+	constTrue
+	constFalse
+	constInt
+	constFloat
+	constString
+	constFunction
+)
+
+// BytecodeHeader describes data in the binary bytecode format.
+type BytecodeHeader struct {
+	// Version of the bytecode format
+	Version uint16
+	// Version of Risor that wrote the bytecode
+	RisorVersion string
+	// SHA-256 hash of the content that follows the header
+	Hash [sha256.Size]byte
+}
+
+// IsBytecode returns true if the data begins with the magic bytes of the
+// binary bytecode format.
+func IsBytecode(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(BytecodeMagic))
+}
+
+// ReadBytecodeHeader reads the header of data in the binary bytecode format.
+// The content that follows the header isn't checked.
+func ReadBytecodeHeader(data []byte) (*BytecodeHeader, error) {
+	header, _, err := readBytecodeHeader(data)
+	return header, err
+}
+
+func readBytecodeHeader(data []byte) (*BytecodeHeader, []byte, error) {
+	if !IsBytecode(data) {
+		return nil, nil, fmt.Errorf("invalid bytecode: missing header")
+	}
+	d := &bytecodeDecoder{data: data[len(BytecodeMagic):]}
+	header := &BytecodeHeader{}
+	header.Version = d.uint16()
+	header.RisorVersion = string(d.bytes(d.count()))
+	copy(header.Hash[:], d.bytes(sha256.Size))
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	return header, d.data, nil
+}
+
+// RisorVersion returns the version of Risor that is recorded in the header of
+// bytecode written by this program.
+func RisorVersion() string {
+	return risorVersion()
+}
+
+// risorVersion returns the version of the Risor module that this program was
+// built with, as recorded in the build info.
+var risorVersion = sync.OnceValue(func() string {
+	const modulePath = "github.com/risor-io/risor"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+})
+
+// marshalBytecode converts a Code object into the binary bytecode format.
+func marshalBytecode(code *Code) ([]byte, error) {
+	e := &bytecodeEncoder{
+		stringIndex:   map[string]uint64{},
+		constantIndex: map[constantKey]uint64{},
+		functionIndex: map[*Function]uint64{},
+	}
+	// The symbol table and code objects are encoded first, since this fills
+	// in the string table and constant pool that precede them
+	body, err := e.symbolTable(nil, definitionFromSymbolTable(code.symbols))
+	if err != nil {
+		return nil, err
+	}
+	allCode := code.Flatten()
+	body = binary.AppendUvarint(body, uint64(len(allCode)))
+	for _, c := range allCode {
+		if body, err = e.code(body, c); err != nil {
+			return nil, err
+		}
+	}
+	var content []byte
+	content = binary.AppendUvarint(content, uint64(len(e.strings)))
+	for _, s := range e.strings {
+		content = binary.AppendUvarint(content, uint64(len(s)))
+		content = append(content, s...)
+	}
+	content = binary.AppendUvarint(content, e.constantCount)
+	content = append(content, e.constants...)
+	content = append(content, body...)
+
+	version := risorVersion()
+	hash := sha256.Sum256(content)
+	data := make([]byte, 0, len(BytecodeMagic)+2+len(version)+len(hash)+len(content)+1)
+	data = append(data, BytecodeMagic...)
+	data = binary.LittleEndian.AppendUint16(data, BytecodeVersion)
+	data = binary.AppendUvarint(data, uint64(len(version)))
+	data = append(data, version...)
+	data = append(data, hash[:]...)
+	return append(data, content...), nil
+}
+
+// unmarshalBytecode converts data in the binary bytecode format into a Code
+// object. The data must have been written with the current format version by
+// the same version of Risor.
+func unmarshalBytecode(data []byte) (*Code, error) {
+	header, content, err := readBytecodeHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	if header.Version != BytecodeVersion {
+		return nil, fmt.Errorf("unsupported bytecode version: %d (expected %d)",
+			header.Version, BytecodeVersion)
+	}
+	if version := risorVersion(); header.RisorVersion != version {
+		return nil, fmt.Errorf("unsupported bytecode: written by Risor %s (running %s)",
+			header.RisorVersion, version)
+	}
+	if sha256.Sum256(content) != header.Hash {
+		return nil, fmt.Errorf("invalid bytecode: hash mismatch")
+	}
+	d := &bytecodeDecoder{data: content}
+	d.strings = make([]string, d.count())
+	for i := range d.strings {
+		d.strings[i] = string(d.bytes(d.count()))
+	}
+	d.constants = make([]any, d.count())
+	for i := range d.constants {
+		d.constants[i] = d.poolEntry()
+	}
+	state := &state{SymbolTable: d.symbolTable()}
+	state.Code = make([]*codeDef, d.count())
+	for i := range state.Code {
+		state.Code[i] = d.code()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	if len(state.Code) == 0 {
+		return nil, fmt.Errorf("invalid bytecode: no code")
+	}
+	return codeFromState(state)
+}
+
+// constantKey identifies a constant in the pool, so that each distinct value
+// is only stored once. Floats are compared by their bits, so that 0.0 and
+// -0.0 are kept apart.
+type constantKey struct {
+	typ byte
+	num uint64
+	str string
+}
+
+type bytecodeEncoder struct {
+	strings       []string
+	stringIndex   map[string]uint64
+	constants     []byte
+	constantCount uint64
+	constantIndex map[constantKey]uint64
+	functionIndex map[*Function]uint64
+}
+
+func (e *bytecodeEncoder) string(buf []byte, s string) []byte {
+	index, ok := e.stringIndex[s]
+	if !ok {
+		index = uint64(len(e.strings))
+		e.strings = append(e.strings, s)
+		e.stringIndex[s] = index
+	}
+	return binary.AppendUvarint(buf, index)
+}
+
+func (e *bytecodeEncoder) stringList(buf []byte, values []string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(values)))
+	for _, s := range values {
+		buf = e.string(buf, s)
+	}
+	return buf
+}
+
+// constant appends a reference to the given constant.
+func (e *bytecodeEncoder) constant(buf []byte, c any) ([]byte, error) {
+	index, err := e.addConstant(c)
+	if err != nil {
+		return nil, err
+	}
+	return binary.AppendUvarint(buf, index), nil
+}
+
+// addConstant returns the index of the given constant in the pool, adding
+// the constant to the pool if it isn't there already.
+func (e *bytecodeEncoder) addConstant(c any) (uint64, error) {
+	var key constantKey
+	switch c := c.(type) {
+	case nil:
+		key = constantKey{typ: constNil}
+	case bool:
+		key = constantKey{typ: constFalse}
+		if c {
+			key.typ = constTrue
+		}
+	case int:
+		key = constantKey{typ: constInt, num: uint64(c)}
+	case int64:
+		key = constantKey{typ: constInt, num: uint64(c)}
+	case float32:
+		key = constantKey{typ: constFloat, num: math.Float64bits(float64(c))}
+	case float64:
+		key = constantKey{typ: constFloat, num: math.Float64bits(c)}
+	case string:
+		key = constantKey{typ: constString, str: c}
+	case *Function:
+		if index, ok := e.functionIndex[c]; ok {
+			return index, nil
+		}
+		index, err := e.function(c)
+		if err != nil {
+			return 0, err
+		}
+		e.functionIndex[c] = index
+		return index, nil
+	default:
+		return 0, fmt.Errorf("unknown constant type: %T", c)
+	}
+	if index, ok := e.constantIndex[key]; ok {
+		return index, nil
+	}
+	index := e.constantCount
+	e.constantCount++
+	e.constantIndex[key] = index
+	e.constants = append(e.constants, key.typ)
+	switch key.typ {
+	case constInt:
+		e.constants = binary.AppendVarint(e.constants, int64(key.num))
+	case constFloat:
+		e.constants = binary.LittleEndian.AppendUint64(e.constants, key.num)
+	case constString:
+		e.constants = e.string(e.constants, key.str)
+	}
+	return index, nil
+}
+
+// function adds a function to the constant pool and returns its index. The
+// defaults of the function are added to the pool first.
+func (e *bytecodeEncoder) function(fn *Function) (uint64, error) {
+	var defaults []byte
+	defaults = binary.AppendUvarint(defaults, uint64(len(fn.defaults)))
+	for _, value := range fn.defaults {
+		var err error
+		if defaults, err = e.constant(defaults, value); err != nil {
+			return 0, err
+		}
+	}
+	index := e.constantCount
+	e.constantCount++
+	e.constants = append(e.constants, constFunction)
+	e.constants = e.string(e.constants, fn.id)
+	e.constants = e.string(e.constants, fn.name)
+	e.constants = e.stringList(e.constants, fn.parameters)
+	e.constants = append(e.constants, defaults...)
+	e.constants = e.string(e.constants, fn.restParam)
+	return index, nil
+}
+
+func (e *bytecodeEncoder) symbol(buf []byte, def *symbolDef) ([]byte, error) {
+	buf = e.string(buf, def.Name)
+	buf = binary.AppendUvarint(buf, uint64(def.Index))
+	buf = appendBool(buf, def.IsConstant)
+	// The value is encoded as its index in the constant pool plus one, so
+	// that zero indicates that there is no value
+	if def.Value == nil {
+		return append(buf, 0), nil
+	}
+	index, err := e.addConstant(def.Value)
+	if err != nil {
+		return nil, err
+	}
+	return binary.AppendUvarint(buf, index+1), nil
+}
+
+func (e *bytecodeEncoder) symbolTable(buf []byte, def *symbolTableDef) ([]byte, error) {
+	var err error
+	buf = e.string(buf, def.ID)
+	buf = appendBool(buf, def.IsBlock)
+	buf = binary.AppendUvarint(buf, uint64(len(def.Symbols)))
+	for _, symbol := range def.Symbols {
+		if buf, err = e.symbol(buf, symbol); err != nil {
+			return nil, err
+		}
+	}
+	// Sort by name so that the encoding is deterministic
+	names := make([]string, 0, len(def.SymbolsByName))
+	for name := range def.SymbolsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	buf = binary.AppendUvarint(buf, uint64(len(names)))
+	for _, name := range names {
+		buf = e.string(buf, name)
+		if buf, err = e.symbol(buf, def.SymbolsByName[name]); err != nil {
+			return nil, err
+		}
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(def.Free)))
+	for _, resolution := range def.Free {
+		if buf, err = e.symbol(buf, resolution.Symbol); err != nil {
+			return nil, err
+		}
+		buf = e.string(buf, string(resolution.Scope))
+		buf = binary.AppendVarint(buf, int64(resolution.Depth))
+		buf = binary.AppendVarint(buf, int64(resolution.FreeIndex))
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(def.Children)))
+	for _, child := range def.Children {
+		if buf, err = e.symbolTable(buf, child); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+func (e *bytecodeEncoder) code(buf []byte, code *Code) ([]byte, error) {
+	var err error
+	var parentID string
+	if code.parent != nil {
+		parentID = code.parent.id
+	}
+	buf = e.string(buf, code.id)
+	buf = e.string(buf, code.name)
+	buf = e.string(buf, parentID)
+	buf = e.string(buf, code.symbols.ID())
+	buf = e.string(buf, code.functionID)
+	buf = appendBool(buf, code.isGenerator)
+	buf = binary.AppendUvarint(buf, uint64(len(code.instructions)))
+	for _, instruction := range code.instructions {
+		buf = binary.AppendUvarint(buf, uint64(instruction))
+	}
+	buf = binary.AppendUvarint(buf, uint64(len(code.constants)))
+	for _, constant := range code.constants {
+		if buf, err = e.constant(buf, constant); err != nil {
+			return nil, err
+		}
+	}
+	buf = e.stringList(buf, code.names)
+	buf = e.string(buf, code.source)
+	buf = e.string(buf, code.filename)
+	// Offsets are encoded as the difference from the previous offset
+	buf = binary.AppendUvarint(buf, uint64(len(code.locations)))
+	var offset int
+	for _, loc := range code.locations {
+		buf = binary.AppendVarint(buf, int64(loc.Offset-offset))
+		buf = binary.AppendUvarint(buf, uint64(loc.Line))
+		buf = binary.AppendUvarint(buf, uint64(loc.Column))
+		offset = loc.Offset
+	}
+	return buf, nil
+}
+
+func appendBool(buf []byte, value bool) []byte {
+	if value {
+		return append(buf, 1)
+	}
+	return append(buf, 0)
+}
+
+// bytecodeDecoder reads the binary bytecode format. The first error that
+// occurs is recorded and all reads that follow it return zero values, so
+// that errors only need to be checked once decoding is done.
+type bytecodeDecoder struct {
+	data      []byte
+	strings   []string
+	constants []any
+	err       error
+}
+
+func (d *bytecodeDecoder) fail(format string, args ...any) {
+	if d.err == nil {
+		d.err = fmt.Errorf("invalid bytecode: "+format, args...)
+	}
+	d.data = nil
+}
+
+func (d *bytecodeDecoder) bytes(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(d.data) {
+		d.fail("unexpected end of data")
+		return nil
+	}
+	value := d.data[:n]
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *bytecodeDecoder) byte() byte {
+	if value := d.bytes(1); value != nil {
+		return value[0]
+	}
+	return 0
+}
+
+func (d *bytecodeDecoder) uint16() uint16 {
+	if value := d.bytes(2); value != nil {
+		return binary.LittleEndian.Uint16(value)
+	}
+	return 0
+}
+
+func (d *bytecodeDecoder) bool() bool {
+	return d.byte() != 0
+}
+
+func (d *bytecodeDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.fail("malformed integer")
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *bytecodeDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.fail("malformed integer")
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+// instruction reads an opcode or operand, which must fit in an op.Code.
+func (d *bytecodeDecoder) instruction() op.Code {
+	value := d.uvarint()
+	if value > math.MaxUint16 {
+		d.fail("instruction out of range: %d", value)
+		return 0
+	}
+	return op.Code(value)
+}
+
+// count reads the number of items in a sequence or bytes in a string. Since
+// each takes at least one byte, the count can't exceed the remaining data.
+func (d *bytecodeDecoder) count() int {
+	value := d.uvarint()
+	if value > uint64(len(d.data)) {
+		d.fail("unexpected end of data")
+		return 0
+	}
+	return int(value)
+}
+
+func (d *bytecodeDecoder) string() string {
+	index := d.uvarint()
+	if index >= uint64(len(d.strings)) {
+		d.fail("string index out of range")
+		return ""
+	}
+	return d.strings[index]
+}
+
+// stringList reads a list of strings. An empty list is returned as nil.
+func (d *bytecodeDecoder) stringList() []string {
+	count := d.count()
+	if count == 0 {
+		return nil
+	}
+	values := make([]string, count)
+	for i := range values {
+		values[i] = d.string()
+	}
+	return values
+}
+
+func (d *bytecodeDecoder) constant() any {
+	index := d.uvarint()
+	if index >= uint64(len(d.constants)) {
+		d.fail("constant index out of range")
+		return nil
+	}
+	return d.constants[index]
+}
+
+// constantList reads a list of constant references. An empty list is
+// returned as nil.
+func (d *bytecodeDecoder) constantList() []any {
+	count := d.count()
+	if count == 0 {
+		return nil
+	}
+	values := make([]any, count)
+	for i := range values {
+		values[i] = d.constant()
+	}
+	return values
+}
+
+// poolEntry reads an entry of the constant pool. Entries may only refer to
+// the entries that precede them.
+func (d *bytecodeDecoder) poolEntry() any {
+	switch typ := d.byte(); typ {
+	case constNil:
+		return nil
+	case constTrue:
+		return true
+	case constFalse:
+		return false
+	case constInt:
+		return d.varint()
+	case constFloat:
+		if value := d.bytes(8); value != nil {
+			return math.Float64frombits(binary.LittleEndian.Uint64(value))
+		}
+		return nil
+	case constString:
+		return d.string()
+	case constFunction:
+		opts := FunctionOpts{
+			ID:            d.string(),
+			Name:          d.string(),
+			Parameters:    d.stringList(),
+			Defaults:      d.constantList(),
+			RestParameter: d.string(),
+		}
+		// The compiler never leaves these nil, even when they're empty
+		if opts.Parameters == nil {
+			opts.Parameters = []string{}
+		}
+		if opts.Defaults == nil {
+			opts.Defaults = []any{}
+		}
+		return NewFunction(opts)
+	default:
+		d.fail("unknown constant type: %d", typ)
+		return nil
+	}
+}
+
+func (d *bytecodeDecoder) symbol() *symbolDef {
+	def := &symbolDef{
+		Name:       d.string(),
+		Index:      uint16(d.uvarint()),
+		IsConstant: d.bool(),
+	}
+	if index := d.uvarint(); index > 0 {
+		if index > uint64(len(d.constants)) {
+			d.fail("constant index out of range")
+			return def
+		}
+		def.Value = d.constants[index-1]
+	}
+	return def
+}
+
+func (d *bytecodeDecoder) symbolTable() *symbolTableDef {
+	def := &symbolTableDef{
+		ID:            d.string(),
+		IsBlock:       d.bool(),
+		SymbolsByName: map[string]*symbolDef{},
+	}
+	def.Symbols = make([]*symbolDef, d.count())
+	for i := range def.Symbols {
+		def.Symbols[i] = d.symbol()
+	}
+	for count := d.count(); count > 0; count-- {
+		name := d.string()
+		def.SymbolsByName[name] = d.symbol()
+	}
+	for count := d.count(); count > 0; count-- {
+		def.Free = append(def.Free, &resolutionDef{
+			Symbol:    d.symbol(),
+			Scope:     Scope(d.string()),
+			Depth:     int(d.varint()),
+			FreeIndex: int(d.varint()),
+		})
+	}
+	for count := d.count(); count > 0; count-- {
+		def.Children = append(def.Children, d.symbolTable())
+	}
+	return def
+}
+
+func (d *bytecodeDecoder) code() *codeDef {
+	def := &codeDef{
+		ID:            d.string(),
+		Name:          d.string(),
+		ParentID:      d.string(),
+		SymbolTableID: d.string(),
+		FunctionID:    d.string(),
+		IsGenerator:   d.bool(),
+	}
+	def.Instructions = make([]op.Code, d.count())
+	for i := range def.Instructions {
+		def.Instructions[i] = d.instruction()
+	}
+	def.constants = d.constantList()
+	def.Names = d.stringList()
+	def.Source = d.string()
+	def.Filename = d.string()
+	var offset int
+	for count := d.count(); count > 0; count-- {
+		offset += int(d.varint())
+		def.Locations = append(def.Locations, &locationDef{
+			Offset: offset,
+			Line:   int(d.uvarint()),
+			Column: int(d.uvarint()),
+		})
+	}
+	return def
+}
@@ -0,0 +1,246 @@
+package compiler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const bytecodeTestSource = `
+const LIMIT = 100
+const RATIO = 0.5
+const NAME = "risor"
+func scale(x, factor=0.5, label="scaled") {
+	if x > LIMIT {
+		return LIMIT * factor
+	}
+	return x * factor
+}
+func count(n) {
+	total := 0
+	for i := 0; i < n; i++ {
+		total += i
+	}
+	return total
+}
+[scale(10), scale(200, 2.0), count(10), RATIO, NAME, "risor", -0.0, 0.0]
+`
+
+// clearLoops clears the loop state of the compiler, which isn't marshaled.
+func clearLoops(code *Code) {
+	code.loops = nil
+	for _, child := range code.children {
+		clearLoops(child)
+	}
+}
+
+func TestBytecodeRoundTrip(t *testing.T) {
+	codeA, err := compileSource(bytecodeTestSource)
+	require.Nil(t, err)
+	clearLoops(codeA)
+	data, err := MarshalCode(codeA)
+	require.Nil(t, err)
+	require.True(t, IsBytecode(data))
+	codeB, err := UnmarshalCode(data)
+	require.Nil(t, err)
+	require.Equal(t, codeA, codeB)
+
+	// The values of constants keep their types, unlike with JSON
+	for _, name := range []string{"LIMIT", "RATIO", "NAME"} {
+		symA, found := codeA.symbols.Get(name)
+		require.True(t, found)
+		symB, found := codeB.symbols.Get(name)
+		require.True(t, found)
+		require.Equal(t, symA.Value(), symB.Value())
+	}
+
+	// Zero and negative zero remain distinct constants
+	var zeros []float64
+	for _, constant := range codeB.constants {
+		if value, ok := constant.(float64); ok && value == 0 {
+			zeros = append(zeros, value)
+		}
+	}
+	require.Len(t, zeros, 2)
+	require.NotEqual(t, math.Signbit(zeros[0]), math.Signbit(zeros[1]))
+}
+
+func TestBytecodeJSONRoundTrip(t *testing.T) {
+	codeA, err := compileSource(`
+	func scale(x, factor=0.5) {
+		return x * factor
+	}
+	[scale(10), scale(200, 2.0), "risor"]
+	`)
+	require.Nil(t, err)
+	data, err := MarshalCodeJSON(codeA)
+	require.Nil(t, err)
+	require.False(t, IsBytecode(data))
+	codeB, err := UnmarshalCode(data)
+	require.Nil(t, err)
+	require.Equal(t, codeA, codeB)
+}
+
+func TestBytecodeSize(t *testing.T) {
+	code, err := compileSource(bytecodeTestSource)
+	require.Nil(t, err)
+	binaryData, err := MarshalCode(code)
+	require.Nil(t, err)
+	jsonData, err := MarshalCodeJSON(code)
+	require.Nil(t, err)
+	require.Less(t, len(binaryData)*3, len(jsonData))
+}
+
+func TestBytecodeDeterministic(t *testing.T) {
+	code, err := compileSource(bytecodeTestSource)
+	require.Nil(t, err)
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+	for i := 0; i < 5; i++ {
+		other, err := MarshalCode(code)
+		require.Nil(t, err)
+		require.Equal(t, data, other)
+	}
+}
+
+func TestBytecodeConstantDeduplication(t *testing.T) {
+	code, err := compileSource(`
+	func a() { return "shared" }
+	func b() { return "shared" }
+	["shared", 1.5, 1.5]
+	`)
+	require.Nil(t, err)
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+	_, content, err := readBytecodeHeader(data)
+	require.Nil(t, err)
+	d := &bytecodeDecoder{data: content}
+	d.strings = make([]string, d.count())
+	for i := range d.strings {
+		d.strings[i] = string(d.bytes(d.count()))
+	}
+	d.constants = make([]any, d.count())
+	for i := range d.constants {
+		d.constants[i] = d.poolEntry()
+	}
+	require.Nil(t, d.err)
+	countOf := func(values []any, value any) int {
+		var count int
+		for _, v := range values {
+			if v == value {
+				count++
+			}
+		}
+		return count
+	}
+	require.Equal(t, 1, countOf(d.constants, "shared"))
+	require.Equal(t, 1, countOf(d.constants, 1.5))
+	var shared int
+	for _, s := range d.strings {
+		if s == "shared" {
+			shared++
+		}
+	}
+	require.Equal(t, 1, shared)
+}
+
+func TestBytecodeHeader(t *testing.T) {
+	code, err := compileSource(`1 + 2`)
+	require.Nil(t, err)
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+	header, err := ReadBytecodeHeader(data)
+	require.Nil(t, err)
+	require.Equal(t, BytecodeVersion, header.Version)
+	require.Equal(t, risorVersion(), header.RisorVersion)
+	_, content, err := readBytecodeHeader(data)
+	require.Nil(t, err)
+	require.Equal(t, sha256.Sum256(content), header.Hash)
+
+	_, err = ReadBytecodeHeader([]byte(`{"code": []}`))
+	require.EqualError(t, err, "invalid bytecode: missing header")
+	_, err = ReadBytecodeHeader([]byte(BytecodeMagic))
+	require.EqualError(t, err, "invalid bytecode: unexpected end of data")
+}
+
+func TestBytecodeVersionMismatch(t *testing.T) {
+	code, err := compileSource(`1 + 2`)
+	require.Nil(t, err)
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+	binary.LittleEndian.PutUint16(data[len(BytecodeMagic):], BytecodeVersion+1)
+	_, err = UnmarshalCode(data)
+	require.EqualError(t, err, "unsupported bytecode version: 2 (expected 1)")
+}
+
+func TestBytecodeRisorVersionMismatch(t *testing.T) {
+	code, err := compileSource(`1 + 2`)
+	require.Nil(t, err)
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+	header, content, err := readBytecodeHeader(data)
+	require.Nil(t, err)
+
+	// Rewrite the header as if another version of Risor wrote the data
+	var other []byte
+	other = append(other, BytecodeMagic...)
+	other = binary.LittleEndian.AppendUint16(other, BytecodeVersion)
+	other = binary.AppendUvarint(other, uint64(len("v0.0.1")))
+	other = append(other, "v0.0.1"...)
+	other = append(other, header.Hash[:]...)
+	other = append(other, content...)
+	_, err = UnmarshalCode(other)
+	require.EqualError(t, err, "unsupported bytecode: written by Risor v0.0.1 (running "+risorVersion()+")")
+}
+
+func TestBytecodeInstructionOutOfRange(t *testing.T) {
+	var data []byte
+	// The ID, name, parent ID, symbol table ID and function ID of the code
+	for i := 0; i < 5; i++ {
+		data = binary.AppendUvarint(data, 0)
+	}
+	data = append(data, 0) // Not a generator
+	data = binary.AppendUvarint(data, 1)
+	data = binary.AppendUvarint(data, math.MaxUint16+1)
+	d := &bytecodeDecoder{data: data, strings: []string{""}}
+	d.code()
+	require.EqualError(t, d.err, "invalid bytecode: instruction out of range: 65536")
+}
+
+func TestBytecodeCorruption(t *testing.T) {
+	code, err := compileSource(bytecodeTestSource)
+	require.Nil(t, err)
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+
+	corrupted := bytes.Clone(data)
+	corrupted[len(corrupted)-1] ^= 0xff
+	_, err = UnmarshalCode(corrupted)
+	require.EqualError(t, err, "invalid bytecode: hash mismatch")
+
+	_, err = UnmarshalCode(data[:len(data)-10])
+	require.EqualError(t, err, "invalid bytecode: hash mismatch")
+}
+
+func TestBytecodeTruncatedContent(t *testing.T) {
+	code, err := compileSource(bytecodeTestSource)
+	require.Nil(t, err)
+	data, err := MarshalCode(code)
+	require.Nil(t, err)
+	header, content, err := readBytecodeHeader(data)
+	require.Nil(t, err)
+	headerSize := len(data) - len(content)
+
+	// Content that matches its hash but ends early must still be rejected
+	for _, size := range []int{0, 1, len(content) / 2, len(content) - 1} {
+		truncated := bytes.Clone(data[:headerSize+size])
+		hash := sha256.Sum256(truncated[headerSize:])
+		copy(truncated[headerSize-len(header.Hash):], hash[:])
+		_, err := UnmarshalCode(truncated)
+		require.NotNil(t, err, "size %d", size)
+	}
+}
@@ -3,15 +3,26 @@ package compiler
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 
 	"github.com/risor-io/risor/op"
+	"github.com/risor-io/risor/token"
 )
 
 type loop struct {
 	code        *Code
+	label       string // Optional label targeted by break and continue
 	continuePos []int
 	breakPos    []int
 	isRangeLoop bool
+	// Number of try statements that were active when the loop started.
+	// Break and continue statements unwind the try statements above this.
+	tryDepth int
+	// Incremented while a finally block within the loop body is compiled
+	finallyDepth int
+	// Number of switch values that were on the stack when the loop started.
+	// Break and continue statements pop the switch values above this.
+	switchDepth int
 }
 
 func (l *loop) end() {
@@ -19,10 +30,21 @@ func (l *loop) end() {
 	code.loops = code.loops[:len(code.loops)-1]
 }
 
+// Location maps an instruction offset to the position in the source code that
+// produced it. The location applies to all instructions from Offset up to the
+// Offset of the next location in the table. Line and Column are 0-indexed, to
+// be consistent with token.Position.
+type Location struct {
+	Offset int
+	Line   int
+	Column int
+}
+
 type Code struct {
 	id           string
 	name         string
 	isNamed      bool
+	isGenerator  bool
 	parent       *Code
 	children     []*Code
 	symbols      *SymbolTable
@@ -32,10 +54,12 @@ type Code struct {
 	source       string
 	functionID   string
 	filename     string // The source file this code came from
+	locations    []Location
 
 	// Used during compilation only
-	loops      []*loop
-	pipeActive bool
+	loops       []*loop
+	pipeActive  bool
+	switchDepth int // Number of switch values currently on the stack
 }
 
 func (c *Code) ID() string {
@@ -55,6 +79,12 @@ func (c *Code) IsNamed() bool {
 	return c.isNamed
 }
 
+// IsGenerator returns true if the code contains a yield statement, which
+// means calling its function produces a generator.
+func (c *Code) IsGenerator() bool {
+	return c.isGenerator
+}
+
 func (c *Code) FunctionID() string {
 	return c.functionID
 }
@@ -72,6 +102,7 @@ func (c *Code) newChild(name, source, funcID string) *Code {
 		symbols:    c.symbols.NewChild(),
 		source:     source,
 		functionID: funcID,
+		filename:   c.filename,
 	}
 	c.children = append(c.children, child)
 	return child
@@ -163,3 +194,47 @@ func (c *Code) Flatten() []*Code {
 func (c *Code) Filename() string {
 	return c.filename
 }
+
+func (c *Code) LocationCount() int {
+	return len(c.locations)
+}
+
+func (c *Code) Location(index int) Location {
+	return c.locations[index]
+}
+
+// LocationAt returns the source position of the instruction at the given
+// offset. The returned bool is false if no position information is available.
+func (c *Code) LocationAt(offset int) (token.Position, bool) {
+	// Find the first location that starts after the offset. The location
+	// immediately before that one covers the offset.
+	i := sort.Search(len(c.locations), func(i int) bool {
+		return c.locations[i].Offset > offset
+	})
+	if i == 0 {
+		return token.Position{}, false
+	}
+	loc := c.locations[i-1]
+	return token.Position{
+		Line:   loc.Line,
+		Column: loc.Column,
+		File:   c.filename,
+	}, true
+}
+
+// addLocation records that instructions starting at the given offset were
+// produced by source code at the given position. Consecutive instructions
+// from the same position share a single entry in the table.
+func (c *Code) addLocation(offset int, pos token.Position) {
+	if count := len(c.locations); count > 0 {
+		last := c.locations[count-1]
+		if last.Line == pos.Line && last.Column == pos.Column {
+			return
+		}
+	}
+	c.locations = append(c.locations, Location{
+		Offset: offset,
+		Line:   pos.Line,
+		Column: pos.Column,
+	})
+}
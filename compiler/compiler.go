@@ -5,6 +5,7 @@ package compiler
 import (
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"strings"
 
@@ -44,6 +45,28 @@ type Compiler struct {
 
 	// Source filename
 	filename string
+
+	// Source position of the node currently being compiled
+	position token.Position
+
+	// Try statements that are currently being compiled
+	tries []*tryBlock
+
+	// The call and pipe expressions in tail position within the expression
+	// being returned, whose final calls can be tail calls
+	tailCalls []ast.Node
+
+	// Whether to fold constants, eliminate dead code and thread jumps
+	optimize bool
+}
+
+// tryBlock tracks a try statement while its try or catch block is being
+// compiled. Statements that transfer control out of the block, like return
+// and break, must discard its exception handlers and run its finally block.
+type tryBlock struct {
+	code         *Code
+	handlerCount int
+	finally      *ast.Block
 }
 
 // Option is a configuration function for a Compiler.
@@ -71,6 +94,15 @@ func WithFilename(filename string) Option {
 	}
 }
 
+// WithoutOptimization disables the optimizations that are applied by default,
+// such as constant folding and dead code elimination. This produces bytecode
+// that corresponds directly to the source code, which may be easier to debug.
+func WithoutOptimization() Option {
+	return func(c *Compiler) {
+		c.optimize = false
+	}
+}
+
 // Compile the given AST node and return the compiled code object. This is a
 // shorthand for compiler.New(options).Compile(node).
 func Compile(node ast.Node, options ...Option) (*Code, error) {
@@ -84,7 +116,7 @@ func Compile(node ast.Node, options ...Option) (*Code, error) {
 // New creates and returns a new Compiler. Any supplied options are used to
 // configure the compilation process.
 func New(options ...Option) (*Compiler, error) {
-	c := &Compiler{}
+	c := &Compiler{optimize: true}
 	for _, opt := range options {
 		opt(c)
 	}
@@ -144,6 +176,10 @@ func (c *Compiler) Compile(node ast.Node) (*Code, error) {
 	if c.failure != nil {
 		return nil, c.failure
 	}
+	if c.optimize {
+		threadJumps(c.main)
+		fuseInstructions(c.main)
+	}
 	return c.main, nil
 }
 
@@ -180,6 +216,17 @@ func (c *Compiler) collectFunctionDeclarations(node ast.Node) error {
 
 // compile the given AST node and all its children.
 func (c *Compiler) compile(node ast.Node) error {
+	// Track the source position of the node being compiled, so that the
+	// emitted instructions can be mapped back to the source code. Nodes that
+	// are synthesized by the compiler have no token and inherit the position
+	// of their parent.
+	if node != nil {
+		if tok := node.Token(); tok.Type != "" {
+			prevPosition := c.position
+			c.position = tok.StartPosition
+			defer func() { c.position = prevPosition }()
+		}
+	}
 	switch node := node.(type) {
 	case *ast.Nil:
 		if err := c.compileNil(); err != nil {
@@ -245,6 +292,10 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileReturn(node); err != nil {
 			return err
 		}
+	case *ast.Yield:
+		if err := c.compileYield(node); err != nil {
+			return err
+		}
 	case *ast.Call:
 		if err := c.compileCall(node); err != nil {
 			return err
@@ -265,6 +316,18 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileSet(node); err != nil {
 			return err
 		}
+	case *ast.ListComprehension:
+		if err := c.compileListComprehension(node); err != nil {
+			return err
+		}
+	case *ast.MapComprehension:
+		if err := c.compileMapComprehension(node); err != nil {
+			return err
+		}
+	case *ast.SetComprehension:
+		if err := c.compileSetComprehension(node); err != nil {
+			return err
+		}
 	case *ast.Index:
 		if err := c.compileIndex(node); err != nil {
 			return err
@@ -293,6 +356,14 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileConst(node); err != nil {
 			return err
 		}
+	case *ast.Struct:
+		if err := c.compileStruct(node); err != nil {
+			return err
+		}
+	case *ast.Try:
+		if err := c.compileTry(node); err != nil {
+			return err
+		}
 	case *ast.Postfix:
 		if err := c.compilePostfix(node); err != nil {
 			return err
@@ -325,10 +396,18 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileSwitch(node); err != nil {
 			return err
 		}
+	case *ast.Match:
+		if err := c.compileMatch(node); err != nil {
+			return err
+		}
 	case *ast.MultiVar:
 		if err := c.compileMultiVar(node); err != nil {
 			return err
 		}
+	case *ast.Destructure:
+		if err := c.compileDestructure(node); err != nil {
+			return err
+		}
 	case *ast.SetAttr:
 		if err := c.compileSetAttr(node); err != nil {
 			return err
@@ -349,6 +428,12 @@ func (c *Compiler) compile(node ast.Node) error {
 		if err := c.compileReceive(node); err != nil {
 			return err
 		}
+	case *ast.Select:
+		if err := c.compileSelect(node); err != nil {
+			return err
+		}
+	case *ast.Spread:
+		return c.formatError("spread syntax is only valid in calls, lists, and maps", node.Token().StartPosition)
 	default:
 		panic(fmt.Sprintf("compile error: unknown ast node type: %T", node))
 	}
@@ -357,15 +442,24 @@ func (c *Compiler) compile(node ast.Node) error {
 
 // startLoop should be called when starting to compile a new loop. This is used
 // to understand which loop that "break" and "continue" statements should target.
-func (c *Compiler) startLoop() *loop {
+// The label is optional and must not be used by an enclosing loop.
+func (c *Compiler) startLoop(label *ast.Ident) (*loop, error) {
 	currentCode := c.current
-	loop := &loop{code: currentCode}
+	loop := &loop{code: currentCode, tryDepth: len(c.tries), switchDepth: currentCode.switchDepth}
+	if label != nil {
+		loop.label = label.Literal()
+		for _, outer := range currentCode.loops {
+			if outer.label == loop.label {
+				return nil, c.formatError(fmt.Sprintf("duplicate loop label %q", loop.label), label.Token().StartPosition)
+			}
+		}
+	}
 	currentCode.loops = append(currentCode.loops, loop)
-	return loop
+	return loop, nil
 }
 
 // currentLoop returns the loop that is currently being compiled, which is the
-// loop that "break" and "continue" statements should target.
+// loop that unlabeled "break" and "continue" statements should target.
 func (c *Compiler) currentLoop() *loop {
 	loops := c.current.loops
 	if len(loops) == 0 {
@@ -374,6 +468,18 @@ func (c *Compiler) currentLoop() *loop {
 	return loops[len(loops)-1]
 }
 
+// labeledLoop returns the index of the enclosing loop with the given label
+// within the loops of the current code, or -1 if there is no such loop.
+func (c *Compiler) labeledLoop(label string) int {
+	loops := c.current.loops
+	for i := len(loops) - 1; i >= 0; i-- {
+		if loops[i].label == label {
+			return i
+		}
+	}
+	return -1
+}
+
 func (c *Compiler) currentPosition() int {
 	return len(c.current.instructions)
 }
@@ -434,7 +540,7 @@ func (c *Compiler) compileBlock(node *ast.Block) error {
 	defer func() {
 		code.symbols = code.symbols.parent
 	}()
-	statements := node.Statements()
+	statements := c.reachable(node.Statements())
 	count := len(statements)
 	if count == 0 {
 		// Guarantee that the block evaluates to a value
@@ -456,6 +562,11 @@ func (c *Compiler) compileBlock(node *ast.Block) error {
 			c.emit(op.Nil)
 		}
 	}
+	for _, stmt := range node.Statements()[count:] {
+		if err := c.compileUnreachable(stmt); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -499,6 +610,10 @@ func (c *Compiler) compileVar(node *ast.Var) error {
 
 func (c *Compiler) compileIdent(node *ast.Ident) error {
 	name := node.Literal()
+	if value, ok := c.constantValue(name); ok && c.optimize {
+		c.emitConstant(value)
+		return nil
+	}
 	resolution, found := c.current.symbols.Resolve(name)
 	if !found {
 		return c.formatError(fmt.Sprintf("undefined variable %q", name), node.Token().StartPosition)
@@ -594,6 +709,11 @@ func (c *Compiler) compileSwitch(node *ast.Switch) error {
 
 	jumpDefaultPos := c.emit(op.JumpForward, Placeholder)
 
+	// The switch value stays on the stack while the case blocks run, so break
+	// and continue statements within them need to know to pop it.
+	c.current.switchDepth++
+	defer func() { c.current.switchDepth-- }()
+
 	// Update case jump positions and compile case blocks
 	var offset int
 	var endBlockPosits []int
@@ -651,156 +771,642 @@ func (c *Compiler) compileSwitch(node *ast.Switch) error {
 	return nil
 }
 
-func (c *Compiler) compileImport(node *ast.Import) error {
-	moduleName := node.Path().Value()
-	c.emit(op.LoadConst, c.constant(moduleName))
-	c.emit(op.Import)
-
-	// Determine the variable name to store the imported module
-	var name string
-	if node.Alias() != nil {
-		name = node.Alias().String()
-	} else {
-		name = node.ModuleName()
+func (c *Compiler) compileDestructure(node *ast.Destructure) error {
+	targets, expr := node.Value()
+	if err := c.compile(expr); err != nil {
+		return err
 	}
+	return c.compileTargets(targets, node.IsWalrus())
+}
 
-	var sym *Symbol
-	var found bool
-	sym, found = c.current.symbols.Get(name)
-	if !found {
-		var err error
-		sym, err = c.current.symbols.InsertConstant(name)
-		if err != nil {
-			return err
+// compileTargets compiles code that assigns the value at TOS to the given
+// destructuring targets and removes it from the stack. More than one target
+// unpacks the value as though the targets were a list.
+func (c *Compiler) compileTargets(targets []ast.Expression, isWalrus bool) error {
+	if len(targets) == 1 {
+		if _, ok := targets[0].(*ast.Spread); !ok {
+			return c.compileTarget(targets[0], isWalrus)
 		}
 	}
-	if c.current.parent == nil {
-		c.emit(op.StoreGlobal, sym.Index())
-	} else {
-		c.emit(op.StoreFast, sym.Index())
+	return c.compileListTarget(targets, isWalrus)
+}
+
+// compileTarget compiles code that assigns the value at TOS to a single
+// destructuring target and removes it from the stack. The name "_" discards
+// the corresponding value.
+func (c *Compiler) compileTarget(target ast.Expression, isWalrus bool) error {
+	switch target := target.(type) {
+	case *ast.Ident:
+		return c.compileStoreTarget(target, isWalrus)
+	case *ast.List:
+		return c.compileListTarget(target.Items(), isWalrus)
+	case *ast.Set:
+		// A set of names such as {name, age} assigns map values by key
+		for _, item := range target.Items() {
+			ident, ok := item.(*ast.Ident)
+			if !ok {
+				return c.formatError(fmt.Sprintf("invalid assignment target: %s", item.String()), item.Token().StartPosition)
+			}
+			c.emit(op.Copy, 0)
+			c.emit(op.LoadConst, c.constant(ident.Literal()))
+			c.emit(op.BinarySubscr)
+			if err := c.compileStoreTarget(ident, isWalrus); err != nil {
+				return err
+			}
+		}
+		c.emit(op.PopTop)
+		return nil
+	case *ast.Map:
+		items := target.Items()
+		for _, key := range target.Order() {
+			var name string
+			switch key := key.(type) {
+			case *ast.Ident:
+				name = key.Literal()
+			case *ast.String:
+				name = key.Value()
+			default:
+				return c.formatError("map target keys must be names or strings", key.Token().StartPosition)
+			}
+			c.emit(op.Copy, 0)
+			c.emit(op.LoadConst, c.constant(name))
+			c.emit(op.BinarySubscr)
+			if err := c.compileTarget(items[key], isWalrus); err != nil {
+				return err
+			}
+		}
+		c.emit(op.PopTop)
+		return nil
 	}
-	return nil
+	return c.formatError(fmt.Sprintf("invalid assignment target: %s", target.String()), target.Token().StartPosition)
 }
 
-func (c *Compiler) compileFromImport(node *ast.FromImport) error {
-	if len(node.Parents()) > 255 {
-		return fmt.Errorf("compile error: too many parents in from-import")
+// compileListTarget compiles code that unpacks the value at TOS into a list
+// of targets, which may include one rest target such as ...rest that receives
+// a list of the remaining items.
+func (c *Compiler) compileListTarget(items []ast.Expression, isWalrus bool) error {
+	if len(items) > math.MaxUint16 {
+		return c.formatError("too many variables in multi-variable assignment", items[0].Token().StartPosition)
 	}
-	for _, parent := range node.Parents() {
-		c.emit(op.LoadConst, c.constant(parent.String()))
+	targets := make([]ast.Expression, len(items))
+	rest := -1
+	for i, item := range items {
+		targets[i] = item
+		if spread, ok := item.(*ast.Spread); ok {
+			if rest >= 0 {
+				return c.formatError("multiple rest targets in assignment", item.Token().StartPosition)
+			}
+			rest = i
+			targets[i] = spread.Value()
+		}
 	}
-	aliases := map[string]string{}
-	for _, im := range node.Imports() {
-		name := im.Path().Value()
-		alias := name
-		if im.Alias() != nil {
-			alias = im.Alias().String()
+	// The unpacked items are pushed in order, so they are assigned in reverse
+	if rest < 0 {
+		c.emit(op.Unpack, uint16(len(items)))
+	} else {
+		c.emit(op.UnpackRest, uint16(rest), uint16(len(items)-rest-1))
+	}
+	for i := len(targets) - 1; i >= 0; i-- {
+		if err := c.compileTarget(targets[i], isWalrus); err != nil {
+			return err
 		}
-		c.emit(op.LoadConst, c.constant(name))
-		aliases[name] = alias
 	}
-	c.emit(op.FromImport, uint16(len(node.Parents())), uint16(len(node.Imports())))
-	for _, im := range node.Imports() {
-		alias := aliases[im.Path().Value()]
-		var sym *Symbol
-		var found bool
-		sym, found = c.current.symbols.Get(alias)
-		if !found {
-			var err error
-			sym, err = c.current.symbols.InsertConstant(alias)
-			if err != nil {
-				return err
-			}
+	return nil
+}
+
+// compileStoreTarget stores the value at TOS in the named variable, which is
+// declared if this is a ":=" statement.
+func (c *Compiler) compileStoreTarget(ident *ast.Ident, isWalrus bool) error {
+	name := ident.Literal()
+	if name == "_" {
+		c.emit(op.PopTop)
+		return nil
+	}
+	code := c.current
+	if isWalrus {
+		sym, err := code.symbols.InsertVariable(name)
+		if err != nil {
+			return err
 		}
-		if c.current.parent == nil {
+		if code.symbols.IsGlobal() {
 			c.emit(op.StoreGlobal, sym.Index())
 		} else {
 			c.emit(op.StoreFast, sym.Index())
 		}
+		return nil
+	}
+	resolution, found := code.symbols.Resolve(name)
+	if !found {
+		return c.formatError(fmt.Sprintf("undefined variable %q", name), ident.Token().StartPosition)
+	}
+	if resolution.symbol.IsConstant() {
+		return c.formatError(fmt.Sprintf("cannot assign to constant %q", name), ident.Token().StartPosition)
+	}
+	switch resolution.scope {
+	case Global:
+		c.emit(op.StoreGlobal, resolution.symbol.Index())
+	case Local:
+		c.emit(op.StoreFast, resolution.symbol.Index())
+	case Free:
+		c.emit(op.StoreFree, uint16(resolution.freeIndex))
 	}
 	return nil
 }
 
-func (c *Compiler) compileSlice(node *ast.Slice) error {
-	if err := c.compile(node.Left()); err != nil {
+func (c *Compiler) compileMatch(node *ast.Match) error {
+	// The value being matched stays on the stack while the cases are tested.
+	// Each case tests its patterns against a copy of the value and jumps to
+	// the next case if none of them match, or if its guard is false. The
+	// value is removed before running the block of the chosen case, so that
+	// break and continue statements leave the stack balanced.
+	if err := c.compile(node.Value()); err != nil {
 		return err
 	}
-	to := node.ToIndex()
-	if to == nil {
-		c.emit(op.Copy, 0)
-		c.emit(op.Length)
-	} else {
-		if err := c.compile(to); err != nil {
+	var defaultCase *ast.MatchCase
+	var endBlockPosits []int
+	for _, mc := range node.Cases() {
+		if mc.IsDefault() {
+			defaultCase = mc
+			continue
+		}
+		endPos, err := c.compileMatchCase(mc)
+		if err != nil {
 			return err
 		}
+		endBlockPosits = append(endBlockPosits, endPos)
 	}
-	from := node.FromIndex()
-	if from == nil {
-		c.emit(op.LoadConst, c.constant(int64(0)))
+
+	// Compile the default case block if it exists
+	c.emit(op.PopTop)
+	if defaultCase != nil && defaultCase.Block() != nil {
+		if err := c.compile(defaultCase.Block()); err != nil {
+			return err
+		}
 	} else {
-		if err := c.compile(from); err != nil {
+		c.emit(op.Nil)
+	}
+	for _, pos := range endBlockPosits {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
 			return err
 		}
+		c.changeOperand(pos, delta)
 	}
-	c.emit(op.Slice)
 	return nil
 }
 
-func (c *Compiler) compileRange(node *ast.Range) error {
-	if err := c.compile(node.Container()); err != nil {
-		return err
+// compileMatchCase compiles one case of a match expression. It returns the
+// position of the jump to the end of the match, which the caller updates.
+func (c *Compiler) compileMatchCase(node *ast.MatchCase) (int, error) {
+	// Variables bound by the patterns are scoped to the case
+	code := c.current
+	code.symbols = code.symbols.NewBlock()
+	defer func() {
+		code.symbols = code.symbols.parent
+	}()
+	patterns := node.Patterns()
+	var matchedPosits []int
+	for _, pattern := range patterns {
+		if len(patterns) > 1 && patternBindsVariables(pattern) {
+			return 0, c.formatError("alternative patterns cannot bind variables", pattern.Token().StartPosition)
+		}
+		c.emit(op.Copy, 0)
+		if err := c.compilePattern(pattern); err != nil {
+			return 0, err
+		}
+		matchedPosits = append(matchedPosits, c.emit(op.PopJumpForwardIfTrue, Placeholder))
 	}
-	c.emit(op.Range)
-	return nil
-}
-
-func (c *Compiler) compileTernary(node *ast.Ternary) error {
-	// evaluate the condition and then conditionally jump to the false case
-	if err := c.compile(node.Condition()); err != nil {
-		return err
+	nextCasePosits := []int{c.emit(op.JumpForward, Placeholder)}
+	for _, pos := range matchedPosits {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return 0, err
+		}
+		c.changeOperand(pos, delta)
 	}
-	jumpIfFalsePos := c.emit(op.PopJumpForwardIfFalse, Placeholder)
-
-	// true case execution, then jump over false case
-	if err := c.compile(node.IfTrue()); err != nil {
-		return err
+	if guard := node.Guard(); guard != nil {
+		if err := c.compile(guard); err != nil {
+			return 0, err
+		}
+		nextCasePosits = append(nextCasePosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
 	}
-	trueCaseEndPos := c.emit(op.JumpForward, Placeholder)
-
-	// set the jump amount to reach the beginning of the false case
-	falseCaseDelta, err := c.calculateDelta(jumpIfFalsePos)
-	if err != nil {
-		return err
+	c.emit(op.PopTop)
+	if node.Block() == nil {
+		c.emit(op.Nil)
+	} else if err := c.compile(node.Block()); err != nil {
+		return 0, err
 	}
-	c.changeOperand(jumpIfFalsePos, falseCaseDelta)
+	endPos := c.emit(op.JumpForward, Placeholder)
+	for _, pos := range nextCasePosits {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return 0, err
+		}
+		c.changeOperand(pos, delta)
+	}
+	return endPos, nil
+}
 
-	// false case execution
-	if err := c.compile(node.IfFalse()); err != nil {
-		return err
+// compilePattern compiles code that tests whether the value at TOS matches
+// the given pattern. The value is replaced with a boolean result. Variables
+// named in the pattern are assigned as the test proceeds.
+func (c *Compiler) compilePattern(pattern ast.Expression) error {
+	switch pattern := pattern.(type) {
+	case *ast.Ident:
+		// A bare name matches anything and binds the value, except for "_"
+		// which matches anything without binding it
+		name := pattern.Literal()
+		if name == "_" {
+			c.emit(op.PopTop)
+		} else {
+			code := c.current
+			sym, err := code.symbols.InsertVariable(name)
+			if err != nil {
+				return c.formatError(fmt.Sprintf("variable %q bound more than once in pattern", name), pattern.Token().StartPosition)
+			}
+			if code.symbols.IsGlobal() {
+				c.emit(op.StoreGlobal, sym.Index())
+			} else {
+				c.emit(op.StoreFast, sym.Index())
+			}
+		}
+		c.emit(op.True)
+		return nil
+	case *ast.Prefix:
+		if pattern.Operator() != "-" {
+			break
+		}
+		switch pattern.Right().(type) {
+		case *ast.Int, *ast.Float:
+			return c.compileValuePattern(pattern)
+		}
+	case *ast.Int, *ast.Float, *ast.String, *ast.Bool, *ast.Nil, *ast.GetAttr:
+		return c.compileValuePattern(pattern)
+	case *ast.List:
+		return c.compileListPattern(pattern)
+	case *ast.Map:
+		return c.compileMapPattern(pattern)
+	case *ast.Call:
+		return c.compileTypePattern(pattern)
 	}
+	return c.formatError(fmt.Sprintf("invalid pattern: %s", pattern.String()), pattern.Token().StartPosition)
+}
 
-	// set the jump amount for the end of the true case
-	endDelta, err := c.calculateDelta(trueCaseEndPos)
-	if err != nil {
+// compileValuePattern compiles a pattern that matches values equal to the
+// given literal or attribute lookup.
+func (c *Compiler) compileValuePattern(pattern ast.Expression) error {
+	if err := c.compile(pattern); err != nil {
 		return err
 	}
-	c.changeOperand(trueCaseEndPos, endDelta)
+	c.emit(op.CompareOp, uint16(op.Equal))
 	return nil
 }
 
-func (c *Compiler) compileString(node *ast.String) error {
-	// Is the string a template or a simple string?
-	tmpl := node.Template()
-
-	// Simple strings are just emitted as a constant
-	if tmpl == nil {
-		c.emit(op.LoadConst, c.constant(node.Value()))
-		return nil
+// compileListPattern compiles a pattern like [a, b, ...rest], which matches
+// lists whose items match the item patterns. Without a rest pattern, the
+// list must have exactly as many items as there are item patterns.
+func (c *Compiler) compileListPattern(pattern *ast.List) error {
+	items := pattern.Items()
+	var rest *ast.Spread
+	if count := len(items); count > 0 {
+		if spread, ok := items[count-1].(*ast.Spread); ok {
+			rest = spread
+			items = items[:count-1]
+		}
+	}
+	var failPosits []int
+	c.emit(op.Copy, 0)
+	c.emit(op.MatchType, c.current.addName("list"))
+	failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	c.emit(op.Copy, 0)
+	c.emit(op.Length)
+	c.emit(op.LoadConst, c.constant(int64(len(items))))
+	if rest != nil {
+		c.emit(op.CompareOp, uint16(op.GreaterThanOrEqual))
+	} else {
+		c.emit(op.CompareOp, uint16(op.Equal))
 	}
-
-	fragments := tmpl.Fragments()
-	if len(fragments) > math.MaxUint16 {
-		return fmt.Errorf("compile error: string template exceeded max fragment size")
+	failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	for i, item := range items {
+		if _, ok := item.(*ast.Spread); ok {
+			return c.formatError("a rest pattern must be the last item in a list pattern", item.Token().StartPosition)
+		}
+		c.emit(op.Copy, 0)
+		c.emit(op.LoadConst, c.constant(int64(i)))
+		c.emit(op.BinarySubscr)
+		if err := c.compilePattern(item); err != nil {
+			return err
+		}
+		failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	}
+	if rest != nil {
+		// Slice off the remaining items, i.e. value[len(items):]
+		c.emit(op.Copy, 0)
+		c.emit(op.Copy, 0)
+		c.emit(op.Length)
+		c.emit(op.LoadConst, c.constant(int64(len(items))))
+		c.emit(op.Slice)
+		if err := c.compilePattern(rest.Value()); err != nil {
+			return err
+		}
+		failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	}
+	return c.finishPattern(failPosits)
+}
+
+// compileMapPattern compiles a pattern like {name: n, "id": 1}, which matches
+// maps that contain all the given keys with values that match the value
+// patterns. Other keys in the map are ignored.
+func (c *Compiler) compileMapPattern(pattern *ast.Map) error {
+	var failPosits []int
+	c.emit(op.Copy, 0)
+	c.emit(op.MatchType, c.current.addName("map"))
+	failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	items := pattern.Items()
+	for _, key := range pattern.Order() {
+		var name string
+		switch key := key.(type) {
+		case *ast.Ident:
+			name = key.Literal()
+		case *ast.String:
+			name = key.Value()
+		default:
+			return c.formatError("map pattern keys must be names or strings", key.Token().StartPosition)
+		}
+		c.emit(op.Copy, 0)
+		c.emit(op.LoadConst, c.constant(name))
+		c.emit(op.ContainsOp, 0)
+		failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+		c.emit(op.Copy, 0)
+		c.emit(op.LoadConst, c.constant(name))
+		c.emit(op.BinarySubscr)
+		if err := c.compilePattern(items[key]); err != nil {
+			return err
+		}
+		failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	}
+	return c.finishPattern(failPosits)
+}
+
+// compileTypePattern compiles a pattern like string(s) or Point(x=0), which
+// matches values of the named type. The optional positional pattern is
+// matched against the value itself, while keyword patterns are matched
+// against the attributes of the value.
+func (c *Compiler) compileTypePattern(pattern *ast.Call) error {
+	typeName, ok := pattern.Function().(*ast.Ident)
+	if !ok {
+		return c.formatError("type pattern must begin with a type name", pattern.Token().StartPosition)
+	}
+	args := pattern.Arguments()
+	if len(args) > 1 {
+		return c.formatError("type pattern accepts at most one positional pattern", pattern.Token().StartPosition)
+	}
+	var failPosits []int
+	c.emit(op.Copy, 0)
+	c.emit(op.MatchType, c.current.addName(typeName.Literal()))
+	failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	for _, arg := range args {
+		argExpr, ok := arg.(ast.Expression)
+		if !ok {
+			return c.formatError("invalid pattern", pattern.Token().StartPosition)
+		}
+		c.emit(op.Copy, 0)
+		if err := c.compilePattern(argExpr); err != nil {
+			return err
+		}
+		failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	}
+	for _, kw := range pattern.Keywords() {
+		c.emit(op.Copy, 0)
+		c.emit(op.LoadAttr, c.current.addName(kw.Name().Literal()))
+		if err := c.compilePattern(kw.Value()); err != nil {
+			return err
+		}
+		failPosits = append(failPosits, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	}
+	return c.finishPattern(failPosits)
+}
+
+// finishPattern ends a pattern that has the value at TOS and jumps to the
+// given positions when a check fails. It replaces the value with True if all
+// checks passed, or False otherwise.
+func (c *Compiler) finishPattern(failPosits []int) error {
+	c.emit(op.PopTop)
+	c.emit(op.True)
+	endPos := c.emit(op.JumpForward, Placeholder)
+	for _, pos := range failPosits {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+	c.emit(op.PopTop)
+	c.emit(op.False)
+	delta, err := c.calculateDelta(endPos)
+	if err != nil {
+		return err
+	}
+	c.changeOperand(endPos, delta)
+	return nil
+}
+
+// patternBindsVariables returns true if the pattern assigns any variables.
+func patternBindsVariables(pattern ast.Node) bool {
+	switch pattern := pattern.(type) {
+	case *ast.Ident:
+		return pattern.Literal() != "_"
+	case *ast.Spread:
+		return patternBindsVariables(pattern.Value())
+	case *ast.List:
+		for _, item := range pattern.Items() {
+			if patternBindsVariables(item) {
+				return true
+			}
+		}
+	case *ast.Map:
+		for _, value := range pattern.Items() {
+			if patternBindsVariables(value) {
+				return true
+			}
+		}
+	case *ast.Call:
+		for _, arg := range pattern.Arguments() {
+			if patternBindsVariables(arg) {
+				return true
+			}
+		}
+		for _, kw := range pattern.Keywords() {
+			if patternBindsVariables(kw.Value()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (c *Compiler) compileImport(node *ast.Import) error {
+	moduleName := node.Path().Value()
+	c.emit(op.LoadConst, c.constant(moduleName))
+	c.emit(op.Import)
+
+	// Determine the variable name to store the imported module
+	var name string
+	if node.Alias() != nil {
+		name = node.Alias().String()
+	} else {
+		name = node.ModuleName()
+	}
+
+	var sym *Symbol
+	var found bool
+	sym, found = c.current.symbols.Get(name)
+	if !found {
+		var err error
+		sym, err = c.current.symbols.InsertConstant(name)
+		if err != nil {
+			return err
+		}
+	}
+	if c.current.parent == nil {
+		c.emit(op.StoreGlobal, sym.Index())
+	} else {
+		c.emit(op.StoreFast, sym.Index())
+	}
+	return nil
+}
+
+func (c *Compiler) compileFromImport(node *ast.FromImport) error {
+	if len(node.Parents()) > 255 {
+		return fmt.Errorf("compile error: too many parents in from-import")
+	}
+	for _, parent := range node.Parents() {
+		c.emit(op.LoadConst, c.constant(parent.String()))
+	}
+	aliases := map[string]string{}
+	for _, im := range node.Imports() {
+		name := im.Path().Value()
+		alias := name
+		if im.Alias() != nil {
+			alias = im.Alias().String()
+		}
+		c.emit(op.LoadConst, c.constant(name))
+		aliases[name] = alias
+	}
+	c.emit(op.FromImport, uint16(len(node.Parents())), uint16(len(node.Imports())))
+	for _, im := range node.Imports() {
+		alias := aliases[im.Path().Value()]
+		var sym *Symbol
+		var found bool
+		sym, found = c.current.symbols.Get(alias)
+		if !found {
+			var err error
+			sym, err = c.current.symbols.InsertConstant(alias)
+			if err != nil {
+				return err
+			}
+		}
+		if c.current.parent == nil {
+			c.emit(op.StoreGlobal, sym.Index())
+		} else {
+			c.emit(op.StoreFast, sym.Index())
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) compileSlice(node *ast.Slice) error {
+	if err := c.compile(node.Left()); err != nil {
+		return err
+	}
+	to := node.ToIndex()
+	if to == nil {
+		c.emit(op.Copy, 0)
+		c.emit(op.Length)
+	} else {
+		if err := c.compile(to); err != nil {
+			return err
+		}
+	}
+	from := node.FromIndex()
+	if from == nil {
+		c.emit(op.LoadConst, c.constant(int64(0)))
+	} else {
+		if err := c.compile(from); err != nil {
+			return err
+		}
+	}
+	c.emit(op.Slice)
+	return nil
+}
+
+func (c *Compiler) compileRange(node *ast.Range) error {
+	if err := c.compile(node.Container()); err != nil {
+		return err
+	}
+	c.emit(op.Range)
+	return nil
+}
+
+func (c *Compiler) compileTernary(node *ast.Ternary) error {
+	if value, ok := c.fold(node.Condition()); ok {
+		if condition, ok := value.(bool); ok {
+			live, dead := node.IfTrue(), node.IfFalse()
+			if !condition {
+				live, dead = dead, live
+			}
+			if err := c.compile(live); err != nil {
+				return err
+			}
+			return c.compileUnreachable(dead)
+		}
+	}
+	// evaluate the condition and then conditionally jump to the false case
+	if err := c.compile(node.Condition()); err != nil {
+		return err
+	}
+	jumpIfFalsePos := c.emit(op.PopJumpForwardIfFalse, Placeholder)
+
+	// true case execution, then jump over false case
+	if err := c.compile(node.IfTrue()); err != nil {
+		return err
+	}
+	trueCaseEndPos := c.emit(op.JumpForward, Placeholder)
+
+	// set the jump amount to reach the beginning of the false case
+	falseCaseDelta, err := c.calculateDelta(jumpIfFalsePos)
+	if err != nil {
+		return err
+	}
+	c.changeOperand(jumpIfFalsePos, falseCaseDelta)
+
+	// false case execution
+	if err := c.compile(node.IfFalse()); err != nil {
+		return err
+	}
+
+	// set the jump amount for the end of the true case
+	endDelta, err := c.calculateDelta(trueCaseEndPos)
+	if err != nil {
+		return err
+	}
+	c.changeOperand(trueCaseEndPos, endDelta)
+	return nil
+}
+
+func (c *Compiler) compileString(node *ast.String) error {
+	// Is the string a template or a simple string?
+	tmpl := node.Template()
+
+	// Simple strings are just emitted as a constant
+	if tmpl == nil {
+		c.emit(op.LoadConst, c.constant(node.Value()))
+		return nil
+	}
+
+	fragments := tmpl.Fragments()
+	if len(fragments) > math.MaxUint16 {
+		return fmt.Errorf("compile error: string template exceeded max fragment size")
 	}
 
 	var expressionIndex int
@@ -815,11 +1421,20 @@ func (c *Compiler) compileString(node *ast.String) error {
 			// Nil expression should be treated as empty string
 			if expr == nil {
 				c.emit(op.LoadConst, c.constant(""))
-				continue
-			}
-			if err := c.compile(expr); err != nil {
+			} else if err := c.compile(expr); err != nil {
 				return err
 			}
+			// Apply any conversion and format specifier to the value
+			if f.Conversion() != "" || f.FormatSpec() != "" {
+				conversion := op.FormatNone
+				switch f.Conversion() {
+				case "s":
+					conversion = op.FormatStr
+				case "r":
+					conversion = op.FormatRepr
+				}
+				c.emit(op.FormatValue, uint16(conversion), c.constant(f.FormatSpec()))
+			}
 		case false:
 			// Push the fragment as a constant as TOS
 			c.emit(op.LoadConst, c.constant(f.Value()))
@@ -857,7 +1472,11 @@ func (c *Compiler) compilePipe(node *ast.Pipe) error {
 		// Swap the function (TOS) with the argument below it on the stack
 		// and then call the function with one argument
 		c.emit(op.Swap, 1)
-		c.emit(op.Call, 1)
+		if i == len(exprs)-1 && c.isTailCall(node) {
+			c.emit(op.TailCall, 1)
+		} else {
+			c.emit(op.Call, 1)
+		}
 	}
 	return nil
 }
@@ -906,6 +1525,48 @@ func (c *Compiler) compileConst(node *ast.Const) error {
 	if err := c.compile(expr); err != nil {
 		return err
 	}
+	// If the value is known at compile time, references to the constant
+	// are replaced with the value itself
+	value, _ := c.fold(expr)
+	sym, err := c.current.symbols.InsertConstant(name, value)
+	if err != nil {
+		return err
+	}
+	if c.current.parent == nil {
+		c.emit(op.StoreGlobal, sym.Index())
+	} else {
+		c.emit(op.StoreFast, sym.Index())
+	}
+	return nil
+}
+
+func (c *Compiler) compileStruct(node *ast.Struct) error {
+	name := node.Name().Literal()
+	fields := node.Fields()
+	if len(fields) > 255 {
+		return c.formatError("struct exceeded field limit of 255", node.Token().StartPosition)
+	}
+	// The struct name is pushed first, followed by a name and default value
+	// for each field. Defaults are evaluated once, when the struct is declared.
+	c.emit(op.LoadConst, c.constant(name))
+	seen := make(map[string]bool, len(fields))
+	for i, field := range fields {
+		fieldName := field.Literal()
+		if seen[fieldName] {
+			return c.formatError(fmt.Sprintf("duplicate field %q in struct %q", fieldName, name),
+				field.Token().StartPosition)
+		}
+		seen[fieldName] = true
+		c.emit(op.LoadConst, c.constant(fieldName))
+		if value := node.Defaults()[i]; value != nil {
+			if err := c.compile(value); err != nil {
+				return err
+			}
+		} else {
+			c.emit(op.Nil)
+		}
+	}
+	c.emit(op.BuildStruct, uint16(len(fields)))
 	sym, err := c.current.symbols.InsertConstant(name)
 	if err != nil {
 		return err
@@ -942,6 +1603,10 @@ func (c *Compiler) compileNotIn(node *ast.NotIn) error {
 }
 
 func (c *Compiler) compilePrefix(node *ast.Prefix) error {
+	if value, ok := c.fold(node); ok {
+		c.emitConstant(value)
+		return nil
+	}
 	if err := c.compile(node.Right()); err != nil {
 		return err
 	}
@@ -963,21 +1628,163 @@ func (c *Compiler) compileCall(node *ast.Call) error {
 	if err := c.compile(node.Function()); err != nil {
 		return err
 	}
-	for _, arg := range args {
-		if err := c.compile(arg); err != nil {
-			return err
-		}
+	return c.compileCallArgs(node, c.current.pipeActive)
+}
+
+// compileCallArgs pushes the arguments of the call onto the stack, followed by
+// the instruction that performs the call, or that creates a partial if the
+// partial flag is set. Plain positional arguments are pushed individually.
+// Otherwise the positional arguments are collected into a list, expanding any
+// spreads, and the keyword arguments into a map.
+func (c *Compiler) compileCallArgs(call *ast.Call, partial bool) error {
+	args := call.Arguments()
+	keywords := call.Keywords()
+	if len(keywords) > 0 {
+		if err := c.compileSpreadList(args); err != nil {
+			return err
+		}
+		for _, kw := range keywords {
+			c.emit(op.LoadConst, c.constant(kw.Name().Literal()))
+			if err := c.compile(kw.Value()); err != nil {
+				return err
+			}
+		}
+		c.emit(op.BuildMap, uint16(len(keywords)))
+		if partial {
+			c.emit(op.PartialKw)
+		} else {
+			c.emit(op.CallKw)
+		}
+		return nil
 	}
-	if c.current.pipeActive {
-		c.emit(op.Partial, uint16(argc))
+	if hasSpread(args) {
+		if err := c.compileSpreadList(args); err != nil {
+			return err
+		}
+		if partial {
+			c.emit(op.PartialSpread)
+		} else {
+			c.emit(op.CallSpread)
+		}
+		return nil
+	}
+	for _, arg := range args {
+		if err := c.compile(arg); err != nil {
+			return err
+		}
+	}
+	if partial {
+		c.emit(op.Partial, uint16(len(args)))
+	} else if c.isTailCall(call) {
+		// The VM may reuse the frame of the current function for the call.
+		// If it doesn't, the result of the call is returned as usual.
+		c.emit(op.TailCall, uint16(len(args)))
 	} else {
-		c.emit(op.Call, uint16(argc))
+		c.emit(op.Call, uint16(len(args)))
+	}
+	return nil
+}
+
+// hasSpread returns true if any of the given nodes is a spread expression.
+func hasSpread(nodes []ast.Node) bool {
+	for _, node := range nodes {
+		if _, ok := node.(*ast.Spread); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// compileSpreadList compiles the given nodes into a single list on the top of
+// the stack, expanding spread expressions in place. Runs of regular items are
+// collected with BuildList and each spread is appended with ListExtend.
+func (c *Compiler) compileSpreadList(nodes []ast.Node) error {
+	pending := 0
+	started := false
+	flush := func() {
+		if !started {
+			c.emit(op.BuildList, uint16(pending))
+			started = true
+		} else if pending > 0 {
+			c.emit(op.BuildList, uint16(pending))
+			c.emit(op.ListExtend)
+		}
+		pending = 0
+	}
+	for _, node := range nodes {
+		if spread, ok := node.(*ast.Spread); ok {
+			flush()
+			if err := c.compile(spread.Value()); err != nil {
+				return err
+			}
+			c.emit(op.ListExtend)
+			continue
+		}
+		if err := c.compile(node); err != nil {
+			return err
+		}
+		pending++
 	}
+	flush()
 	return nil
 }
 
 func (c *Compiler) compileObjectCall(node *ast.ObjectCall) error {
-	if err := c.compile(node.Object()); err != nil {
+	var exits []int
+	if err := c.compileObjectCallLink(node, &exits); err != nil {
+		return err
+	}
+	return c.patchChainExits(exits)
+}
+
+func (c *Compiler) compileGetAttr(node *ast.GetAttr) error {
+	var exits []int
+	if err := c.compileGetAttrLink(node, &exits); err != nil {
+		return err
+	}
+	return c.patchChainExits(exits)
+}
+
+func (c *Compiler) compileIndex(node *ast.Index) error {
+	var exits []int
+	if err := c.compileIndexLink(node, &exits); err != nil {
+		return err
+	}
+	return c.patchChainExits(exits)
+}
+
+// compileChainLink compiles an expression that may be a link in a chain of
+// attribute accesses, method calls and indexes, such as a?.b.c[0]. When an
+// optional link finds nil, it jumps to the end of the whole chain, leaving
+// nil as the result. The positions of these jumps are appended to exits.
+func (c *Compiler) compileChainLink(node ast.Expression, exits *[]int) error {
+	switch node := node.(type) {
+	case *ast.GetAttr:
+		return c.compileGetAttrLink(node, exits)
+	case *ast.Index:
+		return c.compileIndexLink(node, exits)
+	case *ast.ObjectCall:
+		return c.compileObjectCallLink(node, exits)
+	default:
+		return c.compile(node)
+	}
+}
+
+// patchChainExits points the short-circuit jumps of an optional chain at the
+// current position, which is the end of the chain.
+func (c *Compiler) patchChainExits(exits []int) error {
+	for _, pos := range exits {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+	return nil
+}
+
+func (c *Compiler) compileObjectCallLink(node *ast.ObjectCall, exits *[]int) error {
+	if err := c.compileChainLink(node.Object(), exits); err != nil {
 		return err
 	}
 	expr := node.Call()
@@ -986,42 +1793,75 @@ func (c *Compiler) compileObjectCall(node *ast.ObjectCall) error {
 		return fmt.Errorf("compile error: invalid call expression")
 	}
 	name := method.Function().String()
-	c.emit(op.LoadAttr, c.current.addName(name))
+	// Plain method calls are compiled so that methods of built-in types can
+	// be called without binding them to the object first
+	if !node.IsOptional() && !c.current.pipeActive &&
+		len(method.Keywords()) == 0 && !hasSpread(method.Arguments()) {
+		return c.compileMethodCall(name, method)
+	}
+	if node.IsOptional() {
+		// Skip the call if the object is nil or the method is missing
+		*exits = append(*exits, c.emit(op.JumpForwardIfNil, Placeholder))
+		c.emit(op.LoadAttrOrNil, c.current.addName(name))
+		*exits = append(*exits, c.emit(op.JumpForwardIfNil, Placeholder))
+	} else {
+		c.emit(op.LoadAttr, c.current.addName(name))
+	}
 	args := method.Arguments()
 	argc := len(args)
 	if argc > MaxArgs {
 		return fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
 	}
+	return c.compileCallArgs(method, c.current.pipeActive)
+}
+
+// compileMethodCall emits a call to the named method of the object on the top
+// of the stack, using LoadMethod and CallMethod.
+func (c *Compiler) compileMethodCall(name string, call *ast.Call) error {
+	args := call.Arguments()
+	argc := len(args)
+	if argc > MaxArgs {
+		return fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
+	}
+	c.emit(op.LoadMethod, c.current.addName(name))
 	for _, arg := range args {
 		if err := c.compile(arg); err != nil {
 			return err
 		}
 	}
-	if c.current.pipeActive {
-		c.emit(op.Partial, uint16(len(args)))
-	} else {
-		c.emit(op.Call, uint16(len(args)))
-	}
+	c.emit(op.CallMethod, uint16(argc))
 	return nil
 }
 
-func (c *Compiler) compileGetAttr(node *ast.GetAttr) error {
-	if err := c.compile(node.Object()); err != nil {
+func (c *Compiler) compileGetAttrLink(node *ast.GetAttr, exits *[]int) error {
+	if err := c.compileChainLink(node.Object(), exits); err != nil {
 		return err
 	}
 	idx := c.current.addName(node.Name())
-	c.emit(op.LoadAttr, idx)
+	if node.IsOptional() {
+		*exits = append(*exits, c.emit(op.JumpForwardIfNil, Placeholder))
+		c.emit(op.LoadAttrOrNil, idx)
+	} else {
+		c.emit(op.LoadAttr, idx)
+	}
 	return nil
 }
 
-func (c *Compiler) compileIndex(node *ast.Index) error {
-	if err := c.compile(node.Left()); err != nil {
+func (c *Compiler) compileIndexLink(node *ast.Index, exits *[]int) error {
+	if err := c.compileChainLink(node.Left(), exits); err != nil {
 		return err
 	}
+	if node.IsOptional() {
+		*exits = append(*exits, c.emit(op.JumpForwardIfNil, Placeholder))
+	}
 	if err := c.compile(node.Index()); err != nil {
 		return err
 	}
-	c.emit(op.BinarySubscr)
+	if node.IsOptional() {
+		c.emit(op.BinarySubscrOrNil)
+	} else {
+		c.emit(op.BinarySubscr)
+	}
 	return nil
 }
 
@@ -1031,6 +1871,13 @@ func (c *Compiler) compileList(node *ast.List) error {
 	if count > math.MaxUint16 {
 		return fmt.Errorf("compile error: list literal exceeds max size")
 	}
+	nodes := make([]ast.Node, 0, count)
+	for _, expr := range items {
+		nodes = append(nodes, expr)
+	}
+	if hasSpread(nodes) {
+		return c.compileSpreadList(nodes)
+	}
 	for _, expr := range items {
 		if err := c.compile(expr); err != nil {
 			return err
@@ -1042,9 +1889,36 @@ func (c *Compiler) compileList(node *ast.List) error {
 
 func (c *Compiler) compileMap(node *ast.Map) error {
 	items := node.Items()
-	count := len(items)
-	for k, v := range items {
+	keys := node.Order()
+	if keys == nil {
+		keys = make([]ast.Expression, 0, len(items))
+		for k := range items {
+			keys = append(keys, k)
+		}
+	}
+	// Runs of key-value pairs are collected with BuildMap and each spread is
+	// merged into the map with MapMerge, so later entries take precedence.
+	pending := 0
+	started := false
+	flush := func() {
+		if !started {
+			c.emit(op.BuildMap, uint16(pending))
+			started = true
+		} else if pending > 0 {
+			c.emit(op.BuildMap, uint16(pending))
+			c.emit(op.MapMerge)
+		}
+		pending = 0
+	}
+	for _, k := range keys {
 		switch k := k.(type) {
+		case *ast.Spread:
+			flush()
+			if err := c.compile(k.Value()); err != nil {
+				return err
+			}
+			c.emit(op.MapMerge)
+			continue
 		case *ast.String:
 			if err := c.compile(k); err != nil {
 				return err
@@ -1054,11 +1928,12 @@ func (c *Compiler) compileMap(node *ast.Map) error {
 		default:
 			return fmt.Errorf("compile error: invalid map key type: %v", k)
 		}
-		if err := c.compile(v); err != nil {
+		if err := c.compile(items[k]); err != nil {
 			return err
 		}
+		pending++
 	}
-	c.emit(op.BuildMap, uint16(count))
+	flush()
 	return nil
 }
 
@@ -1081,6 +1956,237 @@ func (c *Compiler) compileReceive(node *ast.Receive) error {
 	return nil
 }
 
+func (c *Compiler) compileSelect(node *ast.Select) error {
+	// For each case, push the channel, the value to send (nil for receives)
+	// and a flag that is true for sends. The Select instruction pops these
+	// and pushes the received value, the received "ok" flag and the index of
+	// the chosen case. An index equal to the case count selects the default.
+	var cases []*ast.SelectCase
+	var defaultCase *ast.SelectCase
+	for _, sc := range node.Cases() {
+		if sc.IsDefault() {
+			defaultCase = sc
+			continue
+		}
+		cases = append(cases, sc)
+		if send := sc.Send(); send != nil {
+			if err := c.compile(send.Channel()); err != nil {
+				return err
+			}
+			if err := c.compile(send.Value()); err != nil {
+				return err
+			}
+			c.emit(op.True)
+		} else {
+			if err := c.compile(sc.Receive().Channel()); err != nil {
+				return err
+			}
+			c.emit(op.Nil)
+			c.emit(op.False)
+		}
+	}
+	if len(cases) > math.MaxUint16 {
+		return c.formatError("select statement has too many cases", node.Token().StartPosition)
+	}
+	var hasDefault uint16
+	if defaultCase != nil {
+		hasDefault = 1
+	}
+	c.emit(op.Select, uint16(len(cases)), hasDefault)
+
+	// Jump to the block of the chosen case
+	var caseJumpPositions []int
+	for i := range cases {
+		c.emit(op.Copy, 0)
+		c.emit(op.LoadConst, c.constant(int64(i)))
+		c.emit(op.CompareOp, uint16(op.Equal))
+		caseJumpPositions = append(caseJumpPositions, c.emit(op.PopJumpForwardIfTrue, Placeholder))
+	}
+
+	// Otherwise the default case was chosen
+	c.emit(op.PopTop)
+	c.emit(op.PopTop)
+	c.emit(op.PopTop)
+	if defaultCase != nil && defaultCase.Block() != nil {
+		if err := c.compile(defaultCase.Block()); err != nil {
+			return err
+		}
+		c.emit(op.PopTop)
+	}
+	var endBlockPosits []int
+	for i, sc := range cases {
+		endBlockPosits = append(endBlockPosits, c.emit(op.JumpForward, Placeholder))
+		delta, err := c.calculateDelta(caseJumpPositions[i])
+		if err != nil {
+			return err
+		}
+		c.changeOperand(caseJumpPositions[i], delta)
+		c.emit(op.PopTop) // the case index
+		if err := c.compileSelectCase(sc); err != nil {
+			return err
+		}
+	}
+	for _, pos := range endBlockPosits {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+	return nil
+}
+
+func (c *Compiler) compileSelectCase(node *ast.SelectCase) error {
+	code := c.current
+	code.symbols = code.symbols.NewBlock()
+	defer func() {
+		code.symbols = code.symbols.parent
+	}()
+	// The "ok" flag is on top of the received value. Assign each to its
+	// variable, if one was named.
+	for _, ident := range []*ast.Ident{node.OkIdent(), node.ValueIdent()} {
+		if ident == nil {
+			c.emit(op.PopTop)
+			continue
+		}
+		sym, err := code.symbols.InsertVariable(ident.Literal())
+		if err != nil {
+			return err
+		}
+		if code.symbols.IsGlobal() {
+			c.emit(op.StoreGlobal, sym.Index())
+		} else {
+			c.emit(op.StoreFast, sym.Index())
+		}
+	}
+	if node.Block() != nil {
+		if err := c.compile(node.Block()); err != nil {
+			return err
+		}
+		c.emit(op.PopTop)
+	}
+	return nil
+}
+
+func (c *Compiler) compileListComprehension(node *ast.ListComprehension) error {
+	c.emit(op.BuildList, 0)
+	clauses := node.Clauses()
+	return c.compileComprehension(clauses, func() error {
+		if err := c.compile(node.Element()); err != nil {
+			return err
+		}
+		c.emit(op.ListAppend, uint16(len(clauses)))
+		return nil
+	})
+}
+
+func (c *Compiler) compileMapComprehension(node *ast.MapComprehension) error {
+	c.emit(op.BuildMap, 0)
+	clauses := node.Clauses()
+	return c.compileComprehension(clauses, func() error {
+		if err := c.compile(node.Key()); err != nil {
+			return err
+		}
+		if err := c.compile(node.Value()); err != nil {
+			return err
+		}
+		c.emit(op.MapAdd, uint16(len(clauses)))
+		return nil
+	})
+}
+
+func (c *Compiler) compileSetComprehension(node *ast.SetComprehension) error {
+	c.emit(op.BuildSet, 0)
+	clauses := node.Clauses()
+	return c.compileComprehension(clauses, func() error {
+		if err := c.compile(node.Element()); err != nil {
+			return err
+		}
+		c.emit(op.SetAdd, uint16(len(clauses)))
+		return nil
+	})
+}
+
+// compileComprehension compiles the nested loops of a comprehension, with the
+// collection being built at TOS. The loop iterators sit above the collection
+// while the loops run, so the instruction emitted by addElement refers to the
+// collection by its depth, which is the number of clauses. The loop variables
+// are scoped to the comprehension.
+func (c *Compiler) compileComprehension(clauses []*ast.ComprehensionClause, addElement func() error) error {
+	code := c.current
+	code.symbols = code.symbols.NewBlock()
+	defer func() {
+		code.symbols = code.symbols.parent
+	}()
+	return c.compileComprehensionClause(clauses, addElement)
+}
+
+func (c *Compiler) compileComprehensionClause(clauses []*ast.ComprehensionClause, addElement func() error) error {
+	clause := clauses[0]
+	if err := c.compile(clause.Iterable()); err != nil {
+		return err
+	}
+	c.emit(op.GetIter)
+
+	// A single variable receives each value, while two receive the key and
+	// value, which matches the behavior of for-in and for-range loops
+	names := clause.Names()
+	nameCount := uint16(3)
+	if len(names) == 2 {
+		nameCount = 2
+	}
+	code := c.current
+	iterPos := c.emit(op.ForIter, 0, nameCount)
+	for _, name := range names {
+		sym, err := code.symbols.InsertVariable(name.Literal())
+		if err != nil {
+			return err
+		}
+		if code.symbols.IsGlobal() {
+			c.emit(op.StoreGlobal, sym.Index())
+		} else {
+			c.emit(op.StoreFast, sym.Index())
+		}
+	}
+
+	// Skip to the next item if any condition is false
+	var skipPositions []int
+	for _, cond := range clause.Conditions() {
+		if err := c.compile(cond); err != nil {
+			return err
+		}
+		skipPositions = append(skipPositions, c.emit(op.PopJumpForwardIfFalse, Placeholder))
+	}
+	if len(clauses) > 1 {
+		if err := c.compileComprehensionClause(clauses[1:], addElement); err != nil {
+			return err
+		}
+	} else if err := addElement(); err != nil {
+		return err
+	}
+	for _, pos := range skipPositions {
+		delta, err := c.calculateDelta(pos)
+		if err != nil {
+			return err
+		}
+		c.changeOperand(pos, delta)
+	}
+
+	// Jump back to the start of the loop, and update the ForIter instruction
+	// to jump "here" when done
+	delta, err := c.calculateDelta(iterPos)
+	if err != nil {
+		return err
+	}
+	c.emit(op.JumpBackward, delta)
+	delta, err = c.calculateDelta(iterPos)
+	if err != nil {
+		return err
+	}
+	c.changeOperand(iterPos, delta)
+	return nil
+}
+
 func (c *Compiler) compileSet(node *ast.Set) error {
 	items := node.Items()
 	count := len(items)
@@ -1097,7 +2203,11 @@ func (c *Compiler) compileFunc(node *ast.Func) error {
 	// Python cell variables:
 	// https://stackoverflow.com/questions/23757143/what-is-a-cell-in-the-context-of-an-interpreter-or-compiler
 
-	if len(node.Parameters()) > 255 {
+	paramsCount := len(node.Parameters())
+	if node.RestParameter() != nil {
+		paramsCount++
+	}
+	if paramsCount > 255 {
 		return c.formatError("function exceeded parameter limit of 255", node.Token().StartPosition)
 	}
 
@@ -1168,113 +2278,379 @@ func (c *Compiler) compileFunc(node *ast.Func) error {
 		}
 	}
 
-	// Add the parameter names to the symbol table
+	// Add the parameter names to the symbol table. A rest parameter follows
+	// the positional parameters.
 	for _, arg := range node.Parameters() {
 		if _, err := code.symbols.InsertVariable(arg.Literal()); err != nil {
 			return err
 		}
 	}
+	var restParam string
+	if rest := node.RestParameter(); rest != nil {
+		restParam = rest.Literal()
+		if _, err := code.symbols.InsertVariable(restParam); err != nil {
+			return err
+		}
+	}
+
+	// Add the function's own name to its symbol table. This supports recursive
+	// calls to the function. Later when we create the function object, we'll
+	// add the object value to the table.
+	if code.isNamed {
+		if _, err := code.symbols.InsertConstant(functionName); err != nil {
+			return err
+		}
+	}
+
+	// Any function containing a yield statement is a generator. This is
+	// decided up front since unreachable statements aren't compiled.
+	code.isGenerator = containsYield(node.Body())
+
+	// Compile the function body
+	if err := c.compileFunctionBlock(node.Body()); err != nil {
+		return err
+	}
+
+	// We're done compiling the function, so switch back to compiling the parent
+	c.current = c.current.parent
+
+	// Create the function that contains the compiled code
+	fn := NewFunction(FunctionOpts{
+		ID:            functionID,
+		Name:          functionName,
+		Parameters:    params,
+		Defaults:      defaults,
+		RestParameter: restParam,
+		Code:          code,
+	})
+
+	// Emit the code to load the function object onto the stack. If there are
+	// free variables, we use LoadClosure, otherwise we use LoadConst.
+	freeCount := code.symbols.FreeCount()
+	if freeCount > 0 {
+		for i := uint16(0); i < freeCount; i++ {
+			resolution := code.symbols.Free(i)
+			c.emit(op.MakeCell, resolution.symbol.Index(), uint16(resolution.depth-1))
+		}
+		c.emit(op.LoadClosure, c.constant(fn), freeCount)
+	} else {
+		c.emit(op.LoadConst, c.constant(fn))
+	}
+
+	// If the function was named, we store it as a named variable in the current
+	// code. Otherwise, we just leave it on the stack.
+	if code.isNamed {
+		// Check if the function name already exists in the symbol table
+		// (it would have been added in the first pass for forward references)
+		funcSymbol, found := c.current.symbols.Get(functionName)
+		if !found {
+			var err error
+			funcSymbol, err = c.current.symbols.InsertConstant(functionName)
+			if err != nil {
+				return err
+			}
+		}
+		// Duplicate function on the stack, so that we ensure the function
+		// evaluates to a value even when it's named.
+		c.emit(op.Copy, 0)
+		if c.current.parent == nil {
+			c.emit(op.StoreGlobal, funcSymbol.Index())
+		} else {
+			c.emit(op.StoreFast, funcSymbol.Index())
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) compileControl(node *ast.Control) error {
+	literal := node.Literal()
+	loops := c.current.loops
+	if len(loops) == 0 {
+		if literal == "break" {
+			return c.formatError("invalid break statement outside of a loop", node.Token().StartPosition)
+		}
+		return c.formatError("invalid continue statement outside of a loop", node.Token().StartPosition)
+	}
+	// By default the innermost loop is targeted
+	target := len(loops) - 1
+	if label := node.Label(); label != nil {
+		if target = c.labeledLoop(label.Literal()); target < 0 {
+			return c.formatError(fmt.Sprintf("invalid %s statement: unknown loop label %q",
+				literal, label.Literal()), label.Token().StartPosition)
+		}
+	}
+	loop := loops[target]
+	for _, inner := range loops[target:] {
+		if inner.finallyDepth > 0 {
+			return c.formatError(fmt.Sprintf("invalid %s statement in finally block", literal), node.Token().StartPosition)
+		}
+	}
+	// Leave any try statements that are inside the loop
+	if err := c.unwindTries(loop.tryDepth); err != nil {
+		return err
+	}
+	// Pop the iterators of any for-range loops being exited. When breaking,
+	// this includes the iterator of the target loop itself.
+	exited := loops[target+1:]
+	if literal == "break" {
+		exited = loops[target:]
+	}
+	for _, inner := range exited {
+		if inner.isRangeLoop {
+			c.emit(op.PopTop)
+		}
+	}
+	// Pop the values of any switch statements within the target loop
+	for i := loop.switchDepth; i < c.current.switchDepth; i++ {
+		c.emit(op.PopTop)
+	}
+	position := c.emit(op.JumpForward, Placeholder)
+	if literal == "break" {
+		loop.breakPos = append(loop.breakPos, position)
+	} else {
+		loop.continuePos = append(loop.continuePos, position)
+	}
+	return nil
+}
+
+func (c *Compiler) compileReturn(node *ast.Return) error {
+	if c.current.IsRoot() {
+		return c.formatError("invalid return statement outside of a function", node.Token().StartPosition)
+	}
+	value := node.Value()
+	if value == nil {
+		c.emit(op.Nil)
+	} else {
+		if tailCalls := c.findTailCalls(value, nil); len(tailCalls) > 0 {
+			prevTailCalls := c.tailCalls
+			c.tailCalls = tailCalls
+			defer func() { c.tailCalls = prevTailCalls }()
+		}
+		if err := c.compile(value); err != nil {
+			return err
+		}
+	}
+	// Leave any try statements in this function. The return value stays on
+	// the stack while finally blocks run.
+	if err := c.unwindTries(0); err != nil {
+		return err
+	}
+	c.emit(op.ReturnValue)
+	return nil
+}
+
+// findTailCalls appends the calls in tail position within the returned
+// expression whose final call can be compiled as a tail call. This applies to
+// calls and pipes, including those that produce the value of a branch of a
+// ternary or if expression. Calls with keyword or spread arguments and calls
+// within a try statement are excluded, since the try statement has to handle
+// errors and run its finally block after the call.
+func (c *Compiler) findTailCalls(expr ast.Node, calls []ast.Node) []ast.Node {
+	switch expr := expr.(type) {
+	case *ast.Call:
+		if len(expr.Keywords()) > 0 || hasSpread(expr.Arguments()) || c.current.pipeActive {
+			return calls
+		}
+	case *ast.Pipe:
+	case *ast.Ternary:
+		calls = c.findTailCalls(expr.IfTrue(), calls)
+		return c.findTailCalls(expr.IfFalse(), calls)
+	case *ast.If:
+		calls = c.findBlockTailCalls(expr.Consequence(), calls)
+		return c.findBlockTailCalls(expr.Alternative(), calls)
+	default:
+		return calls
+	}
+	for _, t := range c.tries {
+		if t.code == c.current {
+			return calls
+		}
+	}
+	return append(calls, expr)
+}
+
+// findBlockTailCalls appends the calls in tail position within the final
+// expression of a block, which is the value of the block.
+func (c *Compiler) findBlockTailCalls(block *ast.Block, calls []ast.Node) []ast.Node {
+	if block == nil {
+		return calls
+	}
+	statements := c.reachable(block.Statements())
+	if len(statements) == 0 {
+		return calls
+	}
+	if last := statements[len(statements)-1]; last.IsExpression() {
+		return c.findTailCalls(last, calls)
+	}
+	return calls
+}
+
+// isTailCall returns true if the given call or pipe expression is in tail
+// position within the expression being returned.
+func (c *Compiler) isTailCall(expr ast.Node) bool {
+	return slices.Contains(c.tailCalls, expr)
+}
+
+func (c *Compiler) compileYield(node *ast.Yield) error {
+	if c.current.IsRoot() {
+		return c.formatError("invalid yield statement outside of a function", node.Token().StartPosition)
+	}
+	// Any function containing a yield statement is a generator
+	c.current.isGenerator = true
+	value := node.Value()
+	if value == nil {
+		c.emit(op.Nil)
+	} else {
+		if err := c.compile(value); err != nil {
+			return err
+		}
+	}
+	c.emit(op.Yield)
+	return nil
+}
 
-	// Add the function's own name to its symbol table. This supports recursive
-	// calls to the function. Later when we create the function object, we'll
-	// add the object value to the table.
-	if code.isNamed {
-		if _, err := code.symbols.InsertConstant(functionName); err != nil {
+// unwindTries emits the code needed to exit the active try statements in the
+// current function, from the innermost out to the given depth. The exception
+// handlers of each try statement are discarded and its finally block is run.
+func (c *Compiler) unwindTries(depth int) error {
+	tries := c.tries
+	defer func() { c.tries = tries }()
+	for i := len(tries) - 1; i >= depth && tries[i].code == c.current; i-- {
+		t := tries[i]
+		for j := 0; j < t.handlerCount; j++ {
+			c.emit(op.PopExcept)
+		}
+		if t.finally == nil {
+			continue
+		}
+		// The finally block runs outside of its own try statement. The capacity
+		// is limited so that any nested try statements don't overwrite the stack.
+		c.tries = tries[:i:i]
+		if err := c.compileFinally(t.finally); err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
-	// Compile the function body
-	if err := c.compileFunctionBlock(node.Body()); err != nil {
+func (c *Compiler) compileFinally(node *ast.Block) error {
+	// Jumping out of a finally block is not supported, since the block may
+	// be running with an error or return value on the stack
+	if loop := c.currentLoop(); loop != nil {
+		loop.finallyDepth++
+		defer func() { loop.finallyDepth-- }()
+	}
+	if err := c.compile(node); err != nil {
 		return err
 	}
+	c.emit(op.PopTop)
+	return nil
+}
 
-	// We're done compiling the function, so switch back to compiling the parent
-	c.current = c.current.parent
-
-	// Create the function that contains the compiled code
-	fn := NewFunction(FunctionOpts{
-		ID:         functionID,
-		Name:       functionName,
-		Parameters: params,
-		Defaults:   defaults,
-		Code:       code,
-	})
+func (c *Compiler) compileTry(node *ast.Try) error {
+	// Register the exception handlers. When both are present, the catch
+	// handler is on top, so that errors in the try block are caught first
+	// and errors in the catch block are handled by the finally handler.
+	catchBlock := node.CatchBlock()
+	finallyBlock := node.FinallyBlock()
+	var catchPos, finallyPos, handlerCount int
+	if finallyBlock != nil {
+		finallyPos = c.emit(op.SetupFinally, Placeholder)
+		handlerCount++
+	}
+	if catchBlock != nil {
+		catchPos = c.emit(op.SetupCatch, Placeholder)
+		handlerCount++
+	}
+	depth := len(c.tries)
+	defer func() { c.tries = c.tries[:depth] }()
+	t := &tryBlock{code: c.current, handlerCount: handlerCount, finally: finallyBlock}
+	c.tries = append(c.tries, t)
+
+	// Compile the try block, then remove the handlers and jump past the
+	// error handling code if it completes without error
+	if err := c.compile(node.Body()); err != nil {
+		return err
+	}
+	c.emit(op.PopTop)
+	for i := 0; i < handlerCount; i++ {
+		c.emit(op.PopExcept)
+	}
+	jumpPos := c.emit(op.JumpForward, Placeholder)
 
-	// Emit the code to load the function object onto the stack. If there are
-	// free variables, we use LoadClosure, otherwise we use LoadConst.
-	freeCount := code.symbols.FreeCount()
-	if freeCount > 0 {
-		for i := uint16(0); i < freeCount; i++ {
-			resolution := code.symbols.Free(i)
-			c.emit(op.MakeCell, resolution.symbol.Index(), uint16(resolution.depth-1))
+	// When an error is not caught, the finally block runs with the error on
+	// the stack, and then the error is raised again
+	if finallyBlock != nil {
+		delta, err := c.calculateDelta(finallyPos)
+		if err != nil {
+			return err
 		}
-		c.emit(op.LoadClosure, c.constant(fn), freeCount)
-	} else {
-		c.emit(op.LoadConst, c.constant(fn))
+		c.changeOperand(finallyPos, delta)
+		c.tries = c.tries[:depth]
+		if err := c.compileFinally(finallyBlock); err != nil {
+			return err
+		}
+		c.emit(op.Raise)
 	}
 
-	// If the function was named, we store it as a named variable in the current
-	// code. Otherwise, we just leave it on the stack.
-	if code.isNamed {
-		// Check if the function name already exists in the symbol table
-		// (it would have been added in the first pass for forward references)
-		funcSymbol, found := c.current.symbols.Get(functionName)
-		if !found {
-			var err error
-			funcSymbol, err = c.current.symbols.InsertConstant(functionName)
-			if err != nil {
-				return err
-			}
+	// The catch block starts with the caught error on the stack. It either
+	// falls through to the finally block or ends the try statement.
+	if catchBlock != nil {
+		delta, err := c.calculateDelta(catchPos)
+		if err != nil {
+			return err
 		}
-		// Duplicate function on the stack, so that we ensure the function
-		// evaluates to a value even when it's named.
-		c.emit(op.Copy, 0)
-		if c.current.parent == nil {
-			c.emit(op.StoreGlobal, funcSymbol.Index())
-		} else {
-			c.emit(op.StoreFast, funcSymbol.Index())
+		c.changeOperand(catchPos, delta)
+		if finallyBlock != nil {
+			t.handlerCount = 1
+			c.tries = append(c.tries[:depth], t)
 		}
+		if err := c.compileCatch(node.CatchIdent(), catchBlock); err != nil {
+			return err
+		}
+		if finallyBlock != nil {
+			c.emit(op.PopExcept)
+		}
+		c.tries = c.tries[:depth]
 	}
-	return nil
-}
 
-func (c *Compiler) compileControl(node *ast.Control) error {
-	literal := node.Literal()
-	loop := c.currentLoop()
-	if loop == nil {
-		if literal == "break" {
-			return c.formatError("invalid break statement outside of a loop", node.Token().StartPosition)
-		}
-		return c.formatError("invalid continue statement outside of a loop", node.Token().StartPosition)
+	delta, err := c.calculateDelta(jumpPos)
+	if err != nil {
+		return err
 	}
-	if literal == "break" {
-		// When breaking from a for-range loop, we need to pop the iterator from the stack
-		if loop.isRangeLoop {
-			c.emit(op.PopTop)
+	c.changeOperand(jumpPos, delta)
+	if finallyBlock != nil {
+		if err := c.compileFinally(finallyBlock); err != nil {
+			return err
 		}
-		position := c.emit(op.JumpForward, Placeholder)
-		loop.breakPos = append(loop.breakPos, position)
-	} else {
-		position := c.emit(op.JumpForward, Placeholder)
-		loop.continuePos = append(loop.continuePos, position)
 	}
 	return nil
 }
 
-func (c *Compiler) compileReturn(node *ast.Return) error {
-	if c.current.IsRoot() {
-		return c.formatError("invalid return statement outside of a function", node.Token().StartPosition)
-	}
-	value := node.Value()
-	if value == nil {
-		c.emit(op.Nil)
+func (c *Compiler) compileCatch(ident *ast.Ident, node *ast.Block) error {
+	code := c.current
+	code.symbols = code.symbols.NewBlock()
+	defer func() {
+		code.symbols = code.symbols.parent
+	}()
+	// Assign the caught error to the variable, if one was named
+	if ident == nil {
+		c.emit(op.PopTop)
 	} else {
-		if err := c.compile(value); err != nil {
+		sym, err := code.symbols.InsertVariable(ident.Literal())
+		if err != nil {
 			return err
 		}
+		if code.symbols.IsGlobal() {
+			c.emit(op.StoreGlobal, sym.Index())
+		} else {
+			c.emit(op.StoreFast, sym.Index())
+		}
 	}
-	c.emit(op.ReturnValue)
+	if err := c.compile(node); err != nil {
+		return err
+	}
+	c.emit(op.PopTop)
 	return nil
 }
 
@@ -1436,7 +2812,22 @@ func (c *Compiler) compileSetAttr(node *ast.SetAttr) error {
 	return nil
 }
 
-func (c *Compiler) compileForRange(forNode *ast.For, names []string, container ast.Node) error {
+// nameTargets returns identifiers for the given variable names, so that they
+// may be assigned like any other destructuring target.
+func nameTargets(tok token.Token, names []string) []ast.Expression {
+	targets := make([]ast.Expression, 0, len(names))
+	for _, name := range names {
+		targets = append(targets, ast.NewIdent(token.Token{
+			Type:          token.IDENT,
+			Literal:       name,
+			StartPosition: tok.StartPosition,
+			EndPosition:   tok.EndPosition,
+		}))
+	}
+	return targets
+}
+
+func (c *Compiler) compileForRange(forNode *ast.For, targets []ast.Expression, container ast.Node) error {
 	if err := c.compile(container); err != nil {
 		return err
 	}
@@ -1444,27 +2835,24 @@ func (c *Compiler) compileForRange(forNode *ast.For, names []string, container a
 	c.emit(op.GetIter)
 
 	code := c.current
+	loop, err := c.startLoop(forNode.Label())
+	if err != nil {
+		return err
+	}
 	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
 	loop.isRangeLoop = true
 	defer func() {
 		loop.end()
 		code.symbols = code.symbols.parent
 	}()
 
-	iterPos := c.emit(op.ForIter, 0, uint16(len(names)))
+	iterPos := c.emit(op.ForIter, 0, uint16(len(targets)))
 
-	// assign the current value of the iterator to the loop variable
-	for _, name := range names {
-		sym, err := code.symbols.InsertVariable(name)
-		if err != nil {
+	// assign the current value of the iterator to the loop variables
+	for _, target := range targets {
+		if err := c.compileTarget(target, true); err != nil {
 			return err
 		}
-		if code.symbols.IsGlobal() {
-			c.emit(op.StoreGlobal, sym.Index())
-		} else {
-			c.emit(op.StoreFast, sym.Index())
-		}
 	}
 
 	// compile the body of the loop
@@ -1509,8 +2897,11 @@ func (c *Compiler) compileForRange(forNode *ast.For, names []string, container a
 
 func (c *Compiler) compileForCondition(forNode *ast.For, condition ast.Expression) error {
 	code := c.current
+	loop, err := c.startLoop(forNode.Label())
+	if err != nil {
+		return err
+	}
 	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
 	defer func() {
 		loop.end()
 		code.symbols = code.symbols.parent
@@ -1564,20 +2955,32 @@ func (c *Compiler) compileFor(node *ast.For) error {
 		switch cond := cond.(type) {
 		case *ast.Var:
 			name, rhs := cond.Value()
+			targets := nameTargets(cond.Token(), []string{name})
 			if rangeNode, ok := rhs.(*ast.Range); ok {
-				return c.compileForRange(node, []string{name}, rangeNode.Container())
+				return c.compileForRange(node, targets, rangeNode.Container())
 			} else {
-				return c.compileForRange(node, []string{name}, rhs)
+				return c.compileForRange(node, targets, rhs)
 			}
 		case *ast.MultiVar:
 			names, rhs := cond.Value()
 			if len(names) != 2 {
 				return c.formatError("invalid for loop", node.Token().StartPosition)
 			}
+			targets := nameTargets(cond.Token(), names)
+			if rangeNode, ok := rhs.(*ast.Range); ok {
+				return c.compileForRange(node, targets, rangeNode.Container())
+			} else {
+				return c.compileForRange(node, targets, rhs)
+			}
+		case *ast.Destructure:
+			targets, rhs := cond.Value()
+			if len(targets) > 2 {
+				return c.formatError("invalid for loop", node.Token().StartPosition)
+			}
 			if rangeNode, ok := rhs.(*ast.Range); ok {
-				return c.compileForRange(node, names, rangeNode.Container())
+				return c.compileForRange(node, targets, rangeNode.Container())
 			} else {
-				return c.compileForRange(node, names, rhs)
+				return c.compileForRange(node, targets, rhs)
 			}
 		case *ast.Range:
 			return c.compileForRange(node, nil, cond.Container())
@@ -1590,8 +2993,11 @@ func (c *Compiler) compileFor(node *ast.For) error {
 
 	// For-Condition loop e.g. `for i := 0; i < 10; i++ { ... }`
 	code := c.current
+	loop, err := c.startLoop(node.Label())
+	if err != nil {
+		return err
+	}
 	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
 	defer func() {
 		loop.end()
 		code.symbols = code.symbols.parent
@@ -1677,8 +3083,11 @@ func (c *Compiler) compileFor(node *ast.For) error {
 
 func (c *Compiler) compileSimpleFor(node *ast.For) error {
 	code := c.current
+	loop, err := c.startLoop(node.Label())
+	if err != nil {
+		return err
+	}
 	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
 	defer func() {
 		loop.end()
 		code.symbols = code.symbols.parent
@@ -1720,8 +3129,11 @@ func (c *Compiler) compileForIn(node *ast.ForIn) error {
 	c.emit(op.GetIter)
 
 	code := c.current
+	loop, err := c.startLoop(node.Label())
+	if err != nil {
+		return err
+	}
 	code.symbols = code.symbols.NewBlock()
-	loop := c.startLoop()
 	loop.isRangeLoop = true
 	defer func() {
 		loop.end()
@@ -1730,18 +3142,25 @@ func (c *Compiler) compileForIn(node *ast.ForIn) error {
 
 	// ForIter instruction: pop iterator, advance it, push current values
 	// Use 3 to indicate Python-style single variable (gets value, not key)
-	iterPos := c.emit(op.ForIter, 0, 3) // 3 indicates Python-style single variable (gets value)
-
-	// Assign the current value of the iterator to the loop variable
-	varName := node.Variable().Literal()
-	sym, err := code.symbols.InsertVariable(varName)
-	if err != nil {
-		return err
+	targets := node.Targets()
+	if variable := node.Variable(); variable != nil {
+		targets = []ast.Expression{variable}
+	}
+	var iterPos int
+	switch len(targets) {
+	case 1:
+		iterPos = c.emit(op.ForIter, 0, 3) // 3 indicates Python-style single variable (gets value)
+	case 2:
+		iterPos = c.emit(op.ForIter, 0, 2) // Two variables get the key and value
+	default:
+		return c.formatError("invalid for loop", node.Token().StartPosition)
 	}
-	if code.symbols.IsGlobal() {
-		c.emit(op.StoreGlobal, sym.Index())
-	} else {
-		c.emit(op.StoreFast, sym.Index())
+
+	// Assign the current value of the iterator to the loop variables
+	for _, target := range targets {
+		if err := c.compileTarget(target, true); err != nil {
+			return err
+		}
 	}
 
 	// Compile the body of the loop
@@ -1785,6 +3204,29 @@ func (c *Compiler) compileForIn(node *ast.ForIn) error {
 }
 
 func (c *Compiler) compileIf(node *ast.If) error {
+	// Only one branch is compiled if the condition is a constant
+	if value, ok := c.fold(node.Condition()); ok {
+		if condition, ok := value.(bool); ok {
+			consequence, alternative := node.Consequence(), node.Alternative()
+			if condition {
+				if err := c.compile(consequence); err != nil {
+					return err
+				}
+				if alternative == nil {
+					return nil
+				}
+				return c.compileUnreachable(alternative)
+			}
+			if alternative != nil {
+				if err := c.compile(alternative); err != nil {
+					return err
+				}
+			} else {
+				c.emit(op.Nil)
+			}
+			return c.compileUnreachable(consequence)
+		}
+	}
 	if err := c.compile(node.Condition()); err != nil {
 		return err
 	}
@@ -1847,12 +3289,18 @@ func (c *Compiler) changeOperand(instructionIndex int, operand uint16) {
 }
 
 func (c *Compiler) compileInfix(node *ast.Infix) error {
+	if value, ok := c.fold(node); ok {
+		c.emitConstant(value)
+		return nil
+	}
 	operator := node.Operator()
 	// Short-circuit operators
 	if operator == "&&" {
 		return c.compileAnd(node)
 	} else if operator == "||" {
 		return c.compileOr(node)
+	} else if operator == "??" {
+		return c.compileNilCoalesce(node)
 	}
 	// Non-short-circuit operators
 	if err := c.compile(node.Left()); err != nil {
@@ -1938,8 +3386,33 @@ func (c *Compiler) compileOr(node *ast.Infix) error {
 	return nil
 }
 
+func (c *Compiler) compileNilCoalesce(node *ast.Infix) error {
+	// The "??" operator only evaluates the RHS if the LHS is nil
+	if err := c.compile(node.Left()); err != nil {
+		return err
+	}
+	jumpPos := c.emit(op.JumpForwardIfNotNil, Placeholder)
+	c.emit(op.PopTop)
+	if err := c.compile(node.Right()); err != nil {
+		return err
+	}
+	delta, err := c.calculateDelta(jumpPos)
+	if err != nil {
+		return err
+	}
+	c.changeOperand(jumpPos, delta)
+	return nil
+}
+
 func (c *Compiler) compileGoStmt(node *ast.Go) error {
 	expr := node.Call()
+	call, ok := expr.(*ast.Call)
+	if objCall, isObjCall := expr.(*ast.ObjectCall); isObjCall {
+		call, ok = objCall.Call().(*ast.Call)
+	}
+	if ok && len(call.Keywords()) > 0 {
+		return c.formatError("keyword arguments are not supported in go statements", node.Token().StartPosition)
+	}
 	switch expr := expr.(type) {
 	case *ast.Call:
 		if err := c.compilePartial(expr); err != nil {
@@ -1982,13 +3455,7 @@ func (c *Compiler) compilePartial(call *ast.Call) error {
 	if err := c.compile(call.Function()); err != nil {
 		return err
 	}
-	for _, arg := range args {
-		if err := c.compile(arg); err != nil {
-			return err
-		}
-	}
-	c.emit(op.Partial, uint16(argc))
-	return nil
+	return c.compileCallArgs(call, true)
 }
 
 func (c *Compiler) compilePartialObjectCall(node *ast.ObjectCall) error {
@@ -2007,13 +3474,7 @@ func (c *Compiler) compilePartialObjectCall(node *ast.ObjectCall) error {
 	if argc > MaxArgs {
 		return fmt.Errorf("compile error: max args limit of %d exceeded (got %d)", MaxArgs, argc)
 	}
-	for _, arg := range args {
-		if err := c.compile(arg); err != nil {
-			return err
-		}
-	}
-	c.emit(op.Partial, uint16(len(args)))
-	return nil
+	return c.compileCallArgs(method, true)
 }
 
 func (c *Compiler) constant(obj any) uint16 {
@@ -2031,6 +3492,7 @@ func (c *Compiler) emit(opcode op.Code, operands ...uint16) int {
 	code := c.current
 	pos := len(code.instructions)
 	code.instructions = append(code.instructions, inst...)
+	code.addLocation(pos, c.position)
 	return pos
 }
 
@@ -2098,3 +3560,162 @@ func (c *Compiler) formatError(msg string, pos token.Position) error {
 	b.WriteString(fmt.Sprintf(" (%s)", lineCol))
 	return fmt.Errorf("%s", b.String())
 }
+
+// containsYield returns true if the given node contains a yield statement,
+// not counting those inside nested functions. This determines whether a
+// function is a generator, including when the yield statement is in code
+// that is never compiled because it can't be reached.
+func containsYield(node ast.Node) bool {
+	anyYield := func(nodes ...ast.Node) bool {
+		for _, n := range nodes {
+			if containsYield(n) {
+				return true
+			}
+		}
+		return false
+	}
+	switch node := node.(type) {
+	case nil:
+		return false
+	case *ast.Yield:
+		return true
+	case *ast.Func:
+		return false
+	case *ast.Block:
+		return node != nil && anyYield(node.Statements()...)
+	case *ast.String:
+		return anyYield(expressionNodes(node.TemplateExpressions())...)
+	case *ast.List:
+		return anyYield(expressionNodes(node.Items())...)
+	case *ast.Set:
+		return anyYield(expressionNodes(node.Items())...)
+	case *ast.Map:
+		for key, value := range node.Items() {
+			if anyYield(key, value) {
+				return true
+			}
+		}
+	case *ast.Prefix:
+		return anyYield(node.Right())
+	case *ast.Infix:
+		return anyYield(node.Left(), node.Right())
+	case *ast.In:
+		return anyYield(node.Left(), node.Right())
+	case *ast.NotIn:
+		return anyYield(node.Left(), node.Right())
+	case *ast.Ternary:
+		return anyYield(node.Condition(), node.IfTrue(), node.IfFalse())
+	case *ast.If:
+		return anyYield(node.Condition(), node.Consequence(), node.Alternative())
+	case *ast.Call:
+		if anyYield(node.Function()) || anyYield(node.Arguments()...) {
+			return true
+		}
+		for _, keyword := range node.Keywords() {
+			if anyYield(keyword.Value()) {
+				return true
+			}
+		}
+	case *ast.ObjectCall:
+		return anyYield(node.Object(), node.Call())
+	case *ast.GetAttr:
+		return anyYield(node.Object())
+	case *ast.Index:
+		return node != nil && anyYield(node.Left(), node.Index())
+	case *ast.Slice:
+		return anyYield(node.Left(), node.FromIndex(), node.ToIndex())
+	case *ast.Pipe:
+		return anyYield(expressionNodes(node.Expressions())...)
+	case *ast.Spread:
+		return anyYield(node.Value())
+	case *ast.Range:
+		return anyYield(node.Container())
+	case *ast.Receive:
+		return node != nil && anyYield(node.Channel())
+	case *ast.Switch:
+		if anyYield(node.Value()) {
+			return true
+		}
+		for _, choice := range node.Choices() {
+			if anyYield(expressionNodes(choice.Expressions())...) || anyYield(choice.Block()) {
+				return true
+			}
+		}
+	case *ast.Match:
+		if anyYield(node.Value()) {
+			return true
+		}
+		for _, matchCase := range node.Cases() {
+			if anyYield(matchCase.Guard(), matchCase.Block()) {
+				return true
+			}
+		}
+	case *ast.ListComprehension:
+		return anyYield(node.Element()) || clausesContainYield(node.Clauses())
+	case *ast.MapComprehension:
+		return anyYield(node.Key(), node.Value()) || clausesContainYield(node.Clauses())
+	case *ast.SetComprehension:
+		return anyYield(node.Element()) || clausesContainYield(node.Clauses())
+	case *ast.Var:
+		_, value := node.Value()
+		return anyYield(value)
+	case *ast.MultiVar:
+		_, value := node.Value()
+		return anyYield(value)
+	case *ast.Destructure:
+		_, value := node.Value()
+		return anyYield(value)
+	case *ast.Const:
+		_, value := node.Value()
+		return anyYield(value)
+	case *ast.Assign:
+		return anyYield(node.Value(), node.Index())
+	case *ast.SetAttr:
+		return anyYield(node.Object(), node.Value())
+	case *ast.Return:
+		return anyYield(node.Value())
+	case *ast.Control:
+		return anyYield(node.Value())
+	case *ast.For:
+		return anyYield(node.Init(), node.Condition(), node.Post(), node.Consequence())
+	case *ast.ForIn:
+		return anyYield(node.Iterable(), node.Consequence())
+	case *ast.Try:
+		return anyYield(node.Body(), node.CatchBlock(), node.FinallyBlock())
+	case *ast.Select:
+		for _, selectCase := range node.Cases() {
+			if anyYield(selectCase.Send(), selectCase.Receive(), selectCase.Block()) {
+				return true
+			}
+		}
+	case *ast.Send:
+		return node != nil && anyYield(node.Channel(), node.Value())
+	case *ast.Go:
+		return anyYield(node.Call())
+	case *ast.Defer:
+		return anyYield(node.Call())
+	}
+	return false
+}
+
+func clausesContainYield(clauses []*ast.ComprehensionClause) bool {
+	for _, clause := range clauses {
+		if containsYield(clause.Iterable()) {
+			return true
+		}
+		for _, cond := range clause.Conditions() {
+			if containsYield(cond) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func expressionNodes(exprs []ast.Expression) []ast.Node {
+	nodes := make([]ast.Node, len(exprs))
+	for i, expr := range exprs {
+		nodes[i] = expr
+	}
+	return nodes
+}
@@ -90,6 +90,56 @@ func TestCompileErrors(t *testing.T) {
 			input:  "\n defer func() {}()",
 			errMsg: "compile error: defer statement outside of a function\n\nlocation: t.risor:2:2 (line 2, column 2)",
 		},
+		{
+			name:   "yield outside of a function",
+			input:  "\n yield 1",
+			errMsg: "compile error: invalid yield statement outside of a function\n\nlocation: t.risor:2:2 (line 2, column 2)",
+		},
+		{
+			name:   "keyword arguments in go statement",
+			input:  "func f(a) {}\ngo f(a=1)",
+			errMsg: "compile error: keyword arguments are not supported in go statements\n\nlocation: t.risor:2:1 (line 2, column 1)",
+		},
+		{
+			name:   "spread outside of a call or literal",
+			input:  "x := [1]\ny := ...x",
+			errMsg: "compile error: spread syntax is only valid in calls, lists, and maps\n\nlocation: t.risor:2:6 (line 2, column 6)",
+		},
+		{
+			name:   "match pattern that is not valid",
+			input:  "match 1 {\ncase a + b: 1\n}",
+			errMsg: "compile error: invalid pattern: (a + b)\n\nlocation: t.risor:2:8 (line 2, column 8)",
+		},
+		{
+			name:   "match alternatives that bind variables",
+			input:  "match 1 {\ncase 1, [a]: 1\n}",
+			errMsg: "compile error: alternative patterns cannot bind variables\n\nlocation: t.risor:2:9 (line 2, column 9)",
+		},
+		{
+			name:   "match pattern that binds a name twice",
+			input:  "match 1 {\ncase [a, a]: 1\n}",
+			errMsg: "compile error: variable \"a\" bound more than once in pattern\n\nlocation: t.risor:2:10 (line 2, column 10)",
+		},
+		{
+			name:   "match rest pattern that is not last",
+			input:  "match 1 {\ncase [...a, b]: 1\n}",
+			errMsg: "compile error: a rest pattern must be the last item in a list pattern\n\nlocation: t.risor:2:7 (line 2, column 7)",
+		},
+		{
+			name:   "break with unknown label",
+			input:  "for {\n  break outer\n}",
+			errMsg: "compile error: invalid break statement: unknown loop label \"outer\"\n\nlocation: t.risor:2:9 (line 2, column 9)",
+		},
+		{
+			name:   "duplicate loop label",
+			input:  "outer: for {\n  outer: for { break outer }\n}",
+			errMsg: "compile error: duplicate loop label \"outer\"\n\nlocation: t.risor:2:3 (line 2, column 3)",
+		},
+		{
+			name:   "label of a loop in an enclosing function",
+			input:  "outer: for {\n  func() { continue outer }\n}",
+			errMsg: "compile error: invalid continue statement outside of a loop\n\nlocation: t.risor:2:12 (line 2, column 12)",
+		},
 	}
 	for _, tt := range testCase {
 		t.Run(tt.name, func(t *testing.T) {
@@ -261,7 +311,7 @@ func TestStringImport(t *testing.T) {
 			astNode, err := parser.Parse(context.Background(), tt.input)
 			require.NoError(t, err)
 
-			code, err := Compile(astNode)
+			code, err := Compile(astNode, WithoutOptimization())
 			require.NoError(t, err)
 
 			require.Equal(t, tt.expectedCode, code.instructions)
@@ -524,6 +574,154 @@ func TestForInCompilation(t *testing.T) {
 	require.Equal(t, expectedConstants, code.constants)
 }
 
+func TestListComprehensionCompilation(t *testing.T) {
+	input := "[x for x in [1] if x]"
+	expectedCode := []op.Code{
+		op.BuildList, 0, // Create the empty result list
+		op.LoadConst, 0, // 1
+		op.BuildList, 1, // Create array [1]
+		op.GetIter,        // Get iterator
+		op.ForIter, 15, 3, // Jump past the loop when done
+		op.StoreGlobal, 0, // Store to variable x
+		op.LoadGlobal, 0, // Load x for the condition
+		op.PopJumpForwardIfFalse, 6, // Skip the item if the condition is false
+		op.LoadGlobal, 0, // Load x as the element
+		op.ListAppend, 1, // Append to the list beneath the iterator
+		op.JumpBackward, 13, // Jump back to ForIter
+	}
+	expectedConstants := []interface{}{int64(1)}
+
+	program, err := parser.Parse(context.Background(), input)
+	require.NoError(t, err)
+
+	code, err := Compile(program)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedCode, code.instructions)
+	require.Equal(t, expectedConstants, code.constants)
+}
+
+func TestOptionalChainCompilation(t *testing.T) {
+	input := "a := nil; a?.b.c ?? 1"
+	expectedCode := []op.Code{
+		op.Nil,
+		op.StoreGlobal, 0,
+		op.LoadGlobal, 0,
+		op.JumpForwardIfNil, 6, // Skip the rest of the chain if a is nil
+		op.LoadAttrOrNil, 0, // a?.b
+		op.LoadAttr, 1, // .c
+		op.JumpForwardIfNotNil, 5, // Skip the default if the chain isn't nil
+		op.PopTop,
+		op.LoadConst, 0, // 1
+	}
+	program, err := parser.Parse(context.Background(), input)
+	require.NoError(t, err)
+
+	code, err := Compile(program)
+	require.NoError(t, err)
+
+	require.Equal(t, expectedCode, code.instructions)
+	require.Equal(t, []string{"b", "c"}, code.names)
+}
+
+func TestGeneratorCompilation(t *testing.T) {
+	input := "func gen() { yield 1 }; func f() { func() { yield 2 } }"
+	program, err := parser.Parse(context.Background(), input)
+	require.NoError(t, err)
+
+	code, err := Compile(program)
+	require.NoError(t, err)
+	require.False(t, code.IsGenerator())
+
+	gen := code.constants[0].(*Function).Code()
+	require.True(t, gen.IsGenerator())
+	require.Equal(t, []op.Code{
+		op.LoadConst, 0,
+		op.Yield,
+		op.Nil,
+		op.ReturnValue,
+	}, gen.instructions)
+
+	// Only the function containing the yield statement is a generator
+	f := code.constants[1].(*Function).Code()
+	require.False(t, f.IsGenerator())
+	inner := f.constants[0].(*Function).Code()
+	require.True(t, inner.IsGenerator())
+}
+
+func TestGeneratorUnreachableYield(t *testing.T) {
+	// A function is a generator even if its yield statements are never
+	// compiled because they can't be reached
+	for _, input := range []string{
+		`func g() { if false { yield 1 } }`,
+		`func g() { return 5; yield 1 }`,
+		`func g() { x := true ? 1 : func() { yield 2 }; for { break; yield x } }`,
+		`func g() { return 1 }; func h() { for { break; [y for y in [if true { 1 } else { yield 2 }]] } }`,
+	} {
+		program, err := parser.Parse(context.Background(), input)
+		require.NoError(t, err)
+		code, err := Compile(program)
+		require.NoError(t, err)
+		fns := []*Code{}
+		for _, c := range code.constants {
+			if fn, ok := c.(*Function); ok {
+				fns = append(fns, fn.Code())
+			}
+		}
+		require.True(t, fns[len(fns)-1].IsGenerator(), input)
+	}
+	program, err := parser.Parse(context.Background(), `func g() { if false { func() { yield 1 } } }`)
+	require.NoError(t, err)
+	code, err := Compile(program)
+	require.NoError(t, err)
+	require.False(t, code.constants[0].(*Function).Code().IsGenerator())
+}
+
+func TestTailCallCompilation(t *testing.T) {
+	tests := []struct {
+		input     string
+		tailCalls int
+		calls     int
+	}{
+		{`func f(x) { return g(x) }`, 1, 0},
+		{`func f(x) { g(x) }`, 1, 0},
+		{`func f(x) { return x | g }`, 1, 0},
+		{`func f(x) { return x | g(1) | g }`, 1, 1},
+		{`func f(x) { return g(g(x)) }`, 1, 1},
+		{`func f(x) { return g(x) + 1 }`, 0, 1},
+		{`func f(x) { return g(func() { return g(x) }) }`, 1, 0},
+		{`func f(x) { return g(...x) }`, 0, 0},
+		{`func f(x) { return g(x, y=1) }`, 0, 0},
+		{`func f(x) { try { return g(x) } catch { return nil } }`, 0, 1},
+		{`func f(x) { return x ? g(x) : g(g(x)) }`, 2, 1},
+		{`func f(x) { return x ? 1 : x | g }`, 1, 0},
+		{`func f(x) { return g(x ? g(x) : 2) }`, 1, 1},
+		{`func f(x) { if x { g(x) } else { y := 1; g(y) } }`, 2, 0},
+		{`func f(x) { return if x { g(x); g(x) } else if g(x) { 1 } else { g(x) + 1 } }`, 1, 3},
+		{`func f(x) { if x { g(x) } }`, 1, 0},
+		{`func f(x) { try { return x ? g(x) : g(1) } catch { return nil } }`, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := parser.Parse(context.Background(), tt.input)
+			require.NoError(t, err)
+			code, err := Compile(program, WithGlobalNames([]string{"g"}))
+			require.NoError(t, err)
+			var tailCalls, calls int
+			for _, instr := range NewInstructionIter(code.constants[0].(*Function).Code()).All() {
+				switch instr[0] {
+				case op.TailCall:
+					tailCalls++
+				case op.Call:
+					calls++
+				}
+			}
+			require.Equal(t, tt.tailCalls, tailCalls)
+			require.Equal(t, tt.calls, calls)
+		})
+	}
+}
+
 func TestForInCompilationErrors(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -640,3 +838,39 @@ func TestForInNestedLoops(t *testing.T) {
 	require.Equal(t, 2, getIterCount, "Expected 2 GetIter instructions for nested loops")
 	require.Equal(t, 2, forIterCount, "Expected 2 ForIter instructions for nested loops")
 }
+
+func TestSourceLocations(t *testing.T) {
+	input := `x := 1
+func add(a, b) {
+	return a + b
+}
+add(x, 2)`
+	program, err := parser.Parse(context.Background(), input)
+	require.Nil(t, err)
+
+	code, err := Compile(program, WithFilename("test.risor"))
+	require.Nil(t, err)
+	require.Greater(t, code.LocationCount(), 0)
+
+	// The first instruction loads the constant on line 1
+	pos, ok := code.LocationAt(0)
+	require.True(t, ok)
+	require.Equal(t, 1, pos.LineNumber())
+	require.Equal(t, "test.risor", pos.File)
+
+	// The last instruction belongs to the call on line 5
+	pos, ok = code.LocationAt(code.InstructionCount() - 1)
+	require.True(t, ok)
+	require.Equal(t, 5, pos.LineNumber())
+
+	// The function body has its own location table and inherits the filename
+	fn, ok := code.Constant(1).(*Function)
+	require.True(t, ok)
+	pos, ok = fn.Code().LocationAt(0)
+	require.True(t, ok)
+	require.Equal(t, 3, pos.LineNumber())
+	require.Equal(t, "test.risor", fn.Code().Filename())
+
+	_, ok = (&Code{}).LocationAt(0)
+	require.False(t, ok)
+}
@@ -11,6 +11,7 @@ type Function struct {
 	name       string
 	parameters []string
 	defaults   []any
+	restParam  string
 	code       *Code
 }
 
@@ -42,6 +43,12 @@ func (f *Function) Default(index int) any {
 	return f.defaults[index]
 }
 
+// RestParameter returns the name of the parameter that collects any extra
+// arguments into a list, or an empty string if the function is not variadic.
+func (f *Function) RestParameter() string {
+	return f.restParam
+}
+
 func (f *Function) RequiredArgsCount() int {
 	return len(f.parameters) - len(f.defaults)
 }
@@ -59,6 +66,9 @@ func (f *Function) String() string {
 		}
 		parameters = append(parameters, name)
 	}
+	if f.restParam != "" {
+		parameters = append(parameters, "..."+f.restParam)
+	}
 	out.WriteString("func")
 	if f.name != "" {
 		out.WriteString(" " + f.name)
@@ -81,11 +91,12 @@ func (f *Function) String() string {
 }
 
 type FunctionOpts struct {
-	ID         string
-	Name       string
-	Parameters []string
-	Defaults   []any
-	Code       *Code
+	ID            string
+	Name          string
+	Parameters    []string
+	Defaults      []any
+	RestParameter string
+	Code          *Code
 }
 
 func NewFunction(opts FunctionOpts) *Function {
@@ -94,6 +105,7 @@ func NewFunction(opts FunctionOpts) *Function {
 		name:       opts.Name,
 		parameters: opts.Parameters,
 		defaults:   opts.Defaults,
+		restParam:  opts.RestParameter,
 		code:       opts.Code,
 	}
 }
@@ -0,0 +1,370 @@
+package compiler
+
+import (
+	"math"
+	"strings"
+
+	"github.com/risor-io/risor/ast"
+	"github.com/risor-io/risor/op"
+)
+
+// The optimizations in this file are enabled by default and may be disabled
+// using the WithoutOptimization option. They must never change the behavior
+// of a program, so folding is limited to operations on ints, floats, strings
+// and bools whose results are known exactly at compile time. Anything else,
+// including operations that would fail at runtime, is left to the VM.
+
+// fold attempts to evaluate the given expression at compile time. It returns
+// the resulting int64, float64, string or bool value and true on success.
+func (c *Compiler) fold(node ast.Expression) (any, bool) {
+	if !c.optimize {
+		return nil, false
+	}
+	switch node := node.(type) {
+	case *ast.Int:
+		return node.Value(), true
+	case *ast.Float:
+		return node.Value(), true
+	case *ast.Bool:
+		return node.Value(), true
+	case *ast.String:
+		if node.Template() != nil {
+			return nil, false
+		}
+		return node.Value(), true
+	case *ast.Ident:
+		return c.constantValue(node.Literal())
+	case *ast.Prefix:
+		right, ok := c.fold(node.Right())
+		if !ok {
+			return nil, false
+		}
+		return foldPrefix(node.Operator(), right)
+	case *ast.Infix:
+		left, ok := c.fold(node.Left())
+		if !ok {
+			return nil, false
+		}
+		right, ok := c.fold(node.Right())
+		if !ok {
+			return nil, false
+		}
+		return foldInfix(node.Operator(), left, right)
+	}
+	return nil, false
+}
+
+// constantValue returns the value of the constant with the given name, if it
+// was declared with a value that could be folded. Unlike Resolve, this has no
+// side effects, so inlining a constant doesn't capture it in a closure.
+func (c *Compiler) constantValue(name string) (any, bool) {
+	for table := c.current.symbols; table != nil; table = table.parent {
+		if sym, ok := table.symbolsByName[name]; ok {
+			if sym.isConstant && sym.value != nil {
+				return sym.value, true
+			}
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
+// emitConstant emits the instruction that loads a folded value.
+func (c *Compiler) emitConstant(value any) {
+	switch value {
+	case true:
+		c.emit(op.True)
+	case false:
+		c.emit(op.False)
+	default:
+		c.emit(op.LoadConst, c.constant(value))
+	}
+}
+
+func foldPrefix(operator string, value any) (any, bool) {
+	switch operator {
+	case "-":
+		switch value := value.(type) {
+		case int64:
+			return -value, true
+		case float64:
+			return -value, true
+		}
+	case "!":
+		if value, ok := value.(bool); ok {
+			return !value, true
+		}
+	}
+	return nil, false
+}
+
+func foldInfix(operator string, left, right any) (any, bool) {
+	switch left := left.(type) {
+	case int64:
+		if right, ok := right.(int64); ok {
+			return foldInt(operator, left, right)
+		}
+	case float64:
+		if right, ok := right.(float64); ok {
+			return foldFloat(operator, left, right)
+		}
+	case string:
+		if right, ok := right.(string); ok {
+			return foldString(operator, left, right)
+		}
+	case bool:
+		if right, ok := right.(bool); ok {
+			switch operator {
+			case "==":
+				return left == right, true
+			case "!=":
+				return left != right, true
+			case "&&":
+				return left && right, true
+			case "||":
+				return left || right, true
+			}
+		}
+	}
+	return nil, false
+}
+
+func foldInt(operator string, left, right int64) (any, bool) {
+	switch operator {
+	case "+":
+		return left + right, true
+	case "-":
+		return left - right, true
+	case "*":
+		return left * right, true
+	case "/":
+		if right == 0 {
+			return nil, false
+		}
+		return left / right, true
+	case "%":
+		if right == 0 {
+			return nil, false
+		}
+		return left % right, true
+	case "**":
+		return int64(math.Pow(float64(left), float64(right))), true
+	case "<<":
+		return left << uint(right), true
+	case ">>":
+		return left >> uint(right), true
+	case "&":
+		return left & right, true
+	}
+	return compareOrdered(operator, left, right)
+}
+
+func foldFloat(operator string, left, right float64) (any, bool) {
+	var result float64
+	switch operator {
+	case "+":
+		result = left + right
+	case "-":
+		result = left - right
+	case "*":
+		result = left * right
+	case "/":
+		result = left / right
+	case "**":
+		result = math.Pow(left, right)
+	default:
+		return compareOrdered(operator, left, right)
+	}
+	// Infinities and NaN can't be represented as constants
+	if math.IsInf(result, 0) || math.IsNaN(result) {
+		return nil, false
+	}
+	return result, true
+}
+
+func foldString(operator string, left, right string) (any, bool) {
+	if operator == "+" {
+		return left + right, true
+	}
+	return compareOrdered(operator, strings.Compare(left, right), 0)
+}
+
+func compareOrdered[T int | int64 | float64](operator string, left, right T) (any, bool) {
+	switch operator {
+	case "==":
+		return left == right, true
+	case "!=":
+		return left != right, true
+	case "<":
+		return left < right, true
+	case "<=":
+		return left <= right, true
+	case ">":
+		return left > right, true
+	case ">=":
+		return left >= right, true
+	}
+	return nil, false
+}
+
+// reachable returns the statements of a block up to and including the first
+// statement that unconditionally transfers control out of it. The remaining
+// statements can never run.
+func (c *Compiler) reachable(statements []ast.Node) []ast.Node {
+	if !c.optimize {
+		return statements
+	}
+	for i, stmt := range statements {
+		switch stmt.(type) {
+		case *ast.Return, *ast.Control:
+			return statements[:i+1]
+		}
+	}
+	return statements
+}
+
+// compileUnreachable compiles code that can never run and then discards the
+// instructions, constants and names it produced. The code is still compiled so that it reports the
+// same errors, such as undefined variables, as it would without optimization.
+func (c *Compiler) compileUnreachable(node ast.Node) error {
+	code := c.current
+	instructionCount := len(code.instructions)
+	locationCount := len(code.locations)
+	constantCount := len(code.constants)
+	nameCount := len(code.names)
+	childCount := len(code.children)
+	// Break and continue statements record jumps to be patched by their loop
+	type loopJumps struct{ breaks, continues int }
+	jumps := make([]loopJumps, len(code.loops))
+	for i, loop := range code.loops {
+		jumps[i] = loopJumps{len(loop.breakPos), len(loop.continuePos)}
+	}
+	err := c.compile(node)
+	code.instructions = truncate(code.instructions, instructionCount)
+	code.locations = truncate(code.locations, locationCount)
+	code.constants = truncate(code.constants, constantCount)
+	code.names = truncate(code.names, nameCount)
+	code.children = truncate(code.children, childCount)
+	for i, loop := range code.loops {
+		loop.breakPos = loop.breakPos[:jumps[i].breaks]
+		loop.continuePos = loop.continuePos[:jumps[i].continues]
+	}
+	return err
+}
+
+// truncate returns the first n elements of the slice, or nil if n is zero.
+func truncate[T any](s []T, n int) []T {
+	if n == 0 {
+		return nil
+	}
+	return s[:n]
+}
+
+// threadJumps retargets jumps whose destination is an unconditional jump, so
+// that they go directly to the final destination. Instructions aren't moved,
+// so the offsets in the location and exception tables remain valid.
+func threadJumps(code *Code) {
+	instructions := code.instructions
+	for pos := 0; pos < len(instructions); {
+		opcode := instructions[pos]
+		switch opcode {
+		case op.JumpForward, op.JumpBackward, op.PopJumpForwardIfFalse,
+			op.PopJumpForwardIfTrue, op.JumpForwardIfNil, op.JumpForwardIfNotNil:
+			target := jumpTarget(instructions, pos)
+			// Limit the number of hops in case of a cycle of jumps
+			for hops := 0; hops < 16 && target < len(instructions); hops++ {
+				next := instructions[target]
+				if next != op.JumpForward && next != op.JumpBackward {
+					break
+				}
+				target = jumpTarget(instructions, target)
+			}
+			switch {
+			case target-pos > math.MaxUint16 || pos-target > math.MaxUint16:
+				// The destination is too far away to be encoded
+			case target > pos:
+				if opcode == op.JumpBackward {
+					opcode = op.JumpForward
+				}
+				instructions[pos] = opcode
+				instructions[pos+1] = op.Code(target - pos)
+			case target < pos && (opcode == op.JumpForward || opcode == op.JumpBackward):
+				// Only unconditional jumps can be turned into backward jumps
+				instructions[pos] = op.JumpBackward
+				instructions[pos+1] = op.Code(pos - target)
+			}
+		}
+		pos += 1 + op.GetInfo(opcode).OperandCount
+	}
+	for _, child := range code.children {
+		threadJumps(child)
+	}
+}
+
+// jumpTarget returns the offset of the instruction that the jump at the given
+// offset transfers control to.
+func jumpTarget(instructions []op.Code, pos int) int {
+	delta := int(instructions[pos+1])
+	if instructions[pos] == op.JumpBackward {
+		return pos - delta
+	}
+	return pos + delta
+}
+
+// fuseInstructions replaces the opcode of the first instruction in common
+// sequences of instructions with a superinstruction that executes the whole
+// sequence in one step. As with threadJumps, instructions aren't moved. The
+// rest of each sequence is left in place to supply operands to the
+// superinstruction and to run as usual if a jump lands in the middle of it.
+func fuseInstructions(code *Code) {
+	instructions := code.instructions
+	for pos := 0; pos < len(instructions); {
+		opcode := instructions[pos]
+		if fused, ok := superinstruction(code, pos); ok {
+			instructions[pos] = fused
+		}
+		pos += 1 + op.GetInfo(opcode).OperandCount
+	}
+	for _, child := range code.children {
+		fuseInstructions(child)
+	}
+}
+
+// superinstruction returns the superinstruction that can replace the sequence
+// of instructions starting at the given offset, if there is one.
+func superinstruction(code *Code, pos int) (op.Code, bool) {
+	instructions := code.instructions
+	// Checks the opcode of the instruction at the given distance from pos.
+	// Every instruction in a fused sequence has exactly one operand.
+	at := func(distance int, opcode op.Code) bool {
+		return pos+distance < len(instructions) && instructions[pos+distance] == opcode
+	}
+	switch instructions[pos] {
+	case op.LoadFast:
+		switch {
+		case at(2, op.LoadConst) && at(4, op.BinaryOp) && at(6, op.StoreFast):
+			// Only x = x + n, as compiled for x++ and x += n
+			_, isInt := code.constants[instructions[pos+3]].(int64)
+			if isInt && op.BinaryOpType(instructions[pos+5]) == op.Add &&
+				instructions[pos+1] == instructions[pos+7] {
+				return op.IncrementFast, true
+			}
+			return op.BinaryOpFastConst, true
+		case at(2, op.LoadConst) && at(4, op.CompareOp) && at(6, op.PopJumpForwardIfFalse):
+			return op.CompareFastConstJump, true
+		case at(2, op.LoadConst) && at(4, op.BinaryOp):
+			return op.BinaryOpFastConst, true
+		case at(2, op.LoadFast):
+			// Leave the second LOAD_FAST alone if it starts a longer sequence
+			if next, ok := superinstruction(code, pos+2); ok && next != op.LoadFastLoadFast {
+				return op.Invalid, false
+			}
+			return op.LoadFastLoadFast, true
+		}
+	case op.CompareOp:
+		if at(2, op.PopJumpForwardIfFalse) {
+			return op.CompareJump, true
+		}
+	}
+	return op.Invalid, false
+}
@@ -0,0 +1,228 @@
+package compiler
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/op"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConstantFolding(t *testing.T) {
+	tests := []struct {
+		input             string
+		expectedCode      []op.Code
+		expectedConstants []any
+	}{
+		{
+			input:             `60 * 60`,
+			expectedCode:      []op.Code{op.LoadConst, 0},
+			expectedConstants: []any{int64(3600)},
+		},
+		{
+			input:             `-(2 + 3) * 4 - 1`,
+			expectedCode:      []op.Code{op.LoadConst, 0},
+			expectedConstants: []any{int64(-21)},
+		},
+		{
+			input:             `1.5 * 2.0`,
+			expectedCode:      []op.Code{op.LoadConst, 0},
+			expectedConstants: []any{3.0},
+		},
+		{
+			input:             `"foo" + "bar"`,
+			expectedCode:      []op.Code{op.LoadConst, 0},
+			expectedConstants: []any{"foobar"},
+		},
+		{
+			input:        `"a" < "b" && !(3 > 4)`,
+			expectedCode: []op.Code{op.True},
+		},
+		{
+			// Division by zero is left to fail at runtime
+			input:             `1 / 0`,
+			expectedCode:      []op.Code{op.LoadConst, 0, op.LoadConst, 1, op.BinaryOp, op.Code(op.Divide)},
+			expectedConstants: []any{int64(1), int64(0)},
+		},
+		{
+			// Mixed types are left to the VM
+			input:             `1 + 2.5`,
+			expectedCode:      []op.Code{op.LoadConst, 0, op.LoadConst, 1, op.BinaryOp, op.Code(op.Add)},
+			expectedConstants: []any{int64(1), 2.5},
+		},
+		{
+			input: `const HOUR = 60 * 60; HOUR * 2`,
+			expectedCode: []op.Code{
+				op.LoadConst, 0,
+				op.StoreGlobal, 0,
+				op.LoadConst, 1,
+			},
+			expectedConstants: []any{int64(3600), int64(7200)},
+		},
+		{
+			input: `if false { 1 } else { 2 }`,
+			expectedCode: []op.Code{
+				op.LoadConst, 0,
+			},
+			expectedConstants: []any{int64(2)},
+		},
+		{
+			input: `const DEBUG = false; if DEBUG { 1 }`,
+			expectedCode: []op.Code{
+				op.False,
+				op.StoreGlobal, 0,
+				op.Nil,
+			},
+		},
+		{
+			input: `true ? "yes" : "no"`,
+			expectedCode: []op.Code{
+				op.LoadConst, 0,
+			},
+			expectedConstants: []any{"yes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := parser.Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			code, err := Compile(program)
+			require.Nil(t, err)
+			require.Equal(t, tt.expectedCode, code.instructions)
+			require.Equal(t, tt.expectedConstants, code.constants)
+		})
+	}
+}
+
+func TestConstantInlining(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `
+	const LIMIT = 10
+	func f(x) { return x < LIMIT }
+	`)
+	require.Nil(t, err)
+	code, err := Compile(program)
+	require.Nil(t, err)
+	fn, ok := code.constants[1].(*Function)
+	require.True(t, ok)
+	// The constant is loaded directly rather than captured as a free variable
+	require.Equal(t, uint16(0), fn.Code().symbols.FreeCount())
+	require.Equal(t, []any{int64(10)}, fn.Code().constants)
+}
+
+func TestDeadCodeElimination(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `
+	func f(x) {
+		for {
+			if x {
+				return 1
+				print("unreachable")
+			}
+			break
+			print("unreachable")
+		}
+	}
+	`)
+	require.Nil(t, err)
+	code, err := Compile(program, WithGlobalNames([]string{"print"}))
+	require.Nil(t, err)
+	fn, ok := code.constants[0].(*Function)
+	require.True(t, ok)
+	for _, instr := range NewInstructionIter(fn.Code()).All() {
+		require.NotEqual(t, op.Call, instr[0])
+	}
+}
+
+func TestJumpThreading(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `
+	x := 1
+	y := 2
+	if x {
+		if y { 1 } else { 2 }
+	} else {
+		3
+	}
+	`)
+	require.Nil(t, err)
+	// Counts the jumps that land on an unconditional jump
+	countChainedJumps := func(code *Code) int {
+		var count, pos int
+		for _, instr := range NewInstructionIter(code).All() {
+			switch instr[0] {
+			case op.JumpForward, op.PopJumpForwardIfFalse:
+				target := pos + int(instr[1])
+				if target < len(code.instructions) && code.instructions[target] == op.JumpForward {
+					count++
+				}
+			}
+			pos += len(instr)
+		}
+		return count
+	}
+	code, err := Compile(program, WithoutOptimization())
+	require.Nil(t, err)
+	require.Equal(t, 1, countChainedJumps(code))
+	code, err = Compile(program)
+	require.Nil(t, err)
+	require.Equal(t, 0, countChainedJumps(code))
+}
+
+func TestWithoutOptimization(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `const X = 2 * 3; if false { X }`)
+	require.Nil(t, err)
+	code, err := Compile(program, WithoutOptimization())
+	require.Nil(t, err)
+	require.Equal(t, []op.Code{
+		op.LoadConst, 0,
+		op.LoadConst, 1,
+		op.BinaryOp, op.Code(op.Multiply),
+		op.StoreGlobal, 0,
+		op.False,
+		op.PopJumpForwardIfFalse, 6,
+		op.LoadGlobal, 0,
+		op.JumpForward, 3,
+		op.Nil,
+	}, code.instructions)
+}
+
+func TestSuperinstructions(t *testing.T) {
+	program, err := parser.Parse(context.Background(), `
+	func f(n) {
+		total := 0
+		for i := 0; i < n; i++ {
+			total = total + i
+		}
+		return total - 1
+	}
+	`)
+	require.Nil(t, err)
+	code, err := Compile(program)
+	require.Nil(t, err)
+	fn, ok := code.constants[0].(*Function)
+	require.True(t, ok)
+	var opcodes []op.Code
+	for _, instr := range NewInstructionIter(fn.Code()).All() {
+		opcodes = append(opcodes, instr[0])
+	}
+	// The instructions of each fused sequence are left in place
+	require.Equal(t, []op.Code{
+		op.LoadConst, op.StoreFast, // total := 0
+		op.LoadConst, op.StoreFast, // i := 0
+		op.LoadFastLoadFast, op.LoadFast, op.CompareJump, op.PopJumpForwardIfFalse, // i < n
+		op.LoadFastLoadFast, op.LoadFast, op.BinaryOp, op.StoreFast, // total = total + i
+		op.Nil, op.PopTop,
+		op.IncrementFast, op.LoadConst, op.BinaryOp, op.StoreFast, // i++
+		op.JumpBackward,
+		op.BinaryOpFastConst, op.LoadConst, op.BinaryOp, // total - 1
+		op.ReturnValue,
+	}, opcodes)
+
+	// Superinstructions are only used when optimizations are enabled
+	code, err = Compile(program, WithoutOptimization())
+	require.Nil(t, err)
+	fn, ok = code.constants[0].(*Function)
+	require.True(t, ok)
+	for _, instr := range NewInstructionIter(fn.Code()).All() {
+		require.Less(t, instr[0], op.LoadFastLoadFast)
+	}
+}
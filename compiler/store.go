@@ -7,8 +7,16 @@ import (
 	"github.com/risor-io/risor/op"
 )
 
-// MarshalCode converts a Code object into a JSON representation.
+// MarshalCode converts a Code object into the binary bytecode format. Use
+// MarshalCodeJSON for a representation that is easier to inspect.
 func MarshalCode(code *Code) ([]byte, error) {
+	return marshalBytecode(code)
+}
+
+// MarshalCodeJSON converts a Code object into a JSON representation. This is
+// intended for debugging, since it's larger and slower to load than the binary
+// bytecode format.
+func MarshalCodeJSON(code *Code) ([]byte, error) {
 	cdef, err := stateFromCode(code)
 	if err != nil {
 		return nil, err
@@ -16,8 +24,12 @@ func MarshalCode(code *Code) ([]byte, error) {
 	return json.Marshal(cdef)
 }
 
-// UnmarshalCode converts a JSON representation of a Code object into a Code.
+// UnmarshalCode converts data in the binary bytecode format, or a JSON
+// representation produced by MarshalCodeJSON, into a Code object.
 func UnmarshalCode(data []byte) (*Code, error) {
+	if IsBytecode(data) {
+		return unmarshalBytecode(data)
+	}
 	var def state
 	if err := json.Unmarshal(data, &def); err != nil {
 		return nil, err
@@ -27,10 +39,11 @@ func UnmarshalCode(data []byte) (*Code, error) {
 
 // Used to marshal a Function.
 type functionDef struct {
-	ID         string            `json:"id"`
-	Name       string            `json:"name"`
-	Parameters []string          `json:"parameters"`
-	Defaults   []json.RawMessage `json:"defaults"`
+	ID            string            `json:"id"`
+	Name          string            `json:"name"`
+	Parameters    []string          `json:"parameters"`
+	Defaults      []json.RawMessage `json:"defaults"`
+	RestParameter string            `json:"rest_parameter,omitempty"`
 }
 
 type constantDef struct {
@@ -88,6 +101,13 @@ type symbolTableDef struct {
 	Children      []*symbolTableDef     `json:"children,omitempty"`
 }
 
+// Used to marshal a Location.
+type locationDef struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
 // Flat form of a Code object used in marshaling.
 type codeDef struct {
 	ID            string            `json:"id,omitempty"`
@@ -95,10 +115,17 @@ type codeDef struct {
 	ParentID      string            `json:"parent_id,omitempty"`
 	SymbolTableID string            `json:"symbol_table_id"`
 	FunctionID    string            `json:"function_id,omitempty"`
+	IsGenerator   bool              `json:"is_generator,omitempty"`
 	Instructions  []op.Code         `json:"instructions,omitempty"`
 	Constants     []json.RawMessage `json:"constants,omitempty"`
 	Names         []string          `json:"names,omitempty"`
 	Source        string            `json:"source,omitempty"`
+	Filename      string            `json:"filename,omitempty"`
+	Locations     []*locationDef    `json:"locations,omitempty"`
+
+	// Decoded constants, which take the place of Constants when the code
+	// was read from the binary bytecode format
+	constants []any
 }
 
 // A representation of a Code object that can be marshalled more easily.
@@ -125,9 +152,11 @@ func codeFromState(state *state) (*Code, error) {
 		if !found && c.ParentID != "" {
 			return nil, fmt.Errorf("parent code not found: %s", c.ParentID)
 		}
-		constants, err := unmarshalConstants(c.Constants)
-		if err != nil {
-			return nil, err
+		constants := c.constants
+		if constants == nil {
+			if constants, err = unmarshalConstants(c.Constants); err != nil {
+				return nil, err
+			}
 		}
 		code := &Code{
 			id:           c.ID,
@@ -135,11 +164,14 @@ func codeFromState(state *state) (*Code, error) {
 			name:         c.Name,
 			isNamed:      c.Name != "" && c.Name != "__main__",
 			functionID:   c.FunctionID,
+			isGenerator:  c.IsGenerator,
 			symbols:      codeSymbols,
 			instructions: CopyInstructions(c.Instructions),
 			constants:    constants,
 			names:        copyStrings(c.Names),
 			source:       c.Source,
+			filename:     c.Filename,
+			locations:    locationsFromDefinition(c.Locations),
 		}
 		codesByID[code.id] = code
 		codes = append(codes, code)
@@ -206,10 +238,11 @@ func unmarshalConstant(constant json.RawMessage) (any, error) {
 			return nil, err
 		}
 		f := NewFunction(FunctionOpts{
-			ID:         def.Value.ID,
-			Name:       def.Value.Name,
-			Parameters: def.Value.Parameters,
-			Defaults:   defaults,
+			ID:            def.Value.ID,
+			Name:          def.Value.Name,
+			Parameters:    def.Value.Parameters,
+			Defaults:      defaults,
+			RestParameter: def.Value.RestParameter,
 		})
 		return f, nil
 	default:
@@ -339,11 +372,14 @@ func stateFromCode(code *Code) (*state, error) {
 			ID:            code.id,
 			Constants:     constants,
 			FunctionID:    code.functionID,
+			IsGenerator:   code.isGenerator,
 			SymbolTableID: code.symbols.ID(),
 			Instructions:  CopyInstructions(code.instructions),
 			Name:          code.name,
 			Names:         copyStrings(code.names),
 			Source:        code.source,
+			Filename:      code.filename,
+			Locations:     definitionFromLocations(code.locations),
 		}
 		if code.parent != nil {
 			cdef.ParentID = code.parent.id
@@ -395,10 +431,11 @@ func definitionFromFunction(function *Function) (*functionDef, error) {
 		return nil, err
 	}
 	return &functionDef{
-		ID:         function.id,
-		Name:       function.name,
-		Parameters: copyStrings(function.parameters),
-		Defaults:   defaults,
+		ID:            function.id,
+		Name:          function.name,
+		Parameters:    copyStrings(function.parameters),
+		Defaults:      defaults,
+		RestParameter: function.restParam,
 	}, nil
 }
 
@@ -411,6 +448,36 @@ func definitionFromResolution(resolution *Resolution) *resolutionDef {
 	}
 }
 
+func definitionFromLocations(locations []Location) []*locationDef {
+	if locations == nil {
+		return nil
+	}
+	defs := make([]*locationDef, 0, len(locations))
+	for _, loc := range locations {
+		defs = append(defs, &locationDef{
+			Offset: loc.Offset,
+			Line:   loc.Line,
+			Column: loc.Column,
+		})
+	}
+	return defs
+}
+
+func locationsFromDefinition(defs []*locationDef) []Location {
+	if defs == nil {
+		return nil
+	}
+	locations := make([]Location, 0, len(defs))
+	for _, def := range defs {
+		locations = append(locations, Location{
+			Offset: def.Offset,
+			Line:   def.Line,
+			Column: def.Column,
+		})
+	}
+	return locations
+}
+
 func copyStrings(src []string) []string {
 	if src == nil {
 		return nil
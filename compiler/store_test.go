@@ -2,7 +2,6 @@ package compiler
 
 import (
 	"context"
-	"fmt"
 	"testing"
 
 	"github.com/risor-io/risor/op"
@@ -10,13 +9,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func compileSource(source string) (*Code, error) {
+func compileSource(source string, options ...Option) (*Code, error) {
 	program, err := parser.Parse(context.Background(), source)
 	if err != nil {
 		return nil, err
 	}
 	opt := WithGlobalNames([]string{"len", "list", "string", "print"})
-	code, err := Compile(program, opt)
+	code, err := Compile(program, append([]Option{opt}, options...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -72,7 +71,6 @@ func TestMarshalCode3(t *testing.T) {
 	require.Nil(t, err)
 	data, err := MarshalCode(codeA)
 	require.Nil(t, err)
-	fmt.Println(string(data))
 	codeB, err := UnmarshalCode(data)
 	require.Nil(t, err)
 	require.Equal(t, codeA, codeB)
@@ -112,7 +110,6 @@ func TestMarshalCode4(t *testing.T) {
 	require.Nil(t, err)
 	data, err := MarshalCode(codeA)
 	require.Nil(t, err)
-	fmt.Println(string(data))
 	codeB, err := UnmarshalCode(data)
 	require.Nil(t, err)
 	// Loops state should not factor in
@@ -158,7 +155,7 @@ func TestCodeConstants(t *testing.T) {
 }
 
 func TestCompiledInstructions(t *testing.T) {
-	code, err := compileSource(`1 + 2`)
+	code, err := compileSource(`1 + 2`, WithoutOptimization())
 	require.Nil(t, err)
 	instrs := NewInstructionIter(code).All()
 	require.Equal(t, [][]op.Code{
@@ -180,3 +177,20 @@ func TestCompiledInstructions(t *testing.T) {
 		{op.BinaryOp, op.Code(op.Add)},
 	}, instrs)
 }
+
+func TestMarshalGeneratorCode(t *testing.T) {
+	codeA, err := compileSource(`
+	func gen(n) {
+		yield n
+		yield n + 1
+	}
+	list(gen(3))
+	`)
+	require.Nil(t, err)
+	data, err := MarshalCode(codeA)
+	require.Nil(t, err)
+	codeB, err := UnmarshalCode(data)
+	require.Nil(t, err)
+	require.Equal(t, codeA, codeB)
+	require.True(t, codeB.children[0].IsGenerator())
+}
@@ -48,7 +48,8 @@ func Disassemble(code *compiler.Code) ([]Instruction, error) {
 		var constant interface{}
 		var annotation string
 		switch info.Name {
-		case "LOAD_FAST", "STORE_FAST":
+		case "LOAD_FAST", "STORE_FAST", "LOAD_FAST_LOAD_FAST", "BINARY_OP_FAST_CONST",
+			"INCREMENT_FAST", "COMPARE_FAST_CONST_JUMP":
 			annotation, err = getLocalVariableName(code, int(val[1]))
 			if err != nil {
 				return nil, err
@@ -58,7 +59,7 @@ func Disassemble(code *compiler.Code) ([]Instruction, error) {
 			if err != nil {
 				return nil, err
 			}
-		case "LOAD_ATTR", "STORE_ATTR":
+		case "LOAD_ATTR", "LOAD_METHOD", "STORE_ATTR":
 			nameIndex := int(val[1])
 			name, err := getName(code, nameIndex)
 			if err != nil {
@@ -67,7 +68,7 @@ func Disassemble(code *compiler.Code) ([]Instruction, error) {
 			annotation = fmt.Sprintf("%v", name)
 		case "BINARY_OP":
 			annotation = op.BinaryOpType(val[1]).String()
-		case "COMPARE_OP":
+		case "COMPARE_OP", "COMPARE_JUMP":
 			annotation = op.CompareOpType(val[1]).String()
 		case "LOAD_CONST":
 			constant, err = getConstantValue(code, int(val[1]))
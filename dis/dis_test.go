@@ -40,7 +40,7 @@ func TestFunctionDissasembly(t *testing.T) {
 |      2 | POP_TOP      |          |          |
 |      3 | LOAD_GLOBAL  |        0 | error    |
 |      5 | LOAD_CONST   |        1 | "kaboom" |
-|      7 | CALL         |        1 |          |
+|      7 | TAIL_CALL    |        1 |          |
 |      9 | RETURN_VALUE |          |          |
 +--------+--------------+----------+----------+
 `)
@@ -2,7 +2,11 @@
 // friendly message in addition to the default error message.
 package errz
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
 var typeErrorsAreFatal = false
 
@@ -99,6 +103,82 @@ func TypeErrorf(format string, args ...any) *TypeError {
 	return NewTypeError(fmt.Errorf(format, args...))
 }
 
+// StackFrame describes one active function call at the time a runtime error
+// occurred. Line and Column are 1-indexed and are zero if the position is
+// not known.
+type StackFrame struct {
+	Function string
+	File     string
+	Line     int
+	Column   int
+}
+
+func (f StackFrame) String() string {
+	file := f.File
+	if file == "" {
+		file = "<unknown>"
+	}
+	if f.Line == 0 {
+		return fmt.Sprintf("%s (%s)", f.Function, file)
+	}
+	return fmt.Sprintf("%s (%s:%d:%d)", f.Function, file, f.Line, f.Column)
+}
+
+// RuntimeError wraps an error that occurred while evaluating Risor code and
+// records the Risor call stack that was active at the time. The message of
+// the wrapped error is not modified.
+type RuntimeError struct {
+	Err error
+
+	// Stack holds the active calls, ordered from the innermost call where
+	// the error occurred out to the entrypoint.
+	Stack []StackFrame
+}
+
+func (r *RuntimeError) Error() string {
+	return r.Err.Error()
+}
+
+func (r *RuntimeError) Unwrap() error {
+	return r.Err
+}
+
+func (r *RuntimeError) IsFatal() bool {
+	var err Error
+	if errors.As(r.Err, &err) {
+		return err.IsFatal()
+	}
+	return false
+}
+
+// Traceback returns a human readable representation of the call stack, with
+// the most recent call last.
+func (r *RuntimeError) Traceback() string {
+	var sb strings.Builder
+	sb.WriteString("traceback (most recent call last):")
+	for i := len(r.Stack) - 1; i >= 0; i-- {
+		sb.WriteString("\n  at ")
+		sb.WriteString(r.Stack[i].String())
+	}
+	return sb.String()
+}
+
+func (r *RuntimeError) FriendlyErrorMessage() string {
+	msg := r.Err.Error()
+	var friendlyErr FriendlyError
+	if errors.As(r.Err, &friendlyErr) {
+		msg = friendlyErr.FriendlyErrorMessage()
+	}
+	if len(r.Stack) == 0 {
+		return msg
+	}
+	return fmt.Sprintf("%s\n%s", msg, r.Traceback())
+}
+
+func NewRuntimeError(err error, stack []StackFrame) *RuntimeError {
+	return &RuntimeError{Err: err, Stack: stack}
+}
+
 // AreTypeErrorsFatal returns whether type errors are considered fatal.
 func AreTypeErrorsFatal() bool {
 	return typeErrorsAreFatal
@@ -0,0 +1,281 @@
+package importer
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+// BundleMagic identifies data produced by MarshalBundle.
+const BundleMagic = "RSB\x00"
+
+// BundleVersion is the version of the bundle format. This is separate from
+// the version of the bytecode format used for each code object in a bundle.
+const BundleVersion uint16 = 1
+
+// Bundle holds the compiled code of a script along with the compiled code of
+// every module it imports, so that the script can run without access to any
+// of the source code.
+type Bundle struct {
+	// The compiled code of the script.
+	Main *compiler.Code
+
+	// The compiled code of each module the script imports, by module name.
+	Modules map[string]*compiler.Code
+}
+
+// NewBundle returns a Bundle holding the given code and the modules that it
+// imports, directly or through other modules. The modules are located using
+// the given Importer, which may be nil if the code doesn't import anything.
+func NewBundle(ctx context.Context, main *compiler.Code, importer Importer) (*Bundle, error) {
+	b := &Bundle{Main: main, Modules: map[string]*compiler.Code{}}
+	if err := b.addImports(ctx, main, importer); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// addModule adds the named module and the modules it imports.
+func (b *Bundle) addModule(ctx context.Context, name string, importer Importer) error {
+	code, err := b.loadModule(ctx, name, importer)
+	if err != nil || code == nil {
+		return err
+	}
+	return b.addImports(ctx, code, importer)
+}
+
+// loadModule imports the named module and adds its code to the bundle. Nil
+// is returned if the module is already in the bundle.
+func (b *Bundle) loadModule(ctx context.Context, name string, importer Importer) (*compiler.Code, error) {
+	if _, ok := b.Modules[name]; ok {
+		return nil, nil
+	}
+	if importer == nil {
+		return nil, fmt.Errorf("imports are disabled")
+	}
+	module, err := importer.Import(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	code := module.Code()
+	if code == nil {
+		return nil, fmt.Errorf("import error: module %q has no code", name)
+	}
+	b.Modules[name] = code
+	return code, nil
+}
+
+// addImports adds the modules named by the import statements in the given
+// code. Module names are resolved the same way as they are by the VM.
+func (b *Bundle) addImports(ctx context.Context, code *compiler.Code, importer Importer) error {
+	for _, c := range code.Flatten() {
+		for _, stmt := range findImports(c) {
+			if stmt.from == nil {
+				if err := b.addModule(ctx, stmt.names[0], importer); err != nil {
+					return err
+				}
+				continue
+			}
+			// Each name is either a module or a symbol inside the parent
+			// module. Only a missing module means it is a symbol; other
+			// errors, such as compile errors, are reported.
+			parent := filepath.Join(stmt.from...)
+			for _, name := range stmt.names {
+				imported, err := b.loadModule(ctx, filepath.Join(parent, name), importer)
+				if errors.Is(err, ErrNotFound) {
+					imported, err = b.loadModule(ctx, parent, importer)
+				}
+				if err != nil {
+					return err
+				}
+				if imported != nil {
+					if err := b.addImports(ctx, imported, importer); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// importStatement describes an import statement found in compiled code. For
+// a from-import, from holds the path of the parent module.
+type importStatement struct {
+	from  []string
+	names []string
+}
+
+// findImports returns the import statements compiled into the given code,
+// not including the code of nested functions. The names of the imported
+// modules are loaded as constants immediately before the import instruction.
+func findImports(code *compiler.Code) []importStatement {
+	var stmts []importStatement
+	instructions := compiler.NewInstructionIter(code).All()
+	// Returns the string constants loaded by the given number of instructions
+	// that precede the instruction at index i
+	loadedStrings := func(i, count int) ([]string, bool) {
+		if count > i {
+			return nil, false
+		}
+		values := make([]string, 0, count)
+		for _, instr := range instructions[i-count : i] {
+			if instr[0] != op.LoadConst {
+				return nil, false
+			}
+			value, ok := code.Constant(int(instr[1])).(string)
+			if !ok {
+				return nil, false
+			}
+			values = append(values, value)
+		}
+		return values, true
+	}
+	for i, instr := range instructions {
+		switch instr[0] {
+		case op.Import:
+			if names, ok := loadedStrings(i, 1); ok {
+				stmts = append(stmts, importStatement{names: names})
+			}
+		case op.FromImport:
+			parentLen, importsCount := int(instr[1]), int(instr[2])
+			values, ok := loadedStrings(i, parentLen+importsCount)
+			if !ok {
+				continue
+			}
+			stmts = append(stmts, importStatement{
+				from:  values[:parentLen],
+				names: values[parentLen:],
+			})
+		}
+	}
+	return stmts
+}
+
+// Importer returns an Importer that imports the modules held in the bundle.
+// Other modules are imported using the fallback Importer, if it isn't nil.
+func (b *Bundle) Importer(fallback Importer) Importer {
+	return &bundleImporter{modules: b.Modules, fallback: fallback}
+}
+
+type bundleImporter struct {
+	modules  map[string]*compiler.Code
+	fallback Importer
+}
+
+func (i *bundleImporter) Import(ctx context.Context, name string) (*object.Module, error) {
+	if code, ok := i.modules[name]; ok {
+		return object.NewModule(name, code), nil
+	}
+	if i.fallback == nil {
+		return nil, fmt.Errorf("import error: module %q %w", name, ErrNotFound)
+	}
+	return i.fallback.Import(ctx, name)
+}
+
+// IsBundle returns true if the data begins with the magic bytes of a bundle.
+func IsBundle(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(BundleMagic))
+}
+
+// MarshalBundle converts a Bundle into a compact binary representation. Each
+// code object in the bundle is stored in the compiler's bytecode format.
+func MarshalBundle(b *Bundle) ([]byte, error) {
+	names := make([]string, 0, len(b.Modules))
+	for name := range b.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var data []byte
+	data = append(data, BundleMagic...)
+	data = binary.LittleEndian.AppendUint16(data, BundleVersion)
+	data = binary.AppendUvarint(data, uint64(len(names)))
+	for _, name := range names {
+		code, err := compiler.MarshalCode(b.Modules[name])
+		if err != nil {
+			return nil, err
+		}
+		data = appendBytes(data, []byte(name))
+		data = appendBytes(data, code)
+	}
+	code, err := compiler.MarshalCode(b.Main)
+	if err != nil {
+		return nil, err
+	}
+	return appendBytes(data, code), nil
+}
+
+// UnmarshalBundle converts data produced by MarshalBundle into a Bundle.
+func UnmarshalBundle(data []byte) (*Bundle, error) {
+	if !IsBundle(data) {
+		return nil, fmt.Errorf("invalid bundle: missing header")
+	}
+	data = data[len(BundleMagic):]
+	if len(data) < 2 {
+		return nil, fmt.Errorf("invalid bundle: unexpected end of data")
+	}
+	if version := binary.LittleEndian.Uint16(data); version != BundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version: %d (expected %d)",
+			version, BundleVersion)
+	}
+	data = data[2:]
+	count, n := binary.Uvarint(data)
+	if n <= 0 || count > uint64(len(data)) {
+		return nil, fmt.Errorf("invalid bundle: unexpected end of data")
+	}
+	data = data[n:]
+	b := &Bundle{Modules: make(map[string]*compiler.Code, count)}
+	for ; count > 0; count-- {
+		var name, codeData []byte
+		var ok bool
+		if name, data, ok = readBytes(data); !ok {
+			return nil, fmt.Errorf("invalid bundle: unexpected end of data")
+		}
+		if codeData, data, ok = readBytes(data); !ok {
+			return nil, fmt.Errorf("invalid bundle: unexpected end of data")
+		}
+		code, err := compiler.UnmarshalCode(codeData)
+		if err != nil {
+			return nil, fmt.Errorf("module %q: %w", name, err)
+		}
+		b.Modules[string(name)] = code
+	}
+	codeData, data, ok := readBytes(data)
+	if !ok {
+		return nil, fmt.Errorf("invalid bundle: unexpected end of data")
+	}
+	if len(data) > 0 {
+		return nil, fmt.Errorf("invalid bundle: unexpected data after main code")
+	}
+	main, err := compiler.UnmarshalCode(codeData)
+	if err != nil {
+		return nil, err
+	}
+	b.Main = main
+	return b, nil
+}
+
+// appendBytes appends the length of the value followed by the value itself.
+func appendBytes(data, value []byte) []byte {
+	data = binary.AppendUvarint(data, uint64(len(value)))
+	return append(data, value...)
+}
+
+// readBytes reads a value written by appendBytes and returns the value along
+// with the remaining data.
+func readBytes(data []byte) ([]byte, []byte, bool) {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || size > uint64(len(data)-n) {
+		return nil, nil, false
+	}
+	data = data[n:]
+	return data[:size], data[size:], true
+}
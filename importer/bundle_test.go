@@ -0,0 +1,156 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/stretchr/testify/require"
+)
+
+// writeModules writes the given modules to a temporary directory and returns
+// the directory.
+func writeModules(t *testing.T, modules map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, source := range modules {
+		path := filepath.Join(dir, name+".risor")
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(source), 0o644))
+	}
+	return dir
+}
+
+func compileMain(t *testing.T, source string) *compiler.Code {
+	t.Helper()
+	code, err := parseAndCompile(context.Background(), source, CompileOptions{})
+	require.NoError(t, err)
+	return code
+}
+
+func TestNewBundle(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"a":        `import b; func a() { return b.b() }`,
+		"b":        `import a; func b() { return 2 }`,
+		"pkg/c":    `func c() { return 3 }`,
+		"pkg/d":    `func d() { return 4 }`,
+		"unused":   `func unused() { return 5 }`,
+		"deferred": `func deferred() { return 6 }`,
+	})
+	main := compileMain(t, `
+	import a
+	from pkg import c
+	from pkg.d import d
+	func f() {
+		import deferred
+		return deferred.deferred()
+	}
+	a.a() + c.c() + d() + f()
+	`)
+	bundle, err := NewBundle(context.Background(), main, NewLocalImporter(LocalImporterOptions{
+		SourceDir: dir,
+	}))
+	require.NoError(t, err)
+	require.Equal(t, main, bundle.Main)
+	var names []string
+	for name := range bundle.Modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	require.Equal(t, []string{"a", "b", "deferred", "pkg/c", "pkg/d"}, names)
+}
+
+func TestNewBundleMissingModule(t *testing.T) {
+	main := compileMain(t, `import missing`)
+	_, err := NewBundle(context.Background(), main, NewLocalImporter(LocalImporterOptions{
+		SourceDir: t.TempDir(),
+	}))
+	require.EqualError(t, err, `import error: module "missing" not found`)
+
+	_, err = NewBundle(context.Background(), main, nil)
+	require.EqualError(t, err, "imports are disabled")
+	bundle, err := NewBundle(context.Background(), compileMain(t, `1 + 2`), nil)
+	require.NoError(t, err)
+	require.Empty(t, bundle.Modules)
+}
+
+func TestNewBundleFromImportErrors(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"pkg":         `func broken() { return 1 }`,
+		"pkg/broken":  `x :=`,
+		"pkg/missing": `import nowhere`,
+	})
+	importer := NewLocalImporter(LocalImporterOptions{SourceDir: dir})
+
+	// Only a missing module is treated as a name inside the parent module
+	bundle, err := NewBundle(context.Background(), compileMain(t, `from pkg import other`), importer)
+	require.NoError(t, err)
+	require.Contains(t, bundle.Modules, "pkg")
+
+	_, err = NewBundle(context.Background(), compileMain(t, `from pkg import broken`), importer)
+	require.ErrorContains(t, err, "parse error")
+	_, err = NewBundle(context.Background(), compileMain(t, `from pkg import missing`), importer)
+	require.EqualError(t, err, `import error: module "nowhere" not found`)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestBundleMarshaling(t *testing.T) {
+	dir := writeModules(t, map[string]string{
+		"a":     `func a() { return 1 }`,
+		"pkg/b": `func b() { return 2 }`,
+	})
+	main := compileMain(t, `import a; from pkg import b; a.a() + b.b()`)
+	bundle, err := NewBundle(context.Background(), main, NewLocalImporter(LocalImporterOptions{
+		SourceDir: dir,
+	}))
+	require.NoError(t, err)
+	data, err := MarshalBundle(bundle)
+	require.NoError(t, err)
+	require.True(t, IsBundle(data))
+	require.False(t, compiler.IsBytecode(data))
+
+	other, err := UnmarshalBundle(data)
+	require.NoError(t, err)
+	require.Equal(t, bundle.Main.Source(), other.Main.Source())
+	require.Len(t, other.Modules, 2)
+	require.Equal(t, "func a() { return 1 }", other.Modules["a"].Source())
+	require.Equal(t, "func b() { return 2 }", other.Modules["pkg/b"].Source())
+
+	// The encoding doesn't depend on the order of the modules in the map
+	again, err := MarshalBundle(other)
+	require.NoError(t, err)
+	require.Equal(t, data, again)
+
+	_, err = UnmarshalBundle(data[:len(data)-1])
+	require.EqualError(t, err, "invalid bundle: unexpected end of data")
+	_, err = UnmarshalBundle(append(data, 0))
+	require.EqualError(t, err, "invalid bundle: unexpected data after main code")
+	_, err = UnmarshalBundle([]byte("RSB\x00\x02\x00"))
+	require.EqualError(t, err, "unsupported bundle version: 2 (expected 1)")
+	_, err = UnmarshalBundle([]byte("import a"))
+	require.EqualError(t, err, "invalid bundle: missing header")
+}
+
+func TestBundleImporter(t *testing.T) {
+	ctx := context.Background()
+	bundle := &Bundle{Modules: map[string]*compiler.Code{
+		"a": compileMain(t, `func a() { return 1 }`),
+	}}
+
+	module, err := bundle.Importer(nil).Import(ctx, "a")
+	require.NoError(t, err)
+	require.Equal(t, "a", module.Name().Value())
+	require.Equal(t, bundle.Modules["a"], module.Code())
+
+	_, err = bundle.Importer(nil).Import(ctx, "foo/bar")
+	require.EqualError(t, err, `import error: module "foo/bar" not found`)
+
+	// Modules not in the bundle are imported using the fallback
+	fallback := NewLocalImporter(LocalImporterOptions{SourceDir: "fixtures"})
+	module, err = bundle.Importer(fallback).Import(ctx, "foo/bar")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar"}, module.Code().GlobalNames())
+}
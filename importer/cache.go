@@ -0,0 +1,203 @@
+package importer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+
+	"github.com/risor-io/risor/compiler"
+)
+
+// CompileOptions configure how source code is compiled.
+type CompileOptions struct {
+	// The filename of the source code, which is used in error messages.
+	Filename string
+
+	// Global names that should be available when the code is compiled. The
+	// order of the names affects the compiled code.
+	GlobalNames []string
+
+	// Disables compiler optimizations.
+	WithoutOptimization bool
+}
+
+// CodeCache stores compiled code in a directory, so that source code that
+// hasn't changed doesn't need to be compiled again. Entries are keyed by a
+// hash of the source code, the compile options, the version of the bytecode
+// format and the build of Risor, so an entry is never used for code it
+// doesn't match.
+//
+// The cache is best effort. If an entry can't be read or written, the code
+// is compiled as usual. The cache isn't used at all if the build of Risor
+// can't be identified.
+type CodeCache struct {
+	dir string
+}
+
+// NewCodeCache returns a cache that stores compiled code in the given
+// directory. The directory is created when the first entry is written.
+func NewCodeCache(dir string) *CodeCache {
+	return &CodeCache{dir: dir}
+}
+
+// DefaultCacheDir returns the default directory for cached code, which is
+// located within the user's cache directory.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "risor", "bytecode"), nil
+}
+
+// Dir returns the directory that the cache is stored in.
+func (c *CodeCache) Dir() string {
+	return c.dir
+}
+
+// Compile returns the compiled code for the given source code, either from
+// the cache or by compiling it and adding the result to the cache.
+func (c *CodeCache) Compile(ctx context.Context, source string, opts CompileOptions) (*compiler.Code, error) {
+	if buildID() == "" {
+		return parseAndCompile(ctx, source, opts)
+	}
+	path := filepath.Join(c.dir, cacheKey(source, opts)+".rsc")
+	if data, err := os.ReadFile(path); err == nil && compiler.IsBytecode(data) {
+		if code, err := compiler.UnmarshalCode(data); err == nil {
+			return code, nil
+		}
+	}
+	code, err := parseAndCompile(ctx, source, opts)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := compiler.MarshalCode(code); err == nil {
+		c.write(path, data)
+	}
+	return code, nil
+}
+
+// write writes an entry to a temporary file and then renames it, so that an
+// incomplete entry is never visible to other processes.
+func (c *CodeCache) write(path string, data []byte) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+	f, err := os.CreateTemp(c.dir, "*.tmp")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// cacheKey returns a hash of everything that affects the result of compiling
+// the given source code.
+func cacheKey(source string, opts CompileOptions) string {
+	h := sha256.New()
+	writeString := func(s string) {
+		h.Write(binary.AppendUvarint(nil, uint64(len(s))))
+		h.Write([]byte(s))
+	}
+	h.Write(binary.LittleEndian.AppendUint16(nil, compiler.BytecodeVersion))
+	writeString(buildID())
+	writeString(opts.Filename)
+	h.Write(binary.AppendUvarint(nil, uint64(len(opts.GlobalNames))))
+	for _, name := range opts.GlobalNames {
+		writeString(name)
+	}
+	if opts.WithoutOptimization {
+		h.Write([]byte{1})
+	} else {
+		h.Write([]byte{0})
+	}
+	writeString(source)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// buildID identifies the build of Risor that this program uses. Code compiled
+// by one build may not match code compiled by another, so this is part of
+// each cache key. A released version of Risor is identified by its version.
+// Otherwise, as for a build from source or "go run", the build is identified
+// by its VCS revision if the source tree was unmodified, or else by a hash of
+// the executable. An empty string means that the build can't be identified.
+var buildID = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if ok {
+		if version := releaseVersion(info); version != "" {
+			return version
+		}
+		var revision string
+		var modified bool
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+		// The revision only describes the Risor source if it is in the
+		// main module, rather than a dependency replaced by a local copy
+		if revision != "" && !modified && info.Main.Path == risorModulePath {
+			return "vcs:" + revision
+		}
+	}
+	path, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return ""
+	}
+	return "exe:" + hex.EncodeToString(h.Sum(nil))
+})
+
+const risorModulePath = "github.com/risor-io/risor"
+
+// releaseVersion returns the version of the Risor module in the build info,
+// if it identifies the source code exactly. Builds from a local source tree
+// have the version "(devel)" or no version, and modified trees are marked
+// "+dirty".
+func releaseVersion(info *debug.BuildInfo) string {
+	var version string
+	if info.Main.Path == risorModulePath {
+		version = info.Main.Version
+	} else {
+		for _, dep := range info.Deps {
+			if dep.Path != risorModulePath {
+				continue
+			}
+			version = dep.Version
+			if dep.Replace != nil {
+				// A replacement without a version is a local directory
+				version = dep.Replace.Version
+			}
+		}
+	}
+	if version == "(devel)" || strings.HasSuffix(version, "+dirty") {
+		return ""
+	}
+	return version
+}
@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCodeCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCodeCache(filepath.Join(t.TempDir(), "cache"))
+	opts := CompileOptions{Filename: "main.risor", GlobalNames: []string{"len"}}
+
+	code, err := cache.Compile(ctx, `len([1, 2, 3])`, opts)
+	require.NoError(t, err)
+	entries, err := os.ReadDir(cache.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// The second compile is served from the cache
+	cached, err := cache.Compile(ctx, `len([1, 2, 3])`, opts)
+	require.NoError(t, err)
+	require.NotSame(t, code, cached)
+	require.Equal(t, code.Source(), cached.Source())
+	require.Equal(t, "main.risor", cached.Filename())
+	entries, err = os.ReadDir(cache.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// Different source code or options use different entries
+	_, err = cache.Compile(ctx, `len([1, 2])`, opts)
+	require.NoError(t, err)
+	_, err = cache.Compile(ctx, `len([1, 2, 3])`, CompileOptions{
+		Filename:            "main.risor",
+		GlobalNames:         []string{"len"},
+		WithoutOptimization: true,
+	})
+	require.NoError(t, err)
+	entries, err = os.ReadDir(cache.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+}
+
+func TestCodeCacheInvalidEntry(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCodeCache(t.TempDir())
+	source := `1 + 2`
+	opts := CompileOptions{}
+	path := filepath.Join(cache.Dir(), cacheKey(source, opts)+".rsc")
+
+	// A corrupted entry is replaced by compiling the code again
+	require.NoError(t, os.WriteFile(path, []byte("RSC\x00garbage"), 0o644))
+	code, err := cache.Compile(ctx, source, opts)
+	require.NoError(t, err)
+	require.Equal(t, "(1 + 2)", code.Source())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NotEqual(t, "RSC\x00garbage", string(data))
+}
+
+func TestCodeCacheCompileError(t *testing.T) {
+	cache := NewCodeCache(t.TempDir())
+	_, err := cache.Compile(context.Background(), `x :=`, CompileOptions{})
+	require.Error(t, err)
+	entries, err := os.ReadDir(cache.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+}
+
+func TestLocalImporterWithCache(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCodeCache(t.TempDir())
+	newImporter := func() *LocalImporter {
+		return NewLocalImporter(LocalImporterOptions{SourceDir: "fixtures", Cache: cache})
+	}
+	module, err := newImporter().Import(ctx, "foo/bar")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar"}, module.Code().GlobalNames())
+
+	// A new importer reads the module from the cache
+	entries, err := os.ReadDir(cache.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	module, err = newImporter().Import(ctx, "foo/bar")
+	require.NoError(t, err)
+	require.Equal(t, []string{"bar"}, module.Code().GlobalNames())
+	entries, err = os.ReadDir(cache.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestCodeCacheUnknownBuild(t *testing.T) {
+	prevBuildID := buildID
+	buildID = func() string { return "" }
+	defer func() { buildID = prevBuildID }()
+
+	// The cache isn't used if the build can't be identified
+	cache := NewCodeCache(t.TempDir())
+	code, err := cache.Compile(context.Background(), `1 + 2`, CompileOptions{})
+	require.NoError(t, err)
+	require.Equal(t, "(1 + 2)", code.Source())
+	entries, err := os.ReadDir(cache.Dir())
+	require.NoError(t, err)
+	require.Len(t, entries, 0)
+}
+
+func TestBuildID(t *testing.T) {
+	// Test binaries aren't stamped with a version or VCS information, so
+	// they are identified by a hash of the executable
+	require.Regexp(t, "^exe:[0-9a-f]{64}$", buildID())
+}
+
+func TestReleaseVersion(t *testing.T) {
+	main := func(version string) *debug.BuildInfo {
+		return &debug.BuildInfo{Main: debug.Module{Path: risorModulePath, Version: version}}
+	}
+	dep := func(version string, replace *debug.Module) *debug.BuildInfo {
+		return &debug.BuildInfo{
+			Main: debug.Module{Path: "example.com/app", Version: "(devel)"},
+			Deps: []*debug.Module{
+				{Path: "example.com/other", Version: "v1.0.0"},
+				{Path: risorModulePath, Version: version, Replace: replace},
+			},
+		}
+	}
+	tests := []struct {
+		info     *debug.BuildInfo
+		expected string
+	}{
+		{main("v1.8.0"), "v1.8.0"},
+		{main("(devel)"), ""},
+		{main(""), ""},
+		{main("v1.8.1-0.20250101000000-abcdef123456+dirty"), ""},
+		{dep("v1.8.0", nil), "v1.8.0"},
+		{dep("v1.8.0", &debug.Module{Path: "example.com/fork", Version: "v1.9.0"}), "v1.9.0"},
+		{dep("v1.8.0", &debug.Module{Path: "../risor"}), ""},
+		{&debug.BuildInfo{Main: debug.Module{Path: "example.com/app"}}, ""},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, releaseVersion(tt.info))
+	}
+}
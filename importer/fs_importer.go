@@ -59,10 +59,13 @@ func (i *FSImporter) Import(ctx context.Context, name string) (*object.Module, e
 
 	source, fullPath, found := i.readFileWithExtensions(name, i.extensions)
 	if !found {
-		return nil, fmt.Errorf("import error: module %q not found", name)
+		return nil, fmt.Errorf("import error: module %q %w", name, ErrNotFound)
 	}
 
-	code, err := parseAndCompile(ctx, source, fullPath, i.globalNames)
+	code, err := parseAndCompile(ctx, source, CompileOptions{
+		Filename:    fullPath,
+		GlobalNames: i.globalNames,
+	})
 	if err != nil {
 		return nil, err
 	}
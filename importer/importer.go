@@ -3,6 +3,7 @@ package importer
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -15,6 +16,10 @@ import (
 
 var defaultExtensions = []string{".risor", ".rsr"}
 
+// ErrNotFound is wrapped by the errors that importers return when the
+// requested module doesn't exist.
+var ErrNotFound = errors.New("not found")
+
 // Importer is an interface used to import Risor code modules
 type Importer interface {
 	// Import a module by name
@@ -28,6 +33,7 @@ type LocalImporter struct {
 	codeCache   map[string]*compiler.Code
 	sourceDir   string
 	extensions  []string
+	cache       *CodeCache
 	mutex       sync.Mutex
 }
 
@@ -42,6 +48,10 @@ type LocalImporterOptions struct {
 
 	// Optional list of file extensions to try when locating a Risor module.
 	Extensions []string
+
+	// Optional cache used to avoid compiling modules that haven't changed
+	// since they were last imported.
+	Cache *CodeCache
 }
 
 // NewLocalImporter returns an Importer that can read Risor code modules from
@@ -59,6 +69,7 @@ func NewLocalImporter(opts LocalImporterOptions) *LocalImporter {
 		codeCache:   map[string]*compiler.Code{},
 		sourceDir:   opts.SourceDir,
 		extensions:  opts.Extensions,
+		cache:       opts.Cache,
 	}
 }
 
@@ -73,10 +84,17 @@ func (i *LocalImporter) Import(ctx context.Context, name string) (*object.Module
 
 	source, fullPath, found := readFileWithExtensions(i.sourceDir, name, i.extensions)
 	if !found {
-		return nil, fmt.Errorf("import error: module %q not found", name)
+		return nil, fmt.Errorf("import error: module %q %w", name, ErrNotFound)
 	}
 
-	code, err := parseAndCompile(ctx, source, fullPath, i.globalNames)
+	compileOpts := CompileOptions{Filename: fullPath, GlobalNames: i.globalNames}
+	var code *compiler.Code
+	var err error
+	if i.cache != nil {
+		code, err = i.cache.Compile(ctx, source, compileOpts)
+	} else {
+		code, err = parseAndCompile(ctx, source, compileOpts)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -97,15 +115,18 @@ func readFileWithExtensions(dir, name string, extensions []string) (string, stri
 	return "", "", false
 }
 
-func parseAndCompile(ctx context.Context, source, filepath string, globalNames []string) (*compiler.Code, error) {
-	ast, err := parser.Parse(ctx, source, parser.WithFilename(filepath))
+func parseAndCompile(ctx context.Context, source string, opts CompileOptions) (*compiler.Code, error) {
+	ast, err := parser.Parse(ctx, source, parser.WithFilename(opts.Filename))
 	if err != nil {
 		return nil, err
 	}
-	var opts []compiler.Option
-	if len(globalNames) > 0 {
-		opts = append(opts, compiler.WithGlobalNames(globalNames))
+	var compilerOpts []compiler.Option
+	if len(opts.GlobalNames) > 0 {
+		compilerOpts = append(compilerOpts, compiler.WithGlobalNames(opts.GlobalNames))
+	}
+	compilerOpts = append(compilerOpts, compiler.WithFilename(opts.Filename))
+	if opts.WithoutOptimization {
+		compilerOpts = append(compilerOpts, compiler.WithoutOptimization())
 	}
-	opts = append(opts, compiler.WithFilename(filepath))
-	return compiler.Compile(ast, opts...)
+	return compiler.Compile(ast, compilerOpts...)
 }
@@ -1,7 +1,10 @@
 // Package tmpl is used to parse Risor string templates.
 package tmpl
 
-import "fmt"
+import (
+	"fmt"
+	"unicode"
+)
 
 type Fragment struct {
 	// value is the fragment text. If the fragment is an expression, this will
@@ -9,6 +12,10 @@ type Fragment struct {
 	value string
 	// isVariable is true if this is an expression, false if it is raw text.
 	isVariable bool
+	// conversion is "r" or "s" if the expression is followed by !r or !s.
+	conversion string
+	// spec is the format specifier that follows a ":" after the expression.
+	spec string
 }
 
 // Value returns the fragment text. If the fragment is an expression, this will
@@ -22,6 +29,18 @@ func (f *Fragment) IsVariable() bool {
 	return f.isVariable
 }
 
+// Conversion returns "r" or "s" if the expression is followed by a !r or !s
+// conversion, or an empty string otherwise.
+func (f *Fragment) Conversion() string {
+	return f.conversion
+}
+
+// FormatSpec returns the format specifier of the expression, as in ".2f"
+// for the expression {value:.2f}, or an empty string if there is none.
+func (f *Fragment) FormatSpec() string {
+	return f.spec
+}
+
 // Template defines a string template which may contain any number of
 // expressions within.
 type Template struct {
@@ -110,5 +129,93 @@ func Parse(s string) (*Template, error) {
 	if curFragment != nil && curFragment.isVariable {
 		return nil, fmt.Errorf("missing '}' in template: %v", s)
 	}
+	for _, f := range template.fragments {
+		if f.isVariable {
+			f.value, f.conversion, f.spec = splitExpression(f.value)
+		}
+	}
 	return template, nil
 }
+
+// splitExpression splits the text of an expression into the expression itself,
+// an optional !r or !s conversion and an optional format specifier, as in
+// "value!r:>10". The separators are only recognized outside of brackets and
+// string literals, and a ":" that completes a ternary expression is kept.
+func splitExpression(s string) (expr, conversion, spec string) {
+	runes := []rune(s)
+	var depth, ternaries int
+	var quote rune
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		if quote != 0 {
+			if char == '\\' {
+				i++
+			} else if char == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch char {
+		case '"', '`':
+			quote = char
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case '?':
+			// Skip the ?. and ?? operators, which aren't part of a ternary
+			if next == '.' || next == '?' {
+				i++
+			} else if depth == 0 {
+				ternaries++
+			}
+		case '!':
+			// A conversion must follow an operand, otherwise this is a
+			// negation as in "!s" or "x && !r"
+			if depth > 0 || (next != 'r' && next != 's') || !endsOperand(runes[:i]) {
+				continue
+			}
+			if i+2 == len(runes) {
+				return string(runes[:i]), string(next), ""
+			}
+			if runes[i+2] == ':' {
+				return string(runes[:i]), string(next), string(runes[i+3:])
+			}
+		case ':':
+			if depth > 0 {
+				continue
+			}
+			if ternaries > 0 {
+				ternaries--
+				continue
+			}
+			return string(runes[:i]), "", string(runes[i+1:])
+		}
+	}
+	return s, "", ""
+}
+
+// endsOperand returns true if the given text is non-empty and ends with a
+// complete operand, such as a name, a literal or a closing bracket, rather
+// than with an operator.
+func endsOperand(runes []rune) bool {
+	for i := len(runes) - 1; i >= 0; i-- {
+		char := runes[i]
+		switch {
+		case unicode.IsSpace(char):
+			continue
+		case char == '_' || unicode.IsLetter(char) || unicode.IsDigit(char):
+			return true
+		}
+		switch char {
+		case ')', ']', '}', '"', '`', '\'':
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -53,6 +53,54 @@ func TestParseString(t *testing.T) {
 	}
 }
 
+func TestParseFormatSpec(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []*Fragment
+	}{
+		{
+			"{value:.2f}|{n:08d}",
+			[]*Fragment{
+				{value: "value", isVariable: true, spec: ".2f"},
+				{value: "|", isVariable: false},
+				{value: "n", isVariable: true, spec: "08d"},
+			},
+		},
+		{
+			"{x!r}{s!s:>20}{t:%Y-%m-%d}",
+			[]*Fragment{
+				{value: "x", isVariable: true, conversion: "r"},
+				{value: "s", isVariable: true, conversion: "s", spec: ">20"},
+				{value: "t", isVariable: true, spec: "%Y-%m-%d"},
+			},
+		},
+		{
+			`{a ? "x:y" : b[1:2]:^9}{m?.k ?? c != d}{f(":")}`,
+			[]*Fragment{
+				{value: `a ? "x:y" : b[1:2]`, isVariable: true, spec: "^9"},
+				{value: "m?.k ?? c != d", isVariable: true},
+				{value: `f(":")`, isVariable: true},
+			},
+		},
+		{
+			// A "!" that doesn't follow an operand is a negation
+			"{!s}{x > 0 && !r}{ !r:>5}{f()!r}{x !s}",
+			[]*Fragment{
+				{value: "!s", isVariable: true},
+				{value: "x > 0 && !r", isVariable: true},
+				{value: " !r", isVariable: true, spec: ">5"},
+				{value: "f()", isVariable: true, conversion: "r"},
+				{value: "x ", isVariable: true, conversion: "s"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		res, err := Parse(tc.input)
+		require.Nil(t, err)
+		require.Equal(t, tc.want, res.Fragments())
+	}
+}
+
 func TestParseStringErrors(t *testing.T) {
 	tests := []struct {
 		input   string
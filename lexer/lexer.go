@@ -146,13 +146,27 @@ func (l *Lexer) Next() (token.Token, error) {
 			ch := l.ch
 			l.readChar()
 			tok = l.newToken(token.EQ, string(ch)+string(l.ch))
+		} else if l.peekChar() == rune('>') {
+			ch := l.ch
+			l.readChar()
+			tok = l.newToken(token.FAT_ARROW, string(ch)+string(l.ch))
 		} else {
 			tok = l.newToken(token.ASSIGN, string(l.ch))
 		}
 	case rune(';'):
 		tok = l.newToken(token.SEMICOLON, string(l.ch))
 	case rune('?'):
-		tok = l.newToken(token.QUESTION, string(l.ch))
+		if l.peekChar() == rune('.') {
+			ch := l.ch
+			l.readChar()
+			tok = l.newToken(token.QUESTION_PERIOD, string(ch)+string(l.ch))
+		} else if l.peekChar() == rune('?') {
+			ch := l.ch
+			l.readChar()
+			tok = l.newToken(token.QUESTION_QUESTION, string(ch)+string(l.ch))
+		} else {
+			tok = l.newToken(token.QUESTION, string(l.ch))
+		}
 	case rune('('):
 		tok = l.newToken(token.LPAREN, string(l.ch))
 	case rune(')'):
@@ -160,7 +174,13 @@ func (l *Lexer) Next() (token.Token, error) {
 	case rune(','):
 		tok = l.newToken(token.COMMA, string(l.ch))
 	case rune('.'):
-		tok = l.newToken(token.PERIOD, string(l.ch))
+		if l.peekChar() == rune('.') && l.peekCharAt(1) == rune('.') {
+			l.readChar()
+			l.readChar()
+			tok = l.newToken(token.ELLIPSIS, "...")
+		} else {
+			tok = l.newToken(token.PERIOD, string(l.ch))
+		}
 	case rune('+'):
 		if l.peekChar() == rune('+') {
 			ch := l.ch
@@ -188,6 +208,10 @@ func (l *Lexer) Next() (token.Token, error) {
 			ch := l.ch
 			l.readChar()
 			tok = l.newToken(token.MINUS_EQUALS, string(ch)+string(l.ch))
+		} else if l.peekChar() == rune('>') {
+			ch := l.ch
+			l.readChar()
+			tok = l.newToken(token.ARROW, string(ch)+string(l.ch))
 		} else {
 			tok = l.newToken(token.MINUS, string(l.ch))
 		}
@@ -626,6 +650,16 @@ func (l *Lexer) peekChar() rune {
 	return l.characters[l.nextPosition]
 }
 
+// peekCharAt returns the character at the given offset past the next
+// character, without advancing the lexer.
+func (l *Lexer) peekCharAt(offset int) rune {
+	index := l.nextPosition + offset
+	if index >= len(l.characters) {
+		return rune(0)
+	}
+	return l.characters[index]
+}
+
 // GetLineText returns the text of the line containing the given token.
 func (l *Lexer) GetLineText(t token.Token) string {
 	if len(l.characters) == 0 {
@@ -530,6 +530,121 @@ baz.qux();
 	}
 }
 
+func TestEllipsis(t *testing.T) {
+	input := `f(...args) a..b`
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "args"},
+		{token.RPAREN, ")"},
+		{token.IDENT, "a"},
+		{token.PERIOD, "."},
+		{token.PERIOD, "."},
+		{token.IDENT, "b"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		require.Equal(t, tt.expectedType, tok.Type, "tests[%d]", i)
+		require.Equal(t, tt.expectedLiteral, tok.Literal, "tests[%d]", i)
+	}
+}
+
+func TestOptionalChaining(t *testing.T) {
+	input := `a?.b?.[0] ?? c ? d : e`
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.IDENT, "a"},
+		{token.QUESTION_PERIOD, "?."},
+		{token.IDENT, "b"},
+		{token.QUESTION_PERIOD, "?."},
+		{token.LBRACKET, "["},
+		{token.INT, "0"},
+		{token.RBRACKET, "]"},
+		{token.QUESTION_QUESTION, "??"},
+		{token.IDENT, "c"},
+		{token.QUESTION, "?"},
+		{token.IDENT, "d"},
+		{token.COLON, ":"},
+		{token.IDENT, "e"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		require.Equal(t, tt.expectedType, tok.Type, "tests[%d]", i)
+		require.Equal(t, tt.expectedLiteral, tok.Literal, "tests[%d]", i)
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	input := `func f(x: int) -> list { x - 1 }`
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.FUNC, "func"},
+		{token.IDENT, "f"},
+		{token.LPAREN, "("},
+		{token.IDENT, "x"},
+		{token.COLON, ":"},
+		{token.IDENT, "int"},
+		{token.RPAREN, ")"},
+		{token.ARROW, "->"},
+		{token.IDENT, "list"},
+		{token.LBRACE, "{"},
+		{token.IDENT, "x"},
+		{token.MINUS, "-"},
+		{token.INT, "1"},
+		{token.RBRACE, "}"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		require.Equal(t, tt.expectedType, tok.Type, "tests[%d]", i)
+		require.Equal(t, tt.expectedLiteral, tok.Literal, "tests[%d]", i)
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	input := `(a, b=1) => a == b`
+	tests := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+	}{
+		{token.LPAREN, "("},
+		{token.IDENT, "a"},
+		{token.COMMA, ","},
+		{token.IDENT, "b"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.RPAREN, ")"},
+		{token.FAT_ARROW, "=>"},
+		{token.IDENT, "a"},
+		{token.EQ, "=="},
+		{token.IDENT, "b"},
+		{token.EOF, ""},
+	}
+	l := New(input)
+	for i, tt := range tests {
+		tok, err := l.Next()
+		require.Nil(t, err)
+		require.Equal(t, tt.expectedType, tok.Type, "tests[%d]", i)
+		require.Equal(t, tt.expectedLiteral, tok.Literal, "tests[%d]", i)
+	}
+}
+
 // TestDiv is designed to test that a division is recognized; that it is
 // not confused with a regular-expression.
 func TestDiv(t *testing.T) {
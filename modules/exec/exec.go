@@ -50,6 +50,20 @@ func CommandFunc(ctx context.Context, args ...object.Object) object.Object {
 	return NewCommand(exec.CommandContext(ctx, name, strArgs...))
 }
 
+// CommandWithKwargs is like CommandFunc, but also accepts the options
+// supported by exec as keyword arguments, e.g. command("ls", dir="/tmp").
+func CommandWithKwargs(ctx context.Context, kwargs *object.Map, args ...object.Object) object.Object {
+	result := CommandFunc(ctx, args...)
+	cmd, ok := result.(*Command)
+	if !ok || kwargs.Size() == 0 {
+		return result
+	}
+	if err := configureCommand(cmd.value, kwargs); err != nil {
+		return object.NewError(err)
+	}
+	return cmd
+}
+
 func LookPath(ctx context.Context, args ...object.Object) object.Object {
 	if err := arg.Require("look_path", 1, args); err != nil {
 		return err
@@ -198,7 +212,7 @@ func configureCommand(cmd *exec.Cmd, params *object.Map) error {
 
 func Module() *object.Module {
 	return object.NewBuiltinsModule("exec", map[string]object.Object{
-		"command":   object.NewBuiltin("exec.command", CommandFunc),
+		"command":   object.NewKeywordBuiltin("exec.command", CommandWithKwargs),
 		"look_path": object.NewBuiltin("exec.look_path", LookPath),
 	}, Exec)
 }
@@ -171,3 +171,24 @@ func TestConfigureWithBadMaps(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandWithKwargs(t *testing.T) {
+	ctx := context.Background()
+	cmdObj, ok := CommandWithKwargs(ctx,
+		object.NewMap(map[string]object.Object{
+			"dir": object.NewString("/tmp"),
+			"env": object.NewMap(map[string]object.Object{"FOO": object.NewString("bar")}),
+		}),
+		object.NewString("ls")).(*Command)
+	require.True(t, ok)
+	cmd := cmdObj.Value()
+	require.Equal(t, "/tmp", cmd.Dir)
+	require.Equal(t, []string{"FOO=bar"}, cmd.Env)
+
+	result := CommandWithKwargs(ctx,
+		object.NewMap(map[string]object.Object{"cwd": object.NewString("/tmp")}),
+		object.NewString("ls"))
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "exec found unexpected key \"cwd\"", errObj.Message().Value())
+}
@@ -72,11 +72,10 @@ func (r *Result) JSON() object.Object {
 	default:
 		return object.Errorf("eval error: exec.result.json does not support stdout type %T", stdout)
 	}
-	var obj interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
-		return object.Errorf("value error: json.unmarshal failed with: %s", err.Error())
+	scriptObj, decodeErr := object.FromJSON(data)
+	if decodeErr != nil {
+		return object.Errorf("value error: json.unmarshal failed with: %s", decodeErr.Error())
 	}
-	scriptObj := object.FromGoType(obj)
 	if scriptObj == nil {
 		return object.TypeErrorf("type error: json.unmarshal failed")
 	}
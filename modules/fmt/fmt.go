@@ -18,9 +18,9 @@ func Printf(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	var values []interface{}
-	for _, arg := range args[1:] {
-		values = append(values, object.PrintableValue(arg))
+	values, printErr := printableValues(ctx, args[1:])
+	if printErr != nil {
+		return printErr
 	}
 	stdout := os.GetDefaultOS(ctx).Stdout()
 	if _, ioErr := fmt.Fprintf(stdout, format, values...); ioErr != nil {
@@ -30,9 +30,9 @@ func Printf(ctx context.Context, args ...object.Object) object.Object {
 }
 
 func Println(ctx context.Context, args ...object.Object) object.Object {
-	var values []interface{}
-	for _, arg := range args {
-		values = append(values, object.PrintableValue(arg))
+	values, printErr := printableValues(ctx, args)
+	if printErr != nil {
+		return printErr
 	}
 	stdout := os.GetDefaultOS(ctx).Stdout()
 	if _, ioErr := fmt.Fprintln(stdout, values...); ioErr != nil {
@@ -50,9 +50,9 @@ func Errorf(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	var values []interface{}
-	for _, arg := range args[1:] {
-		values = append(values, object.PrintableValue(arg))
+	values, printErr := printableValues(ctx, args[1:])
+	if printErr != nil {
+		return printErr
 	}
 	return object.NewError(fmt.Errorf(format, values...)).WithRaised(false)
 }
@@ -66,13 +66,31 @@ func Sprintf(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	var values []interface{}
-	for _, arg := range args[1:] {
-		values = append(values, object.PrintableValue(arg))
+	values, printErr := printableValues(ctx, args[1:])
+	if printErr != nil {
+		return printErr
 	}
 	return object.NewString(fmt.Sprintf(format, values...))
 }
 
+// printableValues converts the arguments to values for formatting, using the
+// result of the __str__ hook for values that define it.
+func printableValues(ctx context.Context, args []object.Object) ([]interface{}, *object.Error) {
+	values := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		s, ok, err := object.CallStrHook(ctx, arg)
+		if err != nil {
+			return nil, object.NewError(err)
+		}
+		if ok {
+			values = append(values, s)
+		} else {
+			values = append(values, object.PrintableValue(arg))
+		}
+	}
+	return values, nil
+}
+
 func Builtins() map[string]object.Object {
 	return map[string]object.Object{
 		"print":   object.NewBuiltin("print", Println),
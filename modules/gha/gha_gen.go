@@ -100,12 +100,19 @@ func AddPath(ctx context.Context, args ...object.Object) object.Object {
 //
 // Useful if you want to write your own "Module()" function.
 func addGeneratedBuiltins(builtins map[string]object.Object) map[string]object.Object {
-	builtins["is_debug"] = object.NewBuiltin("is_debug", IsDebug)
-	builtins["log_debug"] = object.NewBuiltin("log_debug", LogDebug)
-	builtins["start_group"] = object.NewBuiltin("start_group", StartGroup)
-	builtins["end_group"] = object.NewBuiltin("end_group", EndGroup)
-	builtins["set_output"] = object.NewBuiltin("set_output", SetOutput)
-	builtins["set_env"] = object.NewBuiltin("set_env", SetEnv)
-	builtins["add_path"] = object.NewBuiltin("add_path", AddPath)
+	builtins["is_debug"] = object.NewBuiltin("is_debug", IsDebug).
+		WithSignature(object.MustParseSignature("() -> bool"))
+	builtins["log_debug"] = object.NewBuiltin("log_debug", LogDebug).
+		WithSignature(object.MustParseSignature("(msg)"))
+	builtins["start_group"] = object.NewBuiltin("start_group", StartGroup).
+		WithSignature(object.MustParseSignature("(msg: string)"))
+	builtins["end_group"] = object.NewBuiltin("end_group", EndGroup).
+		WithSignature(object.MustParseSignature("()"))
+	builtins["set_output"] = object.NewBuiltin("set_output", SetOutput).
+		WithSignature(object.MustParseSignature("(key: string, value)"))
+	builtins["set_env"] = object.NewBuiltin("set_env", SetEnv).
+		WithSignature(object.MustParseSignature("(key: string, value)"))
+	builtins["add_path"] = object.NewBuiltin("add_path", AddPath).
+		WithSignature(object.MustParseSignature("(path: string)"))
 	return builtins
 }
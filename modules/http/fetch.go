@@ -3,30 +3,76 @@ package http
 import (
 	"context"
 
+	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/object"
 )
 
+// requestOptions are the options accepted by fetch and request, either in an
+// options map or as keyword arguments.
+var requestOptions = map[string]bool{
+	"body":         true,
+	"data":         true,
+	"headers":      true,
+	"method":       true,
+	"params":       true,
+	"proxy":        true,
+	"resolver":     true,
+	"storeCookies": true,
+	"timeout":      true,
+}
+
 func Fetch(ctx context.Context, args ...object.Object) object.Object {
+	return FetchWithKwargs(ctx, object.NewMap(nil), args...)
+}
+
+// FetchWithKwargs is like Fetch, but also accepts the request options as
+// keyword arguments, e.g. fetch(url, method="POST", timeout=1000).
+func FetchWithKwargs(ctx context.Context, kwargs *object.Map, args ...object.Object) object.Object {
+	urlArg, params, errObj := requestParams("fetch", kwargs, args)
+	if errObj != nil {
+		return errObj
+	}
+	req, errObj := NewRequestFromParams(urlArg, params)
+	if errObj != nil {
+		return errObj
+	}
+
+	return req.Send(ctx)
+}
+
+// requestParams returns the URL and options given to fetch or request. Keyword
+// arguments are checked against the known options and take precedence over
+// the keys of an options map.
+func requestParams(name string, kwargs *object.Map, args []object.Object) (string, *object.Map, *object.Error) {
 	numArgs := len(args)
 	if numArgs < 1 || numArgs > 2 {
-		return object.NewArgsRangeError("fetch", 1, 2, numArgs)
+		return "", nil, object.NewArgsRangeError(name, 1, 2, numArgs)
 	}
-	urlArg, argErr := object.AsString(args[0])
-	if argErr != nil {
-		return argErr
+	urlArg, errObj := object.AsString(args[0])
+	if errObj != nil {
+		return "", nil, errObj
 	}
-	var errObj *object.Error
 	var params *object.Map
 	if numArgs == 2 {
 		params, errObj = object.AsMap(args[1])
 		if errObj != nil {
-			return errObj
+			return "", nil, errObj
 		}
 	}
-	req, errObj := NewRequestFromParams(urlArg, params)
-	if errObj != nil {
-		return errObj
+	if kwargs == nil || kwargs.Size() == 0 {
+		return urlArg, params, nil
 	}
-
-	return req.Send(ctx)
+	if params == nil {
+		params = object.NewMap(nil)
+	} else {
+		params = params.Copy()
+	}
+	for _, key := range kwargs.SortedKeys() {
+		if !requestOptions[key] {
+			return "", nil, object.NewError(errz.ArgsErrorf(
+				"args error: %s() got an unexpected keyword argument %q", name, key))
+		}
+		params.Set(key, kwargs.Get(key))
+	}
+	return urlArg, params, nil
 }
@@ -94,3 +94,48 @@ func TestBasicFetch(t *testing.T) {
 		"User-Agent":      []string{"Go-http-client/1.1"},
 	}, gotHeaders)
 }
+
+func TestFetchWithKwargs(t *testing.T) {
+	gotMethod := ""
+	gotHeaders := make(http.Header)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotHeaders = r.Header.Clone()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer svr.Close()
+
+	ctx := context.Background()
+	ctx = limits.WithLimits(ctx, limits.New())
+
+	kwargs := object.NewMap(map[string]object.Object{
+		"method": object.NewString("PUT"),
+		"headers": object.NewMap(map[string]object.Object{
+			"Foo": object.NewString("baz"),
+		}),
+	})
+	options := object.NewMap(map[string]object.Object{
+		"method": object.NewString("PATCH"),
+	})
+	result := FetchWithKwargs(ctx, kwargs, object.NewString(svr.URL), options)
+	if errObj, ok := result.(*object.Error); ok {
+		require.Nil(t, errObj, errObj)
+	}
+	resp, ok := result.(*HttpResponse)
+	require.True(t, ok)
+	require.Equal(t, int64(204), resp.StatusCode().Value())
+	require.Equal(t, "PUT", gotMethod)
+	require.Equal(t, "baz", gotHeaders.Get("Foo"))
+	// The options map is not modified by the keyword arguments
+	require.Equal(t, object.NewString("PATCH"), options.Get("method"))
+}
+
+func TestFetchUnexpectedKwarg(t *testing.T) {
+	kwargs := object.NewMap(map[string]object.Object{
+		"methd": object.NewString("PUT"),
+	})
+	result := FetchWithKwargs(context.Background(), kwargs, object.NewString("http://localhost"))
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.Equal(t, "args error: fetch() got an unexpected keyword argument \"methd\"", errObj.Message().Value())
+}
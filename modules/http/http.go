@@ -8,21 +8,15 @@ import (
 )
 
 func NewHttpRequest(ctx context.Context, args ...object.Object) object.Object {
-	numArgs := len(args)
-	if numArgs < 1 || numArgs > 2 {
-		return object.NewArgsRangeError("fetch", 1, 2, numArgs)
-	}
-	urlArg, argErr := object.AsString(args[0])
-	if argErr != nil {
-		return argErr
-	}
-	var errObj *object.Error
-	var params *object.Map
-	if numArgs == 2 {
-		params, errObj = object.AsMap(args[1])
-		if errObj != nil {
-			return errObj
-		}
+	return NewHttpRequestWithKwargs(ctx, object.NewMap(nil), args...)
+}
+
+// NewHttpRequestWithKwargs is like NewHttpRequest, but also accepts the
+// request options as keyword arguments.
+func NewHttpRequestWithKwargs(ctx context.Context, kwargs *object.Map, args ...object.Object) object.Object {
+	urlArg, params, errObj := requestParams("request", kwargs, args)
+	if errObj != nil {
+		return errObj
 	}
 	req, errObj := NewRequestFromParams(urlArg, params)
 	if errObj != nil {
@@ -105,7 +99,7 @@ func Handle(ctx context.Context, args ...object.Object) object.Object {
 
 func Builtins() map[string]object.Object {
 	return map[string]object.Object{
-		"fetch": object.NewBuiltin("fetch", Fetch),
+		"fetch": object.NewKeywordBuiltin("fetch", FetchWithKwargs),
 	}
 }
 
@@ -125,7 +119,7 @@ func Module(opts ...ModuleOpts) *object.Module {
 		"patch":   object.NewBuiltin("http.patch", MethodCmd(http.MethodPatch)),
 		"post":    object.NewBuiltin("http.post", MethodCmd(http.MethodPost)),
 		"put":     object.NewBuiltin("http.put", MethodCmd(http.MethodPut)),
-		"request": object.NewBuiltin("http.request", NewHttpRequest),
+		"request": object.NewKeywordBuiltin("http.request", NewHttpRequestWithKwargs),
 	}
 	if listenersAllowed {
 		builtins["listen_and_serve"] = object.NewBuiltin("http.listen_and_serve", ListenAndServe)
@@ -16,11 +16,10 @@ func Unmarshal(ctx context.Context, args ...object.Object) object.Object {
 	if err != nil {
 		return err
 	}
-	var obj interface{}
-	if err := json.Unmarshal(data, &obj); err != nil {
-		return object.Errorf("value error: json.unmarshal failed with: %s", err.Error())
+	scriptObj, decodeErr := object.FromJSON(data)
+	if decodeErr != nil {
+		return object.Errorf("value error: json.unmarshal failed with: %s", decodeErr.Error())
 	}
-	scriptObj := object.FromGoType(obj)
 	if scriptObj == nil {
 		return object.TypeErrorf("type error: json.unmarshal failed")
 	}
@@ -303,27 +303,27 @@ func Inf(ctx context.Context, args ...object.Object) object.Object {
 
 func Module() *object.Module {
 	return object.NewBuiltinsModule("math", map[string]object.Object{
-		"abs":    object.NewBuiltin("abs", Abs),
-		"atan2":  object.NewBuiltin("atan2", Atan2),
-		"ceil":   object.NewBuiltin("ceil", Ceil),
-		"cos":    object.NewBuiltin("cos", Cos),
+		"abs":    object.NewBuiltin("abs", Abs).WithSignature(object.MustParseSignature("(x: int | float) -> int | float")),
+		"atan2":  object.NewBuiltin("atan2", Atan2).WithSignature(object.MustParseSignature("(y: int | float | byte | bigint | decimal, x: int | float | byte | bigint | decimal) -> float")),
+		"ceil":   object.NewBuiltin("ceil", Ceil).WithSignature(object.MustParseSignature("(x: int | float) -> int | float")),
+		"cos":    object.NewBuiltin("cos", Cos).WithSignature(object.MustParseSignature("(x: int | float) -> float")),
 		"E":      object.NewFloat(math.E),
-		"floor":  object.NewBuiltin("floor", Floor),
-		"inf":    object.NewBuiltin("inf", Inf),
-		"is_inf": object.NewBuiltin("is_inf", IsInf),
-		"log":    object.NewBuiltin("log", Log),
-		"log10":  object.NewBuiltin("log10", Log10),
-		"log2":   object.NewBuiltin("log2", Log2),
-		"max":    object.NewBuiltin("max", Max),
-		"min":    object.NewBuiltin("min", Min),
-		"mod":    object.NewBuiltin("mod", Mod),
+		"floor":  object.NewBuiltin("floor", Floor).WithSignature(object.MustParseSignature("(x: int | float) -> int | float")),
+		"inf":    object.NewBuiltin("inf", Inf).WithSignature(object.MustParseSignature("(sign?: int | byte | bigint) -> float")),
+		"is_inf": object.NewBuiltin("is_inf", IsInf).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal) -> bool")),
+		"log":    object.NewBuiltin("log", Log).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal) -> float")),
+		"log10":  object.NewBuiltin("log10", Log10).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal) -> float")),
+		"log2":   object.NewBuiltin("log2", Log2).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal) -> float")),
+		"max":    object.NewBuiltin("max", Max).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal, y: int | float | byte | bigint | decimal) -> float")),
+		"min":    object.NewBuiltin("min", Min).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal, y: int | float | byte | bigint | decimal) -> float")),
+		"mod":    object.NewBuiltin("mod", Mod).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal, y: int | float | byte | bigint | decimal) -> float")),
 		"PI":     object.NewFloat(math.Pi),
-		"pow":    object.NewBuiltin("pow", Pow),
-		"pow10":  object.NewBuiltin("pow10", Pow10),
-		"round":  object.NewBuiltin("round", Round),
-		"sin":    object.NewBuiltin("sin", Sin),
-		"sqrt":   object.NewBuiltin("sqrt", Sqrt),
-		"sum":    object.NewBuiltin("sum", Sum),
-		"tan":    object.NewBuiltin("tan", Tan),
+		"pow":    object.NewBuiltin("pow", Pow).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal, y: int | float | byte | bigint | decimal) -> float")),
+		"pow10":  object.NewBuiltin("pow10", Pow10).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal) -> float")),
+		"round":  object.NewBuiltin("round", Round).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal) -> float")),
+		"sin":    object.NewBuiltin("sin", Sin).WithSignature(object.MustParseSignature("(x: int | float) -> float")),
+		"sqrt":   object.NewBuiltin("sqrt", Sqrt).WithSignature(object.MustParseSignature("(x: int | float) -> float")),
+		"sum":    object.NewBuiltin("sum", Sum).WithSignature(object.MustParseSignature("(items: list | set) -> float")),
+		"tan":    object.NewBuiltin("tan", Tan).WithSignature(object.MustParseSignature("(x: int | float | byte | bigint | decimal) -> float")),
 	})
 }
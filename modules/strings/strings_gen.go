@@ -331,24 +331,42 @@ func TrimSpace(ctx context.Context, args ...object.Object) object.Object {
 //
 // Useful if you want to write your own "Module()" function.
 func addGeneratedBuiltins(builtins map[string]object.Object) map[string]object.Object {
-	builtins["contains"] = object.NewBuiltin("contains", Contains)
-	builtins["has_prefix"] = object.NewBuiltin("has_prefix", HasPrefix)
-	builtins["has_suffix"] = object.NewBuiltin("has_suffix", HasSuffix)
-	builtins["count"] = object.NewBuiltin("count", Count)
-	builtins["compare"] = object.NewBuiltin("compare", Compare)
-	builtins["repeat"] = object.NewBuiltin("repeat", Repeat)
-	builtins["join"] = object.NewBuiltin("join", Join)
-	builtins["split"] = object.NewBuiltin("split", Split)
-	builtins["fields"] = object.NewBuiltin("fields", Fields)
-	builtins["index"] = object.NewBuiltin("index", Index)
-	builtins["last_index"] = object.NewBuiltin("last_index", LastIndex)
-	builtins["replace_all"] = object.NewBuiltin("replace_all", ReplaceAll)
-	builtins["to_lower"] = object.NewBuiltin("to_lower", ToLower)
-	builtins["to_upper"] = object.NewBuiltin("to_upper", ToUpper)
-	builtins["trim"] = object.NewBuiltin("trim", Trim)
-	builtins["trim_prefix"] = object.NewBuiltin("trim_prefix", TrimPrefix)
-	builtins["trim_suffix"] = object.NewBuiltin("trim_suffix", TrimSuffix)
-	builtins["trim_space"] = object.NewBuiltin("trim_space", TrimSpace)
+	builtins["contains"] = object.NewBuiltin("contains", Contains).
+		WithSignature(object.MustParseSignature("(s: string, substr: string) -> bool"))
+	builtins["has_prefix"] = object.NewBuiltin("has_prefix", HasPrefix).
+		WithSignature(object.MustParseSignature("(s: string, prefix: string) -> bool"))
+	builtins["has_suffix"] = object.NewBuiltin("has_suffix", HasSuffix).
+		WithSignature(object.MustParseSignature("(s: string, suffix: string) -> bool"))
+	builtins["count"] = object.NewBuiltin("count", Count).
+		WithSignature(object.MustParseSignature("(s: string, substr: string) -> int"))
+	builtins["compare"] = object.NewBuiltin("compare", Compare).
+		WithSignature(object.MustParseSignature("(a: string, b: string) -> int"))
+	builtins["repeat"] = object.NewBuiltin("repeat", Repeat).
+		WithSignature(object.MustParseSignature("(s: string, count: int | byte) -> string"))
+	builtins["join"] = object.NewBuiltin("join", Join).
+		WithSignature(object.MustParseSignature("(list: list, sep: string) -> string"))
+	builtins["split"] = object.NewBuiltin("split", Split).
+		WithSignature(object.MustParseSignature("(s: string, sep: string) -> list"))
+	builtins["fields"] = object.NewBuiltin("fields", Fields).
+		WithSignature(object.MustParseSignature("(s: string) -> list"))
+	builtins["index"] = object.NewBuiltin("index", Index).
+		WithSignature(object.MustParseSignature("(s: string, substr: string) -> int"))
+	builtins["last_index"] = object.NewBuiltin("last_index", LastIndex).
+		WithSignature(object.MustParseSignature("(s: string, substr: string) -> int"))
+	builtins["replace_all"] = object.NewBuiltin("replace_all", ReplaceAll).
+		WithSignature(object.MustParseSignature("(s: string, old: string, new: string) -> string"))
+	builtins["to_lower"] = object.NewBuiltin("to_lower", ToLower).
+		WithSignature(object.MustParseSignature("(s: string) -> string"))
+	builtins["to_upper"] = object.NewBuiltin("to_upper", ToUpper).
+		WithSignature(object.MustParseSignature("(s: string) -> string"))
+	builtins["trim"] = object.NewBuiltin("trim", Trim).
+		WithSignature(object.MustParseSignature("(s: string, cutset: string) -> string"))
+	builtins["trim_prefix"] = object.NewBuiltin("trim_prefix", TrimPrefix).
+		WithSignature(object.MustParseSignature("(s: string, prefix: string) -> string"))
+	builtins["trim_suffix"] = object.NewBuiltin("trim_suffix", TrimSuffix).
+		WithSignature(object.MustParseSignature("(s: string, prefix: string) -> string"))
+	builtins["trim_space"] = object.NewBuiltin("trim_space", TrimSpace).
+		WithSignature(object.MustParseSignature("(s: string) -> string"))
 	return builtins
 }
 
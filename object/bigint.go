@@ -0,0 +1,160 @@
+package object
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+// BigInt wraps big.Int and implements Object and Hashable interfaces. Unlike
+// Int, a BigInt has arbitrary precision and never overflows.
+type BigInt struct {
+	*base
+	value *big.Int
+}
+
+func (b *BigInt) Inspect() string {
+	return b.value.String()
+}
+
+func (b *BigInt) Type() Type {
+	return BIGINT
+}
+
+// Value returns the underlying big.Int, which must not be modified.
+func (b *BigInt) Value() *big.Int {
+	return b.value
+}
+
+func (b *BigInt) HashKey() HashKey {
+	return HashKey{Type: b.Type(), StrValue: b.value.String()}
+}
+
+func (b *BigInt) Interface() interface{} {
+	return new(big.Int).Set(b.value)
+}
+
+func (b *BigInt) String() string {
+	return b.Inspect()
+}
+
+func (b *BigInt) Compare(other Object) (int, error) {
+	switch other := other.(type) {
+	case *BigInt:
+		return b.value.Cmp(other.value), nil
+	case *Int:
+		return b.value.Cmp(big.NewInt(other.value)), nil
+	case *Byte:
+		return b.value.Cmp(big.NewInt(int64(other.value))), nil
+	case *Float:
+		return compareFloat(new(big.Float).SetInt(b.value), other.value), nil
+	case *Decimal:
+		return newDecimal(b.value, 0).cmp(other), nil
+	default:
+		return 0, errz.TypeErrorf("type error: unable to compare bigint and %s", other.Type())
+	}
+}
+
+func (b *BigInt) Equals(other Object) Object {
+	switch other.(type) {
+	case *BigInt, *Int, *Byte, *Float, *Decimal:
+		if value, err := b.Compare(other); err == nil && value == 0 {
+			return True
+		}
+	}
+	return False
+}
+
+func (b *BigInt) IsTruthy() bool {
+	return b.value.Sign() != 0
+}
+
+func (b *BigInt) RunOperation(opType op.BinaryOpType, right Object) Object {
+	switch right := right.(type) {
+	case *BigInt:
+		return b.runOperationBigInt(opType, right.value)
+	case *Int:
+		return b.runOperationBigInt(opType, big.NewInt(right.value))
+	case *Byte:
+		return b.runOperationBigInt(opType, big.NewInt(int64(right.value)))
+	case *Float:
+		f, _ := new(big.Float).SetInt(b.value).Float64()
+		return NewFloat(f).RunOperation(opType, right)
+	case *Decimal:
+		return newDecimal(b.value, 0).RunOperation(opType, right)
+	default:
+		return TypeErrorf("type error: unsupported operation for bigint: %v on type %s", opType, right.Type())
+	}
+}
+
+func (b *BigInt) runOperationBigInt(opType op.BinaryOpType, right *big.Int) Object {
+	result := new(big.Int)
+	switch opType {
+	case op.Add:
+		result.Add(b.value, right)
+	case op.Subtract:
+		result.Sub(b.value, right)
+	case op.Multiply:
+		result.Mul(b.value, right)
+	case op.Divide:
+		if right.Sign() == 0 {
+			return Errorf("value error: division by zero")
+		}
+		result.Quo(b.value, right)
+	case op.Modulo:
+		if right.Sign() == 0 {
+			return Errorf("value error: division by zero")
+		}
+		result.Rem(b.value, right)
+	case op.Xor:
+		result.Xor(b.value, right)
+	case op.Power:
+		if right.Sign() < 0 {
+			return Errorf("value error: negative exponent for bigint (%s given)", right)
+		}
+		result.Exp(b.value, right, nil)
+	case op.LShift, op.RShift:
+		if right.Sign() < 0 || !right.IsInt64() || right.Int64() > math.MaxUint32 {
+			return Errorf("value error: invalid shift count for bigint (%s given)", right)
+		}
+		if opType == op.LShift {
+			result.Lsh(b.value, uint(right.Int64()))
+		} else {
+			result.Rsh(b.value, uint(right.Int64()))
+		}
+	case op.BitwiseAnd:
+		result.And(b.value, right)
+	case op.BitwiseOr:
+		result.Or(b.value, right)
+	default:
+		return TypeErrorf("type error: unsupported operation for bigint: %v on type bigint", opType)
+	}
+	return NewBigInt(result)
+}
+
+func (b *BigInt) MarshalJSON() ([]byte, error) {
+	// JSON numbers have arbitrary precision, so the digits are written as-is
+	return []byte(b.value.String()), nil
+}
+
+// NewBigInt returns a BigInt holding a copy of the given value.
+func NewBigInt(value *big.Int) *BigInt {
+	return &BigInt{value: new(big.Int).Set(value)}
+}
+
+// compareFloat compares an exact value with a float. NaN compares as less
+// than every other value, as there is no meaningful ordering.
+func compareFloat(value *big.Float, f float64) int {
+	if math.IsNaN(f) {
+		return -1
+	}
+	if math.IsInf(f, 0) {
+		if f > 0 {
+			return -1
+		}
+		return 1
+	}
+	return value.Cmp(big.NewFloat(f))
+}
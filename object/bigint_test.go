@@ -0,0 +1,109 @@
+package object
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/risor-io/risor/op"
+	"github.com/stretchr/testify/require"
+)
+
+func mustBigInt(t *testing.T, s string) *BigInt {
+	t.Helper()
+	value, ok := new(big.Int).SetString(s, 10)
+	require.True(t, ok)
+	return NewBigInt(value)
+}
+
+func TestBigIntBasics(t *testing.T) {
+	value := mustBigInt(t, "-123456789012345678901234567890")
+	require.Equal(t, BIGINT, value.Type())
+	require.Equal(t, "-123456789012345678901234567890", value.String())
+	require.Equal(t, "-123456789012345678901234567890", value.Inspect())
+	require.Equal(t, value.Value(), value.Interface())
+	require.True(t, value.IsTruthy())
+	require.False(t, NewBigInt(new(big.Int)).IsTruthy())
+}
+
+func TestBigIntOperations(t *testing.T) {
+	max := NewBigInt(big.NewInt(9223372036854775807))
+	tests := []struct {
+		left     Object
+		opType   op.BinaryOpType
+		right    Object
+		expected Object
+	}{
+		{max, op.Add, NewInt(1), mustBigInt(t, "9223372036854775808")},
+		{max, op.Multiply, NewInt(2), mustBigInt(t, "18446744073709551614")},
+		{NewInt(2), op.Power, NewBigInt(big.NewInt(70)), mustBigInt(t, "1180591620717411303424")},
+		{NewBigInt(big.NewInt(-7)), op.Divide, NewInt(2), NewBigInt(big.NewInt(-3))},
+		{NewBigInt(big.NewInt(-7)), op.Modulo, NewInt(2), NewBigInt(big.NewInt(-1))},
+		{NewBigInt(big.NewInt(1)), op.LShift, NewInt(64), mustBigInt(t, "18446744073709551616")},
+		{NewBigInt(big.NewInt(6)), op.BitwiseAnd, NewByte(3), NewBigInt(big.NewInt(2))},
+		{NewBigInt(big.NewInt(3)), op.Add, NewFloat(0.5), NewFloat(3.5)},
+		{NewFloat(0.5), op.Add, NewBigInt(big.NewInt(3)), NewFloat(3.5)},
+		{NewBigInt(big.NewInt(3)), op.Add, NewDecimal(big.NewInt(25), 2), NewDecimal(big.NewInt(325), 2)},
+	}
+	for _, tc := range tests {
+		result := tc.left.RunOperation(tc.opType, tc.right)
+		require.Equal(t, tc.expected.Type(), result.Type(), "%v %v %v", tc.left, tc.opType, tc.right)
+		require.Equal(t, tc.expected.Inspect(), result.Inspect(), "%v %v %v", tc.left, tc.opType, tc.right)
+	}
+}
+
+func TestBigIntOperationErrors(t *testing.T) {
+	one := NewBigInt(big.NewInt(1))
+	tests := []struct {
+		opType   op.BinaryOpType
+		right    Object
+		expected string
+	}{
+		{op.Divide, NewInt(0), "value error: division by zero"},
+		{op.Modulo, NewBigInt(new(big.Int)), "value error: division by zero"},
+		{op.Power, NewInt(-1), "value error: negative exponent for bigint (-1 given)"},
+		{op.LShift, NewInt(-1), "value error: invalid shift count for bigint (-1 given)"},
+		{op.Add, NewString("x"), "type error: unsupported operation for bigint: + on type string"},
+	}
+	for _, tc := range tests {
+		result, ok := one.RunOperation(tc.opType, tc.right).(*Error)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, result.Message().Value())
+	}
+}
+
+func TestBigIntCompare(t *testing.T) {
+	big := mustBigInt(t, "100000000000000000000")
+	tests := []struct {
+		first    Comparable
+		second   Object
+		expected int
+	}{
+		{big, NewInt(1), 1},
+		{NewInt(1), big, -1},
+		{big, NewFloat(1e20), 0},
+		{NewFloat(1e21), big, 1},
+		{big, big, 0},
+		{big, NewDecimal(big.Value(), 0), 0},
+		{NewBigInt(mustBigInt(t, "3").Value()), NewDecimal(big.Value(), 20), 1},
+	}
+	for _, tc := range tests {
+		result, err := tc.first.Compare(tc.second)
+		require.Nil(t, err)
+		require.Equal(t, tc.expected, result, "first: %v, second: %v", tc.first, tc.second)
+	}
+	require.Equal(t, True, big.Equals(NewFloat(1e20)))
+	require.Equal(t, True, NewInt(5).Equals(NewBigInt(mustBigInt(t, "5").Value())))
+	require.Equal(t, False, big.Equals(NewString("100000000000000000000")))
+}
+
+func TestBigIntJSON(t *testing.T) {
+	value := mustBigInt(t, "123456789012345678901234567890")
+	data, err := json.Marshal(NewList([]Object{value}))
+	require.Nil(t, err)
+	require.Equal(t, "[123456789012345678901234567890]", string(data))
+
+	decoded, err := FromJSON(data)
+	require.Nil(t, err)
+	require.Equal(t, NewList([]Object{value}), decoded)
+}
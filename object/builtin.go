@@ -8,11 +8,18 @@ import (
 	"github.com/risor-io/risor/op"
 )
 
-var _ Callable = (*Builtin)(nil) // Ensure that *Builtin implements Callable
+var (
+	_ Callable        = (*Builtin)(nil) // Ensure that *Builtin implements Callable
+	_ KeywordCallable = (*Builtin)(nil) // Ensure that *Builtin implements KeywordCallable
+)
 
 // BuiltinFunction holds the type of a built-in function.
 type BuiltinFunction func(ctx context.Context, args ...Object) Object
 
+// KeywordBuiltinFunction holds the type of a built-in function that accepts
+// keyword arguments. The kwargs map is never nil.
+type KeywordBuiltinFunction func(ctx context.Context, kwargs *Map, args ...Object) Object
+
 // Builtin wraps func and implements Object interface.
 type Builtin struct {
 	*base
@@ -20,6 +27,9 @@ type Builtin struct {
 	// The function that this object wraps.
 	fn BuiltinFunction
 
+	// The keyword-accepting function that this object wraps (optional).
+	kwfn KeywordBuiltinFunction
+
 	// The name of the function.
 	name string
 
@@ -33,6 +43,9 @@ type Builtin struct {
 	// If true, this function is built to handle errors and it should be
 	// invoked even if one of its parameters evaluates to an error.
 	isErrorHandler bool
+
+	// Describes the parameters and return type of the function (optional).
+	signature *Signature
 }
 
 func (b *Builtin) Type() Type {
@@ -51,10 +64,39 @@ func (b *Builtin) IsErrorHandler() bool {
 	return b.isErrorHandler
 }
 
+// Signature returns the signature of the builtin, or nil if it is unknown.
+func (b *Builtin) Signature() *Signature {
+	return b.signature
+}
+
+// WithSignature sets the signature of the builtin and returns the builtin.
+func (b *Builtin) WithSignature(sig *Signature) *Builtin {
+	b.signature = sig
+	return b
+}
+
 func (b *Builtin) Call(ctx context.Context, args ...Object) Object {
 	return b.fn(ctx, args...)
 }
 
+// AcceptsKeywords returns true if the builtin accepts keyword arguments.
+func (b *Builtin) AcceptsKeywords() bool {
+	return b.kwfn != nil
+}
+
+func (b *Builtin) CallWithKeywords(ctx context.Context, kwargs *Map, args ...Object) Object {
+	if b.kwfn == nil {
+		if kwargs == nil || kwargs.Size() == 0 {
+			return b.fn(ctx, args...)
+		}
+		return TypeErrorf("type error: %s() does not accept keyword arguments", b.Key())
+	}
+	if kwargs == nil {
+		kwargs = NewMap(nil)
+	}
+	return b.kwfn(ctx, kwargs, args...)
+}
+
 func (b *Builtin) Inspect() string {
 	if b.module == nil {
 		return fmt.Sprintf("builtin(%s)", b.name)
@@ -147,6 +189,16 @@ func NewBuiltin(name string, fn BuiltinFunction, module ...*Module) *Builtin {
 	return b
 }
 
+// NewKeywordBuiltin creates a builtin function that accepts keyword arguments.
+// When called without keyword arguments, fn receives an empty map.
+func NewKeywordBuiltin(name string, fn KeywordBuiltinFunction, module ...*Module) *Builtin {
+	b := NewBuiltin(name, func(ctx context.Context, args ...Object) Object {
+		return fn(ctx, NewMap(nil), args...)
+	}, module...)
+	b.kwfn = fn
+	return b
+}
+
 func NewErrorHandler(name string, fn BuiltinFunction, module ...*Module) *Builtin {
 	b := NewBuiltin(name, fn, module...)
 	b.isErrorHandler = true
@@ -12,6 +12,11 @@ type CallFunc func(ctx context.Context, fn *Function, args []Object) (Object, er
 // SpawnFunc is a type signature for a function that can spawn a Risor thread.
 type SpawnFunc func(ctx context.Context, fn Callable, args []Object) (*Thread, error)
 
+// ResumeFunc is a type signature for a function that resumes a suspended
+// generator. It returns the next yielded value and a bool that is false once
+// the generator has finished.
+type ResumeFunc func(ctx context.Context, gen *Generator) (Object, bool, error)
+
 ////////////////////////////////////////////////////////////////////////////////
 
 const callFuncKey = contextKey("risor:call")
@@ -74,3 +79,23 @@ func GetCloneCallFunc(ctx context.Context) (CallFunc, bool) {
 	}
 	return nil, false
 }
+
+////////////////////////////////////////////////////////////////////////////////
+
+const resumeFuncKey = contextKey("risor:resume")
+
+// WithResumeFunc adds a ResumeFunc to the context, which can be used by
+// generators to continue running their function.
+func WithResumeFunc(ctx context.Context, fn ResumeFunc) context.Context {
+	return context.WithValue(ctx, resumeFuncKey, fn)
+}
+
+// GetResumeFunc returns the ResumeFunc from the context, if it exists.
+func GetResumeFunc(ctx context.Context) (ResumeFunc, bool) {
+	if fn, ok := ctx.Value(resumeFuncKey).(ResumeFunc); ok {
+		if fn != nil {
+			return fn, ok
+		}
+	}
+	return nil, false
+}
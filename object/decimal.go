@@ -0,0 +1,357 @@
+package object
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+// DecimalDivisionScale is the minimum number of digits kept after the decimal
+// point when dividing decimals. Results are rounded half to even.
+const DecimalDivisionScale = 16
+
+// MaxDecimalExponent is the largest exponent, in either direction, accepted
+// by ParseDecimal, and the largest number of places accepted by round. Larger
+// values take too long to expand, and may come from untrusted input such as
+// JSON documents.
+const MaxDecimalExponent = 10000
+
+// Decimal is an exact base 10 number with a fixed number of digits after the
+// decimal point, which makes it suitable for currency and other values that
+// floats are unable to represent exactly. It implements Object and Hashable.
+//
+// The number is stored as an unscaled integer and a scale, so that the
+// decimal "12.50" is stored as 1250 with a scale of 2. Addition, subtraction
+// and multiplication are always exact.
+type Decimal struct {
+	*base
+	value *big.Int
+	scale int32
+}
+
+func (d *Decimal) Inspect() string {
+	return d.String()
+}
+
+func (d *Decimal) Type() Type {
+	return DECIMAL
+}
+
+// Unscaled returns the unscaled value of the decimal, which must not be
+// modified.
+func (d *Decimal) Unscaled() *big.Int {
+	return d.value
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d *Decimal) Scale() int32 {
+	return d.scale
+}
+
+// Rat returns the decimal as a big.Rat.
+func (d *Decimal) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(d.value, pow10(d.scale))
+}
+
+// Int returns the integer part of the decimal, truncated toward zero.
+func (d *Decimal) Int() *big.Int {
+	return new(big.Int).Quo(d.value, pow10(d.scale))
+}
+
+// Float64 returns the nearest float64 to the decimal.
+func (d *Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+func (d *Decimal) HashKey() HashKey {
+	// Trailing zeros are dropped so that equal decimals have equal keys
+	return HashKey{Type: d.Type(), StrValue: d.normalize().String()}
+}
+
+func (d *Decimal) Interface() interface{} {
+	return d.Rat()
+}
+
+func (d *Decimal) String() string {
+	digits := new(big.Int).Abs(d.value).String()
+	var sb strings.Builder
+	if d.value.Sign() < 0 {
+		sb.WriteByte('-')
+	}
+	if d.scale == 0 {
+		sb.WriteString(digits)
+		return sb.String()
+	}
+	scale := int(d.scale)
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+	sb.WriteString(digits[:len(digits)-scale])
+	sb.WriteByte('.')
+	sb.WriteString(digits[len(digits)-scale:])
+	return sb.String()
+}
+
+func (d *Decimal) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "round":
+		return NewBuiltin("decimal.round", d.round), true
+	case "scale":
+		return NewBuiltin("decimal.scale", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError("decimal.scale", 0, len(args))
+			}
+			return NewInt(int64(d.scale))
+		}), true
+	default:
+		return nil, false
+	}
+}
+
+// round returns the decimal rounded half to even to the given number of
+// digits after the decimal point, which defaults to zero.
+func (d *Decimal) round(ctx context.Context, args ...Object) Object {
+	if len(args) > 1 {
+		return NewArgsRangeError("decimal.round", 0, 1, len(args))
+	}
+	var places int64
+	if len(args) == 1 {
+		var err *Error
+		if places, err = AsInt(args[0]); err != nil {
+			return err
+		}
+		if places < 0 {
+			return Errorf("value error: decimal.round() places must be non-negative (%d given)", places)
+		}
+		if places > MaxDecimalExponent {
+			return Errorf("value error: decimal.round() places must be at most %d (%d given)",
+				MaxDecimalExponent, places)
+		}
+	}
+	return d.rescale(int32(places))
+}
+
+func (d *Decimal) Compare(other Object) (int, error) {
+	switch other := other.(type) {
+	case *Decimal:
+		return d.cmp(other), nil
+	case *BigInt:
+		return d.cmp(newDecimal(other.value, 0)), nil
+	case *Int:
+		return d.cmp(newDecimal(big.NewInt(other.value), 0)), nil
+	case *Byte:
+		return d.cmp(newDecimal(big.NewInt(int64(other.value)), 0)), nil
+	case *Float:
+		return compareFloat(new(big.Float).SetRat(d.Rat()), other.value), nil
+	default:
+		return 0, errz.TypeErrorf("type error: unable to compare decimal and %s", other.Type())
+	}
+}
+
+func (d *Decimal) Equals(other Object) Object {
+	switch other.(type) {
+	case *Decimal, *BigInt, *Int, *Byte, *Float:
+		if value, err := d.Compare(other); err == nil && value == 0 {
+			return True
+		}
+	}
+	return False
+}
+
+func (d *Decimal) IsTruthy() bool {
+	return d.value.Sign() != 0
+}
+
+func (d *Decimal) RunOperation(opType op.BinaryOpType, right Object) Object {
+	switch right := right.(type) {
+	case *Decimal:
+		return d.runOperationDecimal(opType, right)
+	case *BigInt:
+		return d.runOperationDecimal(opType, newDecimal(right.value, 0))
+	case *Int:
+		return d.runOperationDecimal(opType, newDecimal(big.NewInt(right.value), 0))
+	case *Byte:
+		return d.runOperationDecimal(opType, newDecimal(big.NewInt(int64(right.value)), 0))
+	case *Float:
+		// Mixing floats into exact arithmetic would silently lose precision
+		return TypeErrorf("type error: unsupported operation for decimal: %v on type float (use decimal() to convert the float)", opType)
+	default:
+		return TypeErrorf("type error: unsupported operation for decimal: %v on type %s", opType, right.Type())
+	}
+}
+
+func (d *Decimal) runOperationDecimal(opType op.BinaryOpType, right *Decimal) Object {
+	scale := max(d.scale, right.scale)
+	switch opType {
+	case op.Add:
+		return newDecimal(new(big.Int).Add(d.scaled(scale), right.scaled(scale)), scale)
+	case op.Subtract:
+		return newDecimal(new(big.Int).Sub(d.scaled(scale), right.scaled(scale)), scale)
+	case op.Multiply:
+		return newDecimal(new(big.Int).Mul(d.value, right.value), d.scale+right.scale)
+	case op.Divide:
+		if right.value.Sign() == 0 {
+			return Errorf("value error: division by zero")
+		}
+		// Divide at a scale that is large enough to be useful, then trim any
+		// trailing zeros that go beyond the scale of the operands
+		target := max(scale, DecimalDivisionScale)
+		numerator := new(big.Int).Mul(d.value, pow10(target+right.scale-d.scale))
+		result := newDecimal(quoHalfEven(numerator, right.value), target)
+		return result.trim(scale)
+	case op.Modulo:
+		if right.value.Sign() == 0 {
+			return Errorf("value error: division by zero")
+		}
+		return newDecimal(new(big.Int).Rem(d.scaled(scale), right.scaled(scale)), scale)
+	case op.Power:
+		exp := right.normalize()
+		if exp.scale > 0 {
+			return Errorf("value error: decimal exponent must be an integer (%s given)", right.String())
+		}
+		// The scale of the result is the scale of the base times the exponent
+		limit := int64(math.MaxInt32) / max(int64(d.scale), 1)
+		if exp.value.Sign() < 0 || !exp.value.IsInt64() || exp.value.Int64() > limit {
+			return Errorf("value error: invalid exponent for decimal (%s given)", right.String())
+		}
+		return newDecimal(new(big.Int).Exp(d.value, exp.value, nil), d.scale*int32(exp.value.Int64()))
+	default:
+		return TypeErrorf("type error: unsupported operation for decimal: %v on type decimal", opType)
+	}
+}
+
+func (d *Decimal) MarshalJSON() ([]byte, error) {
+	// JSON numbers have arbitrary precision, so the digits are written as-is
+	return []byte(d.String()), nil
+}
+
+func (d *Decimal) cmp(other *Decimal) int {
+	scale := max(d.scale, other.scale)
+	return d.scaled(scale).Cmp(other.scaled(scale))
+}
+
+// scaled returns the unscaled value of the decimal at the given scale, which
+// must not be less than the scale of the decimal.
+func (d *Decimal) scaled(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.value
+	}
+	return new(big.Int).Mul(d.value, pow10(scale-d.scale))
+}
+
+// rescale returns the decimal with the given scale, rounding half to even if
+// digits are removed.
+func (d *Decimal) rescale(scale int32) *Decimal {
+	if scale >= d.scale {
+		return newDecimal(d.scaled(scale), scale)
+	}
+	return newDecimal(quoHalfEven(d.value, pow10(d.scale-scale)), scale)
+}
+
+// trim removes trailing zeros after the decimal point, while keeping at least
+// the given number of digits.
+func (d *Decimal) trim(scale int32) *Decimal {
+	value := new(big.Int).Set(d.value)
+	result := d.scale
+	ten := big.NewInt(10)
+	remainder := new(big.Int)
+	quotient := new(big.Int)
+	for result > scale {
+		quotient.QuoRem(value, ten, remainder)
+		if remainder.Sign() != 0 {
+			break
+		}
+		value.Set(quotient)
+		result--
+	}
+	return newDecimal(value, result)
+}
+
+// normalize returns the decimal without any trailing zeros.
+func (d *Decimal) normalize() *Decimal {
+	return d.trim(0)
+}
+
+// NewDecimal returns a Decimal equal to value * 10^-scale. The value is
+// copied. A negative scale multiplies the value by a power of ten.
+func NewDecimal(value *big.Int, scale int32) *Decimal {
+	if scale < 0 {
+		return newDecimal(new(big.Int).Mul(value, pow10(-scale)), 0)
+	}
+	return newDecimal(new(big.Int).Set(value), scale)
+}
+
+// newDecimal returns a Decimal that takes ownership of the given value.
+func newDecimal(value *big.Int, scale int32) *Decimal {
+	return &Decimal{value: value, scale: scale}
+}
+
+// ParseDecimal parses a decimal from a string such as "12.50", "-3" or
+// "1.5e3". The number of digits after the decimal point is preserved. An
+// error is returned if the exponent exceeds MaxDecimalExponent.
+func ParseDecimal(s string) (*Decimal, error) {
+	text := strings.TrimSpace(s)
+	mantissa, exponent, hasExponent := strings.Cut(strings.ToLower(text), "e")
+	var exp int64
+	if hasExponent {
+		var err error
+		if exp, err = strconv.ParseInt(exponent, 10, 64); err != nil {
+			if errors.Is(err, strconv.ErrRange) {
+				return nil, fmt.Errorf("invalid decimal %q: exponent out of range", s)
+			}
+			return nil, fmt.Errorf("invalid decimal %q", s)
+		}
+		if exp < -MaxDecimalExponent || exp > MaxDecimalExponent {
+			return nil, fmt.Errorf("invalid decimal %q: exponent out of range", s)
+		}
+	}
+	whole, fraction, _ := strings.Cut(mantissa, ".")
+	value, ok := new(big.Int).SetString(whole+fraction, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	scale := int64(len(fraction)) - exp
+	if scale < math.MinInt32 || scale > math.MaxInt32 {
+		return nil, fmt.Errorf("invalid decimal %q: exponent out of range", s)
+	}
+	return NewDecimal(value, int32(scale)), nil
+}
+
+// NewDecimalFromFloat returns the Decimal with the fewest digits that converts
+// back to the given float, so that 0.1 becomes exactly 0.1.
+func NewDecimalFromFloat(f float64) (*Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("cannot convert %v to decimal", f)
+	}
+	return ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+// quoHalfEven returns n / d rounded half to even.
+func quoHalfEven(n, d *big.Int) *big.Int {
+	quotient, remainder := new(big.Int).QuoRem(n, d, new(big.Int))
+	if remainder.Sign() == 0 {
+		return quotient
+	}
+	half := new(big.Int).Mul(new(big.Int).Abs(remainder), big.NewInt(2))
+	cmp := half.Cmp(new(big.Int).Abs(d))
+	if cmp > 0 || (cmp == 0 && quotient.Bit(0) == 1) {
+		if (n.Sign() < 0) != (d.Sign() < 0) {
+			quotient.Sub(quotient, big.NewInt(1))
+		} else {
+			quotient.Add(quotient, big.NewInt(1))
+		}
+	}
+	return quotient
+}
+
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
@@ -0,0 +1,209 @@
+package object
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/risor-io/risor/op"
+	"github.com/stretchr/testify/require"
+)
+
+func mustDecimal(t *testing.T, s string) *Decimal {
+	t.Helper()
+	value, err := ParseDecimal(s)
+	require.Nil(t, err)
+	return value
+}
+
+func TestDecimalBasics(t *testing.T) {
+	value := mustDecimal(t, "-12.50")
+	require.Equal(t, DECIMAL, value.Type())
+	require.Equal(t, "-12.50", value.String())
+	require.Equal(t, "-12.50", value.Inspect())
+	require.Equal(t, big.NewRat(-25, 2), value.Interface())
+	require.Equal(t, int32(2), value.Scale())
+	require.Equal(t, big.NewInt(-1250), value.Unscaled())
+	require.Equal(t, big.NewInt(-12), value.Int())
+	require.Equal(t, -12.5, value.Float64())
+	require.True(t, value.IsTruthy())
+	require.False(t, mustDecimal(t, "0.00").IsTruthy())
+}
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"0", "0"},
+		{"12.50", "12.50"},
+		{"-0.05", "-0.05"},
+		{".5", "0.5"},
+		{"+3", "3"},
+		{"1.5e3", "1500"},
+		{"1.5E-3", "0.0015"},
+		{" 7 ", "7"},
+	}
+	for _, tc := range tests {
+		value, err := ParseDecimal(tc.input)
+		require.Nil(t, err, tc.input)
+		require.Equal(t, tc.expected, value.String())
+	}
+	for _, input := range []string{"", ".", "-", "abc", "1.2.3", "1e", "0x10", "1_000", "1.-5"} {
+		_, err := ParseDecimal(input)
+		require.NotNil(t, err, input)
+	}
+}
+
+func TestParseDecimalHugeExponent(t *testing.T) {
+	// These must fail quickly rather than expand the exponent
+	for _, input := range []string{"1e1000000000", "1e-1000000000", "1e99999999999999999999", "1e10001"} {
+		_, err := ParseDecimal(input)
+		require.EqualError(t, err, fmt.Sprintf("invalid decimal %q: exponent out of range", input))
+	}
+	value, err := ParseDecimal("1e10000")
+	require.Nil(t, err)
+	require.Equal(t, 10001, len(value.String()))
+}
+
+func TestNewDecimalFromFloat(t *testing.T) {
+	value, err := NewDecimalFromFloat(0.1)
+	require.Nil(t, err)
+	require.Equal(t, "0.1", value.String())
+	_, err = NewDecimalFromFloat(math.Inf(1))
+	require.NotNil(t, err)
+}
+
+func TestDecimalOperations(t *testing.T) {
+	tests := []struct {
+		left     Object
+		opType   op.BinaryOpType
+		right    Object
+		expected string
+	}{
+		{mustDecimal(t, "0.1"), op.Add, mustDecimal(t, "0.2"), "0.3"},
+		{mustDecimal(t, "1.00"), op.Subtract, mustDecimal(t, "0.005"), "0.995"},
+		{mustDecimal(t, "12.50"), op.Multiply, NewInt(3), "37.50"},
+		{mustDecimal(t, "10.00"), op.Divide, NewInt(4), "2.50"},
+		{NewDecimal(big.NewInt(1), 0), op.Divide, NewInt(3), "0.3333333333333333"},
+		{NewDecimal(big.NewInt(2), 0), op.Divide, NewInt(3), "0.6666666666666667"},
+		{NewDecimal(big.NewInt(-2), 0), op.Divide, NewInt(3), "-0.6666666666666667"},
+		{mustDecimal(t, "1.20"), op.Modulo, mustDecimal(t, "0.5"), "0.20"},
+		{mustDecimal(t, "1.5"), op.Power, NewInt(2), "2.25"},
+		{NewInt(2), op.Subtract, mustDecimal(t, "0.01"), "1.99"},
+		{NewBigInt(big.NewInt(2)), op.Multiply, mustDecimal(t, "0.5"), "1.0"},
+	}
+	for _, tc := range tests {
+		result := tc.left.RunOperation(tc.opType, tc.right)
+		require.Equal(t, DECIMAL, result.Type(), "%v %v %v: %v", tc.left, tc.opType, tc.right, result)
+		require.Equal(t, tc.expected, result.Inspect(), "%v %v %v", tc.left, tc.opType, tc.right)
+	}
+}
+
+func TestDecimalOperationErrors(t *testing.T) {
+	one := mustDecimal(t, "1.0")
+	tests := []struct {
+		opType   op.BinaryOpType
+		right    Object
+		expected string
+	}{
+		{op.Divide, NewInt(0), "value error: division by zero"},
+		{op.Modulo, mustDecimal(t, "0.00"), "value error: division by zero"},
+		{op.Power, mustDecimal(t, "0.5"), "value error: decimal exponent must be an integer (0.5 given)"},
+		{op.Power, NewInt(-1), "value error: invalid exponent for decimal (-1 given)"},
+		{op.Add, NewFloat(1), "type error: unsupported operation for decimal: + on type float (use decimal() to convert the float)"},
+		{op.Xor, NewInt(1), "type error: unsupported operation for decimal: ^ on type decimal"},
+	}
+	for _, tc := range tests {
+		result, ok := one.RunOperation(tc.opType, tc.right).(*Error)
+		require.True(t, ok)
+		require.Equal(t, tc.expected, result.Message().Value())
+	}
+	result, ok := NewFloat(1).RunOperation(op.Add, one).(*Error)
+	require.True(t, ok)
+	require.Equal(t, "type error: unsupported operation for float: + on type decimal", result.Message().Value())
+}
+
+func TestDecimalRound(t *testing.T) {
+	tests := []struct {
+		input    string
+		places   int64
+		expected string
+	}{
+		{"2.675", 2, "2.68"},
+		{"2.665", 2, "2.66"},
+		{"-2.5", 0, "-2"},
+		{"3.5", 0, "4"},
+		{"1.2", 3, "1.200"},
+	}
+	for _, tc := range tests {
+		round, ok := mustDecimal(t, tc.input).GetAttr("round")
+		require.True(t, ok)
+		result := round.(*Builtin).Call(context.Background(), NewInt(tc.places))
+		require.Equal(t, tc.expected, result.Inspect())
+	}
+	round, _ := mustDecimal(t, "1.5").GetAttr("round")
+	result := round.(*Builtin).Call(context.Background(), NewInt(1000000000))
+	require.Equal(t, "value error: decimal.round() places must be at most 10000 (1000000000 given)",
+		result.(*Error).Message().Value())
+}
+
+func TestDecimalCompare(t *testing.T) {
+	tests := []struct {
+		first    Comparable
+		second   Object
+		expected int
+	}{
+		{mustDecimal(t, "1.50"), mustDecimal(t, "1.5"), 0},
+		{mustDecimal(t, "1.49"), mustDecimal(t, "1.5"), -1},
+		{mustDecimal(t, "2"), NewInt(1), 1},
+		{NewInt(1), mustDecimal(t, "2"), -1},
+		{mustDecimal(t, "0.5"), NewFloat(0.5), 0},
+		{NewFloat(0.25), mustDecimal(t, "0.5"), -1},
+	}
+	for _, tc := range tests {
+		result, err := tc.first.Compare(tc.second)
+		require.Nil(t, err)
+		require.Equal(t, tc.expected, result, "first: %v, second: %v", tc.first, tc.second)
+	}
+	require.Equal(t, True, mustDecimal(t, "5.00").Equals(NewInt(5)))
+	require.Equal(t, True, NewFloat(0.5).Equals(mustDecimal(t, "0.50")))
+	require.Equal(t, mustDecimal(t, "1.0").HashKey(), mustDecimal(t, "1.000").HashKey())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	data, err := json.Marshal(NewMap(map[string]Object{
+		"amount": mustDecimal(t, "12.50"),
+		"exact":  mustDecimal(t, "1234567890.123456789012"),
+	}))
+	require.Nil(t, err)
+	require.Equal(t, `{"amount":12.50,"exact":1234567890.123456789012}`, string(data))
+
+	decoded, err := FromJSON(data)
+	require.Nil(t, err)
+	m, ok := decoded.(*Map)
+	require.True(t, ok)
+	// Numbers that fit in a float are still decoded as floats
+	require.Equal(t, NewFloat(12.5), m.Get("amount"))
+	require.Equal(t, mustDecimal(t, "1234567890.123456789012"), m.Get("exact"))
+}
+
+func TestDecimalJSONHugeExponent(t *testing.T) {
+	for _, input := range []string{`1e1000000000`, `[1, -1E-1000000000]`, `{"a": 1.5e99999}`} {
+		_, err := FromJSON([]byte(input))
+		require.ErrorContains(t, err, "exponent out of range", input)
+	}
+	// Numbers that floats hold exactly take the fast path
+	decoded, err := FromJSON([]byte(`[0, -0.5, 1e300, 123456789012345, 5e-324, 9007199254740993]`))
+	require.Nil(t, err)
+	list := decoded.(*List).Value()
+	require.Equal(t, NewFloat(0), list[0])
+	require.Equal(t, NewFloat(-0.5), list[1])
+	require.Equal(t, NewFloat(1e300), list[2])
+	require.Equal(t, NewFloat(123456789012345), list[3])
+	require.Equal(t, NewFloat(5e-324), list[4])
+	require.Equal(t, NewBigInt(big.NewInt(9007199254740993)), list[5])
+}
@@ -2,6 +2,7 @@ package object
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/risor-io/risor/errz"
@@ -82,11 +83,36 @@ func (e *Error) GetAttr(name string) (Object, bool) {
 		return NewBuiltin("message", func(ctx context.Context, args ...Object) Object {
 			return e.Message()
 		}), true
+	case "stack":
+		return NewBuiltin("stack", func(ctx context.Context, args ...Object) Object {
+			stack := e.Stack()
+			items := make([]Object, 0, len(stack))
+			for _, frame := range stack {
+				items = append(items, NewMap(map[string]Object{
+					"function": NewString(frame.Function),
+					"file":     NewString(frame.File),
+					"line":     NewInt(int64(frame.Line)),
+					"column":   NewInt(int64(frame.Column)),
+				}))
+			}
+			return NewList(items)
+		}), true
 	default:
 		return nil, false
 	}
 }
 
+// Stack returns the Risor call stack that was active when the error occurred,
+// ordered from the innermost call outwards. Nil is returned if the error did
+// not occur while evaluating Risor code.
+func (e *Error) Stack() []errz.StackFrame {
+	var runtimeErr *errz.RuntimeError
+	if errors.As(e.err, &runtimeErr) {
+		return runtimeErr.Stack
+	}
+	return nil
+}
+
 func (e *Error) Message() *String {
 	return NewString(e.err.Error())
 }
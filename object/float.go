@@ -3,6 +3,7 @@ package object
 import (
 	"encoding/json"
 	"math"
+	"math/big"
 	"strconv"
 
 	"github.com/risor-io/risor/errz"
@@ -67,6 +68,9 @@ func (f *Float) Compare(other Object) (int, error) {
 			return 1, nil
 		}
 		return -1, nil
+	case *BigInt, *Decimal:
+		result, err := other.(Comparable).Compare(f)
+		return -result, err
 	default:
 		return 0, errz.TypeErrorf("type error: unable to compare float and %s", other.Type())
 	}
@@ -86,6 +90,8 @@ func (f *Float) Equals(other Object) Object {
 		if f.value == float64(other.value) {
 			return True
 		}
+	case *BigInt, *Decimal:
+		return other.Equals(f)
 	}
 	return False
 }
@@ -103,6 +109,9 @@ func (f *Float) RunOperation(opType op.BinaryOpType, right Object) Object {
 	case *Byte:
 		rightFloat := float64(right.value)
 		return f.runOperationFloat(opType, rightFloat)
+	case *BigInt:
+		rightFloat, _ := new(big.Float).SetInt(right.value).Float64()
+		return f.runOperationFloat(opType, rightFloat)
 	default:
 		return TypeErrorf("type error: unsupported operation for float: %v on type %s", opType, right.Type())
 	}
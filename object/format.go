@@ -0,0 +1,392 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// formatSpec is a parsed format specifier. The syntax follows the Python
+// format specification mini-language:
+//
+//	[[fill]align][sign][#][0][width][grouping][.precision][verb]
+type formatSpec struct {
+	fill      rune
+	align     rune // One of '<', '>', '^' or '=', or 0 for the default
+	sign      rune // One of '+', '-' or ' ', or 0 for the default
+	alternate bool
+	width     int
+	grouping  rune // One of ',' or '_', or 0 for none
+	precision int  // -1 if not given
+	verb      rune // 0 if not given
+}
+
+func parseFormatSpec(spec string) (*formatSpec, error) {
+	result := &formatSpec{fill: ' ', precision: -1}
+	runes := []rune(spec)
+	i := 0
+	isAlign := func(r rune) bool { return strings.ContainsRune("<>^=", r) }
+	if len(runes) >= 2 && isAlign(runes[1]) {
+		result.fill, result.align = runes[0], runes[1]
+		i = 2
+	} else if len(runes) >= 1 && isAlign(runes[0]) {
+		result.align = runes[0]
+		i = 1
+	}
+	if i < len(runes) && strings.ContainsRune("+- ", runes[i]) {
+		result.sign = runes[i]
+		i++
+	}
+	if i < len(runes) && runes[i] == '#' {
+		result.alternate = true
+		i++
+	}
+	if i < len(runes) && runes[i] == '0' {
+		// Zero padding places zeros between the sign and the digits
+		if result.align == 0 {
+			result.fill, result.align = '0', '='
+		}
+		i++
+	}
+	start := i
+	for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+		i++
+	}
+	if i > start {
+		width, err := strconv.Atoi(string(runes[start:i]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid format specifier %q", spec)
+		}
+		result.width = width
+	}
+	if i < len(runes) && (runes[i] == ',' || runes[i] == '_') {
+		result.grouping = runes[i]
+		i++
+	}
+	if i < len(runes) && runes[i] == '.' {
+		i++
+		start = i
+		for i < len(runes) && runes[i] >= '0' && runes[i] <= '9' {
+			i++
+		}
+		if i == start {
+			return nil, fmt.Errorf("invalid format specifier %q: missing precision", spec)
+		}
+		precision, err := strconv.Atoi(string(runes[start:i]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid format specifier %q", spec)
+		}
+		result.precision = precision
+	}
+	if i < len(runes) {
+		result.verb = runes[i]
+		i++
+	}
+	if i < len(runes) {
+		return nil, fmt.Errorf("invalid format specifier %q", spec)
+	}
+	return result, nil
+}
+
+// Format formats an object according to a format specifier, such as ".2f",
+// ">10" or "08d". The specifier syntax follows the Python format specification
+// mini-language. Times are formatted with strftime directives instead, as in
+// "%Y-%m-%d". Objects that are not numbers, strings or times are formatted
+// as strings using their Inspect representation.
+func Format(obj Object, spec string) (string, error) {
+	if t, ok := obj.(*Time); ok {
+		if spec == "" {
+			return t.Inspect(), nil
+		}
+		return strftime(t.value, spec)
+	}
+	parsed, err := parseFormatSpec(spec)
+	if err != nil {
+		return "", err
+	}
+	switch obj := obj.(type) {
+	case *Int:
+		return parsed.formatInt(big.NewInt(obj.value), obj.Type())
+	case *Byte:
+		return parsed.formatInt(big.NewInt(int64(obj.value)), obj.Type())
+	case *BigInt:
+		return parsed.formatInt(obj.value, obj.Type())
+	case *Float:
+		return parsed.formatFloat(obj.value, obj.Type())
+	case *Decimal:
+		return parsed.formatDecimal(obj)
+	case *String:
+		return parsed.formatString(obj.value, obj.Type())
+	default:
+		return parsed.formatString(obj.Inspect(), obj.Type())
+	}
+}
+
+func (f *formatSpec) invalid(typ Type) error {
+	if f.verb == 0 {
+		return fmt.Errorf("invalid format specifier for %s", typ)
+	}
+	return fmt.Errorf("invalid format specifier %q for %s", string(f.verb), typ)
+}
+
+func (f *formatSpec) formatInt(value *big.Int, typ Type) (string, error) {
+	var digits, prefix string
+	abs := new(big.Int).Abs(value)
+	switch f.verb {
+	case 0, 'd', 'n':
+		digits = abs.Text(10)
+	case 'b':
+		digits, prefix = abs.Text(2), "0b"
+	case 'o':
+		digits, prefix = abs.Text(8), "0o"
+	case 'x':
+		digits, prefix = abs.Text(16), "0x"
+	case 'X':
+		digits, prefix = strings.ToUpper(abs.Text(16)), "0X"
+	case 'c':
+		if f.sign != 0 || f.alternate || !value.IsInt64() || value.Int64() < 0 || value.Int64() > utf8.MaxRune {
+			return "", f.invalid(typ)
+		}
+		return f.pad("", string(rune(value.Int64())), '<'), nil
+	case 'e', 'E', 'f', 'F', 'g', 'G', '%':
+		fl, _ := new(big.Float).SetInt(value).Float64()
+		return f.formatFloat(fl, typ)
+	default:
+		return "", f.invalid(typ)
+	}
+	if f.precision >= 0 {
+		return "", fmt.Errorf("precision is not allowed in an integer format specifier")
+	}
+	if f.grouping != 0 {
+		size := 3
+		if prefix != "" {
+			size = 4
+		}
+		digits = group(digits, f.grouping, size)
+	}
+	if !f.alternate {
+		prefix = ""
+	}
+	return f.pad(f.signOf(value.Sign() < 0)+prefix, digits, '>'), nil
+}
+
+func (f *formatSpec) formatFloat(value float64, typ Type) (string, error) {
+	negative := math.Signbit(value) && !math.IsNaN(value)
+	abs := math.Abs(value)
+	verb := f.verb
+	precision := f.precision
+	var digits string
+	switch verb {
+	case 0:
+		if precision < 0 {
+			digits = strconv.FormatFloat(abs, 'f', -1, 64)
+		} else {
+			digits = strconv.FormatFloat(abs, 'g', max(precision, 1), 64)
+		}
+	case 'e', 'E', 'f', 'F':
+		if precision < 0 {
+			precision = 6
+		}
+		digits = strconv.FormatFloat(abs, byte(lower(verb)), precision, 64)
+	case 'g', 'G':
+		if precision < 0 {
+			precision = 6
+		}
+		digits = strconv.FormatFloat(abs, 'g', max(precision, 1), 64)
+	case '%':
+		if precision < 0 {
+			precision = 6
+		}
+		digits = strconv.FormatFloat(abs*100, 'f', precision, 64) + "%"
+	default:
+		return "", f.invalid(typ)
+	}
+	if math.IsInf(value, 0) {
+		digits = "inf"
+	} else if math.IsNaN(value) {
+		digits = "nan"
+	}
+	if verb == 'E' || verb == 'F' || verb == 'G' {
+		digits = strings.ToUpper(digits)
+	}
+	if f.grouping != 0 {
+		digits = groupNumber(digits, f.grouping)
+	}
+	return f.pad(f.signOf(negative), digits, '>'), nil
+}
+
+func (f *formatSpec) formatDecimal(value *Decimal) (string, error) {
+	switch f.verb {
+	case 0, 'f', 'F', '%':
+		// Decimals are formatted exactly, rounding half to even if the
+		// precision is less than the scale
+		d := value
+		suffix := ""
+		if f.verb == '%' {
+			d = NewDecimal(d.value, d.scale-2)
+			suffix = "%"
+		}
+		if f.precision >= 0 {
+			d = d.rescale(int32(f.precision))
+		}
+		digits := newDecimal(new(big.Int).Abs(d.value), d.scale).String()
+		if f.grouping != 0 {
+			digits = groupNumber(digits, f.grouping)
+		}
+		return f.pad(f.signOf(d.value.Sign() < 0), digits+suffix, '>'), nil
+	case 'e', 'E', 'g', 'G':
+		return f.formatFloat(value.Float64(), value.Type())
+	default:
+		return "", f.invalid(value.Type())
+	}
+}
+
+func (f *formatSpec) formatString(value string, typ Type) (string, error) {
+	if f.verb != 0 && f.verb != 's' {
+		return "", f.invalid(typ)
+	}
+	if f.sign != 0 || f.alternate || f.grouping != 0 || f.align == '=' {
+		return "", fmt.Errorf("invalid format specifier for %s", typ)
+	}
+	if f.precision >= 0 && utf8.RuneCountInString(value) > f.precision {
+		value = string([]rune(value)[:f.precision])
+	}
+	return f.pad("", value, '<'), nil
+}
+
+func (f *formatSpec) signOf(negative bool) string {
+	if negative {
+		return "-"
+	}
+	switch f.sign {
+	case '+':
+		return "+"
+	case ' ':
+		return " "
+	}
+	return ""
+}
+
+// pad combines the sign and body of a formatted value, padding the result to
+// the width of the specifier. The default alignment depends on the type.
+func (f *formatSpec) pad(sign, body string, defaultAlign rune) string {
+	count := utf8.RuneCountInString(sign) + utf8.RuneCountInString(body)
+	if count >= f.width {
+		return sign + body
+	}
+	fill := strings.Repeat(string(f.fill), f.width-count)
+	align := f.align
+	if align == 0 {
+		align = defaultAlign
+	}
+	switch align {
+	case '<':
+		return sign + body + fill
+	case '^':
+		half := (f.width - count) / 2
+		left := strings.Repeat(string(f.fill), half)
+		right := strings.Repeat(string(f.fill), f.width-count-half)
+		return left + sign + body + right
+	case '=':
+		return sign + fill + body
+	default:
+		return fill + sign + body
+	}
+}
+
+// groupNumber inserts separators into the integer part of a formatted number.
+func groupNumber(s string, sep rune) string {
+	end := strings.IndexFunc(s, func(r rune) bool { return r < '0' || r > '9' })
+	if end < 0 {
+		end = len(s)
+	}
+	return group(s[:end], sep, 3) + s[end:]
+}
+
+// group inserts a separator between each group of the given size of digits,
+// counting from the right.
+func group(digits string, sep rune, size int) string {
+	if len(digits) <= size {
+		return digits
+	}
+	var sb strings.Builder
+	first := len(digits) % size
+	if first > 0 {
+		sb.WriteString(digits[:first])
+	}
+	for i := first; i < len(digits); i += size {
+		if sb.Len() > 0 {
+			sb.WriteRune(sep)
+		}
+		sb.WriteString(digits[i : i+size])
+	}
+	return sb.String()
+}
+
+func lower(r rune) rune {
+	if r >= 'A' && r <= 'Z' {
+		return r + 'a' - 'A'
+	}
+	return r
+}
+
+// strftime formats a time using C style directives such as %Y and %m.
+func strftime(t time.Time, format string) (string, error) {
+	var sb strings.Builder
+	runes := []rune(format)
+	for i := 0; i < len(runes); i++ {
+		if runes[i] != '%' {
+			sb.WriteRune(runes[i])
+			continue
+		}
+		i++
+		if i == len(runes) {
+			return "", fmt.Errorf("invalid time format %q: trailing %%", format)
+		}
+		switch runes[i] {
+		case 'a':
+			sb.WriteString(t.Format("Mon"))
+		case 'A':
+			sb.WriteString(t.Format("Monday"))
+		case 'b':
+			sb.WriteString(t.Format("Jan"))
+		case 'B':
+			sb.WriteString(t.Format("January"))
+		case 'd':
+			sb.WriteString(t.Format("02"))
+		case 'f':
+			fmt.Fprintf(&sb, "%06d", t.Nanosecond()/1000)
+		case 'H':
+			sb.WriteString(t.Format("15"))
+		case 'I':
+			sb.WriteString(t.Format("03"))
+		case 'j':
+			fmt.Fprintf(&sb, "%03d", t.YearDay())
+		case 'm':
+			sb.WriteString(t.Format("01"))
+		case 'M':
+			sb.WriteString(t.Format("04"))
+		case 'p':
+			sb.WriteString(t.Format("PM"))
+		case 'S':
+			sb.WriteString(t.Format("05"))
+		case 'y':
+			sb.WriteString(t.Format("06"))
+		case 'Y':
+			fmt.Fprintf(&sb, "%04d", t.Year())
+		case 'z':
+			sb.WriteString(t.Format("-0700"))
+		case 'Z':
+			sb.WriteString(t.Format("MST"))
+		case '%':
+			sb.WriteRune('%')
+		default:
+			return "", fmt.Errorf("invalid time format %q: unsupported directive %%%c", format, runes[i])
+		}
+	}
+	return sb.String(), nil
+}
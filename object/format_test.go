@@ -0,0 +1,103 @@
+package object
+
+import (
+	"math"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	big1, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	tests := []struct {
+		value    Object
+		spec     string
+		expected string
+	}{
+		{NewInt(42), "", "42"},
+		{NewInt(42), "08d", "00000042"},
+		{NewInt(-42), "08d", "-0000042"},
+		{NewInt(42), "+d", "+42"},
+		{NewInt(42), " d", " 42"},
+		{NewInt(42), "<6", "42    "},
+		{NewInt(42), "^6", "  42  "},
+		{NewInt(-42), "*=6", "-***42"},
+		{NewInt(1234567), ",", "1,234,567"},
+		{NewInt(1234567), "_d", "1_234_567"},
+		{NewInt(255), "x", "ff"},
+		{NewInt(255), "#X", "0XFF"},
+		{NewInt(5), "#06b", "0b0101"},
+		{NewInt(8), "o", "10"},
+		{NewInt(65535), "_x", "ffff"},
+		{NewInt(65536), "_x", "1_0000"},
+		{NewInt(65), "c", "A"},
+		{NewInt(3), ".2f", "3.00"},
+		{NewByte(7), "03", "007"},
+		{NewBigInt(big1), ",", "123,456,789,012,345,678,901,234,567,890"},
+		{NewFloat(3.14159), ".2f", "3.14"},
+		{NewFloat(3.14159), "10.3f", "     3.142"},
+		{NewFloat(-3.5), "010.2f", "-000003.50"},
+		{NewFloat(1234.5), ",.1f", "1,234.5"},
+		{NewFloat(0.125), ".1%", "12.5%"},
+		{NewFloat(1234.5), ".2e", "1.23e+03"},
+		{NewFloat(1234.5), "E", "1.234500E+03"},
+		{NewFloat(0.0001), "g", "0.0001"},
+		{NewFloat(2.5), "", "2.5"},
+		{NewFloat(2.5), ">5", "  2.5"},
+		{NewFloat(math.Inf(1)), "f", "inf"},
+		{NewFloat(math.Inf(-1)), "F", "-INF"},
+		{NewFloat(math.NaN()), "f", "nan"},
+		{mustDecimal(t, "12.345"), "", "12.345"},
+		{mustDecimal(t, "12.345"), ".2f", "12.34"},
+		{mustDecimal(t, "12.355"), ".2f", "12.36"},
+		{mustDecimal(t, "-0.5"), ".3f", "-0.500"},
+		{mustDecimal(t, "1234567.5"), ",", "1,234,567.5"},
+		{mustDecimal(t, "0.125"), "%", "12.5%"},
+		{mustDecimal(t, "0.5"), ".0%", "50%"},
+		{mustDecimal(t, "1234.5"), ".1e", "1.2e+03"},
+		{NewString("hi"), "", "hi"},
+		{NewString("hi"), ">5", "   hi"},
+		{NewString("hi"), "*^6", "**hi**"},
+		{NewString("héllo"), "<7", "héllo  "},
+		{NewString("hello"), ".3", "hel"},
+		{NewString("hello"), "s", "hello"},
+		{NewList([]Object{NewInt(1)}), ">5", "  [1]"},
+		{NewTime(time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)), "%Y-%m-%d", "2024-03-09"},
+		{NewTime(time.Date(2024, 3, 9, 14, 5, 7, 0, time.UTC)), "%a %b %d %I:%M:%S %p %j %%", "Sat Mar 09 02:05:07 PM 069 %"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value.Inspect()+" "+tc.spec, func(t *testing.T) {
+			result, err := Format(tc.value, tc.spec)
+			require.Nil(t, err)
+			require.Equal(t, tc.expected, result)
+		})
+	}
+}
+
+func TestFormatErrors(t *testing.T) {
+	tests := []struct {
+		value    Object
+		spec     string
+		expected string
+	}{
+		{NewInt(1), "q", `invalid format specifier "q" for int`},
+		{NewInt(1), ".2d", "precision is not allowed in an integer format specifier"},
+		{NewInt(1), "5.f", `invalid format specifier "5.f": missing precision`},
+		{NewInt(1), "dd", `invalid format specifier "dd"`},
+		{NewInt(-1), "c", `invalid format specifier "c" for int`},
+		{NewFloat(1), "d", `invalid format specifier "d" for float`},
+		{mustDecimal(t, "1"), "x", `invalid format specifier "x" for decimal`},
+		{NewString("a"), "d", `invalid format specifier "d" for string`},
+		{NewString("a"), "+", "invalid format specifier for string"},
+		{NewString("a"), "=5", "invalid format specifier for string"},
+		{NewTime(time.Now()), "%Q", `invalid time format "%Q": unsupported directive %Q`},
+		{NewTime(time.Now()), "%Y%", `invalid time format "%Y%": trailing %`},
+	}
+	for _, tc := range tests {
+		_, err := Format(tc.value, tc.spec)
+		require.NotNil(t, err)
+		require.Equal(t, tc.expected, err.Error())
+	}
+}
@@ -18,6 +18,7 @@ type Function struct {
 	parameters    []string
 	defaults      []Object
 	defaultsCount int
+	restParam     string
 	code          *compiler.Code
 	fn            *compiler.Function
 	instructions  []op.Code
@@ -41,6 +42,9 @@ func (f *Function) Inspect() string {
 		}
 		parameters = append(parameters, name)
 	}
+	if f.restParam != "" {
+		parameters = append(parameters, "..."+f.restParam)
+	}
 	out.WriteString("func")
 	if f.name != "" {
 		out.WriteString(" " + f.name)
@@ -132,6 +136,12 @@ func (f *Function) Defaults() []Object {
 	return f.defaults
 }
 
+// RestParameter returns the name of the parameter that collects any extra
+// arguments into a list, or an empty string if the function is not variadic.
+func (f *Function) RestParameter() string {
+	return f.restParam
+}
+
 func (f *Function) RequiredArgsCount() int {
 	return len(f.parameters) - f.defaultsCount
 }
@@ -189,6 +199,7 @@ func NewFunction(fn *compiler.Function) *Function {
 		parameters:    parameters,
 		defaults:      defaults,
 		defaultsCount: defaultsCount,
+		restParam:     fn.RestParameter(),
 	}
 }
 
@@ -201,6 +212,7 @@ func NewClosure(
 		parameters:    fn.parameters,
 		defaults:      fn.defaults,
 		defaultsCount: fn.defaultsCount,
+		restParam:     fn.restParam,
 		code:          fn.Code(),
 		freeVars:      freeVars,
 	}
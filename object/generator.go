@@ -0,0 +1,165 @@
+package object
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+var _ FallibleIterator = (*Generator)(nil) // Ensure that *Generator implements FallibleIterator
+
+// Generator is an iterator over the values yielded by a generator function.
+// Calling a function that contains a yield statement returns a Generator
+// without running the function body. Each call to Next resumes the function
+// until it yields another value or returns.
+type Generator struct {
+	*base
+	fn      *Function
+	state   any
+	mutex   sync.Mutex
+	done    bool
+	pos     int64
+	current Object
+	err     error
+}
+
+func (g *Generator) Type() Type {
+	return GENERATOR
+}
+
+// Function returns the generator function that produces the values.
+func (g *Generator) Function() *Function {
+	return g.fn
+}
+
+// State returns the execution state of the suspended function. It is owned
+// by the virtual machine that resumes the generator.
+func (g *Generator) State() any {
+	return g.state
+}
+
+func (g *Generator) Inspect() string {
+	return fmt.Sprintf("generator(%s)", g.fn.Name())
+}
+
+func (g *Generator) String() string {
+	return g.Inspect()
+}
+
+// Interface returns nil. Unlike other iterators, a generator isn't converted
+// to a slice of its values, since only a VM can resume the generator function.
+// Advancing it without one would stop the generator with an error.
+func (g *Generator) Interface() interface{} {
+	return nil
+}
+
+func (g *Generator) Equals(other Object) Object {
+	if g == other {
+		return True
+	}
+	return False
+}
+
+func (g *Generator) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "next":
+		return &Builtin{
+			name: "generator.next",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("generator.next", 0, len(args))
+				}
+				value, ok := g.Next(ctx)
+				if !ok {
+					if err := g.Err(); err != nil {
+						return NewError(err)
+					}
+					return Nil
+				}
+				return value
+			},
+		}, true
+	case "entry":
+		return &Builtin{
+			name: "generator.entry",
+			fn: func(ctx context.Context, args ...Object) Object {
+				if len(args) != 0 {
+					return NewArgsError("generator.entry", 0, len(args))
+				}
+				entry, ok := g.Entry()
+				if !ok {
+					return Nil
+				}
+				return entry
+			},
+		}, true
+	}
+	return nil, false
+}
+
+func (g *Generator) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for generator: %v", opType)
+}
+
+// Next resumes the generator function and returns the next value it yields.
+// False is returned once the function returns or fails. In the case of a
+// failure, the error is available from Err.
+func (g *Generator) Next(ctx context.Context) (Object, bool) {
+	g.mutex.Lock()
+	done := g.done
+	g.mutex.Unlock()
+	if done {
+		return nil, false
+	}
+	var value Object
+	var ok bool
+	var err error
+	if resumeFunc, found := GetResumeFunc(ctx); found {
+		value, ok, err = resumeFunc(ctx, g)
+	} else {
+		err = errz.EvalErrorf("eval error: context did not contain a resume function")
+	}
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if err != nil || !ok {
+		if g.err == nil {
+			g.err = err
+		}
+		g.done = true
+		g.current = nil
+		return nil, false
+	}
+	g.pos++
+	g.current = value
+	return value, true
+}
+
+func (g *Generator) Entry() (IteratorEntry, bool) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if g.current == nil {
+		return nil, false
+	}
+	return NewEntry(NewInt(g.pos), g.current), true
+}
+
+// Err returns the error that stopped the generator, if any.
+func (g *Generator) Err() error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.err
+}
+
+func (g *Generator) MarshalJSON() ([]byte, error) {
+	return nil, errz.TypeErrorf("type error: unable to marshal generator")
+}
+
+// NewGenerator returns a generator for the given function. The state holds
+// the suspended execution of the function and is only interpreted by the
+// ResumeFunc that advances the generator.
+func NewGenerator(fn *Function, state any) *Generator {
+	return &Generator{fn: fn, state: state, pos: -1}
+}
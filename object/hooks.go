@@ -0,0 +1,172 @@
+package object
+
+import (
+	"context"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+// Names of the hooks that maps and structs may define in order to customize
+// how operators and builtins treat them. A hook is a function stored under one
+// of these names, which receives the value that defines it as its first
+// argument. For example:
+//
+//	money := func(cents) {
+//		return {
+//			cents: cents,
+//			__add__: func(a, b) { money(a.cents + b.cents) },
+//			__str__: func(self) { sprintf("$%.2f", self.cents / 100.0) },
+//		}
+//	}
+//
+// Binary operator hooks receive the left and right operands in order. They
+// are looked up on the left operand first and then on the right operand, so
+// a hook is also used when its value appears on the right of the operator.
+const (
+	HookAdd        = "__add__"
+	HookSubtract   = "__sub__"
+	HookMultiply   = "__mul__"
+	HookDivide     = "__div__"
+	HookModulo     = "__mod__"
+	HookPower      = "__pow__"
+	HookXor        = "__xor__"
+	HookLShift     = "__lshift__"
+	HookRShift     = "__rshift__"
+	HookBitwiseAnd = "__and__"
+	HookBitwiseOr  = "__or__"
+	HookEq         = "__eq__"      // (a, b) -> bool, used by == and !=
+	HookCmp        = "__cmp__"     // (a, b) -> int, used by <, <=, > and >=
+	HookLen        = "__len__"     // (self) -> int
+	HookGetItem    = "__getitem__" // (self, key) -> value
+	HookIter       = "__iter__"    // (self) -> iterable
+	HookStr        = "__str__"     // (self) -> string
+)
+
+// BinaryOpHook returns the name of the hook that implements the given binary
+// operator, or an empty string if the operator can't be overloaded.
+func BinaryOpHook(opType op.BinaryOpType) string {
+	switch opType {
+	case op.Add:
+		return HookAdd
+	case op.Subtract:
+		return HookSubtract
+	case op.Multiply:
+		return HookMultiply
+	case op.Divide:
+		return HookDivide
+	case op.Modulo:
+		return HookModulo
+	case op.Power:
+		return HookPower
+	case op.Xor:
+		return HookXor
+	case op.LShift:
+		return HookLShift
+	case op.RShift:
+		return HookRShift
+	case op.BitwiseAnd:
+		return HookBitwiseAnd
+	case op.BitwiseOr:
+		return HookBitwiseOr
+	default:
+		return ""
+	}
+}
+
+// GetHook returns the hook with the given name if the object is a map or a
+// struct that defines it. Values that aren't callable are not hooks.
+func GetHook(obj Object, name string) (Object, bool) {
+	var hook Object
+	switch obj := obj.(type) {
+	case *Map:
+		hook = obj.items[name]
+	case *Struct:
+		hook, _ = obj.GetAttr(name)
+	default:
+		return nil, false
+	}
+	switch hook.(type) {
+	case Callable, *Partial:
+		return hook, true
+	default:
+		return nil, false
+	}
+}
+
+// GetBinaryHook returns the hook that implements the binary operation on the
+// two operands, looking at the left operand first.
+func GetBinaryHook(name string, a, b Object) (Object, bool) {
+	if hook, ok := GetHook(a, name); ok {
+		return hook, true
+	}
+	return GetHook(b, name)
+}
+
+// CallHook calls a hook with the given arguments. Errors raised by the hook
+// are returned as Go errors.
+func CallHook(ctx context.Context, hook Object, args ...Object) (Object, error) {
+	if partial, ok := hook.(*Partial); ok {
+		hook = partial.Function()
+		args = append(args, partial.Args()...)
+	}
+	callable, ok := hook.(Callable)
+	if !ok {
+		return nil, errz.TypeErrorf("type error: object is not callable (got %s)", hook.Type())
+	}
+	result := callable.Call(ctx, args...)
+	if err, ok := result.(*Error); ok && err.IsRaised() {
+		return nil, err.Value()
+	}
+	return result, nil
+}
+
+// CallStrHook returns the result of calling the __str__ hook of the object.
+// The boolean result is false if the object doesn't define the hook.
+func CallStrHook(ctx context.Context, obj Object) (string, bool, error) {
+	hook, ok := GetHook(obj, HookStr)
+	if !ok {
+		return "", false, nil
+	}
+	result, err := CallHook(ctx, hook, obj)
+	if err != nil {
+		return "", true, err
+	}
+	s, ok := result.(*String)
+	if !ok {
+		return "", true, errz.TypeErrorf("type error: %s must return a string (got %s)", HookStr, result.Type())
+	}
+	return s.value, true, nil
+}
+
+// CallIterHook returns the result of calling the __iter__ hook of the object.
+// The boolean result is false if the object doesn't define the hook.
+func CallIterHook(ctx context.Context, obj Object) (Object, bool, error) {
+	hook, ok := GetHook(obj, HookIter)
+	if !ok {
+		return nil, false, nil
+	}
+	result, err := CallHook(ctx, hook, obj)
+	if err != nil {
+		return nil, true, err
+	}
+	return result, true, nil
+}
+
+// CallCmpHook returns the result of calling the __cmp__ hook of either
+// operand. The boolean result is false if neither operand defines the hook.
+func CallCmpHook(ctx context.Context, a, b Object) (int64, bool, error) {
+	hook, ok := GetBinaryHook(HookCmp, a, b)
+	if !ok {
+		return 0, false, nil
+	}
+	result, err := CallHook(ctx, hook, a, b)
+	if err != nil {
+		return 0, true, err
+	}
+	value, ok := result.(*Int)
+	if !ok {
+		return 0, true, errz.TypeErrorf("type error: %s must return an int (got %s)", HookCmp, result.Type())
+	}
+	return value.value, true, nil
+}
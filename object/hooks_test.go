@@ -0,0 +1,46 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/op"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBinaryOpHook(t *testing.T) {
+	require.Equal(t, "__add__", BinaryOpHook(op.Add))
+	require.Equal(t, "__lshift__", BinaryOpHook(op.LShift))
+	require.Equal(t, "__or__", BinaryOpHook(op.BitwiseOr))
+	require.Equal(t, "", BinaryOpHook(op.And))
+}
+
+func TestGetHook(t *testing.T) {
+	str := NewBuiltin("str", func(ctx context.Context, args ...Object) Object {
+		return NewString("custom")
+	})
+	m := NewMap(map[string]Object{"__str__": str, "__len__": NewInt(3)})
+
+	hook, ok := GetHook(m, HookStr)
+	require.True(t, ok)
+	require.Equal(t, str, hook)
+
+	// Only callable values are hooks
+	_, ok = GetHook(m, HookLen)
+	require.False(t, ok)
+	_, ok = GetHook(NewList(nil), HookLen)
+	require.False(t, ok)
+
+	hook, ok = GetBinaryHook(HookStr, NewInt(1), m)
+	require.True(t, ok)
+	require.Equal(t, str, hook)
+
+	s, ok, err := CallStrHook(context.Background(), m)
+	require.Nil(t, err)
+	require.True(t, ok)
+	require.Equal(t, "custom", s)
+
+	_, ok, err = CallStrHook(context.Background(), NewInt(1))
+	require.Nil(t, err)
+	require.False(t, ok)
+}
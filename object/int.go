@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"math/big"
 
 	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/op"
@@ -66,6 +67,9 @@ func (i *Int) Compare(other Object) (int, error) {
 			return 1, nil
 		}
 		return -1, nil
+	case *BigInt, *Decimal:
+		result, err := other.(Comparable).Compare(i)
+		return -result, err
 	default:
 		return 0, errz.TypeErrorf("type error: unable to compare int and %s", other.Type())
 	}
@@ -85,6 +89,8 @@ func (i *Int) Equals(other Object) Object {
 		if i.value == int64(other.value) {
 			return True
 		}
+	case *BigInt, *Decimal:
+		return other.Equals(i)
 	}
 	return False
 }
@@ -102,6 +108,10 @@ func (i *Int) RunOperation(opType op.BinaryOpType, right Object) Object {
 	case *Byte:
 		rightInt := int64(right.value)
 		return i.runOperationInt(opType, rightInt)
+	case *BigInt:
+		return NewBigInt(big.NewInt(i.value)).RunOperation(opType, right)
+	case *Decimal:
+		return newDecimal(big.NewInt(i.value), 0).RunOperation(opType, right)
 	default:
 		return TypeErrorf("type error: unsupported operation for int: %v on type %s", opType, right.Type())
 	}
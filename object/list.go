@@ -51,169 +51,136 @@ func (ls *List) Inspect() string {
 	return out.String()
 }
 
+var listMethods = NewMethodTable(LIST, map[string]MethodFunction{
+	"append": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.append", 1, len(args))
+		}
+		ls.Append(args[0])
+		return ls
+	},
+	"clear": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 0 {
+			return NewArgsError("list.clear", 0, len(args))
+		}
+		ls.Clear()
+		return ls
+	},
+	"copy": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 0 {
+			return NewArgsError("list.copy", 0, len(args))
+		}
+		return ls.Copy()
+	},
+	"count": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.count", 1, len(args))
+		}
+		return NewInt(ls.Count(args[0]))
+	},
+	"extend": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.extend", 1, len(args))
+		}
+		other, err := AsList(args[0])
+		if err != nil {
+			return err
+		}
+		ls.Extend(other)
+		return ls
+	},
+	"index": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.index", 1, len(args))
+		}
+		return NewInt(ls.Index(args[0]))
+	},
+	"insert": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 2 {
+			return NewArgsError("list.insert", 2, len(args))
+		}
+		index, err := AsInt(args[0])
+		if err != nil {
+			return err
+		}
+		ls.Insert(index, args[1])
+		return ls
+	},
+	"pop": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.pop", 1, len(args))
+		}
+		index, err := AsInt(args[0])
+		if err != nil {
+			return err
+		}
+		return ls.Pop(index)
+	},
+	"remove": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.remove", 1, len(args))
+		}
+		ls.Remove(args[0])
+		return ls
+	},
+	"reverse": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 0 {
+			return NewArgsError("list.reverse", 0, len(args))
+		}
+		ls.Reverse()
+		return ls
+	},
+	"sort": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 0 {
+			return NewArgsError("list.sort", 0, len(args))
+		}
+		if err := Sort(ctx, ls.items); err != nil {
+			return err
+		}
+		return ls
+	},
+	"map": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.map", 1, len(args))
+		}
+		return ls.Map(ctx, args[0])
+	},
+	"filter": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.filter", 1, len(args))
+		}
+		return ls.Filter(ctx, args[0])
+	},
+	"each": func(ctx context.Context, self Object, args ...Object) Object {
+		ls := self.(*List)
+		if len(args) != 1 {
+			return NewArgsError("list.each", 1, len(args))
+		}
+		return ls.Each(ctx, args[0])
+	},
+})
+
+// Methods returns the table of methods for lists.
+func (ls *List) Methods() *MethodTable {
+	return listMethods
+}
+
 func (ls *List) GetAttr(name string) (Object, bool) {
-	switch name {
-	case "append":
-		return &Builtin{
-			name: "list.append",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.append", 1, len(args))
-				}
-				ls.Append(args[0])
-				return ls
-			},
-		}, true
-	case "clear":
-		return &Builtin{
-			name: "list.clear",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("list.clear", 0, len(args))
-				}
-				ls.Clear()
-				return ls
-			},
-		}, true
-	case "copy":
-		return &Builtin{
-			name: "list.copy",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("list.copy", 0, len(args))
-				}
-				return ls.Copy()
-			},
-		}, true
-	case "count":
-		return &Builtin{
-			name: "list.count",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.count", 1, len(args))
-				}
-				return NewInt(ls.Count(args[0]))
-			},
-		}, true
-	case "extend":
-		return &Builtin{
-			name: "list.extend",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.extend", 1, len(args))
-				}
-				other, err := AsList(args[0])
-				if err != nil {
-					return err
-				}
-				ls.Extend(other)
-				return ls
-			},
-		}, true
-	case "index":
-		return &Builtin{
-			name: "list.index",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.index", 1, len(args))
-				}
-				return NewInt(ls.Index(args[0]))
-			},
-		}, true
-	case "insert":
-		return &Builtin{
-			name: "list.insert",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 2 {
-					return NewArgsError("list.insert", 2, len(args))
-				}
-				index, err := AsInt(args[0])
-				if err != nil {
-					return err
-				}
-				ls.Insert(index, args[1])
-				return ls
-			},
-		}, true
-	case "pop":
-		return &Builtin{
-			name: "list.pop",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.pop", 1, len(args))
-				}
-				index, err := AsInt(args[0])
-				if err != nil {
-					return err
-				}
-				return ls.Pop(index)
-			},
-		}, true
-	case "remove":
-		return &Builtin{
-			name: "list.remove",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.remove", 1, len(args))
-				}
-				ls.Remove(args[0])
-				return ls
-			},
-		}, true
-	case "reverse":
-		return &Builtin{
-			name: "list.reverse",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("list.reverse", 0, len(args))
-				}
-				ls.Reverse()
-				return ls
-			},
-		}, true
-	case "sort":
-		return &Builtin{
-			name: "list.sort",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("list.sort", 0, len(args))
-				}
-				if err := Sort(ls.items); err != nil {
-					return err
-				}
-				return ls
-			},
-		}, true
-	case "map":
-		return &Builtin{
-			name: "list.map",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.map", 1, len(args))
-				}
-				return ls.Map(ctx, args[0])
-			},
-		}, true
-	case "filter":
-		return &Builtin{
-			name: "list.filter",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.filter", 1, len(args))
-				}
-				return ls.Filter(ctx, args[0])
-			},
-		}, true
-	case "each":
-		return &Builtin{
-			name: "list.each",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("list.each", 1, len(args))
-				}
-				return ls.Each(ctx, args[0])
-			},
-		}, true
+	if method, ok := listMethods.Get(name); ok {
+		return method.Bind(ls), true
 	}
 	return nil, false
 }
@@ -670,7 +637,7 @@ func ResolveIntSlice(slice Slice, size int64) (start int64, stop int64, err erro
 		err = fmt.Errorf("slice error: start index is greater than stop index")
 		return
 	}
-	if start > size-1 {
+	if start > size {
 		err = fmt.Errorf("slice error: start index is out of range")
 		return
 	}
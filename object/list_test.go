@@ -45,3 +45,14 @@ func TestListPop(t *testing.T) {
 	require.True(t, ok)
 	require.Equal(t, "index error: index out of range: 1", err.Message().Value())
 }
+
+func TestListGetSliceAtEnd(t *testing.T) {
+	list := NewList([]Object{NewInt(1)})
+
+	result, err := list.GetSlice(Slice{Start: NewInt(1)})
+	require.Nil(t, err)
+	require.Equal(t, NewList([]Object{}), result)
+
+	_, err = list.GetSlice(Slice{Start: NewInt(2)})
+	require.NotNil(t, err)
+}
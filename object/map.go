@@ -57,128 +57,110 @@ func (m *Map) SetAttr(name string, value Object) error {
 	return nil
 }
 
+var mapMethods = NewMethodTable(MAP, map[string]MethodFunction{
+	"keys": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) != 0 {
+			return NewArgsError("map.keys", 0, len(args))
+		}
+		return m.Keys()
+	},
+	"values": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) != 0 {
+			return NewArgsError("map.values", 0, len(args))
+		}
+		return m.Values()
+	},
+	"get": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) < 1 || len(args) > 2 {
+			return NewArgsRangeError("map.get", 1, 2, len(args))
+		}
+		key, err := AsString(args[0])
+		if err != nil {
+			return err
+		}
+		value, found := m.items[key]
+		if !found {
+			if len(args) == 2 {
+				return args[1]
+			}
+			return Nil
+		}
+		return value
+	},
+	"clear": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) != 0 {
+			return NewArgsError("map.clear", 0, len(args))
+		}
+		m.Clear()
+		return m
+	},
+	"copy": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) != 0 {
+			return NewArgsError("map.copy", 0, len(args))
+		}
+		return m.Copy()
+	},
+	"items": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) != 0 {
+			return NewArgsError("map.items", 0, len(args))
+		}
+		return m.ListItems()
+	},
+	"pop": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		nArgs := len(args)
+		if nArgs < 1 || nArgs > 2 {
+			return NewArgsRangeError("map.pop", 1, 2, len(args))
+		}
+		key, err := AsString(args[0])
+		if err != nil {
+			return err
+		}
+		var def Object
+		if nArgs == 2 {
+			def = args[1]
+		}
+		return m.Pop(key, def)
+	},
+	"setdefault": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) != 2 {
+			return NewArgsError("map.setdefault", 2, len(args))
+		}
+		key, err := AsString(args[0])
+		if err != nil {
+			return err
+		}
+		return m.SetDefault(key, args[1])
+	},
+	"update": func(ctx context.Context, self Object, args ...Object) Object {
+		m := self.(*Map)
+		if len(args) != 1 {
+			return NewArgsError("map.update", 1, len(args))
+		}
+		other, err := AsMap(args[0])
+		if err != nil {
+			return err
+		}
+		m.Update(other)
+		return m
+	},
+})
+
+// Methods returns the table of methods for maps. Map items are not included.
+func (m *Map) Methods() *MethodTable {
+	return mapMethods
+}
+
 func (m *Map) GetAttr(name string) (Object, bool) {
-	switch name {
-	case "keys":
-		return &Builtin{
-			name: "map.keys",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("map.keys", 0, len(args))
-				}
-				return m.Keys()
-			},
-		}, true
-	case "values":
-		return &Builtin{
-			name: "map.values",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("map.values", 0, len(args))
-				}
-				return m.Values()
-			},
-		}, true
-	case "get":
-		return &Builtin{
-			name: "map.get",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) < 1 || len(args) > 2 {
-					return NewArgsRangeError("map.get", 1, 2, len(args))
-				}
-				key, err := AsString(args[0])
-				if err != nil {
-					return err
-				}
-				value, found := m.items[key]
-				if !found {
-					if len(args) == 2 {
-						return args[1]
-					}
-					return Nil
-				}
-				return value
-			},
-		}, true
-	case "clear":
-		return &Builtin{
-			name: "map.clear",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("map.clear", 0, len(args))
-				}
-				m.Clear()
-				return m
-			},
-		}, true
-	case "copy":
-		return &Builtin{
-			name: "map.copy",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("map.copy", 0, len(args))
-				}
-				return m.Copy()
-			},
-		}, true
-	case "items":
-		return &Builtin{
-			name: "map.items",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("map.items", 0, len(args))
-				}
-				return m.ListItems()
-			},
-		}, true
-	case "pop":
-		return &Builtin{
-			name: "map.pop",
-			fn: func(ctx context.Context, args ...Object) Object {
-				nArgs := len(args)
-				if nArgs < 1 || nArgs > 2 {
-					return NewArgsRangeError("map.pop", 1, 2, len(args))
-				}
-				key, err := AsString(args[0])
-				if err != nil {
-					return err
-				}
-				var def Object
-				if nArgs == 2 {
-					def = args[1]
-				}
-				return m.Pop(key, def)
-			},
-		}, true
-	case "setdefault":
-		return &Builtin{
-			name: "map.setdefault",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 2 {
-					return NewArgsError("map.setdefault", 2, len(args))
-				}
-				key, err := AsString(args[0])
-				if err != nil {
-					return err
-				}
-				return m.SetDefault(key, args[1])
-			},
-		}, true
-	case "update":
-		return &Builtin{
-			name: "map.update",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("map.update", 1, len(args))
-				}
-				other, err := AsMap(args[0])
-				if err != nil {
-					return err
-				}
-				m.Update(other)
-				return m
-			},
-		}, true
+	if method, ok := mapMethods.Get(name); ok {
+		return method.Bind(m), true
 	}
 	o, ok := m.items[name]
 	return o, ok
@@ -0,0 +1,105 @@
+package object
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+// MethodFunction holds the type of a method implemented by a built-in type.
+// The object the method is called on is passed as self.
+type MethodFunction func(ctx context.Context, self Object, args ...Object) Object
+
+// Method is a method of a built-in type, such as list.append. Unlike the
+// Builtin returned by GetAttr, a Method isn't bound to a particular object and
+// is shared by all objects of its type. This allows the VM to call methods
+// without looking them up by name or allocating a bound function each time.
+type Method struct {
+	*base
+	name string
+	fn   MethodFunction
+}
+
+func (m *Method) Type() Type {
+	return METHOD
+}
+
+// Name returns the qualified name of the method, as in "list.append".
+func (m *Method) Name() string {
+	return m.name
+}
+
+// Call calls the method on the given object.
+func (m *Method) Call(ctx context.Context, self Object, args ...Object) Object {
+	return m.fn(ctx, self, args...)
+}
+
+// Bind returns a builtin function that calls the method on the given object.
+func (m *Method) Bind(self Object) *Builtin {
+	return &Builtin{
+		name: m.name,
+		fn: func(ctx context.Context, args ...Object) Object {
+			return m.fn(ctx, self, args...)
+		},
+	}
+}
+
+func (m *Method) Inspect() string {
+	return fmt.Sprintf("method(%s)", m.name)
+}
+
+func (m *Method) String() string {
+	return m.Inspect()
+}
+
+func (m *Method) Interface() interface{} {
+	return m.fn
+}
+
+func (m *Method) Equals(other Object) Object {
+	if m == other {
+		return True
+	}
+	return False
+}
+
+func (m *Method) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for method: %v", opType)
+}
+
+func (m *Method) MarshalJSON() ([]byte, error) {
+	return nil, errz.TypeErrorf("type error: unable to marshal method")
+}
+
+// MethodTable holds the methods of a built-in type by name.
+type MethodTable struct {
+	methods map[string]*Method
+}
+
+// NewMethodTable returns a table of methods for the given type. The qualified
+// name of each method is formed from the type and the method name.
+func NewMethodTable(typ Type, methods map[string]MethodFunction) *MethodTable {
+	table := &MethodTable{methods: make(map[string]*Method, len(methods))}
+	for name, fn := range methods {
+		table.methods[name] = &Method{name: fmt.Sprintf("%s.%s", typ, name), fn: fn}
+	}
+	return table
+}
+
+// Get returns the method with the given name.
+func (t *MethodTable) Get(name string) (*Method, bool) {
+	method, ok := t.methods[name]
+	return method, ok
+}
+
+// HasMethods is implemented by objects whose methods are defined in a
+// MethodTable. For these objects, a method found in the table must be the
+// same as the attribute of that name returned by GetAttr.
+type HasMethods interface {
+	Object
+
+	// Methods returns the table of methods for the object's type.
+	Methods() *MethodTable
+}
@@ -0,0 +1,54 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodTable(t *testing.T) {
+	method, ok := listMethods.Get("append")
+	require.True(t, ok)
+	require.Equal(t, "list.append", method.Name())
+	require.Equal(t, METHOD, method.Type())
+	require.Equal(t, "method(list.append)", method.Inspect())
+
+	_, ok = listMethods.Get("missing")
+	require.False(t, ok)
+
+	// The same method is shared by all lists
+	a := NewList([]Object{NewInt(1)})
+	b := NewList(nil)
+	require.Same(t, a.Methods(), b.Methods())
+	method.Call(context.Background(), a, NewInt(2))
+	method.Call(context.Background(), b, NewInt(3))
+	require.Equal(t, []Object{NewInt(1), NewInt(2)}, a.Value())
+	require.Equal(t, []Object{NewInt(3)}, b.Value())
+}
+
+func TestMethodBind(t *testing.T) {
+	method, ok := stringMethods.Get("to_upper")
+	require.True(t, ok)
+	bound := method.Bind(NewString("abc"))
+	require.Equal(t, "string.to_upper", bound.Name())
+	require.Equal(t, NewString("ABC"), bound.Call(context.Background()))
+
+	// GetAttr returns the bound method
+	attr, ok := NewString("xyz").GetAttr("to_upper")
+	require.True(t, ok)
+	require.Equal(t, NewString("XYZ"), attr.(*Builtin).Call(context.Background()))
+}
+
+func TestMapMethodsAndItems(t *testing.T) {
+	m := NewMap(map[string]Object{"keys": NewInt(1), "a": NewInt(2)})
+	// Methods take precedence over items with the same name
+	attr, ok := m.GetAttr("keys")
+	require.True(t, ok)
+	require.IsType(t, &Builtin{}, attr)
+	attr, ok = m.GetAttr("a")
+	require.True(t, ok)
+	require.Equal(t, NewInt(2), attr)
+	_, ok = mapMethods.Get("a")
+	require.False(t, ok)
+}
@@ -3,6 +3,7 @@ package object
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/risor-io/risor/compiler"
 	"github.com/risor-io/risor/errz"
@@ -35,12 +36,34 @@ func (m *Module) GetAttr(name string) (Object, bool) {
 	if builtin, found := m.builtins[name]; found {
 		return builtin, true
 	}
-	if index, found := m.globalsIndex[name]; found {
+	if index, found := m.globalsIndex[name]; found && !m.IsPrivateAttr(name) {
 		return m.globals[index], true
 	}
 	return nil, false
 }
 
+// IsPrivateAttr returns true if the name refers to a private global of the
+// module, which is hidden by GetAttr and can't be imported. The builtins of a
+// module are always public, regardless of their names.
+func (m *Module) IsPrivateAttr(name string) bool {
+	if _, found := m.builtins[name]; found {
+		return false
+	}
+	_, found := m.globalsIndex[name]
+	return found && IsPrivateName(name)
+}
+
+// IsPrivateName returns true if the name is private to the module that defines
+// it. Global names that begin with an underscore are private, so they are not
+// visible as attributes of the module and can't be imported from it. Names
+// that begin and end with a double underscore, such as __name__, are public.
+func IsPrivateName(name string) bool {
+	if !strings.HasPrefix(name, "_") {
+		return false
+	}
+	return !(len(name) > 4 && strings.HasPrefix(name, "__") && strings.HasSuffix(name, "__"))
+}
+
 func (m *Module) SetAttr(name string, value Object) error {
 	return errz.TypeErrorf("type error: cannot modify module attributes")
 }
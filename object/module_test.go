@@ -0,0 +1,50 @@
+package object
+
+import (
+	"context"
+	"testing"
+
+	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/parser"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsPrivateName(t *testing.T) {
+	require.True(t, IsPrivateName("_helper"))
+	require.True(t, IsPrivateName("__helper"))
+	require.True(t, IsPrivateName("_"))
+	require.False(t, IsPrivateName("helper"))
+	require.False(t, IsPrivateName("helper_"))
+	require.False(t, IsPrivateName("__version__"))
+}
+
+func TestModulePrivateGlobals(t *testing.T) {
+	program, err := parser.Parse(context.Background(), "_secret := 1; public := 2")
+	require.Nil(t, err)
+	code, err := compiler.Compile(program)
+	require.Nil(t, err)
+	m := NewModule("test", code)
+
+	// The globals are only set once the module code runs
+	value, ok := m.GetAttr("public")
+	require.True(t, ok)
+	require.Equal(t, Nil, value)
+
+	_, ok = m.GetAttr("_secret")
+	require.False(t, ok)
+	require.True(t, m.IsPrivateAttr("_secret"))
+	require.False(t, m.IsPrivateAttr("public"))
+	require.False(t, m.IsPrivateAttr("_missing"))
+}
+
+func TestModulePrivateBuiltins(t *testing.T) {
+	// Builtins are public even if their names begin with an underscore
+	internal := NewBuiltin("_internal", func(ctx context.Context, args ...Object) Object {
+		return Nil
+	})
+	m := NewBuiltinsModule("test", map[string]Object{"_internal": internal})
+	value, ok := m.GetAttr("_internal")
+	require.True(t, ok)
+	require.Equal(t, internal, value)
+	require.False(t, m.IsPrivateAttr("_internal"))
+}
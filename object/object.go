@@ -31,6 +31,7 @@ type Type string
 
 // Type constants
 const (
+	BIGINT        Type = "bigint"
 	BOOL          Type = "bool"
 	BUFFER        Type = "buffer"
 	BUILTIN       Type = "builtin"
@@ -41,6 +42,7 @@ const (
 	COLOR         Type = "color"
 	COMPLEX       Type = "complex"
 	COMPLEX_SLICE Type = "complex_slice"
+	DECIMAL       Type = "decimal"
 	DIR_ENTRY     Type = "dir_entry"
 	DYNAMIC_ATTR  Type = "dynamic_attr"
 	ERROR         Type = "error"
@@ -51,6 +53,7 @@ const (
 	FLOAT         Type = "float"
 	FLOAT_SLICE   Type = "float_slice"
 	FUNCTION      Type = "function"
+	GENERATOR     Type = "generator"
 	GO_FIELD      Type = "go_field"
 	GO_METHOD     Type = "go_method"
 	GO_TYPE       Type = "go_type"
@@ -61,6 +64,7 @@ const (
 	LIST_ITER     Type = "list_iter"
 	MAP           Type = "map"
 	MAP_ITER      Type = "map_iter"
+	METHOD        Type = "method"
 	MODULE        Type = "module"
 	NIL           Type = "nil"
 	PARTIAL       Type = "partial"
@@ -71,6 +75,8 @@ const (
 	SLICE_ITER    Type = "slice_iter"
 	STRING        Type = "string"
 	STRING_ITER   Type = "string_iter"
+	STRUCT        Type = "struct"
+	STRUCT_TYPE   Type = "struct_type"
 	THREAD        Type = "thread"
 	TIME          Type = "time"
 )
@@ -140,6 +146,25 @@ type Iterator interface {
 	Entry() (IteratorEntry, bool)
 }
 
+// FallibleIterator is an Iterator that may fail while advancing. Once Next
+// returns false, Err returns the error that stopped the iteration, if any.
+type FallibleIterator interface {
+	Iterator
+
+	// Err returns the error that ended the iteration, or nil if the
+	// iteration completed normally.
+	Err() error
+}
+
+// IteratorErr returns the error that stopped the given iterator, if it is a
+// FallibleIterator that failed.
+func IteratorErr(iter Iterator) error {
+	if fallible, ok := iter.(FallibleIterator); ok {
+		return fallible.Err()
+	}
+	return nil
+}
+
 // Iterable is an interface that exposes an iterator for an Object.
 type Iterable interface {
 	Iter() Iterator
@@ -173,6 +198,16 @@ type Callable interface {
 	Call(ctx context.Context, args ...Object) Object
 }
 
+// KeywordCallable is implemented by callables that may receive keyword
+// arguments, as in "f(timeout=5)".
+type KeywordCallable interface {
+	Callable
+
+	// CallWithKeywords invokes the callable with the given positional and
+	// keyword arguments and returns the result.
+	CallWithKeywords(ctx context.Context, kwargs *Map, args ...Object) Object
+}
+
 // Hashable types can be hashed and consequently used in a set.
 type Hashable interface {
 	// Hash returns a hash key for the given object.
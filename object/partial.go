@@ -11,8 +11,9 @@ import (
 // Partial is a partially applied function
 type Partial struct {
 	*base
-	fn   Object
-	args []Object
+	fn     Object
+	args   []Object
+	kwargs *Map
 }
 
 func (p *Partial) Function() Object {
@@ -23,6 +24,12 @@ func (p *Partial) Args() []Object {
 	return p.args
 }
 
+// Kwargs returns the keyword arguments applied to the function, or nil if
+// there are none.
+func (p *Partial) Kwargs() *Map {
+	return p.kwargs
+}
+
 func (p *Partial) Type() Type {
 	return PARTIAL
 }
@@ -32,6 +39,11 @@ func (p *Partial) Inspect() string {
 	for _, arg := range p.args {
 		args = append(args, arg.Inspect())
 	}
+	if p.kwargs != nil {
+		for _, key := range p.kwargs.SortedKeys() {
+			args = append(args, key+"="+p.kwargs.Get(key).Inspect())
+		}
+	}
 	return fmt.Sprintf("partial(%s, %s)", p.fn.Inspect(), strings.Join(args, ", "))
 }
 
@@ -60,3 +72,13 @@ func NewPartial(fn Object, args []Object) *Partial {
 		args: args,
 	}
 }
+
+// NewPartialWithKwargs returns a partial that applies both positional and
+// keyword arguments to the given function.
+func NewPartialWithKwargs(fn Object, args []Object, kwargs *Map) *Partial {
+	return &Partial{
+		fn:     fn,
+		args:   args,
+		kwargs: kwargs,
+	}
+}
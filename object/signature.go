@@ -0,0 +1,177 @@
+package object
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Param describes one parameter of a function Signature.
+type Param struct {
+	// Name of the parameter.
+	Name string
+
+	// Types accepted by the parameter. If empty, any type is accepted.
+	Types []Type
+
+	// Optional is true if the parameter may be omitted.
+	Optional bool
+
+	// Variadic is true if the parameter collects any remaining arguments.
+	Variadic bool
+}
+
+// Signature describes the parameters and return type of a function. Builtins
+// may carry a Signature so that tools, such as the static checker, are able to
+// validate calls without running any code.
+type Signature struct {
+	// Params holds the parameters in the order they are accepted.
+	Params []Param
+
+	// Returns holds the types the function may return. If empty, the return
+	// type is unknown.
+	Returns []Type
+}
+
+// MinArgs returns the minimum number of arguments the function accepts.
+func (s *Signature) MinArgs() int {
+	var count int
+	for _, p := range s.Params {
+		if !p.Optional && !p.Variadic {
+			count++
+		}
+	}
+	return count
+}
+
+// MaxArgs returns the maximum number of arguments the function accepts, or
+// -1 if the function is variadic.
+func (s *Signature) MaxArgs() int {
+	for _, p := range s.Params {
+		if p.Variadic {
+			return -1
+		}
+	}
+	return len(s.Params)
+}
+
+func (s *Signature) String() string {
+	params := make([]string, 0, len(s.Params))
+	for _, p := range s.Params {
+		var sb strings.Builder
+		if p.Variadic {
+			sb.WriteString("...")
+		}
+		sb.WriteString(p.Name)
+		if p.Optional {
+			sb.WriteString("?")
+		}
+		if len(p.Types) > 0 {
+			sb.WriteString(": ")
+			sb.WriteString(joinTypes(p.Types))
+		}
+		params = append(params, sb.String())
+	}
+	result := "(" + strings.Join(params, ", ") + ")"
+	if len(s.Returns) > 0 {
+		result += " -> " + joinTypes(s.Returns)
+	}
+	return result
+}
+
+// ParseSignature parses a signature written in the same form as an annotated
+// Risor function, as in "(s: string, sep?: string, ...rest) -> list". A "?"
+// suffix marks a parameter as optional and types may be unions such as
+// "int | float". A parameter without a type accepts any type.
+func ParseSignature(s string) (*Signature, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(") {
+		return nil, fmt.Errorf("invalid signature %q: expected \"(\"", s)
+	}
+	end := strings.Index(s, ")")
+	if end < 0 {
+		return nil, fmt.Errorf("invalid signature %q: expected \")\"", s)
+	}
+	sig := &Signature{}
+	if params := strings.TrimSpace(s[1:end]); params != "" {
+		for _, field := range strings.Split(params, ",") {
+			param, err := parseParam(field)
+			if err != nil {
+				return nil, fmt.Errorf("invalid signature %q: %w", s, err)
+			}
+			if n := len(sig.Params); n > 0 && sig.Params[n-1].Variadic {
+				return nil, fmt.Errorf("invalid signature %q: variadic parameter must be the last parameter", s)
+			}
+			sig.Params = append(sig.Params, param)
+		}
+	}
+	rest := strings.TrimSpace(s[end+1:])
+	if rest == "" {
+		return sig, nil
+	}
+	returns, ok := strings.CutPrefix(rest, "->")
+	if !ok {
+		return nil, fmt.Errorf("invalid signature %q: expected \"->\"", s)
+	}
+	types, err := parseTypes(returns)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature %q: %w", s, err)
+	}
+	sig.Returns = types
+	return sig, nil
+}
+
+// MustParseSignature is like ParseSignature but panics if the signature is
+// invalid. It is intended for signatures defined alongside builtins.
+func MustParseSignature(s string) *Signature {
+	sig, err := ParseSignature(s)
+	if err != nil {
+		panic(err)
+	}
+	return sig
+}
+
+func parseParam(field string) (Param, error) {
+	var param Param
+	name, types, hasTypes := strings.Cut(field, ":")
+	name = strings.TrimSpace(name)
+	if after, ok := strings.CutPrefix(name, "..."); ok {
+		param.Variadic = true
+		name = after
+	}
+	if before, ok := strings.CutSuffix(name, "?"); ok {
+		param.Optional = true
+		name = before
+	}
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return Param{}, fmt.Errorf("invalid parameter name %q", name)
+	}
+	param.Name = name
+	if hasTypes {
+		parsed, err := parseTypes(types)
+		if err != nil {
+			return Param{}, err
+		}
+		param.Types = parsed
+	}
+	return param, nil
+}
+
+func parseTypes(s string) ([]Type, error) {
+	var types []Type
+	for _, name := range strings.Split(s, "|") {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid type name %q", name)
+		}
+		types = append(types, Type(name))
+	}
+	return types, nil
+}
+
+func joinTypes(types []Type) string {
+	names := make([]string, 0, len(types))
+	for _, t := range types {
+		names = append(names, string(t))
+	}
+	return strings.Join(names, " | ")
+}
@@ -0,0 +1,61 @@
+package object
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSignature(t *testing.T) {
+	sig, err := ParseSignature("(s: string, sep?: string | nil, ...rest) -> list")
+	require.Nil(t, err)
+	require.Equal(t, []Param{
+		{Name: "s", Types: []Type{STRING}},
+		{Name: "sep", Types: []Type{STRING, NIL}, Optional: true},
+		{Name: "rest", Variadic: true},
+	}, sig.Params)
+	require.Equal(t, []Type{LIST}, sig.Returns)
+	require.Equal(t, 1, sig.MinArgs())
+	require.Equal(t, -1, sig.MaxArgs())
+	require.Equal(t, "(s: string, sep?: string | nil, ...rest) -> list", sig.String())
+
+	sig, err = ParseSignature("()")
+	require.Nil(t, err)
+	require.Len(t, sig.Params, 0)
+	require.Len(t, sig.Returns, 0)
+	require.Equal(t, 0, sig.MaxArgs())
+
+	sig, err = ParseSignature("(a, b?)")
+	require.Nil(t, err)
+	require.Equal(t, 1, sig.MinArgs())
+	require.Equal(t, 2, sig.MaxArgs())
+}
+
+func TestParseSignatureErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x: int", `invalid signature "x: int": expected "("`},
+		{"(x: int", `invalid signature "(x: int": expected ")"`},
+		{"(x: int) list", `invalid signature "(x: int) list": expected "->"`},
+		{"(x:)", `invalid signature "(x:)": invalid type name ""`},
+		{"(...a, b)", `invalid signature "(...a, b)": variadic parameter must be the last parameter`},
+		{"(a,)", `invalid signature "(a,)": invalid parameter name ""`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := ParseSignature(tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestBuiltinSignature(t *testing.T) {
+	b := NewBuiltin("noop", nil)
+	require.Nil(t, b.Signature())
+	sig := MustParseSignature("(x: int) -> int")
+	require.Equal(t, b, b.WithSignature(sig))
+	require.Equal(t, sig, b.Signature())
+}
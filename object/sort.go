@@ -1,34 +1,46 @@
 package object
 
 import (
-	"fmt"
+	"context"
 	"sort"
+
+	"github.com/risor-io/risor/errz"
 )
 
-// Sort a list in place. If the list contains a non-comparable object, an error
-// is returned.
-func Sort(items []Object) *Error {
-	var comparableErr string
+// Sort a list in place. Items that define the __cmp__ hook are ordered using
+// it. If the list contains a non-comparable object, an error is returned.
+func Sort(ctx context.Context, items []Object) *Error {
+	var sortErr error
 	sort.SliceStable(items, func(a, b int) bool {
-		itemA := items[a]
-		itemB := items[b]
-		compA, ok := itemA.(Comparable)
-		if !ok {
-			comparableErr = fmt.Sprintf(
-				"type error: sorted() encountered a non-comparable item (%s)", itemA.Type())
-		}
-		if _, ok := itemB.(Comparable); !ok {
-			comparableErr = fmt.Sprintf(
-				"type error: sorted() encountered a non-comparable item (%s)", itemB.Type())
+		if sortErr != nil {
+			return false
 		}
-		result, err := compA.Compare(itemB)
+		result, err := compareItems(ctx, items[a], items[b])
 		if err != nil {
-			comparableErr = err.Error()
+			sortErr = err
+			return false
 		}
-		return result == -1
+		return result < 0
 	})
-	if comparableErr != "" {
-		return TypeErrorf(comparableErr)
+	if sortErr != nil {
+		return NewError(sortErr)
 	}
 	return nil
 }
+
+// compareItems compares two items being sorted, using the __cmp__ hook if
+// either item defines one.
+func compareItems(ctx context.Context, a, b Object) (int64, error) {
+	if result, ok, err := CallCmpHook(ctx, a, b); ok {
+		return result, err
+	}
+	compA, ok := a.(Comparable)
+	if !ok {
+		return 0, errz.TypeErrorf("type error: sorted() encountered a non-comparable item (%s)", a.Type())
+	}
+	if _, ok := b.(Comparable); !ok {
+		return 0, errz.TypeErrorf("type error: sorted() encountered a non-comparable item (%s)", b.Type())
+	}
+	result, err := compA.Compare(b)
+	return int64(result), err
+}
@@ -43,168 +43,129 @@ func (s *String) HashKey() HashKey {
 	return HashKey{Type: s.Type(), StrValue: s.value}
 }
 
+var stringMethods = NewMethodTable(STRING, map[string]MethodFunction{
+	"contains": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.contains", 1, len(args))
+		}
+		return s.Contains(args[0])
+	},
+	"has_prefix": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.has_prefix", 1, len(args))
+		}
+		return s.HasPrefix(args[0])
+	},
+	"has_suffix": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.has_suffix", 1, len(args))
+		}
+		return s.HasSuffix(args[0])
+	},
+	"count": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.count", 1, len(args))
+		}
+		return s.Count(args[0])
+	},
+	"join": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.join", 1, len(args))
+		}
+		return s.Join(args[0])
+	},
+	"split": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.split", 1, len(args))
+		}
+		return s.Split(args[0])
+	},
+	"fields": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 0 {
+			return NewArgsError("string.fields", 0, len(args))
+		}
+		return s.Fields()
+	},
+	"index": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.index", 1, len(args))
+		}
+		return s.Index(args[0])
+	},
+	"last_index": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.last_index", 1, len(args))
+		}
+		return s.LastIndex(args[0])
+	},
+	"replace_all": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 2 {
+			return NewArgsError("string.replace_all", 2, len(args))
+		}
+		return s.ReplaceAll(args[0], args[1])
+	},
+	"to_lower": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 0 {
+			return NewArgsError("string.to_lower", 0, len(args))
+		}
+		return s.ToLower()
+	},
+	"to_upper": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 0 {
+			return NewArgsError("string.to_upper", 0, len(args))
+		}
+		return s.ToUpper()
+	},
+	"trim": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.trim", 1, len(args))
+		}
+		return s.Trim(args[0])
+	},
+	"trim_prefix": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.trim_prefix", 1, len(args))
+		}
+		return s.TrimPrefix(args[0])
+	},
+	"trim_space": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 0 {
+			return NewArgsError("string.trim_space", 0, len(args))
+		}
+		return s.TrimSpace()
+	},
+	"trim_suffix": func(ctx context.Context, self Object, args ...Object) Object {
+		s := self.(*String)
+		if len(args) != 1 {
+			return NewArgsError("string.trim_suffix", 1, len(args))
+		}
+		return s.TrimSuffix(args[0])
+	},
+})
+
+// Methods returns the table of methods for strings.
+func (s *String) Methods() *MethodTable {
+	return stringMethods
+}
+
 func (s *String) GetAttr(name string) (Object, bool) {
-	switch name {
-	case "contains":
-		return &Builtin{
-			name: "string.contains",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.contains", 1, len(args))
-				}
-				return s.Contains(args[0])
-			},
-		}, true
-	case "has_prefix":
-		return &Builtin{
-			name: "string.has_prefix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.has_prefix", 1, len(args))
-				}
-				return s.HasPrefix(args[0])
-			},
-		}, true
-	case "has_suffix":
-		return &Builtin{
-			name: "string.has_suffix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.has_suffix", 1, len(args))
-				}
-				return s.HasSuffix(args[0])
-			},
-		}, true
-	case "count":
-		return &Builtin{
-			name: "string.count",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.count", 1, len(args))
-				}
-				return s.Count(args[0])
-			},
-		}, true
-	case "join":
-		return &Builtin{
-			name: "string.join",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.join", 1, len(args))
-				}
-				return s.Join(args[0])
-			},
-		}, true
-	case "split":
-		return &Builtin{
-			name: "string.split",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.split", 1, len(args))
-				}
-				return s.Split(args[0])
-			},
-		}, true
-	case "fields":
-		return &Builtin{
-			name: "string.fields",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.fields", 0, len(args))
-				}
-				return s.Fields()
-			},
-		}, true
-	case "index":
-		return &Builtin{
-			name: "string.index",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.index", 1, len(args))
-				}
-				return s.Index(args[0])
-			},
-		}, true
-	case "last_index":
-		return &Builtin{
-			name: "string.last_index",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.last_index", 1, len(args))
-				}
-				return s.LastIndex(args[0])
-			},
-		}, true
-	case "replace_all":
-		return &Builtin{
-			name: "string.replace_all",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 2 {
-					return NewArgsError("string.replace_all", 2, len(args))
-				}
-				return s.ReplaceAll(args[0], args[1])
-			},
-		}, true
-	case "to_lower":
-		return &Builtin{
-			name: "string.to_lower",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.to_lower", 0, len(args))
-				}
-				return s.ToLower()
-			},
-		}, true
-	case "to_upper":
-		return &Builtin{
-			name: "string.to_upper",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.to_upper", 0, len(args))
-				}
-				return s.ToUpper()
-			},
-		}, true
-	case "trim":
-		return &Builtin{
-			name: "string.trim",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.trim", 1, len(args))
-				}
-				return s.Trim(args[0])
-			},
-		}, true
-	case "trim_prefix":
-		return &Builtin{
-			name: "string.trim_prefix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.trim_prefix", 1, len(args))
-				}
-				return s.TrimPrefix(args[0])
-			},
-		}, true
-	case "trim_space":
-		return &Builtin{
-			name: "string.trim_space",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 0 {
-					return NewArgsError("string.trim_space", 0, len(args))
-				}
-				return s.TrimSpace()
-			},
-		}, true
-	case "trim_suffix":
-		return &Builtin{
-			name: "string.trim_suffix",
-			fn: func(ctx context.Context, args ...Object) Object {
-				if len(args) != 1 {
-					return NewArgsError("string.trim_suffix", 1, len(args))
-				}
-				return s.TrimSuffix(args[0])
-			},
-		}, true
+	if method, ok := stringMethods.Get(name); ok {
+		return method.Bind(s), true
 	}
 	return nil, false
 }
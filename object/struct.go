@@ -0,0 +1,319 @@
+package object
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/op"
+)
+
+var (
+	_ Callable        = (*StructType)(nil) // Ensure that *StructType implements Callable
+	_ KeywordCallable = (*StructType)(nil) // Ensure that *StructType implements KeywordCallable
+)
+
+// StructType is a user defined record type with a fixed, ordered set of
+// fields. Calling a StructType constructs a new Struct instance.
+type StructType struct {
+	*base
+	name     string
+	fields   []string
+	indexes  map[string]int
+	defaults []Object
+
+	// mutableDefaults is true if any default is a list, map, set or struct,
+	// which must be copied for each instance
+	mutableDefaults bool
+}
+
+func (t *StructType) Type() Type {
+	return STRUCT_TYPE
+}
+
+func (t *StructType) Name() string {
+	return t.name
+}
+
+// Fields returns the names of the fields in declaration order.
+func (t *StructType) Fields() []string {
+	fields := make([]string, len(t.fields))
+	copy(fields, t.fields)
+	return fields
+}
+
+func (t *StructType) Inspect() string {
+	return fmt.Sprintf("struct(%s)", t.name)
+}
+
+func (t *StructType) String() string {
+	return t.Inspect()
+}
+
+func (t *StructType) Interface() interface{} {
+	return t
+}
+
+func (t *StructType) GetAttr(name string) (Object, bool) {
+	switch name {
+	case "__name__":
+		return NewString(t.name), true
+	case "fields":
+		return NewBuiltin("fields", func(ctx context.Context, args ...Object) Object {
+			if len(args) != 0 {
+				return NewArgsError("fields", 0, len(args))
+			}
+			fields := make([]Object, 0, len(t.fields))
+			for _, field := range t.fields {
+				fields = append(fields, NewString(field))
+			}
+			return NewList(fields)
+		}), true
+	}
+	return nil, false
+}
+
+func (t *StructType) Equals(other Object) Object {
+	if t == other {
+		return True
+	}
+	return False
+}
+
+// Call constructs a new instance of the struct. Arguments are assigned to the
+// fields in declaration order and any remaining fields take their default
+// values. Each instance receives its own copy of mutable defaults.
+func (t *StructType) Call(ctx context.Context, args ...Object) Object {
+	if len(args) > len(t.fields) {
+		return NewArgsRangeError(t.name, 0, len(t.fields), len(args))
+	}
+	values := make([]Object, len(t.fields))
+	copy(values, t.defaults)
+	copy(values, args)
+	if t.mutableDefaults {
+		copies := map[Object]Object{}
+		for i := len(args); i < len(values); i++ {
+			values[i] = copyDefault(values[i], copies)
+		}
+	}
+	return &Struct{typ: t, values: values}
+}
+
+// CallWithKeywords constructs a new instance of the struct, assigning keyword
+// arguments to the fields of the same name.
+func (t *StructType) CallWithKeywords(ctx context.Context, kwargs *Map, args ...Object) Object {
+	result := t.Call(ctx, args...)
+	s, ok := result.(*Struct)
+	if !ok {
+		return result
+	}
+	for _, key := range kwargs.SortedKeys() {
+		index, ok := t.indexes[key]
+		if !ok {
+			return TypeErrorf("type error: struct %s has no field %q", t.name, key)
+		}
+		if index < len(args) {
+			return NewError(errz.ArgsErrorf("args error: %s() got multiple values for field %q", t.name, key))
+		}
+		s.values[index] = kwargs.Get(key)
+	}
+	return s
+}
+
+func (t *StructType) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for struct type: %v", opType)
+}
+
+func (t *StructType) MarshalJSON() ([]byte, error) {
+	return nil, errz.TypeErrorf("type error: unable to marshal struct type")
+}
+
+// NewStructType returns a new struct type with the given field names. The
+// defaults are parallel to the fields. Immutable defaults are shared by all
+// instances of the struct, while lists, maps, sets and structs are copied for
+// each instance. A nil default is treated as Nil.
+func NewStructType(name string, fields []string, defaults []Object) *StructType {
+	t := &StructType{
+		name:     name,
+		fields:   make([]string, len(fields)),
+		indexes:  make(map[string]int, len(fields)),
+		defaults: make([]Object, len(fields)),
+	}
+	copy(t.fields, fields)
+	for i, field := range fields {
+		t.indexes[field] = i
+		if i < len(defaults) && defaults[i] != nil {
+			t.defaults[i] = defaults[i]
+		} else {
+			t.defaults[i] = Nil
+		}
+		switch t.defaults[i].(type) {
+		case *List, *Map, *Set, *Struct:
+			t.mutableDefaults = true
+		}
+	}
+	return t
+}
+
+// copyDefault returns a copy of a default value, so that the instances of a
+// struct don't share mutable defaults. Containers nested in the value are
+// copied too. The copies map holds the values already copied, which preserves
+// shared and recursive references within the value.
+func copyDefault(value Object, copies map[Object]Object) Object {
+	switch value.(type) {
+	case *List, *Map, *Set, *Struct:
+		if result, ok := copies[value]; ok {
+			return result
+		}
+	}
+	switch value := value.(type) {
+	case *List:
+		result := &List{items: make([]Object, len(value.items))}
+		copies[value] = result
+		for i, item := range value.items {
+			result.items[i] = copyDefault(item, copies)
+		}
+		return result
+	case *Map:
+		result := &Map{items: make(map[string]Object, len(value.items))}
+		copies[value] = result
+		for key, item := range value.items {
+			result.items[key] = copyDefault(item, copies)
+		}
+		return result
+	case *Set:
+		// Set items are hashable, so they are never mutable containers
+		result := NewSetWithSize(len(value.items))
+		copies[value] = result
+		for key, item := range value.items {
+			result.items[key] = item
+		}
+		return result
+	case *Struct:
+		result := &Struct{typ: value.typ, values: make([]Object, len(value.values))}
+		copies[value] = result
+		for i, item := range value.values {
+			result.values[i] = copyDefault(item, copies)
+		}
+		return result
+	}
+	return value
+}
+
+// Struct is an instance of a StructType. Only the fields declared by the type
+// may be read or assigned.
+type Struct struct {
+	*base
+	typ           *StructType
+	values        []Object
+	inspectActive bool
+}
+
+// Type returns the name of the struct type, so that type() reports the
+// declared name rather than "struct".
+func (s *Struct) Type() Type {
+	return Type(s.typ.name)
+}
+
+// StructType returns the type this struct is an instance of.
+func (s *Struct) StructType() *StructType {
+	return s.typ
+}
+
+func (s *Struct) Inspect() string {
+	// A struct can contain itself. Detect if we're already inspecting the
+	// struct and return a placeholder if so.
+	if s.inspectActive {
+		return fmt.Sprintf("%s(...)", s.typ.name)
+	}
+	s.inspectActive = true
+	defer func() { s.inspectActive = false }()
+
+	fields := make([]string, 0, len(s.values))
+	for i, value := range s.values {
+		fields = append(fields, fmt.Sprintf("%s=%s", s.typ.fields[i], value.Inspect()))
+	}
+	return fmt.Sprintf("%s(%s)", s.typ.name, strings.Join(fields, ", "))
+}
+
+func (s *Struct) String() string {
+	return s.Inspect()
+}
+
+func (s *Struct) Interface() interface{} {
+	result := make(map[string]interface{}, len(s.values))
+	for i, value := range s.values {
+		result[s.typ.fields[i]] = value.Interface()
+	}
+	return result
+}
+
+func (s *Struct) GetAttr(name string) (Object, bool) {
+	if index, ok := s.typ.indexes[name]; ok {
+		return s.values[index], true
+	}
+	return nil, false
+}
+
+func (s *Struct) SetAttr(name string, value Object) error {
+	index, ok := s.typ.indexes[name]
+	if !ok {
+		return errz.TypeErrorf("type error: struct %s has no field %q", s.typ.name, name)
+	}
+	s.values[index] = value
+	return nil
+}
+
+func (s *Struct) Equals(other Object) Object {
+	otherStruct, ok := other.(*Struct)
+	if !ok || s.typ != otherStruct.typ {
+		return False
+	}
+	for i, value := range s.values {
+		if !value.Equals(otherStruct.values[i]).IsTruthy() {
+			return False
+		}
+	}
+	return True
+}
+
+func (s *Struct) RunOperation(opType op.BinaryOpType, right Object) Object {
+	return TypeErrorf("type error: unsupported operation for struct: %v", opType)
+}
+
+// MarshalJSON encodes the struct as a JSON object with keys in the order the
+// fields were declared.
+func (s *Struct) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, value := range s.values {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(s.typ.fields[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(data)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// NewStruct returns a new instance of the given struct type with the given
+// field values, which must be parallel to the fields of the type.
+func NewStruct(typ *StructType, values []Object) *Struct {
+	if len(values) != len(typ.fields) {
+		panic(fmt.Sprintf("NewStruct: expected %d values (got %d)", len(typ.fields), len(values)))
+	}
+	return &Struct{typ: typ, values: values}
+}
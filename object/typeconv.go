@@ -6,7 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/big"
 	"reflect"
+	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -76,6 +80,11 @@ func AsInt(obj Object) (int64, *Error) {
 		return obj.value, nil
 	case *Byte:
 		return int64(obj.value), nil
+	case *BigInt:
+		if !obj.value.IsInt64() {
+			return 0, Errorf("value error: bigint is out of range for an integer (%s)", obj.value)
+		}
+		return obj.value.Int64(), nil
 	default:
 		return 0, TypeErrorf("type error: expected an integer (%s given)", obj.Type())
 	}
@@ -107,6 +116,11 @@ func AsFloat(obj Object) (float64, *Error) {
 		return float64(obj.value), nil
 	case *Float:
 		return obj.value, nil
+	case *BigInt:
+		f, _ := new(big.Float).SetInt(obj.value).Float64()
+		return f, nil
+	case *Decimal:
+		return obj.Float64(), nil
 	default:
 		return 0.0, TypeErrorf("type error: expected a number (%s given)", obj.Type())
 	}
@@ -261,10 +275,9 @@ func FromGoType(obj interface{}) Object {
 	case float64:
 		return NewFloat(obj)
 	case json.Number:
-		if n, err := obj.Float64(); err == nil {
-			return NewFloat(n)
-		}
-		return NewString(obj.String())
+		return fromJSONNumber(obj)
+	case *big.Int:
+		return NewBigInt(obj)
 	case string:
 		return NewString(obj)
 	case []string:
@@ -314,6 +327,75 @@ func FromGoType(obj interface{}) Object {
 	}
 }
 
+// FromJSON decodes the given JSON document to an object. Numbers that don't
+// fit in a float without losing precision are decoded as a BigInt or Decimal.
+func FromJSON(data []byte) (Object, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	err := decoder.Decode(&value)
+	if err == nil {
+		if _, err = decoder.Token(); err == io.EOF {
+			result := FromGoType(value)
+			if err, ok := result.(*Error); ok {
+				return nil, err
+			}
+			return result, nil
+		}
+	}
+	// Report the same errors as json.Unmarshal, which are more descriptive
+	// for truncated documents and trailing data
+	if unmarshalErr := json.Unmarshal(data, &value); unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+	return nil, err
+}
+
+// fromJSONNumber converts a JSON number to a float, as long as that can be
+// done without losing precision. Otherwise, integers become a BigInt and other
+// numbers become a Decimal, so that no digits are lost. Numbers with an
+// exponent beyond MaxDecimalExponent are rejected rather than expanded.
+func fromJSONNumber(n json.Number) Object {
+	text := n.String()
+	// Any number with up to 15 significant digits converts to a float and
+	// back without loss, provided it's within the normal range of floats.
+	// This covers nearly all numbers, without the cost of an exact parse.
+	if digits := significantDigits(text); digits <= 15 {
+		f, err := strconv.ParseFloat(text, 64)
+		if err == nil && (digits == 0 || math.Abs(f) >= 0x1p-1022) {
+			return NewFloat(f)
+		}
+	}
+	exact, err := ParseDecimal(text)
+	if err != nil {
+		return NewError(err)
+	}
+	if f, err := n.Float64(); err == nil {
+		if asDecimal, err := NewDecimalFromFloat(f); err == nil && asDecimal.cmp(exact) == 0 {
+			return NewFloat(f)
+		}
+	}
+	if !strings.ContainsAny(text, ".eE") {
+		return NewBigInt(exact.value)
+	}
+	return exact
+}
+
+// significantDigits returns the number of digits in the mantissa of a JSON
+// number, not counting leading zeros.
+func significantDigits(text string) int {
+	mantissa, _, _ := strings.Cut(strings.ToLower(text), "e")
+	var count int
+	for _, c := range mantissa {
+		switch {
+		case c == '0' && count == 0, c < '0' || c > '9':
+		default:
+			count++
+		}
+	}
+	return count
+}
+
 // AsObjects transform a map containing arbitrary Go types to a map of
 // Risor objects, using the best type converter for each type. If an item
 // in the map is of a type that can't be converted, an error is returned.
@@ -14,19 +14,25 @@ const (
 	ReturnValue Code = 4
 	Defer       Code = 5
 	Go          Code = 6
+	CallSpread  Code = 7
+	CallKw      Code = 8
+	Yield       Code = 9
 
 	// Jump
 	JumpBackward          Code = 10
 	JumpForward           Code = 11
 	PopJumpForwardIfFalse Code = 12
 	PopJumpForwardIfTrue  Code = 13
+	JumpForwardIfNil      Code = 14
+	JumpForwardIfNotNil   Code = 15
 
 	// Load
-	LoadAttr   Code = 20
-	LoadFast   Code = 21
-	LoadFree   Code = 22
-	LoadGlobal Code = 23
-	LoadConst  Code = 24
+	LoadAttr      Code = 20
+	LoadFast      Code = 21
+	LoadFree      Code = 22
+	LoadGlobal    Code = 23
+	LoadConst     Code = 24
+	LoadAttrOrNil Code = 25
 
 	// Store
 	StoreAttr   Code = 30
@@ -39,20 +45,29 @@ const (
 	CompareOp     Code = 41
 	UnaryNegative Code = 42
 	UnaryNot      Code = 43
+	FormatValue   Code = 44
 
 	// Build
 	BuildList   Code = 50
 	BuildMap    Code = 51
 	BuildSet    Code = 52
 	BuildString Code = 53
+	BuildStruct Code = 54
+	ListExtend  Code = 55
+	MapMerge    Code = 56
+	ListAppend  Code = 57
+	MapAdd      Code = 58
+	SetAdd      Code = 59
 
 	// Containers
-	BinarySubscr Code = 60
-	StoreSubscr  Code = 61
-	ContainsOp   Code = 62
-	Length       Code = 63
-	Slice        Code = 64
-	Unpack       Code = 65
+	BinarySubscr      Code = 60
+	StoreSubscr       Code = 61
+	ContainsOp        Code = 62
+	Length            Code = 63
+	Slice             Code = 64
+	Unpack            Code = 65
+	BinarySubscrOrNil Code = 66
+	UnpackRest        Code = 67
 
 	// Stack
 	Swap   Code = 70
@@ -76,13 +91,42 @@ const (
 	// Channels
 	Receive Code = 110
 	Send    Code = 111
+	Select  Code = 112
 
 	// Closures
 	LoadClosure Code = 120
 	MakeCell    Code = 121
 
 	// Partials
-	Partial Code = 130
+	Partial       Code = 130
+	PartialSpread Code = 131
+	PartialKw     Code = 132
+
+	// Exceptions
+	SetupCatch   Code = 140
+	SetupFinally Code = 141
+	PopExcept    Code = 142
+	Raise        Code = 143
+
+	// Pattern matching
+	MatchType Code = 150
+
+	// Methods
+	LoadMethod Code = 160
+	CallMethod Code = 161
+
+	// Superinstructions. Each one replaces the opcode of the first instruction
+	// in a common sequence and executes the whole sequence at once. The other
+	// instructions of the sequence are left in place and supply the remaining
+	// operands, so the first operand is the only one counted here.
+	LoadFastLoadFast     Code = 170
+	BinaryOpFastConst    Code = 171
+	IncrementFast        Code = 172
+	CompareJump          Code = 173
+	CompareFastConstJump Code = 174
+
+	// Tail calls
+	TailCall Code = 180
 )
 
 // BinaryOpType describes a type of binary operation, as in an operation that
@@ -174,6 +218,16 @@ func (cop CompareOpType) String() string {
 	}
 }
 
+// FormatConversion describes a conversion that FormatValue applies to a value
+// before formatting it, as in the !r of the template expression {value!r}.
+type FormatConversion uint16
+
+const (
+	FormatNone FormatConversion = 0
+	FormatStr  FormatConversion = 1 // Converts the value with its __str__ hook
+	FormatRepr FormatConversion = 2 // Converts the value with Inspect
+)
+
 // Info contains information about an opcode.
 type Info struct {
 	Code         Code
@@ -191,43 +245,71 @@ func init() {
 	}
 	ops := []opInfo{
 		{BinaryOp, "BINARY_OP", 1},
+		{BinaryOpFastConst, "BINARY_OP_FAST_CONST", 1},
 		{BinarySubscr, "BINARY_SUBSCR", 0},
+		{BinarySubscrOrNil, "BINARY_SUBSCR_OR_NIL", 0},
 		{BuildList, "BUILD_LIST", 1},
 		{BuildMap, "BUILD_MAP", 1},
 		{BuildSet, "BUILD_SET", 1},
 		{BuildString, "BUILD_STRING", 1},
+		{BuildStruct, "BUILD_STRUCT", 1},
 		{Call, "CALL", 1},
+		{CallKw, "CALL_KW", 0},
+		{CallMethod, "CALL_METHOD", 1},
+		{CallSpread, "CALL_SPREAD", 0},
+		{CompareFastConstJump, "COMPARE_FAST_CONST_JUMP", 1},
+		{CompareJump, "COMPARE_JUMP", 1},
 		{CompareOp, "COMPARE_OP", 1},
 		{ContainsOp, "CONTAINS_OP", 1},
 		{Copy, "COPY", 1},
 		{Defer, "DEFER", 0},
 		{False, "FALSE", 0},
 		{ForIter, "FOR_ITER", 2},
+		{FormatValue, "FORMAT_VALUE", 2},
 		{FromImport, "FROM_IMPORT", 2},
 		{GetIter, "GET_ITER", 0},
 		{Go, "GO", 0},
 		{Halt, "HALT", 0},
 		{Import, "IMPORT", 0},
+		{IncrementFast, "INCREMENT_FAST", 1},
 		{JumpBackward, "JUMP_BACKWARD", 1},
 		{JumpForward, "JUMP_FORWARD", 1},
+		{JumpForwardIfNil, "JUMP_FORWARD_IF_NIL", 1},
+		{JumpForwardIfNotNil, "JUMP_FORWARD_IF_NOT_NIL", 1},
 		{Length, "LENGTH", 0},
+		{ListAppend, "LIST_APPEND", 1},
+		{ListExtend, "LIST_EXTEND", 0},
 		{LoadAttr, "LOAD_ATTR", 1},
+		{LoadAttrOrNil, "LOAD_ATTR_OR_NIL", 1},
 		{LoadClosure, "LOAD_CLOSURE", 2},
 		{LoadConst, "LOAD_CONST", 1},
 		{LoadFast, "LOAD_FAST", 1},
+		{LoadFastLoadFast, "LOAD_FAST_LOAD_FAST", 1},
 		{LoadFree, "LOAD_FREE", 1},
 		{LoadGlobal, "LOAD_GLOBAL", 1},
+		{LoadMethod, "LOAD_METHOD", 1},
 		{MakeCell, "MAKE_CELL", 2},
+		{MapAdd, "MAP_ADD", 1},
+		{MapMerge, "MAP_MERGE", 0},
+		{MatchType, "MATCH_TYPE", 1},
 		{Nil, "NIL", 0},
 		{Nop, "NOP", 0},
 		{Partial, "PARTIAL", 1},
+		{PartialKw, "PARTIAL_KW", 0},
+		{PartialSpread, "PARTIAL_SPREAD", 0},
 		{PopJumpForwardIfFalse, "POP_JUMP_FORWARD_IF_FALSE", 1},
 		{PopJumpForwardIfTrue, "POP_JUMP_FORWARD_IF_TRUE", 1},
+		{PopExcept, "POP_EXCEPT", 0},
 		{PopTop, "POP_TOP", 0},
+		{Raise, "RAISE", 0},
 		{Range, "RANGE", 0},
 		{Receive, "RECEIVE", 0},
 		{ReturnValue, "RETURN_VALUE", 0},
+		{Select, "SELECT", 2},
 		{Send, "SEND", 0},
+		{SetAdd, "SET_ADD", 1},
+		{SetupCatch, "SETUP_CATCH", 1},
+		{SetupFinally, "SETUP_FINALLY", 1},
 		{Slice, "SLICE", 0},
 		{StoreAttr, "STORE_ATTR", 1},
 		{StoreFast, "STORE_FAST", 1},
@@ -235,10 +317,13 @@ func init() {
 		{StoreGlobal, "STORE_GLOBAL", 1},
 		{StoreSubscr, "STORE_SUBSCR", 0},
 		{Swap, "SWAP", 1},
+		{TailCall, "TAIL_CALL", 1},
 		{True, "TRUE", 0},
 		{UnaryNegative, "UNARY_NEGATIVE", 0},
 		{UnaryNot, "UNARY_NOT", 0},
 		{Unpack, "UNPACK", 1},
+		{UnpackRest, "UNPACK_REST", 2},
+		{Yield, "YIELD", 0},
 	}
 	for _, o := range ops {
 		infos[o.op] = Info{
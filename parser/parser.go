@@ -32,6 +32,32 @@ var statementTerminators = map[token.Type]bool{
 	token.MINUS_MINUS: true,
 }
 
+// matchValueStarts holds the tokens that may follow "match" to begin a match
+// expression. Since "match" is not a keyword, it otherwise remains usable as
+// a name, e.g. in a call like match(x).
+var matchValueStarts = map[token.Type]bool{
+	token.IDENT:    true,
+	token.INT:      true,
+	token.FLOAT:    true,
+	token.STRING:   true,
+	token.FSTRING:  true,
+	token.BACKTICK: true,
+	token.TRUE:     true,
+	token.FALSE:    true,
+	token.NIL:      true,
+}
+
+// ambiguousMatchValueStarts holds the tokens that may begin the value of a
+// match expression, but that may also follow a name called "match", as in
+// match(x), match[0] or match - 1. These are only treated as the start of a
+// match expression if the value is followed by a block of cases.
+var ambiguousMatchValueStarts = map[token.Type]bool{
+	token.LBRACKET: true,
+	token.LBRACE:   true,
+	token.LPAREN:   true,
+	token.MINUS:    true,
+}
+
 // Parse the provided input as Risor source code and return the AST. This is
 // shorthand way to create a Lexer and Parser and then call Parse on that.
 func Parse(ctx context.Context, input string, options ...Option) (*ast.Program, error) {
@@ -125,6 +151,7 @@ func New(l *lexer.Lexer, options ...Option) *Parser {
 	p.registerPrefix(token.BACKTICK, p.parseString)
 	p.registerPrefix(token.BANG, p.parsePrefixExpr)
 	p.registerPrefix(token.DEFER, p.parseDefer)
+	p.registerPrefix(token.ELLIPSIS, p.parseSpread)
 	p.registerPrefix(token.EOF, p.illegalToken)
 	p.registerPrefix(token.FALSE, p.parseBoolean)
 	p.registerPrefix(token.FLOAT, p.parseFloat)
@@ -174,6 +201,8 @@ func New(l *lexer.Lexer, options ...Option) *Parser {
 	p.registerInfix(token.NOT, p.parseNotIn)
 	p.registerInfix(token.OR, p.parseInfixExpr)
 	p.registerInfix(token.PERIOD, p.parseGetAttr)
+	p.registerInfix(token.QUESTION_PERIOD, p.parseOptionalChain)
+	p.registerInfix(token.QUESTION_QUESTION, p.parseInfixExpr)
 	p.registerInfix(token.PIPE, p.parsePipe)
 	p.registerInfix(token.PLUS_EQUALS, p.parseAssign)
 	p.registerInfix(token.PLUS, p.parseInfixExpr)
@@ -319,17 +348,32 @@ func (p *Parser) parseStatement() ast.Node {
 		stmt = p.parseVar()
 	case token.CONST:
 		stmt = p.parseConst()
+	case token.STRUCT:
+		stmt = p.parseStruct()
 	case token.RETURN:
 		stmt = p.parseReturn()
-	case token.BREAK:
-		stmt = p.parseBreak()
-	case token.CONTINUE:
-		stmt = p.parseContinue()
+	case token.YIELD:
+		stmt = p.parseYield()
+	case token.BREAK, token.CONTINUE:
+		stmt = p.parseLoopControl()
 	case token.NEWLINE:
 		stmt = nil
+	case token.LBRACKET, token.LBRACE:
+		stmt = p.parseExpressionStatement()
+		if stmt != nil && p.peekTokenIs(token.DECLARE) {
+			stmt = p.parseDestructure(stmt)
+		}
 	case token.IDENT:
 		if p.peekTokenIs(token.DECLARE) || p.peekTokenIs(token.COMMA) {
 			stmt = p.parseDeclaration()
+		} else if p.peekTokenIs(token.COLON) {
+			stmt = p.parseLabeledLoop()
+		} else if p.curToken.Literal == "try" && p.peekTokenIs(token.LBRACE) {
+			// "try" is not a keyword, so that the try builtin remains usable
+			stmt = p.parseTry()
+		} else if p.curToken.Literal == "select" && p.peekTokenIs(token.LBRACE) {
+			// "select" is not a keyword, so it may still be used as a name
+			stmt = p.parseSelect()
 		} else {
 			stmt = p.parseExpressionStatement()
 		}
@@ -356,6 +400,13 @@ func (p *Parser) parseVar() ast.Node {
 		}
 		idents = append(idents, ast.NewIdent(p.curToken))
 	}
+	var typ *ast.TypeAnnotation
+	if len(idents) == 1 && p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if typ = p.parseTypeAnnotation("var statement"); typ == nil {
+			return nil
+		}
+	}
 	if !p.expectPeek("var statement", token.ASSIGN) {
 		return nil
 	}
@@ -367,19 +418,22 @@ func (p *Parser) parseVar() ast.Node {
 	if len(idents) > 1 {
 		return ast.NewMultiVar(tok, idents, value, false)
 	}
-	return ast.NewVar(tok, idents[0], value)
+	return ast.NewVar(tok, idents[0], value).Annotate(typ)
 }
 
 func (p *Parser) parseDeclaration() ast.Node {
 	tok := p.curToken
-	idents := []*ast.Ident{ast.NewIdent(p.curToken)}
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		if !p.expectPeek("declaration statement", token.IDENT) {
-			return nil
-		}
-		idents = append(idents, ast.NewIdent(p.curToken))
+	targets := p.parseTargets("declaration statement")
+	if targets == nil {
+		return nil
 	}
+	return p.parseDeclarationValue(tok, targets)
+}
+
+// parseDeclarationValue parses the assignment operator and value that follow
+// the targets of a declaration. The current token must be the last token of
+// the targets.
+func (p *Parser) parseDeclarationValue(tok token.Token, targets []ast.Expression) ast.Node {
 	var isWalrus bool
 	switch p.peekToken.Type {
 	case token.ASSIGN:
@@ -396,18 +450,151 @@ func (p *Parser) parseDeclaration() ast.Node {
 	if value == nil {
 		return nil
 	}
+	idents := make([]*ast.Ident, 0, len(targets))
+	for _, target := range targets {
+		if ident, ok := target.(*ast.Ident); ok {
+			idents = append(idents, ident)
+		}
+	}
+	if len(idents) < len(targets) {
+		return ast.NewDestructure(tok, targets, value, isWalrus)
+	}
 	if len(idents) > 1 {
 		return ast.NewMultiVar(tok, idents, value, isWalrus)
 	}
 	return ast.NewDeclaration(tok, idents[0], value)
 }
 
+// parseDestructure parses the value assigned to a list, set or map of targets
+// with ":=", as in "[a, [b, c]] := x". The current token must be the last
+// token of the targets.
+func (p *Parser) parseDestructure(node ast.Node) ast.Node {
+	target, ok := node.(ast.Expression)
+	if !ok || !p.checkTarget(target, false) {
+		p.setTokenError(node.Token(), "invalid target in declaration statement: %s", node.String())
+		return nil
+	}
+	return p.parseDeclarationValue(node.Token(), []ast.Expression{target})
+}
+
+// parseTargets parses a comma separated list of destructuring targets, as in
+// "a, [b, c], ...rest", starting at the current token.
+func (p *Parser) parseTargets(context string) []ast.Expression {
+	target := p.parseTarget(context)
+	if target == nil {
+		return nil
+	}
+	targets := []ast.Expression{target}
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		switch p.peekToken.Type {
+		case token.IDENT, token.LBRACKET, token.LBRACE, token.ELLIPSIS:
+			p.nextToken()
+		default:
+			p.peekError(context, token.IDENT, p.peekToken)
+			return nil
+		}
+		if target = p.parseTarget(context); target == nil {
+			return nil
+		}
+		targets = append(targets, target)
+	}
+	if !p.checkRestTargets(targets) {
+		return nil
+	}
+	return targets
+}
+
+// parseTarget parses a single destructuring target starting at the current
+// token. A target is a name, a rest target such as ...rest, or a list, set or
+// map of targets.
+func (p *Parser) parseTarget(context string) ast.Expression {
+	if p.curTokenIs(token.IDENT) {
+		return ast.NewIdent(p.curToken)
+	}
+	tok := p.curToken
+	target := p.parseExpression(PREFIX)
+	if target == nil {
+		return nil
+	}
+	if !p.checkTarget(target, true) {
+		p.setTokenError(tok, "invalid target in %s: %s", context, target.String())
+		return nil
+	}
+	return target
+}
+
+// checkTarget returns true if the expression is a valid destructuring target.
+// Rest targets are only valid directly within a list of targets.
+func (p *Parser) checkTarget(target ast.Expression, allowRest bool) bool {
+	switch target := target.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.Spread:
+		_, ok := target.Value().(*ast.Ident)
+		return allowRest && ok
+	case *ast.List:
+		for _, item := range target.Items() {
+			if !p.checkTarget(item, true) {
+				return false
+			}
+		}
+		return p.checkRestTargets(target.Items())
+	case *ast.Set:
+		for _, item := range target.Items() {
+			if _, ok := item.(*ast.Ident); !ok {
+				return false
+			}
+		}
+		return true
+	case *ast.Map:
+		items := target.Items()
+		for _, key := range target.Order() {
+			switch key.(type) {
+			case *ast.Ident, *ast.String:
+			default:
+				return false
+			}
+			if !p.checkTarget(items[key], false) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// checkRestTargets reports an error if a list of targets contains more than
+// one rest target.
+func (p *Parser) checkRestTargets(targets []ast.Expression) bool {
+	var found bool
+	for _, target := range targets {
+		if _, ok := target.(*ast.Spread); !ok {
+			continue
+		}
+		if found {
+			p.setTokenError(target.Token(), "multiple rest targets in assignment")
+			return false
+		}
+		found = true
+	}
+	return true
+}
+
 func (p *Parser) parseConst() *ast.Const {
 	tok := p.curToken
 	if !p.expectPeek("const statement", token.IDENT) {
 		return nil
 	}
 	ident := ast.NewIdent(p.curToken)
+	var typ *ast.TypeAnnotation
+	if p.peekTokenIs(token.COLON) {
+		p.nextToken()
+		if typ = p.parseTypeAnnotation("const statement"); typ == nil {
+			return nil
+		}
+	}
 	if !p.expectPeek("const statement", token.ASSIGN) {
 		return nil
 	}
@@ -416,7 +603,129 @@ func (p *Parser) parseConst() *ast.Const {
 	if value == nil {
 		return nil
 	}
-	return ast.NewConst(tok, ident, value)
+	return ast.NewConst(tok, ident, value).Annotate(typ)
+}
+
+// parseTypeAnnotation parses the type following a ":" or "->" token, which
+// must be the current token. A type is one or more type names separated by
+// "|", as in "int | nil".
+func (p *Parser) parseTypeAnnotation(context string) *ast.TypeAnnotation {
+	var tok token.Token
+	var names []string
+	for {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+		switch p.curToken.Type {
+		case token.IDENT, token.NIL, token.FUNC:
+		default:
+			p.setTokenError(p.curToken, "expected a type name in %s (got %s)", context, p.curToken.Literal)
+			return nil
+		}
+		if names == nil {
+			tok = p.curToken
+		}
+		names = append(names, p.curToken.Literal)
+		if !p.peekTokenIs(token.PIPE) {
+			break
+		}
+		p.nextToken()
+	}
+	return ast.NewTypeAnnotation(tok, names)
+}
+
+func (p *Parser) parseStruct() ast.Node {
+	tok := p.curToken
+	if !p.expectPeek("struct statement", token.IDENT) {
+		return nil
+	}
+	name := ast.NewIdent(p.curToken)
+	if !p.expectPeek("struct statement", token.LBRACE) {
+		return nil
+	}
+	if err := p.nextToken(); err != nil {
+		return nil
+	}
+	p.eatNewlines()
+	var fields []*ast.Ident
+	var defaults []ast.Expression
+	for !p.curTokenIs(token.RBRACE) { // Keep going until we find a "}"
+		if p.curTokenIs(token.EOF) {
+			p.setTokenError(p.prevToken, "unterminated struct statement")
+			return nil
+		}
+		if !p.curTokenIs(token.IDENT) {
+			p.setTokenError(p.curToken, "expected an identifier (got %s)", p.curToken.Literal)
+			return nil
+		}
+		fields = append(fields, ast.NewIdent(p.curToken))
+		// If there is "=expr" after the name then expr is a default value
+		var value ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			if value = p.parseExpression(LOWEST); value == nil {
+				return nil
+			}
+		}
+		defaults = append(defaults, value)
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+		// Fields are separated by commas, newlines, or both
+		switch p.curToken.Type {
+		case token.COMMA, token.NEWLINE, token.SEMICOLON:
+			if err := p.nextToken(); err != nil {
+				return nil
+			}
+			p.eatNewlines()
+		case token.RBRACE, token.EOF:
+		default:
+			p.setTokenError(p.curToken, "unexpected token %q in struct statement", p.curToken.Literal)
+			return nil
+		}
+	}
+	return ast.NewStruct(tok, name, fields, defaults)
+}
+
+func (p *Parser) parseTry() ast.Node {
+	tryToken := p.curToken
+	if !p.expectPeek("try statement", token.LBRACE) {
+		return nil
+	}
+	body := p.parseBlock()
+	if body == nil {
+		return nil
+	}
+	var catchIdent *ast.Ident
+	var catchBlock, finallyBlock *ast.Block
+	if p.peekIdentIs("catch") {
+		p.nextToken() // move to the "catch"
+		if p.peekTokenIs(token.IDENT) {
+			p.nextToken()
+			catchIdent = ast.NewIdent(p.curToken)
+		}
+		if !p.expectPeek("catch block", token.LBRACE) {
+			return nil
+		}
+		if catchBlock = p.parseBlock(); catchBlock == nil {
+			return nil
+		}
+	}
+	if p.peekIdentIs("finally") {
+		p.nextToken() // move to the "finally"
+		if !p.expectPeek("finally block", token.LBRACE) {
+			return nil
+		}
+		if finallyBlock = p.parseBlock(); finallyBlock == nil {
+			return nil
+		}
+	}
+	if catchBlock == nil && finallyBlock == nil {
+		p.setTokenError(tryToken, "try statement requires a catch or finally block")
+		return nil
+	}
+	return ast.NewTry(tryToken, body, catchIdent, catchBlock, finallyBlock)
 }
 
 // Parses the right hand side of an assignment statement.
@@ -452,12 +761,53 @@ func (p *Parser) parseReturn() *ast.Return {
 	return ast.NewReturn(returnToken, value)
 }
 
-func (p *Parser) parseBreak() *ast.Control {
-	return ast.NewControl(p.curToken, nil)
+func (p *Parser) parseYield() *ast.Yield {
+	yieldToken := p.curToken
+	if p.peekTokenIs(token.SEMICOLON) ||
+		p.peekTokenIs(token.NEWLINE) ||
+		p.peekTokenIs(token.RBRACE) ||
+		p.peekTokenIs(token.EOF) {
+		return ast.NewYield(yieldToken, nil)
+	}
+	p.nextToken()
+	value := p.parseExpression(LOWEST)
+	if value == nil {
+		return nil
+	}
+	return ast.NewYield(yieldToken, value)
+}
+
+// parseLoopControl parses a break or continue statement, which may name the
+// label of the loop it targets.
+func (p *Parser) parseLoopControl() *ast.Control {
+	tok := p.curToken
+	if p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		return ast.NewLabeledControl(tok, ast.NewIdent(p.curToken))
+	}
+	return ast.NewControl(tok, nil)
 }
 
-func (p *Parser) parseContinue() *ast.Control {
-	return ast.NewControl(p.curToken, nil)
+// parseLabeledLoop parses a loop preceded by a label, as in "outer: for".
+func (p *Parser) parseLabeledLoop() ast.Node {
+	label := ast.NewIdent(p.curToken)
+	p.nextToken() // move to the ":"
+	if err := p.nextToken(); err != nil {
+		return nil
+	}
+	p.eatNewlines()
+	if !p.curTokenIs(token.FOR) {
+		p.setTokenError(p.curToken, "expected a for loop after label %q (got %s)",
+			label.Literal(), p.curToken.Literal)
+		return nil
+	}
+	switch loop := p.parseFor().(type) {
+	case *ast.For:
+		return loop.WithLabel(label)
+	case *ast.ForIn:
+		return loop.WithLabel(label)
+	}
+	return nil
 }
 
 func (p *Parser) parseExpressionStatement() ast.Node {
@@ -546,7 +896,18 @@ func (p *Parser) parseIdent() ast.Node {
 		p.setTokenError(p.curToken, "invalid identifier")
 		return nil
 	}
-	return ast.NewIdent(p.curToken)
+	if p.curToken.Literal == "match" {
+		if matchValueStarts[p.peekToken.Type] ||
+			(ambiguousMatchValueStarts[p.peekToken.Type] && p.isMatchExpression()) {
+			return p.parseMatch()
+		}
+	}
+	ident := ast.NewIdent(p.curToken)
+	if p.peekTokenIs(token.FAT_ARROW) {
+		// Arrow function with a single parameter, e.g. x => x * 2
+		return p.parseArrowFunc(p.curToken, []ast.Node{ident})
+	}
+	return ident
 }
 
 func (p *Parser) parseInt() ast.Node {
@@ -636,69 +997,241 @@ func (p *Parser) parseSwitch() ast.Node {
 		if !p.expectPeek("switch statement", token.COLON) {
 			return nil
 		}
-		// Now we are at the block of code to be executed for this case
-		p.nextToken()
-		p.eatNewlines()
-		// An empty case statement is valid
-		if p.curTokenIs(token.CASE) || p.curTokenIs(token.DEFAULT) || p.curTokenIs(token.RBRACE) {
-			if isDefaultCase {
-				defaultCaseCount++
-				if defaultCaseCount > 1 {
-					p.setTokenError(caseToken, "switch statement has multiple default blocks")
-					return nil
-				}
-				cases = append(cases, ast.NewDefaultCase(caseToken, nil))
-			} else {
-				cases = append(cases, ast.NewCase(caseToken, caseExprs, nil))
+		block, ok := p.parseCaseBlock()
+		if !ok {
+			return nil
+		}
+		if isDefaultCase {
+			defaultCaseCount++
+			if defaultCaseCount > 1 {
+				p.setTokenError(caseToken, "switch statement has multiple default blocks")
+				return nil
 			}
-			continue
+			cases = append(cases, ast.NewDefaultCase(caseToken, block))
+		} else {
+			cases = append(cases, ast.NewCase(caseToken, caseExprs, block))
 		}
-		blockFirstToken := p.curToken
-		var blockStatements []ast.Node
-		for {
-			// Skip over newlines and semicolons
-			for p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) {
-				if err := p.nextToken(); err != nil {
+	}
+	return ast.NewSwitch(switchToken, switchValue, cases)
+}
+
+// isMatchExpression looks ahead to determine whether the current "match"
+// token begins a match expression, i.e. whether it is followed by a value and
+// then a block starting with "case" or "default". The state of the parser and
+// lexer is restored afterwards.
+func (p *Parser) isMatchExpression() bool {
+	savedParser, savedLexer := *p, *p.l
+	defer func() {
+		*p, *p.l = savedParser, savedLexer
+	}()
+	p.nextToken()
+	if p.parseExpression(LOWEST) == nil || p.err != nil || !p.peekTokenIs(token.LBRACE) {
+		return false
+	}
+	p.nextToken()
+	p.nextToken()
+	p.eatNewlines()
+	return p.err == nil && (p.curTokenIs(token.CASE) || p.curTokenIs(token.DEFAULT))
+}
+
+func (p *Parser) parseMatch() ast.Node {
+	matchToken := p.curToken
+	p.nextToken()
+	matchValue := p.parseExpression(LOWEST)
+	if matchValue == nil {
+		return nil
+	}
+	if !p.expectPeek("match expression", token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+	p.eatNewlines()
+	var cases []*ast.MatchCase
+	var defaultCaseCount int
+	// Each time through this loop we process one case statement
+	for !p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.EOF) {
+			p.setTokenError(p.prevToken, "unterminated match expression")
+			return nil
+		}
+		caseToken := p.curToken
+		var patterns []ast.Expression
+		var guard ast.Expression
+		switch p.curToken.Type {
+		case token.DEFAULT:
+			defaultCaseCount++
+			if defaultCaseCount > 1 {
+				p.setTokenError(caseToken, "match expression has multiple default blocks")
+				return nil
+			}
+		case token.CASE:
+			// One or more comma separated patterns, then an optional guard
+			for {
+				p.nextToken() // move to the pattern
+				pattern := p.parseExpression(LOWEST)
+				if pattern == nil {
 					return nil
 				}
+				patterns = append(patterns, pattern)
+				if !p.peekTokenIs(token.COMMA) {
+					break
+				}
+				p.nextToken() // move to the comma
 			}
-			// Any of these tokens indicate the end of the current case
-			if p.curTokenIs(token.CASE) ||
-				p.curTokenIs(token.DEFAULT) ||
-				p.curTokenIs(token.RBRACE) ||
-				p.curTokenIs(token.EOF) {
-				break
-			}
-			// Parse one statement
-			if s := p.parseStatement(); s != nil {
-				blockStatements = append(blockStatements, s)
-			}
-			if !p.curTokenIs(token.SEMICOLON) &&
-				!statementTerminators[p.peekToken.Type] &&
-				!p.peekTokenIs(token.CASE) &&
-				!p.peekTokenIs(token.DEFAULT) &&
-				!p.peekTokenIs(token.RBRACE) {
-				p.peekError("case statement", token.SEMICOLON, p.peekToken)
-				return nil
+			if p.peekTokenIs(token.IF) {
+				p.nextToken() // move to the "if"
+				p.nextToken() // move to the guard expression
+				if guard = p.parseExpression(LOWEST); guard == nil {
+					return nil
+				}
 			}
-			// Move to the token just beyond the statement
+		default:
+			p.setTokenError(p.curToken, "expected 'case' or 'default' (got %s)", p.curToken.Literal)
+			return nil
+		}
+		if !p.expectPeek("match expression", token.COLON) {
+			return nil
+		}
+		block, ok := p.parseCaseBlock()
+		if !ok {
+			return nil
+		}
+		if caseToken.Type == token.DEFAULT {
+			cases = append(cases, ast.NewMatchDefaultCase(caseToken, block))
+		} else {
+			cases = append(cases, ast.NewMatchCase(caseToken, patterns, guard, block))
+		}
+	}
+	return ast.NewMatch(matchToken, matchValue, cases)
+}
+
+// parseCaseBlock parses the statements following the colon of a case or
+// default clause, up to the next clause or the closing brace. The returned
+// block is nil if the case is empty.
+func (p *Parser) parseCaseBlock() (*ast.Block, bool) {
+	// Now we are at the block of code to be executed for this case
+	p.nextToken()
+	p.eatNewlines()
+	// An empty case statement is valid
+	if p.curTokenIs(token.CASE) || p.curTokenIs(token.DEFAULT) || p.curTokenIs(token.RBRACE) {
+		return nil, true
+	}
+	blockFirstToken := p.curToken
+	var blockStatements []ast.Node
+	for {
+		// Skip over newlines and semicolons
+		for p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) {
 			if err := p.nextToken(); err != nil {
-				return nil
+				return nil, false
 			}
 		}
-		block := ast.NewBlock(blockFirstToken, blockStatements)
-		if isDefaultCase {
+		// Any of these tokens indicate the end of the current case
+		if p.curTokenIs(token.CASE) ||
+			p.curTokenIs(token.DEFAULT) ||
+			p.curTokenIs(token.RBRACE) ||
+			p.curTokenIs(token.EOF) {
+			break
+		}
+		// Parse one statement
+		if s := p.parseStatement(); s != nil {
+			blockStatements = append(blockStatements, s)
+		}
+		if !p.curTokenIs(token.SEMICOLON) &&
+			!statementTerminators[p.peekToken.Type] &&
+			!p.peekTokenIs(token.CASE) &&
+			!p.peekTokenIs(token.DEFAULT) &&
+			!p.peekTokenIs(token.RBRACE) {
+			p.peekError("case statement", token.SEMICOLON, p.peekToken)
+			return nil, false
+		}
+		// Move to the token just beyond the statement
+		if err := p.nextToken(); err != nil {
+			return nil, false
+		}
+	}
+	return ast.NewBlock(blockFirstToken, blockStatements), true
+}
+
+func (p *Parser) parseSelect() ast.Node {
+	selectToken := p.curToken
+	if !p.expectPeek("select statement", token.LBRACE) {
+		return nil
+	}
+	p.nextToken()
+	p.eatNewlines()
+	var cases []*ast.SelectCase
+	var defaultCaseCount int
+	// Each time through this loop we process one case statement
+	for !p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.EOF) {
+			p.setTokenError(p.prevToken, "unterminated select statement")
+			return nil
+		}
+		caseToken := p.curToken
+		var send *ast.Send
+		var receive *ast.Receive
+		var valueIdent, okIdent *ast.Ident
+		switch p.curToken.Type {
+		case token.DEFAULT:
 			defaultCaseCount++
 			if defaultCaseCount > 1 {
-				p.setTokenError(caseToken, "switch statement has multiple default blocks")
+				p.setTokenError(caseToken, "select statement has multiple default blocks")
 				return nil
 			}
-			cases = append(cases, ast.NewDefaultCase(caseToken, block))
-		} else {
-			cases = append(cases, ast.NewCase(caseToken, caseExprs, block))
+		case token.CASE:
+			p.nextToken() // move to the token following "case"
+			// The received value may be assigned to a variable, optionally
+			// followed by a second variable indicating success
+			if p.curTokenIs(token.IDENT) && (p.peekTokenIs(token.DECLARE) || p.peekTokenIs(token.COMMA)) {
+				valueIdent = ast.NewIdent(p.curToken)
+				if p.peekTokenIs(token.COMMA) {
+					p.nextToken()
+					if !p.expectPeek("select case", token.IDENT) {
+						return nil
+					}
+					okIdent = ast.NewIdent(p.curToken)
+				}
+				if !p.expectPeek("select case", token.DECLARE) {
+					return nil
+				}
+				p.nextToken() // move to the receive operation
+			}
+			opToken := p.curToken
+			node := p.parseNode(LOWEST)
+			if node == nil {
+				return nil
+			}
+			switch node := node.(type) {
+			case *ast.Send:
+				send = node
+			case *ast.Receive:
+				receive = node
+			}
+			if (send == nil && receive == nil) || (send != nil && valueIdent != nil) {
+				p.setTokenError(opToken, "select case must be a channel send or receive")
+				return nil
+			}
+		default:
+			p.setTokenError(p.curToken, "expected 'case' or 'default' (got %s)", p.curToken.Literal)
+			return nil
+		}
+		if !p.expectPeek("select statement", token.COLON) {
+			return nil
+		}
+		block, ok := p.parseCaseBlock()
+		if !ok {
+			return nil
+		}
+		switch {
+		case send != nil:
+			cases = append(cases, ast.NewSelectSendCase(caseToken, send, block))
+		case receive != nil:
+			cases = append(cases, ast.NewSelectReceiveCase(caseToken, receive, valueIdent, okIdent, block))
+		default:
+			cases = append(cases, ast.NewSelectDefaultCase(caseToken, block))
 		}
 	}
-	return ast.NewSwitch(switchToken, switchValue, cases)
+	return ast.NewSelect(selectToken, cases)
 }
 
 // validateImportPath ensures that a given path string only contains valid identifiers
@@ -957,6 +1490,19 @@ func (p *Parser) parsePrefixExpr() ast.Node {
 	return ast.NewPrefix(operator, right)
 }
 
+func (p *Parser) parseSpread() ast.Node {
+	ellipsis := p.curToken
+	if err := p.nextToken(); err != nil {
+		return nil
+	}
+	value := p.parseExpression(PREFIX)
+	if value == nil {
+		p.setTokenError(p.curToken, "invalid spread expression")
+		return nil
+	}
+	return ast.NewSpread(ellipsis, value)
+}
+
 func (p *Parser) parseNewline() ast.Node {
 	p.nextToken()
 	return nil
@@ -1020,12 +1566,133 @@ func (p *Parser) parseTernary(conditionNode ast.Node) ast.Node {
 }
 
 func (p *Parser) parseGroupedExpr() ast.Node {
+	lparen := p.curToken
+	// Empty parentheses may only begin an arrow function, e.g. () => 1
+	if p.peekTokenIs(token.RPAREN) {
+		p.nextToken()
+		if !p.peekTokenIs(token.FAT_ARROW) {
+			p.peekError("arrow function", token.FAT_ARROW, p.peekToken)
+			return nil
+		}
+		return p.parseArrowFunc(lparen, nil)
+	}
 	p.nextToken()
-	exp := p.parseExpression(LOWEST)
+	// Parameters with defaults are parsed as assignments, so the contents are
+	// parsed as nodes until it is known whether this is an arrow function
+	node := p.parseNode(LOWEST)
+	if node == nil || p.err != nil {
+		return nil
+	}
+	// A comma separates the parameters of an arrow function, e.g. (a, b) => a
+	items := []ast.Node{node}
+	var comma *token.Token
+	for p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if comma == nil {
+			tok := p.curToken
+			comma = &tok
+		}
+		p.nextToken()
+		item := p.parseNode(LOWEST)
+		if item == nil || p.err != nil {
+			return nil
+		}
+		items = append(items, item)
+	}
+	if comma != nil && !p.peekTokenIs(token.RPAREN) {
+		p.peekError("grouped expression", token.RPAREN, *comma)
+		return nil
+	}
 	if !p.expectPeek("grouped expression", token.RPAREN) {
 		return nil
 	}
-	return exp
+	if p.peekTokenIs(token.FAT_ARROW) {
+		return p.parseArrowFunc(lparen, items)
+	}
+	if comma != nil {
+		p.peekError("grouped expression", token.RPAREN, *comma)
+		return nil
+	}
+	if _, ok := node.(ast.Expression); !ok {
+		p.setTokenError(p.prevToken, "expected expression")
+		return nil
+	}
+	return node
+}
+
+// parseArrowFunc parses the "=>" and body of an arrow function, which is a
+// concise form of an anonymous function. The body is either a block, as in
+// (a, b) => { return a + b }, or a single expression whose value is returned,
+// as in x => x * 2. The parameters were already parsed as expressions, and
+// may be names, defaults like b=1 or a rest parameter like ...rest.
+func (p *Parser) parseArrowFunc(start token.Token, items []ast.Node) ast.Node {
+	params := make([]*ast.Ident, 0, len(items))
+	defaults := map[string]ast.Expression{}
+	var rest *ast.Ident
+	for _, item := range items {
+		if rest != nil {
+			p.setTokenError(item.Token(), "variadic parameter must be the last parameter")
+			return nil
+		}
+		switch item := item.(type) {
+		case *ast.Ident:
+			params = append(params, item)
+			continue
+		case *ast.Assign:
+			if item.Index() == nil && item.Operator() == "=" {
+				ident := ast.NewIdent(token.Token{
+					Type:          token.IDENT,
+					Literal:       item.Name(),
+					StartPosition: item.Token().StartPosition,
+					EndPosition:   item.Token().EndPosition,
+				})
+				params = append(params, ident)
+				defaults[ident.Literal()] = item.Value()
+				continue
+			}
+		case *ast.Spread:
+			if ident, ok := item.Value().(*ast.Ident); ok {
+				rest = ident
+				continue
+			}
+		}
+		p.setTokenError(item.Token(), "invalid arrow function parameter: %s", item.String())
+		return nil
+	}
+	p.nextToken() // move to the "=>"
+	arrow := p.curToken
+	for p.peekTokenIs(token.NEWLINE) {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+	}
+	var body *ast.Block
+	if p.peekTokenIs(token.LBRACE) {
+		p.nextToken()
+		if body = p.parseBlock(); body == nil {
+			return nil
+		}
+	} else {
+		p.nextToken()
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			p.setTokenError(arrow, "arrow function is missing a body")
+			return nil
+		}
+		// The value of the last expression in a function body is returned
+		body = ast.NewBlock(arrow, []ast.Node{value})
+	}
+	// The function is represented just as if it were written with "func"
+	funcToken := token.Token{
+		Type:          token.FUNC,
+		Literal:       "func",
+		StartPosition: start.StartPosition,
+		EndPosition:   arrow.EndPosition,
+	}
+	if rest != nil {
+		return ast.NewVariadicFunc(funcToken, nil, params, defaults, rest, body)
+	}
+	return ast.NewFunc(funcToken, nil, params, defaults, body)
 }
 
 // Parses an entire if, else if, else block. Else-ifs are handled recursively.
@@ -1077,36 +1744,27 @@ func (p *Parser) parseFor() ast.Node {
 		return ast.NewSimpleFor(forToken, consequence)
 	}
 
-	// Check for Python-style "for x in iterable" form
-	if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.IN) {
-		// Parse variable identifier
-		variable := ast.NewIdent(p.curToken)
-		p.nextToken() // Move to 'in'
-		p.nextToken() // Move past 'in'
-
-		// Parse the iterable expression
-		iterable := p.parseExpression(LOWEST)
-		if iterable == nil {
-			p.setTokenError(p.curToken, "invalid iterable in for-in loop")
+	// Check for Python-style "for x in iterable" form, and for loops that
+	// destructure each value, e.g. "for i, [a, b] := range x"
+	var init, condition, post ast.Node
+	if (p.curTokenIs(token.IDENT) && (p.peekTokenIs(token.IN) || p.peekTokenIs(token.COMMA))) ||
+		p.curTokenIs(token.LBRACKET) || p.curTokenIs(token.LBRACE) {
+		targetToken := p.curToken
+		targets := p.parseTargets("for loop")
+		if targets == nil {
 			return nil
 		}
-
-		// Expect opening brace directly (no colon)
-		if !p.expectPeek("for-in loop", token.LBRACE) {
-			return nil
+		if p.peekTokenIs(token.IN) {
+			return p.parseForIn(forToken, targets)
 		}
-
-		consequence := p.parseBlock()
-		if consequence == nil {
+		if init = p.parseDeclarationValue(targetToken, targets); init == nil {
 			return nil
 		}
-
-		return ast.NewForIn(forToken, variable, iterable, consequence)
-	}
-
-	// Parse the initialization or condition
-	var init, condition, post ast.Node
-	if !p.curTokenIs(token.SEMICOLON) {
+		if p.peekTokenIs(token.SEMICOLON) {
+			p.nextToken()
+		}
+	} else if !p.curTokenIs(token.SEMICOLON) {
+		// Parse the initialization or condition
 		init = p.parseStatement()
 		if init == nil {
 			p.setTokenError(p.curToken, "invalid for loop expression")
@@ -1137,6 +1795,11 @@ func (p *Parser) parseFor() ast.Node {
 		if _, ok := expr.(*ast.Range); ok {
 			isRangeLoop = true
 		}
+	case *ast.Destructure:
+		_, expr := initNode.Value()
+		if _, ok := expr.(*ast.Range); ok {
+			isRangeLoop = true
+		}
 	}
 
 	if isRangeLoop {
@@ -1200,6 +1863,40 @@ func (p *Parser) parseFor() ast.Node {
 	return ast.NewFor(forToken, condition, consequence, init, post)
 }
 
+// parseForIn parses the remainder of a Python-style for-in loop, following the
+// given loop targets. One target receives each value, while two targets receive
+// each key and value.
+func (p *Parser) parseForIn(forToken token.Token, targets []ast.Expression) ast.Node {
+	if len(targets) > 2 {
+		p.setTokenError(targets[2].Token(), "too many variables in for-in loop")
+		return nil
+	}
+	p.nextToken() // Move to 'in'
+	p.nextToken() // Move past 'in'
+
+	// Parse the iterable expression
+	iterable := p.parseExpression(LOWEST)
+	if iterable == nil {
+		p.setTokenError(p.curToken, "invalid iterable in for-in loop")
+		return nil
+	}
+
+	// Expect opening brace directly (no colon)
+	if !p.expectPeek("for-in loop", token.LBRACE) {
+		return nil
+	}
+
+	consequence := p.parseBlock()
+	if consequence == nil {
+		return nil
+	}
+
+	if variable, ok := targets[0].(*ast.Ident); ok && len(targets) == 1 {
+		return ast.NewForIn(forToken, variable, iterable, consequence)
+	}
+	return ast.NewForInDestructure(forToken, targets, iterable, consequence)
+}
+
 func (p *Parser) parseBlock() *ast.Block {
 	blockToken := p.curToken // Should be '{'
 	statements := []ast.Node{}
@@ -1232,42 +1929,90 @@ func (p *Parser) parseFunc() ast.Node {
 	if !p.expectPeek("function", token.LPAREN) { // Move to the "("
 		return nil
 	}
-	defaults, params := p.parseFuncParams()
+	defaults, params, rest, types := p.parseFuncParams()
+	var returnType *ast.TypeAnnotation
+	if p.peekTokenIs(token.ARROW) { // Read optional return type
+		p.nextToken()
+		if returnType = p.parseTypeAnnotation("function"); returnType == nil {
+			return nil
+		}
+	}
 	if !p.expectPeek("function", token.LBRACE) { // move to the "{"
 		return nil
 	}
-	return ast.NewFunc(funcToken, ident, params, defaults, p.parseBlock())
+	var fn *ast.Func
+	if rest != nil {
+		fn = ast.NewVariadicFunc(funcToken, ident, params, defaults, rest, p.parseBlock())
+	} else {
+		fn = ast.NewFunc(funcToken, ident, params, defaults, p.parseBlock())
+	}
+	if len(types) > 0 || returnType != nil {
+		fn.Annotate(types, returnType)
+	}
+	return fn
 }
 
-func (p *Parser) parseFuncParams() (map[string]ast.Expression, []*ast.Ident) {
+func (p *Parser) parseFuncParams() (map[string]ast.Expression, []*ast.Ident, *ast.Ident, map[string]*ast.TypeAnnotation) {
 	// If the next parameter is ")", then there are no parameters
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
-		return map[string]ast.Expression{}, nil
+		return map[string]ast.Expression{}, nil, nil, nil
 	}
+	types := map[string]*ast.TypeAnnotation{}
 	defaults := map[string]ast.Expression{}
 	params := make([]*ast.Ident, 0)
+	var rest *ast.Ident
 	p.nextToken()
 	for !p.curTokenIs(token.RPAREN) { // Keep going until we find a ")"
 		if p.curTokenIs(token.EOF) {
 			p.setTokenError(p.prevToken, "unterminated function parameters")
-			return nil, nil
+			return nil, nil, nil, nil
+		}
+		if rest != nil {
+			p.setTokenError(p.curToken, "variadic parameter must be the last parameter")
+			return nil, nil, nil, nil
+		}
+		// A "..." prefix marks the parameter that collects any extra arguments
+		isRest := p.curTokenIs(token.ELLIPSIS)
+		if isRest {
+			if err := p.nextToken(); err != nil {
+				return nil, nil, nil, nil
+			}
 		}
 		if !p.curTokenIs(token.IDENT) {
 			p.setTokenError(p.curToken, "expected an identifier (got %s)", p.curToken.Literal)
-			return nil, nil
+			return nil, nil, nil, nil
 		}
 		ident := ast.NewIdent(p.curToken)
-		params = append(params, ident)
+		if isRest {
+			rest = ident
+		} else {
+			params = append(params, ident)
+		}
 		if err := p.nextToken(); err != nil {
-			return nil, nil
+			return nil, nil, nil, nil
+		}
+		// If there is ": type" after the name then it is a type annotation
+		if p.curTokenIs(token.COLON) {
+			typ := p.parseTypeAnnotation("function parameters")
+			if typ == nil {
+				return nil, nil, nil, nil
+			}
+			types[ident.String()] = typ
+			if err := p.nextToken(); err != nil {
+				return nil, nil, nil, nil
+			}
 		}
 		// If there is "=expr" after the name then expr is a default value
 		if p.curTokenIs(token.ASSIGN) {
+			if isRest {
+				p.setTokenError(p.curToken, "variadic parameter cannot have a default value")
+				return nil, nil, nil, nil
+			}
 			p.nextToken()
 			expr := p.parseExpression(LOWEST)
 			if expr == nil {
-				return nil, nil
+				return nil, nil, nil, nil
 			}
 			defaults[ident.String()] = expr
 			p.nextToken()
@@ -1276,7 +2021,7 @@ func (p *Parser) parseFuncParams() (map[string]ast.Expression, []*ast.Ident) {
 			p.nextToken()
 		}
 	}
-	return defaults, params
+	return defaults, params, rest, types
 }
 
 func (p *Parser) parseGo() ast.Node {
@@ -1374,15 +2119,10 @@ func (p *Parser) parseString() ast.Node {
 
 func (p *Parser) parseList() ast.Node {
 	bracket := p.curToken
-	items := p.parseExprList(token.RBRACKET)
-	return ast.NewList(bracket, items)
-}
-
-func (p *Parser) parseExprList(end token.Type) []ast.Expression {
 	list := make([]ast.Expression, 0)
-	if p.peekTokenIs(end) {
+	if p.peekTokenIs(token.RBRACKET) {
 		p.nextToken()
-		return list
+		return ast.NewList(bracket, list)
 	}
 	for p.peekTokenIs(token.NEWLINE) {
 		if err := p.nextToken(); err != nil {
@@ -1395,6 +2135,21 @@ func (p *Parser) parseExprList(end token.Type) []ast.Expression {
 		p.setTokenError(p.curToken, "invalid syntax in list expression")
 		return nil
 	}
+	for p.peekTokenIs(token.NEWLINE) {
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+	}
+	if p.peekTokenIs(token.FOR) {
+		clauses := p.parseComprehensionClauses()
+		if clauses == nil {
+			return nil
+		}
+		if !p.expectPeek("list comprehension", token.RBRACKET) {
+			return nil
+		}
+		return ast.NewListComprehension(bracket, expr, clauses)
+	}
 	list = append(list, expr)
 	for p.peekTokenIs(token.COMMA) {
 		// move to the comma
@@ -1408,7 +2163,7 @@ func (p *Parser) parseExprList(end token.Type) []ast.Expression {
 			}
 		}
 		// check if the list has ended after the newlines
-		if p.peekTokenIs(end) {
+		if p.peekTokenIs(token.RBRACKET) {
 			break
 		}
 		// move to the next expression
@@ -1422,10 +2177,60 @@ func (p *Parser) parseExprList(end token.Type) []ast.Expression {
 			return nil
 		}
 	}
-	if !p.expectPeek("an expression list", end) {
+	if !p.expectPeek("an expression list", token.RBRACKET) {
 		return nil
 	}
-	return list
+	return ast.NewList(bracket, list)
+}
+
+// parseComprehensionClauses parses the "for" and "if" clauses that follow
+// the element of a comprehension, e.g. "for k, v in m if v > 0". The next
+// token must be the first "for".
+func (p *Parser) parseComprehensionClauses() []*ast.ComprehensionClause {
+	var clauses []*ast.ComprehensionClause
+	for p.peekTokenIs(token.FOR) {
+		p.nextToken() // move to the "for"
+		forToken := p.curToken
+		if !p.expectPeek("comprehension", token.IDENT) {
+			return nil
+		}
+		names := []*ast.Ident{ast.NewIdent(p.curToken)}
+		if p.peekTokenIs(token.COMMA) {
+			p.nextToken()
+			if !p.expectPeek("comprehension", token.IDENT) {
+				return nil
+			}
+			names = append(names, ast.NewIdent(p.curToken))
+		}
+		if !p.expectPeek("comprehension", token.IN) {
+			return nil
+		}
+		p.nextToken() // move to the iterable
+		iterable := p.parseExpression(LOWEST)
+		if iterable == nil {
+			return nil
+		}
+		var conditions []ast.Expression
+		for {
+			for p.peekTokenIs(token.NEWLINE) {
+				if err := p.nextToken(); err != nil {
+					return nil
+				}
+			}
+			if !p.peekTokenIs(token.IF) {
+				break
+			}
+			p.nextToken() // move to the "if"
+			p.nextToken() // move to the condition
+			condition := p.parseExpression(LOWEST)
+			if condition == nil {
+				return nil
+			}
+			conditions = append(conditions, condition)
+		}
+		clauses = append(clauses, ast.NewComprehensionClause(forToken, names, iterable, conditions))
+	}
+	return clauses
 }
 
 func (p *Parser) parseNodeList(end token.Type) []ast.Node {
@@ -1440,7 +2245,7 @@ func (p *Parser) parseNodeList(end token.Type) []ast.Node {
 		}
 	}
 	p.nextToken()
-	expr := p.parseNode(LOWEST)
+	expr := p.parseCallArgument()
 	if expr == nil {
 		p.setTokenError(p.curToken, "invalid syntax in list expression")
 		return nil
@@ -1465,7 +2270,7 @@ func (p *Parser) parseNodeList(end token.Type) []ast.Node {
 		if err := p.nextToken(); err != nil {
 			return nil
 		}
-		list = append(list, p.parseNode(LOWEST))
+		list = append(list, p.parseCallArgument())
 	}
 	for p.peekTokenIs(token.NEWLINE) {
 		if err := p.nextToken(); err != nil {
@@ -1478,6 +2283,24 @@ func (p *Parser) parseNodeList(end token.Type) []ast.Node {
 	return list
 }
 
+// parseCallArgument parses a single argument in a call. An argument of the
+// form "name=value" is a keyword argument.
+func (p *Parser) parseCallArgument() ast.Node {
+	if p.curTokenIs(token.IDENT) && p.peekTokenIs(token.ASSIGN) {
+		name := ast.NewIdent(p.curToken)
+		p.nextToken() // move to the "="
+		if err := p.nextToken(); err != nil {
+			return nil
+		}
+		value := p.parseExpression(LOWEST)
+		if value == nil {
+			return nil
+		}
+		return ast.NewKeywordArg(name, value)
+	}
+	return p.parseNode(LOWEST)
+}
+
 func (p *Parser) parseIndex(leftNode ast.Node) ast.Node {
 	left, ok := leftNode.(ast.Expression)
 	if !ok {
@@ -1517,7 +2340,28 @@ func (p *Parser) parseAssign(name ast.Node) ast.Node {
 	case *ast.Ident:
 		ident = node
 	case *ast.Index:
+		if node.IsOptional() {
+			p.setTokenError(operator, "cannot assign to an optional index")
+			return nil
+		}
 		index = node
+	case *ast.List, *ast.Set, *ast.Map:
+		// Destructuring assignment, e.g. [a, b] = [b, a]
+		if operator.Type != token.ASSIGN {
+			p.setTokenError(operator, "unsupported operator for assignment: %s", operator.Literal)
+			return nil
+		}
+		target := node.(ast.Expression)
+		if !p.checkTarget(target, false) {
+			p.setTokenError(node.Token(), "invalid target in assignment: %s", node.String())
+			return nil
+		}
+		p.nextToken() // move to the RHS value
+		value := p.parseAssignmentValue()
+		if value == nil {
+			return nil
+		}
+		return ast.NewDestructure(node.Token(), []ast.Expression{target}, value, false)
 	default:
 		p.setTokenError(operator, "unexpected token for assignment: %s", name.Literal())
 		return nil
@@ -1553,6 +2397,30 @@ func (p *Parser) parseCall(functionNode ast.Node) ast.Node {
 	if arguments == nil {
 		return nil
 	}
+	// Separate the keyword arguments, which must follow any positional ones
+	var positional []ast.Node
+	var keywords []*ast.KeywordArg
+	seen := map[string]bool{}
+	for _, arg := range arguments {
+		if kw, ok := arg.(*ast.KeywordArg); ok {
+			name := kw.Name().Literal()
+			if seen[name] {
+				p.setTokenError(kw.Token(), "duplicate keyword argument %q", name)
+				return nil
+			}
+			seen[name] = true
+			keywords = append(keywords, kw)
+			continue
+		}
+		if len(keywords) > 0 {
+			p.setTokenError(arg.Token(), "positional argument follows keyword argument")
+			return nil
+		}
+		positional = append(positional, arg)
+	}
+	if len(keywords) > 0 {
+		return ast.NewCallWithKeywords(callToken, function, positional, keywords)
+	}
 	return ast.NewCall(callToken, function, arguments)
 }
 
@@ -1673,11 +2541,25 @@ func (p *Parser) parseMapOrSet() ast.Node {
 	}
 	p.nextToken() // move to the first key
 	firstKey := p.parseExpression(LOWEST)
-	if p.peekTokenIs(token.COLON) { // This is a map
-		p.nextToken() // move to the ":"
-		p.nextToken() // move to the first value
-		firstValue := p.parseExpression(LOWEST)
-		pairs := map[ast.Expression]ast.Expression{firstKey: firstValue}
+	_, isSpread := firstKey.(*ast.Spread)
+	if isSpread || p.peekTokenIs(token.COLON) { // This is a map
+		pairs := map[ast.Expression]ast.Expression{}
+		order := []ast.Expression{firstKey}
+		if !isSpread {
+			p.nextToken() // move to the ":"
+			p.nextToken() // move to the first value
+			pairs[firstKey] = p.parseExpression(LOWEST)
+			if pairs[firstKey] != nil && p.peekTokenIs(token.FOR) {
+				clauses := p.parseComprehensionClauses()
+				if clauses == nil {
+					return nil
+				}
+				if !p.expectPeek("map comprehension", token.RBRACE) {
+					return nil
+				}
+				return ast.NewMapComprehension(firstToken, firstKey, pairs[firstKey], clauses)
+			}
+		}
 		for !p.peekTokenIs(token.RBRACE) {
 			if p.peekTokenIs(token.NEWLINE) {
 				p.nextToken()
@@ -1694,11 +2576,21 @@ func (p *Parser) parseMapOrSet() ast.Node {
 			if p.peekTokenIs(token.RBRACE) {
 				break
 			}
-			key, value := p.parseKeyValue()
-			if key == nil || value == nil {
-				return nil
+			if p.peekTokenIs(token.ELLIPSIS) {
+				p.nextToken()
+				spread := p.parseSpread()
+				if spread == nil {
+					return nil
+				}
+				order = append(order, spread.(ast.Expression))
+			} else {
+				key, value := p.parseKeyValue()
+				if key == nil || value == nil {
+					return nil
+				}
+				pairs[key] = value
+				order = append(order, key)
 			}
-			pairs[key] = value
 			if !p.peekTokenIs(token.COMMA) {
 				break
 			}
@@ -1711,9 +2603,19 @@ func (p *Parser) parseMapOrSet() ast.Node {
 		if !p.expectPeek("map", token.RBRACE) {
 			return nil
 		}
-		return ast.NewMap(firstToken, pairs)
+		return ast.NewOrderedMap(firstToken, pairs, order)
 	} else { // This is a set
 		items := []ast.Expression{firstKey}
+		if firstKey != nil && p.peekTokenIs(token.FOR) {
+			clauses := p.parseComprehensionClauses()
+			if clauses == nil {
+				return nil
+			}
+			if !p.expectPeek("set comprehension", token.RBRACE) {
+				return nil
+			}
+			return ast.NewSetComprehension(firstToken, firstKey, clauses)
+		}
 		if p.peekTokenIs(token.COMMA) {
 			p.nextToken()
 		} else if p.peekTokenIs(token.RBRACE) {
@@ -1772,7 +2674,7 @@ func (p *Parser) parseGetAttr(objNode ast.Node) ast.Node {
 	p.nextToken()
 	p.eatNewlines()
 	if !p.curTokenIs(token.IDENT) {
-		p.setTokenError(p.curToken, "expected an identifier after %q", ".")
+		p.setTokenError(p.curToken, "expected an identifier after %q", period.Literal)
 		return nil
 	}
 	name := p.parseIdent().(*ast.Ident)
@@ -1790,6 +2692,10 @@ func (p *Parser) parseGetAttr(objNode ast.Node) ast.Node {
 		p.peekTokenIs(token.MINUS_EQUALS) ||
 		p.peekTokenIs(token.ASTERISK_EQUALS) ||
 		p.peekTokenIs(token.SLASH_EQUALS) {
+		if period.Type == token.QUESTION_PERIOD {
+			p.setTokenError(p.peekToken, "cannot assign to an optional attribute")
+			return nil
+		}
 		p.nextToken() // move to the operator
 		operator := p.curToken
 		p.nextToken() // move to the value
@@ -1803,6 +2709,30 @@ func (p *Parser) parseGetAttr(objNode ast.Node) ast.Node {
 	return ast.NewGetAttr(period, obj, name)
 }
 
+// parseOptionalChain parses an attribute access, method call or index that
+// uses "?.", as in a?.b, a?.b() or a?.[key].
+func (p *Parser) parseOptionalChain(objNode ast.Node) ast.Node {
+	if !p.peekTokenIs(token.LBRACKET) {
+		return p.parseGetAttr(objNode)
+	}
+	left, ok := objNode.(ast.Expression)
+	if !ok {
+		p.setTokenError(p.curToken, "invalid index expression")
+		return nil
+	}
+	optionalToken := p.curToken
+	p.nextToken() // move to the "["
+	p.nextToken() // move to the index
+	index := p.parseExpression(LOWEST)
+	if index == nil {
+		return nil
+	}
+	if !p.expectPeek("an index expression", token.RBRACKET) {
+		return nil
+	}
+	return ast.NewIndex(optionalToken, left, index)
+}
+
 func (p *Parser) parseSend(channel ast.Node) ast.Node {
 	chanExpr, ok := channel.(ast.Expression)
 	if !ok {
@@ -1842,6 +2772,12 @@ func (p *Parser) peekTokenIs(t token.Type) bool {
 	return p.peekToken.Type == t
 }
 
+// peekIdentIs returns true if the next token is an identifier with the given
+// name. This is used for contextual keywords like "catch" and "finally".
+func (p *Parser) peekIdentIs(name string) bool {
+	return p.peekToken.Type == token.IDENT && p.peekToken.Literal == name
+}
+
 // expectPeek validates if the next token is of the given type, and advances if
 // it is. If it's a different type, then an error is stored.
 func (p *Parser) expectPeek(context string, t token.Type) bool {
@@ -201,6 +201,71 @@ func TestReturn(t *testing.T) {
 	}
 }
 
+func TestOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"a?.b", "a?.b"},
+		{"a?.b.c", "a?.b.c"},
+		{"a?.b?.c()", "a?.b?.c()"},
+		{"a?.[0]", "(a?.[0])"},
+		{"a?.[\"k\"]?.b", "(a?.[\"k\"])?.b"},
+		{"a ?? b", "(a ?? b)"},
+		{"a ?? b ?? c", "((a ?? b) ?? c)"},
+		{"a?.b ?? 1 + 2", "(a?.b ?? (1 + 2))"},
+		{"x == a ?? b", "((x == a) ?? b)"},
+	}
+	for _, tt := range tests {
+		program, err := Parse(context.Background(), tt.input)
+		require.Nil(t, err, tt.input)
+		require.Equal(t, tt.expected, program.String(), tt.input)
+	}
+}
+
+func TestBadOptionalChaining(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"a?.b = 1", "parse error: cannot assign to an optional attribute"},
+		{"a?.[0] = 1", "parse error: cannot assign to an optional index"},
+		{"a?.[0", "parse error: unexpected end of file while parsing an index expression (expected ])"},
+		{"a?.1", "parse error: expected an identifier after \"?.\""},
+	}
+	for _, tt := range tests {
+		_, err := Parse(context.Background(), tt.input)
+		require.NotNil(t, err, tt.input)
+		require.Equal(t, tt.err, err.Error(), tt.input)
+	}
+}
+
+func TestYield(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"yield 1", "yield 1"},
+		{"yield x + 1;", "yield (x + 1)"},
+		{"yield", "yield"},
+		{"yield\n", "yield"},
+	}
+	for _, tt := range tests {
+		program, err := Parse(context.Background(), tt.input)
+		require.Nil(t, err)
+		require.Len(t, program.Statements(), 1)
+		stmt, ok := program.First().(*ast.Yield)
+		require.True(t, ok)
+		require.Equal(t, tt.expected, stmt.String())
+	}
+	program, err := Parse(context.Background(), "func gen() { yield }")
+	require.Nil(t, err)
+	fn, ok := program.First().(*ast.Func)
+	require.True(t, ok)
+	_, ok = fn.Body().Statements()[0].(*ast.Yield)
+	require.True(t, ok)
+}
+
 func TestIdent(t *testing.T) {
 	program, err := Parse(context.Background(), "foobar;")
 	require.Nil(t, err)
@@ -720,12 +785,50 @@ func TestCallExpression(t *testing.T) {
 	call, ok := expr.(*ast.Call)
 	require.True(t, ok)
 	require.Equal(t, "foo", call.Function().String())
-	args := call.Arguments()
-	require.Len(t, args, 2)
-	arg0 := args[0].(*ast.Assign)
-	require.Equal(t, "a = 1", arg0.String())
-	arg1 := args[1].(*ast.Assign)
-	require.Equal(t, "b = 2", arg1.String())
+	require.Len(t, call.Arguments(), 0)
+	keywords := call.Keywords()
+	require.Len(t, keywords, 2)
+	require.Equal(t, "a=1", keywords[0].String())
+	require.Equal(t, "b=2", keywords[1].String())
+	require.Equal(t, "foo(a=1, b=2)", call.String())
+}
+
+func TestKeywordArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(1, b=2)", "f(1, b=2)"},
+		{"f(...args, b=x + 1)", "f(...args, b=(x + 1))"},
+		{"obj.method(a, timeout=5)", "obj.method(a, timeout=5)"},
+		{"f(a == 1)", "f((a == 1))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+}
+
+func TestBadKeywordArguments(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(a=1, 2)", "parse error: positional argument follows keyword argument"},
+		{"f(a=1, a=2)", "parse error: duplicate keyword argument \"a\""},
+		{"f(a=)", "parse error: invalid syntax (unexpected \")\")"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
 }
 
 func TestGetAttr(t *testing.T) {
@@ -941,6 +1044,169 @@ func TestBreak(t *testing.T) {
 	require.True(t, ok)
 }
 
+func TestLabeledLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"outer: for { break outer }", "outer: for { break outer }"},
+		{"outer: for x in [1] { continue outer }", "outer: for x in [1] continue outer"},
+		{"outer:\nfor i := 0; i < 3; i++ { break }", "outer: for i := 0; (i < 3); (i++) { break }"},
+		{"a: for { b: for { continue a } }", "a: for { b: for { continue a } }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+	program, err := Parse(context.Background(), "outer: for { break outer }")
+	require.Nil(t, err)
+	loop, ok := program.First().(*ast.For)
+	require.True(t, ok)
+	require.Equal(t, "outer", loop.Label().Literal())
+	control, ok := loop.Consequence().Statements()[0].(*ast.Control)
+	require.True(t, ok)
+	require.Equal(t, "outer", control.Label().Literal())
+}
+
+func TestDestructure(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		names    []string
+	}{
+		{"[a, [b, c]] := x", "[a, [b, c]] := x", []string{"a", "b", "c"}},
+		{"{name, age} := person", "{name, age} := person", []string{"name", "age"}},
+		{"first, ...rest := items", "first, ...rest := items", []string{"first", "rest"}},
+		{"a, [_, b] = pair", "a, [_, b] = pair", []string{"a", "b"}},
+		{"[a, b] = [b, a]", "[a, b] = [b, a]", []string{"a", "b"}},
+		{`{user: {name: n}, "id": i} := x`, `{user:{name:n}, "id":i} := x`, []string{"n", "i"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			stmt, ok := program.First().(*ast.Destructure)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, stmt.String())
+			require.Equal(t, tt.names, stmt.Names())
+		})
+	}
+}
+
+func TestDestructureLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"for k, {id, name} in items { k }", "for k, {id, name} in items k"},
+		{"for [a, b] in pairs { a }", "for [a, b] in pairs a"},
+		{"for k, v in m { k }", "for k, v in m k"},
+		{"for i, [a, b] := range pairs { a }", "for i, [a, b] := range pairs { a }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+}
+
+func TestBadDestructure(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[a, 1] := x", "parse error: invalid target in declaration statement: [a, 1]"},
+		{"{a: 1} := x", "parse error: invalid target in declaration statement: {a:1}"},
+		{"[a, b] += x", "parse error: unsupported operator for assignment: +="},
+		{"a, ...b, ...c := x", "parse error: multiple rest targets in assignment"},
+		{"a, [b.c] := x", "parse error: invalid target in declaration statement: [b.c]"},
+		{"a, 1 := x", "parse error: unexpected 1 while parsing declaration statement (expected identifier)"},
+		{"for a, b, c in x {}", "parse error: too many variables in for-in loop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x => x * 2", "func(x) { (x * 2) }"},
+		{"(a, b) => a + b", "func(a, b) { (a + b) }"},
+		{"() => 1", "func() { 1 }"},
+		{"(a, ...rest) => rest", "func(a, ...rest) { rest }"},
+		{"(x) => { y := x; y }", "func(x) { y := x\ny }"},
+		{"x =>\n  x", "func(x) { x }"},
+		{"x => y => x + y", "func(x) { func(y) { (x + y) } }"},
+		{"xs.map(x => x + 1)", "xs.map(func(x) { (x + 1) })"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+	program, err := Parse(context.Background(), "(a, b=2) => a")
+	require.Nil(t, err)
+	fn, ok := program.First().(*ast.Func)
+	require.True(t, ok)
+	require.Len(t, fn.Parameters(), 2)
+	require.Equal(t, "2", fn.Defaults()["b"].String())
+}
+
+func TestBadArrowFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"(1, 2) => 3", "parse error: invalid arrow function parameter: 1"},
+		{"(...a, b) => a", "parse error: variadic parameter must be the last parameter"},
+		{"() + 1", "parse error: unexpected + while parsing arrow function (expected =>)"},
+		{"(1, 2)", "parse error: unexpected , while parsing grouped expression (expected ))"},
+		{"x =>", "parse error: arrow function is missing a body"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestBadLabeledLoops(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"outer: x := 1", "parse error: expected a for loop after label \"outer\" (got x)"},
+		{"outer: if true { 1 }", "parse error: expected a for loop after label \"outer\" (got if)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
 func TestBacktick(t *testing.T) {
 	input := "`" + `\\n\t foo bar /hey there/` + "`"
 	program, err := Parse(context.Background(), input)
@@ -1414,3 +1680,393 @@ func TestForLoop(t *testing.T) {
 		}
 	}
 }
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct Point { x, y }", "struct Point { x, y }"},
+		{"struct Point { x = 1, y = 2 }", "struct Point { x = 1, y = 2 }"},
+		{"struct Point {\n  x = 1\n  y\n}", "struct Point { x = 1, y }"},
+		{"struct Point {\n  x = 1,\n  y = [1, 2],\n}", "struct Point { x = 1, y = [1, 2] }"},
+		{"struct Empty {}", "struct Empty {}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			stmt, ok := program.First().(*ast.Struct)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, stmt.String())
+		})
+	}
+}
+
+func TestBadStruct(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"struct { x }", "parse error: unexpected { while parsing struct statement (expected identifier)"},
+		{"struct Point x", "parse error: unexpected x while parsing struct statement (expected {)"},
+		{"struct Point { 1 }", "parse error: expected an identifier (got 1)"},
+		{"struct Point { x y }", "parse error: unexpected token \"y\" in struct statement"},
+		{"struct Point { x = }", "parse error: invalid syntax (unexpected \"}\")"},
+		{"struct Point { x", "parse error: unterminated struct statement"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x * 2 for x in items]", "[(x * 2) for x in items]"},
+		{"[x for x in items if x > 0]", "[x for x in items if (x > 0)]"},
+		{"[x for x in items if x > 0 if x < 9]", "[x for x in items if (x > 0) if (x < 9)]"},
+		{"[[i, j] for i in a for j in b]", "[[i, j] for i in a for j in b]"},
+		{"[\n  x\n  for x in items\n  if x\n]", "[x for x in items if x]"},
+		{"{k: v for k, v in m}", "{k: v for k, v in m}"},
+		{"{x for x in items}", "{x for x in items}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+}
+
+func TestBadComprehensions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[x for 1 in items]", "parse error: unexpected 1 while parsing comprehension (expected identifier)"},
+		{"[x for x items]", "parse error: unexpected items while parsing comprehension (expected IN)"},
+		{"[x for x in items", "parse error: unexpected end of file while parsing list comprehension (expected ])"},
+		{"{k: v for k, v in m]", "parse error: unexpected ] while parsing map comprehension (expected })"},
+		{"{x for x in items, 1}", "parse error: unexpected , while parsing set comprehension (expected })"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { x } catch { y }", "try x catch y"},
+		{"try { x } catch err { err }", "try x catch err err"},
+		{"try { x } finally { z }", "try x finally z"},
+		{"try { x } catch err { y } finally { z }", "try x catch err y finally z"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			stmt, ok := program.First().(*ast.Try)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, stmt.String())
+		})
+	}
+}
+
+func TestSelect(t *testing.T) {
+	input := `select {
+	case v := <-a:
+		v
+	case v, ok := <-b:
+	case <-c:
+	case d <- 1:
+		x
+	default:
+		y
+}`
+	program, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 1)
+	stmt, ok := program.First().(*ast.Select)
+	require.True(t, ok)
+	cases := stmt.Cases()
+	require.Len(t, cases, 5)
+	require.Equal(t, "v", cases[0].ValueIdent().Literal())
+	require.Nil(t, cases[0].OkIdent())
+	require.Equal(t, "a", cases[0].Receive().Channel().String())
+	require.Len(t, cases[0].Block().Statements(), 1)
+	require.Equal(t, "ok", cases[1].OkIdent().Literal())
+	require.Nil(t, cases[1].Block())
+	require.Nil(t, cases[2].ValueIdent())
+	require.Equal(t, "c", cases[2].Receive().Channel().String())
+	require.Equal(t, "d <- 1", cases[3].Send().String())
+	require.True(t, cases[4].IsDefault())
+}
+
+func TestBadSelect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"select { case x: }", "parse error: select case must be a channel send or receive"},
+		{"select { case v := c: }", "parse error: select case must be a channel send or receive"},
+		{"select { case v := c <- 1: }", "parse error: select case must be a channel send or receive"},
+		{"select { default: \n default: }", "parse error: select statement has multiple default blocks"},
+		{"select { foo: }", "parse error: expected 'case' or 'default' (got foo)"},
+		{"select { case <-c:", "parse error: unterminated select statement"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestSelectIdentifierStillParses(t *testing.T) {
+	program, err := Parse(context.Background(), "select := 1; select")
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 2)
+}
+
+func TestMatch(t *testing.T) {
+	input := `match val {
+	case 1, 2:
+		x
+	case [a, ...rest] if a > 0:
+	case {name: n}:
+	case string(s):
+	default:
+		y
+}`
+	program, err := Parse(context.Background(), input)
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 1)
+	match, ok := program.First().(*ast.Match)
+	require.True(t, ok)
+	require.Equal(t, "val", match.Value().String())
+	cases := match.Cases()
+	require.Len(t, cases, 5)
+	require.Len(t, cases[0].Patterns(), 2)
+	require.Nil(t, cases[0].Guard())
+	require.Equal(t, "[a, ...rest]", cases[1].Patterns()[0].String())
+	require.Equal(t, "(a > 0)", cases[1].Guard().String())
+	require.Nil(t, cases[1].Block())
+	require.Equal(t, "{name:n}", cases[2].Patterns()[0].String())
+	require.Equal(t, "string(s)", cases[3].Patterns()[0].String())
+	require.True(t, cases[4].IsDefault())
+}
+
+func TestBadMatch(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match x { default: \n default: }", "parse error: match expression has multiple default blocks"},
+		{"match x { foo: }", "parse error: expected 'case' or 'default' (got foo)"},
+		{"match x { case 1 }", "parse error: unexpected } while parsing match expression (expected :)"},
+		{"match x { case 1:", "parse error: unterminated match expression"},
+		{"match x", "parse error: unexpected end of file while parsing match expression (expected {)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestMatchIdentifierStillParses(t *testing.T) {
+	program, err := Parse(context.Background(), "match := func(x) { x }; match(1); re.match")
+	require.Nil(t, err)
+	require.Len(t, program.Statements(), 3)
+
+	for _, input := range []string{"match[0]", "match - 1", "match(x)", "if match(x) { y }"} {
+		program, err := Parse(context.Background(), input)
+		require.Nil(t, err, input)
+		_, ok := program.First().(*ast.Match)
+		require.False(t, ok, input)
+	}
+}
+
+func TestMatchValueStarts(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"match [1, 2] { case [a, b]: a }", "[1, 2]"},
+		{"match {\"a\": 1} {\n case {a: x}: x }", "{\"a\":1}"},
+		{"match (x + 1) { default: 0 }", "(x + 1)"},
+		{"match -x {\n\n case 1: 2 }", "(-x)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			match, ok := program.First().(*ast.Match)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, match.Value().String())
+			require.Len(t, match.Cases(), 1)
+		})
+	}
+}
+
+func TestTryBuiltinStillParses(t *testing.T) {
+	program, err := Parse(context.Background(), "try(func() { 1 }, 2)")
+	require.Nil(t, err)
+	_, ok := program.First().(*ast.Call)
+	require.True(t, ok)
+}
+
+func TestBadTryStatement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"try { x }", "parse error: try statement requires a catch or finally block"},
+		{"try { x } catch err", "parse error: unexpected end of file while parsing catch block (expected {)"},
+		{"try { x } finally", "parse error: unexpected end of file while parsing finally block (expected {)"},
+		{"try { x", "parse error: unterminated block statement"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestVariadicFunc(t *testing.T) {
+	tests := []struct {
+		input    string
+		params   []string
+		rest     string
+		expected string
+	}{
+		{"func f(...args) { args }", []string{}, "args", "func f(...args) { args }"},
+		{"func(a, ...rest) { a }", []string{"a"}, "rest", "func(a, ...rest) { a }"},
+		{"func(a, b=1, ...rest) { a }", []string{"a", "b"}, "rest", "func(a, b, ...rest) { a }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			function, ok := program.First().(*ast.Func)
+			require.True(t, ok)
+			require.Equal(t, tt.params, function.ParameterNames())
+			require.Equal(t, tt.rest, function.RestParameter().Literal())
+			require.Equal(t, tt.expected, function.String())
+		})
+	}
+}
+
+func TestBadVariadicFunc(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"func(...rest, a) { a }", "parse error: variadic parameter must be the last parameter"},
+		{"func(...rest=1) { 1 }", "parse error: variadic parameter cannot have a default value"},
+		{"func(...) { 1 }", "parse error: expected an identifier (got ))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestSpread(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"f(...args)", "f(...args)"},
+		{"f(a, ...b.c, ...d())", "f(a, ...b.c, ...d())"},
+		{"obj.method(...args)", "obj.method(...args)"},
+		{"[1, ...rest]", "[1, ...rest]"},
+		{"{...a, b: 1, ...c}", "{...a, b:1, ...c}"},
+		{"{...a}", "{...a}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+}
+
+func TestTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"func f(x: int, y: list) -> map { x }", "func f(x: int, y: list) -> map { x }"},
+		{"func(a, b: string = \"x\") { a }", "func(a, b: string) { a }"},
+		{"func(...rest: list) -> int | nil { 1 }", "func(...rest: list) -> int | nil { 1 }"},
+		{"func(f: func) -> nil { f }", "func(f: func) -> nil { f }"},
+		{"var x: int = 1", "var x: int = 1"},
+		{"var x: string | nil = nil", "var x: string | nil = nil"},
+		{"const y: float = 1.5", "const y: float = 1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			program, err := Parse(context.Background(), tt.input)
+			require.Nil(t, err)
+			require.Len(t, program.Statements(), 1)
+			require.Equal(t, tt.expected, program.First().String())
+		})
+	}
+	program, err := Parse(context.Background(), "func f(x: int, y) -> list { x }")
+	require.Nil(t, err)
+	fn, ok := program.First().(*ast.Func)
+	require.True(t, ok)
+	require.Equal(t, []string{"int"}, fn.ParameterType("x").Names())
+	require.Nil(t, fn.ParameterType("y"))
+	require.Equal(t, []string{"list"}, fn.ReturnType().Names())
+}
+
+func TestBadTypeAnnotations(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"func(x: 1) { x }", "parse error: expected a type name in function parameters (got 1)"},
+		{"func(x) -> { x }", "parse error: expected a type name in function (got {)"},
+		{"var x: int | = 1", "parse error: expected a type name in var statement (got =)"},
+		{"const x: = 1", "parse error: expected a type name in const statement (got =)"},
+		{"var a: int, b = 1, 2", "parse error: unexpected , while parsing var statement (expected =)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Parse(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
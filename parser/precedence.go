@@ -7,7 +7,7 @@ const (
 	_ int = iota
 	LOWEST
 	PIPE        // |
-	COND        // OR or AND
+	COND        // OR, AND or ??
 	ASSIGN      // =
 	DECLARE     // :=
 	TERNARY     // ? :
@@ -25,36 +25,38 @@ const (
 
 // Precedences for each token type
 var precedences = map[token.Type]int{
-	token.QUESTION:        TERNARY,
-	token.ASSIGN:          ASSIGN,
-	token.DECLARE:         DECLARE,
-	token.EQ:              EQUALS,
-	token.NOT_EQ:          EQUALS,
-	token.LT:              LESSGREATER,
-	token.LT_EQUALS:       LESSGREATER,
-	token.GT:              LESSGREATER,
-	token.GT_EQUALS:       LESSGREATER,
-	token.PLUS:            SUM,
-	token.PLUS_EQUALS:     SUM,
-	token.MINUS:           SUM,
-	token.MINUS_EQUALS:    SUM,
-	token.SLASH:           PRODUCT,
-	token.SLASH_EQUALS:    PRODUCT,
-	token.ASTERISK:        PRODUCT,
-	token.ASTERISK_EQUALS: PRODUCT,
-	token.AMPERSAND:       PRODUCT,
-	token.GT_GT:           PRODUCT,
-	token.LT_LT:           PRODUCT,
-	token.POW:             POWER,
-	token.MOD:             MOD,
-	token.AND:             COND,
-	token.OR:              COND,
-	token.PIPE:            PIPE,
-	token.LPAREN:          CALL,
-	token.PERIOD:          INDEX,
-	token.LBRACKET:        INDEX,
-	token.IN:              PREFIX,
-	token.NOT:             PREFIX,
-	token.RANGE:           PREFIX,
-	token.SEND:            CALL,
+	token.QUESTION:          TERNARY,
+	token.ASSIGN:            ASSIGN,
+	token.DECLARE:           DECLARE,
+	token.EQ:                EQUALS,
+	token.NOT_EQ:            EQUALS,
+	token.LT:                LESSGREATER,
+	token.LT_EQUALS:         LESSGREATER,
+	token.GT:                LESSGREATER,
+	token.GT_EQUALS:         LESSGREATER,
+	token.PLUS:              SUM,
+	token.PLUS_EQUALS:       SUM,
+	token.MINUS:             SUM,
+	token.MINUS_EQUALS:      SUM,
+	token.SLASH:             PRODUCT,
+	token.SLASH_EQUALS:      PRODUCT,
+	token.ASTERISK:          PRODUCT,
+	token.ASTERISK_EQUALS:   PRODUCT,
+	token.AMPERSAND:         PRODUCT,
+	token.GT_GT:             PRODUCT,
+	token.LT_LT:             PRODUCT,
+	token.POW:               POWER,
+	token.MOD:               MOD,
+	token.AND:               COND,
+	token.OR:                COND,
+	token.QUESTION_QUESTION: COND,
+	token.PIPE:              PIPE,
+	token.LPAREN:            CALL,
+	token.PERIOD:            INDEX,
+	token.QUESTION_PERIOD:   INDEX,
+	token.LBRACKET:          INDEX,
+	token.IN:                PREFIX,
+	token.NOT:               PREFIX,
+	token.RANGE:             PREFIX,
+	token.SEND:              CALL,
 }
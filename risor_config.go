@@ -21,6 +21,7 @@ type Config struct {
 	os                    os.OS
 	localImportPath       string
 	withoutDefaultGlobals bool
+	withoutOptimization   bool
 	withConcurrency       bool
 	listenersAllowed      bool
 	initialized           bool
@@ -159,6 +160,9 @@ func (cfg *Config) CompilerOpts() []compiler.Option {
 	if cfg.filename != "" {
 		opts = append(opts, compiler.WithFilename(cfg.filename))
 	}
+	if cfg.withoutOptimization {
+		opts = append(opts, compiler.WithoutOptimization())
+	}
 	return opts
 }
 
@@ -59,6 +59,14 @@ func WithoutDefaultGlobals() Option {
 	}
 }
 
+// WithoutOptimization disables compiler optimizations such as constant folding
+// and dead code elimination.
+func WithoutOptimization() Option {
+	return func(cfg *Config) {
+		cfg.withoutOptimization = true
+	}
+}
+
 // WithImporter supplies an Importer that will be used to execute import statements.
 func WithImporter(i importer.Importer) Option {
 	return func(cfg *Config) {
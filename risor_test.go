@@ -3,9 +3,12 @@ package risor
 import (
 	"context"
 	"errors"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/risor-io/risor/compiler"
+	"github.com/risor-io/risor/importer"
 	"github.com/risor-io/risor/object"
 	ros "github.com/risor-io/risor/os"
 	"github.com/risor-io/risor/parser"
@@ -440,3 +443,63 @@ func TestDefaultGlobalsFunc(t *testing.T) {
 		require.True(t, ok, "expected global %s", name)
 	}
 }
+
+func TestOptimizationParity(t *testing.T) {
+	scripts := []string{
+		`if false { undefined_name }`,
+		`if true { 1 } else { undefined_name }`,
+		`true ? 1 : undefined_name`,
+		`func f() { return 1; undefined_name }; f()`,
+		`func f(x) { if x { return 1; undefined_name } }; f(true)`,
+		`func f() { if true { return 1 } else { return g() } }; f()`,
+		`r := 0; for i in [1, 2, 3] { if false { break }; r += i }; r`,
+		`r := 0; for i in [1, 2, 3] { r += i; continue; break }; r`,
+		`const DEBUG = false; if DEBUG { func() { 1 } } else { 2 }`,
+		`const DEBUG = false; DEBUG ? "on" : "off"`,
+	}
+	ctx := context.Background()
+	for _, script := range scripts {
+		t.Run(script, func(t *testing.T) {
+			optimized, optimizedErr := Eval(ctx, script)
+			plain, plainErr := Eval(ctx, script, WithoutOptimization())
+			if plainErr != nil {
+				require.NotNil(t, optimizedErr)
+				require.Equal(t, plainErr.Error(), optimizedErr.Error())
+				return
+			}
+			require.Nil(t, optimizedErr)
+			require.Equal(t, plain, optimized)
+		})
+	}
+}
+
+func TestEvalBundle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.Nil(t, os.MkdirAll(filepath.Join(dir, "pkg"), 0o755))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "helper.risor"),
+		[]byte(`func double(x) { return x * 2 }`), 0o644))
+	require.Nil(t, os.WriteFile(filepath.Join(dir, "pkg", "util.risor"),
+		[]byte(`import helper; func quadruple(x) { return helper.double(helper.double(x)) }`), 0o644))
+
+	cfg := NewConfig(WithLocalImporter(dir))
+	ast, err := parser.Parse(ctx, `from pkg.util import quadruple; quadruple(5)`)
+	require.Nil(t, err)
+	code, err := compiler.Compile(ast, cfg.CompilerOpts()...)
+	require.Nil(t, err)
+	bundle, err := importer.NewBundle(ctx, code, importer.NewLocalImporter(importer.LocalImporterOptions{
+		GlobalNames: cfg.GlobalNames(),
+		SourceDir:   dir,
+	}))
+	require.Nil(t, err)
+	data, err := importer.MarshalBundle(bundle)
+	require.Nil(t, err)
+
+	// The bundle runs without access to the source of the modules
+	require.Nil(t, os.RemoveAll(dir))
+	bundle, err = importer.UnmarshalBundle(data)
+	require.Nil(t, err)
+	result, err := EvalCode(ctx, bundle.Main, WithImporter(bundle.Importer(nil)))
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(20), result)
+}
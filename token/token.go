@@ -34,78 +34,84 @@ type Token struct {
 
 // Token types
 const (
-	AND             = "&&"
-	ASSIGN          = "="
-	ASTERISK        = "*"
-	ASTERISK_EQUALS = "*="
-	BACKTICK        = "`"
-	FSTRING         = "'"
-	BANG            = "!"
-	CASE            = "case"
-	COLON           = ":"
-	COMMA           = ","
-	CONST           = "CONST"
-	DECLARE         = ":="
-	DEFAULT         = "DEFAULT"
-	DEFER           = "DEFER"
-	FUNC            = "FUNC"
-	ELSE            = "ELSE"
-	EOF             = "EOF"
-	EQ              = "=="
-	FALSE           = "FALSE"
-	FLOAT           = "FLOAT"
-	FOR             = "FOR"
-	GT              = ">"
-	GT_GT           = ">>"
-	GT_EQUALS       = ">="
-	GO              = "GO"
-	IDENT           = "IDENT"
-	IF              = "IF"
-	ILLEGAL         = "ILLEGAL"
-	INT             = "INT"
-	LBRACE          = "{"
-	LBRACKET        = "["
-	LPAREN          = "("
-	LT              = "<"
-	LT_LT           = "<<"
-	LT_EQUALS       = "<="
-	MINUS           = "-"
-	MINUS_EQUALS    = "-="
-	MINUS_MINUS     = "--"
-	MOD             = "%"
-	NOT_EQ          = "!="
-	NIL             = "nil"
-	NOT             = "NOT"
-	PIPE            = "|"
-	OR              = "||"
-	PERIOD          = "."
-	PLUS            = "+"
-	AMPERSAND       = "&"
-	PLUS_EQUALS     = "+="
-	PLUS_PLUS       = "++"
-	POW             = "**"
-	QUESTION        = "?"
-	RBRACE          = "}"
-	RBRACKET        = "]"
-	RETURN          = "RETURN"
-	RPAREN          = ")"
-	SEMICOLON       = ";"
-	SEND            = "<-"
-	SLASH           = "/"
-	SLASH_EQUALS    = "/="
-	STRING          = "STRING"
-	STRUCT          = "STRUCT"
-	SWITCH          = "switch"
-	TRUE            = "TRUE"
-	NEWLINE         = "EOL"
-	IMPORT          = "IMPORT"
-	BREAK           = "BREAK"
-	CONTINUE        = "CONTINUE"
-	VAR             = "VAR"
-	IN              = "IN"
-	RANGE           = "RANGE"
-	FROM            = "FROM"
-	AS              = "AS"
+	AND               = "&&"
+	ARROW             = "->"
+	ASSIGN            = "="
+	ASTERISK          = "*"
+	ASTERISK_EQUALS   = "*="
+	BACKTICK          = "`"
+	FSTRING           = "'"
+	BANG              = "!"
+	CASE              = "case"
+	COLON             = ":"
+	COMMA             = ","
+	CONST             = "CONST"
+	DECLARE           = ":="
+	DEFAULT           = "DEFAULT"
+	DEFER             = "DEFER"
+	FUNC              = "FUNC"
+	ELLIPSIS          = "..."
+	ELSE              = "ELSE"
+	EOF               = "EOF"
+	EQ                = "=="
+	FALSE             = "FALSE"
+	FAT_ARROW         = "=>"
+	FLOAT             = "FLOAT"
+	FOR               = "FOR"
+	GT                = ">"
+	GT_GT             = ">>"
+	GT_EQUALS         = ">="
+	GO                = "GO"
+	IDENT             = "IDENT"
+	IF                = "IF"
+	ILLEGAL           = "ILLEGAL"
+	INT               = "INT"
+	LBRACE            = "{"
+	LBRACKET          = "["
+	LPAREN            = "("
+	LT                = "<"
+	LT_LT             = "<<"
+	LT_EQUALS         = "<="
+	MINUS             = "-"
+	MINUS_EQUALS      = "-="
+	MINUS_MINUS       = "--"
+	MOD               = "%"
+	NOT_EQ            = "!="
+	NIL               = "nil"
+	NOT               = "NOT"
+	PIPE              = "|"
+	OR                = "||"
+	PERIOD            = "."
+	PLUS              = "+"
+	AMPERSAND         = "&"
+	PLUS_EQUALS       = "+="
+	PLUS_PLUS         = "++"
+	POW               = "**"
+	QUESTION          = "?"
+	QUESTION_PERIOD   = "?."
+	QUESTION_QUESTION = "??"
+	RBRACE            = "}"
+	RBRACKET          = "]"
+	RETURN            = "RETURN"
+	RPAREN            = ")"
+	SEMICOLON         = ";"
+	SEND              = "<-"
+	SLASH             = "/"
+	SLASH_EQUALS      = "/="
+	STRING            = "STRING"
+	STRUCT            = "STRUCT"
+	SWITCH            = "switch"
+	TRUE              = "TRUE"
+	NEWLINE           = "EOL"
+	IMPORT            = "IMPORT"
+	BREAK             = "BREAK"
+	CONTINUE          = "CONTINUE"
+	VAR               = "VAR"
+	IN                = "IN"
+	RANGE             = "RANGE"
+	FROM              = "FROM"
+	AS                = "AS"
+	YIELD             = "YIELD"
 )
 
 // Reserved keywords
@@ -134,6 +140,7 @@ var keywords = map[string]Type{
 	"switch":   SWITCH,
 	"true":     TRUE,
 	"var":      VAR,
+	"yield":    YIELD,
 }
 
 // LookupIdentifier used to determinate whether identifier is keyword nor not
@@ -0,0 +1,30 @@
+package vm
+
+import "github.com/risor-io/risor/object"
+
+// methodCacheEntry is the inline cache entry of a LoadMethod instruction. It
+// holds the result of the most recent method lookup, which is reused as long
+// as the receivers are of the same type. Entries are immutable once stored.
+type methodCacheEntry struct {
+	table  *object.MethodTable
+	method *object.Method // nil if the type has no method with the name
+}
+
+// lookupMethod returns the method with the given name index that is defined
+// in the method table of the object's type, if any. The method is found in the
+// inline cache of the LoadMethod instruction at the given offset when possible.
+func (vm *VirtualMachine) lookupMethod(obj object.Object, offset int, nameIndex uint16) (*object.Method, bool) {
+	owner, ok := obj.(object.HasMethods)
+	if !ok {
+		return nil, false
+	}
+	table := owner.Methods()
+	slot := &vm.activeCode.methodCache[offset]
+	entry := slot.Load()
+	if entry == nil || entry.table != table {
+		method, _ := table.Get(vm.activeCode.Names[nameIndex])
+		entry = &methodCacheEntry{table: table, method: method}
+		slot.Store(entry)
+	}
+	return entry.method, entry.method != nil
+}
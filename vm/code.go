@@ -2,6 +2,7 @@ package vm
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/risor-io/risor/compiler"
 	"github.com/risor-io/risor/object"
@@ -14,6 +15,14 @@ type code struct {
 	Constants    []object.Object
 	Globals      []object.Object
 	Names        []string
+
+	// Inline caches for LoadMethod instructions, indexed by the offset of
+	// the instruction, so that each call site has its own cache
+	methodCache []atomic.Pointer[methodCacheEntry]
+
+	// True if the code creates closures over variables in the frames of
+	// enclosing functions, which it locates relative to its own frame
+	usesOuterFrames bool
 }
 
 func wrapCode(cc *compiler.Code) *code {
@@ -23,10 +32,16 @@ func wrapCode(cc *compiler.Code) *code {
 		Instructions: make([]op.Code, cc.InstructionCount()),
 		Constants:    make([]object.Object, cc.ConstantsCount()),
 		Names:        make([]string, cc.NameCount()),
+		methodCache:  make([]atomic.Pointer[methodCacheEntry], cc.InstructionCount()),
 	}
 	for i := 0; i < cc.InstructionCount(); i++ {
 		c.Instructions[i] = cc.Instruction(i)
 	}
+	for i := 0; i < len(c.Instructions); i += 1 + op.GetInfo(c.Instructions[i]).OperandCount {
+		if c.Instructions[i] == op.MakeCell && c.Instructions[i+2] > 0 {
+			c.usesOuterFrames = true
+		}
+	}
 	for i := 0; i < cc.NameCount(); i++ {
 		c.Names[i] = cc.Name(i)
 	}
@@ -6,6 +6,17 @@ import (
 
 const DefaultFrameLocals = 8
 
+// handler is an exception handler registered by a try statement. When an
+// error occurs, the stack is restored to sp and execution resumes at ip.
+type handler struct {
+	ip int
+	sp int
+	// If true, the handler implements a catch block, which does not handle
+	// fatal errors. Otherwise it implements a finally block, which handles
+	// all errors and then re-raises them.
+	isCatch bool
+}
+
 type frame struct {
 	returnAddr     int
 	returnSp       int
@@ -17,6 +28,8 @@ type frame struct {
 	extendedLocals []object.Object
 	capturedLocals []object.Object
 	defers         []*object.Partial
+	handlers       []handler
+	generator      *generatorState
 }
 
 func (f *frame) ActivateCode(code *code) {
@@ -26,6 +39,8 @@ func (f *frame) ActivateCode(code *code) {
 	f.localsCount = uint16(code.LocalsCount())
 	f.capturedLocals = nil
 	f.defers = nil
+	f.handlers = f.handlers[:0]
+	f.generator = nil
 	for i := 0; i < DefaultFrameLocals; i++ {
 		f.storage[i] = nil
 	}
@@ -54,6 +69,27 @@ func (f *frame) ActivateFunction(fn *object.Function, code *code, returnAddr, re
 	} //lint:ignore S1001 - this loop is faster than using copy
 }
 
+// ActivateGenerator activates the frame to resume a suspended generator. The
+// generator's locals are used as the frame storage so that they persist from
+// one resumption to the next.
+func (f *frame) ActivateGenerator(fn *object.Function, code *code, returnSp int, state *generatorState) {
+	f.code = code
+	f.fn = fn
+	f.returnAddr = StopSignal
+	f.returnSp = returnSp
+	f.localsCount = uint16(len(state.locals))
+	f.extendedLocals = state.locals
+	f.locals = state.locals
+	f.capturedLocals = nil
+	f.defers = state.defers
+	f.handlers = f.handlers[:0]
+	for _, h := range state.handlers {
+		h.sp += returnSp
+		f.handlers = append(f.handlers, h)
+	}
+	f.generator = state
+}
+
 func (f *frame) Locals() []object.Object {
 	return f.locals
 }
@@ -73,6 +109,20 @@ func (f *frame) CaptureLocals() []object.Object {
 	return newStorage
 }
 
+func (f *frame) PushHandler(h handler) {
+	f.handlers = append(f.handlers, h)
+}
+
+func (f *frame) PopHandler() (handler, bool) {
+	count := len(f.handlers)
+	if count == 0 {
+		return handler{}, false
+	}
+	h := f.handlers[count-1]
+	f.handlers = f.handlers[:count-1]
+	return h, true
+}
+
 func (f *frame) Defer(p *object.Partial) {
 	f.defers = append([]*object.Partial{p}, f.defers...)
 }
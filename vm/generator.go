@@ -0,0 +1,143 @@
+package vm
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/object"
+)
+
+// generatorState holds the execution state of a generator function while it
+// is suspended between yields. It is independent of any one VM, so that a
+// generator may be resumed by a cloned VM, e.g. within a spawned thread.
+type generatorState struct {
+	mutex sync.Mutex
+	// The VM that is currently running the generator, if any
+	owner atomic.Pointer[VirtualMachine]
+	// Local variables of the function. These are used as the frame storage
+	// directly, so closures over them stay valid between resumptions.
+	locals []object.Object
+	// The instruction to resume at and the data stack of the frame when it
+	// was suspended
+	ip    int
+	stack []object.Object
+	// Exception handlers, with stack pointers relative to the frame base
+	handlers []handler
+	defers   []*object.Partial
+	// Set by the yield instruction when the generator is suspended
+	suspended bool
+	value     object.Object
+	finished  bool
+}
+
+// newGenerator returns a generator for the given function, which has not yet
+// started running. The locals hold the arguments of the call.
+func (vm *VirtualMachine) newGenerator(fn *object.Function, locals []object.Object) *object.Generator {
+	code := vm.loadCode(fn.Code())
+	state := &generatorState{locals: make([]object.Object, code.LocalsCount())}
+	copy(state.locals, locals)
+	return object.NewGenerator(fn, state)
+}
+
+// resumeGenerator runs a generator function until it yields its next value
+// or returns. False is returned once the function has returned.
+func (vm *VirtualMachine) resumeGenerator(
+	ctx context.Context,
+	gen *object.Generator,
+) (result object.Object, ok bool, resultErr error) {
+	state, valid := gen.State().(*generatorState)
+	if !valid {
+		return nil, false, errz.EvalErrorf("eval error: invalid generator state")
+	}
+	// A generator can't resume itself. Other VMs wait their turn.
+	if state.owner.Load() == vm {
+		return nil, false, errz.EvalErrorf("eval error: generator is already running")
+	}
+	state.mutex.Lock()
+	defer state.mutex.Unlock()
+	if state.finished {
+		return nil, false, nil
+	}
+	state.owner.Store(vm)
+	defer state.owner.Store(nil)
+
+	if err := vm.checkDepth(); err != nil {
+		return nil, false, err
+	}
+	baseFP := vm.fp
+	baseIP := vm.ip
+	baseSP := vm.sp
+
+	// Restore the previous frame when done
+	defer vm.resumeFrame(baseFP, baseIP, baseSP)
+
+	// Reactivate the suspended frame and its stack
+	fn := gen.Function()
+	code := vm.loadCode(fn.Code())
+	vm.fp = baseFP + 1
+	vm.ip = state.ip
+	vm.activeFrame = vm.frameAt(vm.fp)
+	vm.activeFrame.ActivateGenerator(fn, code, baseSP, state)
+	vm.activeCode = code
+	for _, obj := range state.stack {
+		vm.push(obj)
+	}
+	state.suspended = false
+
+	// Evaluate until the next yield or return
+	callFrame := vm.activeFrame
+	err := vm.eval(ctx)
+	if err == nil && state.suspended {
+		value := state.value
+		state.value = nil
+		return value, true, nil
+	}
+
+	// The generator completed, so release its state and run deferred calls
+	state.finished = true
+	state.locals = nil
+	state.stack = nil
+	state.handlers = nil
+	state.defers = nil
+	if err == nil {
+		// Discard the return value of the function
+		vm.pop()
+	}
+	for _, partial := range callFrame.defers {
+		if deferErr := vm.callObjectWithKwargs(ctx, partial.Function(), partial.Args(), partial.Kwargs()); deferErr != nil {
+			err = deferErr
+		} else {
+			vm.pop()
+		}
+	}
+	return nil, false, err
+}
+
+// suspendGenerator saves the state of the active generator frame and then
+// returns control to the caller that resumed it. The yielded value must have
+// been popped off the stack already.
+func (vm *VirtualMachine) suspendGenerator(value object.Object) error {
+	frame := vm.activeFrame
+	state := frame.generator
+	if state == nil {
+		return errz.EvalErrorf("eval error: yield outside of a generator")
+	}
+	base := frame.returnSp
+	state.ip = vm.ip
+	state.stack = append(state.stack[:0], vm.stack[base+1:vm.sp+1]...)
+	state.handlers = state.handlers[:0]
+	for _, h := range frame.handlers {
+		h.sp -= base
+		state.handlers = append(state.handlers, h)
+	}
+	state.defers = frame.defers
+	state.value = value
+	state.suspended = true
+	for vm.sp > base {
+		vm.pop()
+	}
+	vm.resumeFrame(vm.fp-1, frame.returnAddr, base)
+	return nil
+}
@@ -0,0 +1,191 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/risor-io/risor/errz"
+	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
+)
+
+// callHook calls a hook defined by a map or struct and returns its result.
+func (vm *VirtualMachine) callHook(ctx context.Context, hook object.Object, args ...object.Object) (object.Object, error) {
+	if err := vm.callObject(ctx, hook, args); err != nil {
+		return nil, err
+	}
+	result := vm.pop()
+	if err, ok := result.(*object.Error); ok && err.IsRaised() {
+		return nil, err.Value()
+	}
+	return result, nil
+}
+
+// binaryOp runs a binary operation, using a hook that implements the operator
+// if either operand defines one.
+func (vm *VirtualMachine) binaryOp(ctx context.Context, opType op.BinaryOpType, a, b object.Object) (object.Object, error) {
+	if name := object.BinaryOpHook(opType); name != "" {
+		if hook, ok := object.GetBinaryHook(name, a, b); ok {
+			return vm.callHook(ctx, hook, a, b)
+		}
+	}
+	return object.BinaryOp(opType, a, b)
+}
+
+// compare runs a comparison. Equality uses the __eq__ hook and ordering uses
+// the __cmp__ hook, if either operand defines them.
+func (vm *VirtualMachine) compare(ctx context.Context, opType op.CompareOpType, a, b object.Object) (object.Object, error) {
+	switch opType {
+	case op.Equal, op.NotEqual:
+		hook, ok := object.GetBinaryHook(object.HookEq, a, b)
+		if !ok {
+			break
+		}
+		result, err := vm.callHook(ctx, hook, a, b)
+		if err != nil {
+			return nil, err
+		}
+		if opType == op.NotEqual {
+			return object.NewBool(!result.IsTruthy()), nil
+		}
+		return object.NewBool(result.IsTruthy()), nil
+	default:
+		hook, ok := object.GetBinaryHook(object.HookCmp, a, b)
+		if !ok {
+			break
+		}
+		result, err := vm.callHook(ctx, hook, a, b)
+		if err != nil {
+			return nil, err
+		}
+		value, ok := result.(*object.Int)
+		if !ok {
+			return nil, errz.TypeErrorf("type error: %s must return an int (got %s)", object.HookCmp, result.Type())
+		}
+		return object.Compare(opType, value, object.NewInt(0))
+	}
+	return object.Compare(opType, a, b)
+}
+
+// getItem implements the [] operator, using the __getitem__ hook if the
+// object defines one.
+func (vm *VirtualMachine) getItem(ctx context.Context, obj, key object.Object) (object.Object, error) {
+	if hook, ok := object.GetHook(obj, object.HookGetItem); ok {
+		return vm.callHook(ctx, hook, obj, key)
+	}
+	container, ok := obj.(object.Container)
+	if !ok {
+		return nil, errz.TypeErrorf("type error: object is not a container (got %s)", obj.Type())
+	}
+	result, err := container.GetItem(key)
+	if err != nil {
+		return nil, err.Value()
+	}
+	return result, nil
+}
+
+// length returns the length of a container, using the __len__ hook if the
+// object defines one.
+func (vm *VirtualMachine) length(ctx context.Context, obj object.Object) (object.Object, error) {
+	if hook, ok := object.GetHook(obj, object.HookLen); ok {
+		result, err := vm.callHook(ctx, hook, obj)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := result.(*object.Int); !ok {
+			return nil, errz.TypeErrorf("type error: %s must return an int (got %s)", object.HookLen, result.Type())
+		}
+		return result, nil
+	}
+	container, ok := obj.(object.Container)
+	if !ok {
+		return nil, errz.TypeErrorf("type error: object is not a container (got %s)", obj.Type())
+	}
+	return container.Len(), nil
+}
+
+// getIter returns an iterator for the object. If the object defines the
+// __iter__ hook, the iterator is obtained from the value the hook returns.
+func (vm *VirtualMachine) getIter(ctx context.Context, obj object.Object) (object.Iterator, error) {
+	if hook, ok := object.GetHook(obj, object.HookIter); ok {
+		result, err := vm.callHook(ctx, hook, obj)
+		if err != nil {
+			return nil, err
+		}
+		obj = result
+	}
+	switch obj := obj.(type) {
+	case object.Iterable:
+		return obj.Iter(), nil
+	case object.Iterator:
+		return obj, nil
+	default:
+		return nil, errz.TypeErrorf("type error: object is not iterable (got %s)", obj.Type())
+	}
+}
+
+// contains implements the in operator. Lists and objects that define the
+// __iter__ hook are searched item by item, using the __eq__ hook of either
+// value if it defines one.
+func (vm *VirtualMachine) contains(ctx context.Context, containerObj, obj object.Object) (*object.Bool, error) {
+	var iter object.Iterator
+	if list, ok := containerObj.(*object.List); ok {
+		iter = list.Iter()
+	} else if _, ok := object.GetHook(containerObj, object.HookIter); ok {
+		var err error
+		if iter, err = vm.getIter(ctx, containerObj); err != nil {
+			return nil, err
+		}
+	} else if container, ok := containerObj.(object.Container); ok {
+		return container.Contains(obj), nil
+	} else {
+		return nil, errz.TypeErrorf("type error: object is not a container (got %s)", containerObj.Type())
+	}
+	for {
+		item, ok := iter.Next(ctx)
+		if !ok {
+			if err := object.IteratorErr(iter); err != nil {
+				return nil, err
+			}
+			return object.False, nil
+		}
+		result, err := vm.compare(ctx, op.Equal, obj, item)
+		if err != nil {
+			return nil, err
+		}
+		if result.IsTruthy() {
+			return object.True, nil
+		}
+	}
+}
+
+// formatValue implements the conversion and format specifier of a template
+// string expression, as in {value!r} or {value:>10}. Objects that define the
+// __str__ hook are formatted as the string it returns.
+func (vm *VirtualMachine) formatValue(ctx context.Context, obj object.Object, conversion op.FormatConversion, spec string) (object.Object, error) {
+	switch conversion {
+	case op.FormatRepr:
+		obj = object.NewString(obj.Inspect())
+	case op.FormatStr, op.FormatNone:
+		if _, ok := obj.(*object.String); ok {
+			break
+		}
+		s, ok, err := object.CallStrHook(ctx, obj)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			obj = object.NewString(s)
+		} else if conversion == op.FormatStr {
+			obj = object.NewString(obj.Inspect())
+		}
+	}
+	if spec == "" {
+		return obj, nil
+	}
+	s, err := object.Format(obj, spec)
+	if err != nil {
+		return nil, fmt.Errorf("value error: %s", err)
+	}
+	return object.NewString(s), nil
+}
@@ -46,3 +46,21 @@ func WithOS(os os.OS) Option {
 		vm.os = os
 	}
 }
+
+// WithMaxFrameDepth limits the depth of nested function calls. Calls beyond
+// this depth fail with a "recursion limit exceeded" error. The default limit
+// is MaxFrameDepth.
+func WithMaxFrameDepth(depth int) Option {
+	return func(vm *VirtualMachine) {
+		vm.maxFrames = depth
+	}
+}
+
+// WithMaxStackDepth limits the number of objects on the data stack when a
+// function is called. Calls beyond this limit fail with a "recursion limit
+// exceeded" error. The default limit is MaxStackDepth.
+func WithMaxStackDepth(depth int) Option {
+	return func(vm *VirtualMachine) {
+		vm.maxStack = depth
+	}
+}
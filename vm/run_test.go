@@ -2,6 +2,7 @@ package vm
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/risor-io/risor/compiler"
@@ -42,7 +43,14 @@ func TestRunError(t *testing.T) {
 	_, err = Run(ctx, code)
 	require.NotNil(t, err)
 	require.Equal(t, "type error: attribute \"bar\" not found on int object", err.Error())
-	errValue, ok := err.(*errz.TypeError)
+	// The original error is wrapped in a RuntimeError that holds the stack
+	runtimeErr, ok := err.(*errz.RuntimeError)
+	require.True(t, ok)
+	errValue, ok := runtimeErr.Unwrap().(*errz.TypeError)
 	require.True(t, ok)
 	require.Equal(t, "type error: attribute \"bar\" not found on int object", errValue.Error())
+	require.Equal(t, []errz.StackFrame{{Function: "__main__", Line: 1, Column: 15}}, runtimeErr.Stack)
+	var typeErr *errz.TypeError
+	require.True(t, errors.As(err, &typeErr))
+	require.Same(t, errValue, typeErr)
 }
@@ -1,7 +1,10 @@
 package vm
 
 import (
+	"context"
 	"fmt"
+	"reflect"
+	"slices"
 
 	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/object"
@@ -14,12 +17,23 @@ func checkCallArgs(fn *object.Function, argc int) error {
 	// Number of required args when the function is called (those without defaults)
 	requiredArgsCount := fn.RequiredArgsCount()
 
+	// Variadic functions accept any number of extra arguments
+	isVariadic := fn.RestParameter() != ""
+
 	// Check if too many or too few arguments were passed
-	if argc > paramsCount || argc < requiredArgsCount {
+	if (argc > paramsCount && !isVariadic) || argc < requiredArgsCount {
 		msg := "args error: function"
 		if name := fn.Name(); name != "" {
 			msg = fmt.Sprintf("%s %q", msg, name)
 		}
+		if isVariadic {
+			if requiredArgsCount == 1 {
+				msg = fmt.Sprintf("%s takes at least 1 argument (%d given)", msg, argc)
+			} else {
+				msg = fmt.Sprintf("%s takes at least %d arguments (%d given)", msg, requiredArgsCount, argc)
+			}
+			return errz.ArgsErrorf(msg)
+		}
 		switch paramsCount {
 		case 0:
 			msg = fmt.Sprintf("%s takes 0 arguments (%d given)", msg, argc)
@@ -32,3 +46,129 @@ func checkCallArgs(fn *object.Function, argc int) error {
 	}
 	return nil
 }
+
+// bindKwargs binds keyword arguments to the parameters of fn by name and
+// returns the complete positional argument list for the call. Parameters not
+// supplied either way take their default values.
+func bindKwargs(fn *object.Function, args []object.Object, kwargs *object.Map) ([]object.Object, error) {
+	if kwargs == nil || kwargs.Size() == 0 {
+		return args, nil
+	}
+	params := fn.Parameters()
+	name := "function"
+	if fnName := fn.Name(); fnName != "" {
+		name = fmt.Sprintf("%s %q", name, fnName)
+	}
+	// Too many positional arguments leaves no parameters for the keywords
+	if len(args) > len(params) && fn.RestParameter() == "" {
+		return nil, checkCallArgs(fn, len(args))
+	}
+	bound := make([]object.Object, max(len(params), len(args)))
+	copy(bound, args)
+	for _, key := range kwargs.SortedKeys() {
+		index := slices.Index(params, key)
+		if index < 0 {
+			return nil, errz.ArgsErrorf("args error: %s got an unexpected keyword argument %q", name, key)
+		}
+		if index < len(args) {
+			return nil, errz.ArgsErrorf("args error: %s got multiple values for argument %q", name, key)
+		}
+		bound[index] = kwargs.Get(key)
+	}
+	defaults := fn.Defaults()
+	for i := len(args); i < len(params); i++ {
+		if bound[i] != nil {
+			continue
+		}
+		if i < len(defaults) && defaults[i] != nil {
+			bound[i] = defaults[i]
+		} else {
+			return nil, errz.ArgsErrorf("args error: %s missing required argument %q", name, params[i])
+		}
+	}
+	return bound, nil
+}
+
+// extendList appends the items of an iterable object to the given list. This
+// is used to expand spread expressions in calls and list literals.
+func extendList(ctx context.Context, list *object.List, obj object.Object) error {
+	var iter object.Iterator
+	switch obj := obj.(type) {
+	case *object.List:
+		list.Extend(obj)
+		return nil
+	case object.Iterable:
+		iter = obj.Iter()
+	case object.Iterator:
+		iter = obj
+	default:
+		return errz.TypeErrorf("type error: cannot spread %s object (expected an iterable)", obj.Type())
+	}
+	for {
+		value, ok := iter.Next(ctx)
+		if !ok {
+			return object.IteratorErr(iter)
+		}
+		list.Append(value)
+	}
+}
+
+// selectChannels blocks until one of the given channel operations can proceed
+// and then performs it. The items hold a channel, a value to send and a send
+// flag for each case. It returns the index of the chosen case and, for
+// receives, the received value and whether the receive succeeded. An index of
+// len(items)/3 indicates the default case was chosen. A nil channel is never
+// ready, so it disables its case. Context cancellation is returned as an error.
+func selectChannels(ctx context.Context, items []object.Object, hasDefault bool) (chosen int, value object.Object, ok bool, err error) {
+	count := len(items) / 3
+	cases := make([]reflect.SelectCase, 0, count+2)
+	for i := 0; i < count; i++ {
+		channel, sendValue, isSend := items[i*3], items[i*3+1], items[i*3+2]
+		sc := reflect.SelectCase{Dir: reflect.SelectRecv}
+		if isSend == object.True {
+			sc.Dir = reflect.SelectSend
+			sc.Send = reflect.ValueOf(sendValue)
+		}
+		switch ch := channel.(type) {
+		case *object.Chan:
+			sc.Chan = reflect.ValueOf(ch.Value())
+		case *object.NilType:
+		default:
+			return 0, nil, false, errz.TypeErrorf("type error: object is not a channel (got %s)", channel.Type())
+		}
+		cases = append(cases, sc)
+	}
+	cases = append(cases, reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(ctx.Done())})
+	if hasDefault {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+	// Translate a "send on closed channel" panic to an error
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("exec error: %v", r)
+		}
+	}()
+	chosen, recv, recvOK := reflect.Select(cases)
+	switch {
+	case chosen == count:
+		return 0, nil, false, ctx.Err()
+	case chosen > count:
+		return count, object.Nil, false, nil
+	case !recvOK:
+		// A send, or a receive from a closed channel, produces nil
+		return chosen, object.Nil, false, nil
+	}
+	return chosen, recv.Interface().(object.Object), true, nil
+}
+
+// matchesType returns true if the object's type has the given name, or if the
+// object is a struct with the given struct type name.
+func matchesType(obj object.Object, name string) bool {
+	if string(obj.Type()) == name {
+		return true
+	}
+	if s, ok := obj.(*object.Struct); ok {
+		return s.StructType().Name() == name
+	}
+	return false
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math/big"
 	"path/filepath"
 	"strings"
 	"sync"
@@ -21,11 +22,17 @@ import (
 const (
 	MaxArgs       = 256
 	MaxFrameDepth = 1024
-	MaxStackDepth = 1024
+	MaxStackDepth = 64 * 1024
 	StopSignal    = -1
 	MB            = 1024 * 1024
 )
 
+// The frame stack and data stack start small and grow as needed. By default,
+// they are limited to MaxFrameDepth frames and MaxStackDepth objects. These
+// limits are checked when a function is called and may be changed using the
+// WithMaxFrameDepth and WithMaxStackDepth options.
+const initialStackSize = 64
+
 var ErrGlobalNotFound = errors.New("global not found")
 
 type VirtualMachine struct {
@@ -48,8 +55,10 @@ type VirtualMachine struct {
 	runMutex     sync.Mutex
 	cloneMutex   sync.Mutex
 	tmp          [MaxArgs]object.Object
-	stack        [MaxStackDepth]object.Object
-	frames       [MaxFrameDepth]frame
+	stack        []object.Object
+	frames       []*frame
+	maxStack     int
+	maxFrames    int
 }
 
 // New creates a new Virtual Machine.
@@ -81,6 +90,8 @@ func createVM(options []Option) (*VirtualMachine, error) {
 		inputGlobals: map[string]any{},
 		globals:      map[string]object.Object{},
 		loadedCode:   map[*compiler.Code]*code{},
+		maxStack:     MaxStackDepth,
+		maxFrames:    MaxFrameDepth,
 	}
 	if err := vm.applyOptions(options); err != nil {
 		return nil, err
@@ -229,12 +240,10 @@ func (vm *VirtualMachine) resetForNewCode() {
 	vm.loadedCode = map[*compiler.Code]*code{}
 	vm.modules = map[string]*object.Module{}
 
-	// Clear arrays
-	for i := 0; i < MaxStackDepth; i++ {
-		vm.stack[i] = nil
-	}
-	for i := 0; i < MaxFrameDepth; i++ {
-		vm.frames[i] = frame{}
+	// Clear the stacks
+	clear(vm.stack)
+	for _, f := range vm.frames {
+		*f = frame{}
 	}
 	for i := 0; i < MaxArgs; i++ {
 		vm.tmp[i] = nil
@@ -279,6 +288,54 @@ func (vm *VirtualMachine) GlobalNames() []string {
 // Assuming this function returns without error, the result of the evaluation
 // will be on the top of the stack.
 func (vm *VirtualMachine) eval(ctx context.Context) error {
+	// The most recent error that was passed to an exception handler in this
+	// frame. It already has this frame in its call stack if it is re-raised.
+	var handledErr error
+	for {
+		err := vm.execute(ctx)
+		// Errors caused by context cancellation are returned as-is
+		if err == nil || err == ctx.Err() {
+			return err
+		}
+		// Record the active frame in the call stack of the error
+		if err != handledErr {
+			err = vm.addStackFrame(err)
+		}
+		// Resume execution in an exception handler, if one is active
+		if !vm.handleError(err) {
+			return err
+		}
+		handledErr = err
+	}
+}
+
+// handleError transfers control to the innermost exception handler in the
+// active frame that accepts the given error. The stack is unwound to the
+// depth it had when the handler was registered and the error is pushed onto
+// it. Returns false if no handler accepts the error.
+func (vm *VirtualMachine) handleError(err error) bool {
+	for {
+		h, ok := vm.activeFrame.PopHandler()
+		if !ok {
+			return false
+		}
+		// Like the try builtin, catch blocks do not handle fatal errors
+		var errzErr errz.Error
+		if h.isCatch && errors.As(err, &errzErr) && errzErr.IsFatal() {
+			continue
+		}
+		for vm.sp > h.sp {
+			vm.pop()
+		}
+		vm.push(object.NewError(err).WithRaised(false))
+		vm.ip = h.ip
+		return true
+	}
+}
+
+// Execute instructions in the active code until it completes or an error
+// occurs. This is the main dispatch loop of the VM.
+func (vm *VirtualMachine) execute(ctx context.Context) error {
 	// Run to the end of the active code
 	for vm.ip < len(vm.activeCode.Instructions) {
 
@@ -300,23 +357,46 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 		switch opcode {
 		case op.Nop:
 		case op.LoadAttr:
+			obj := vm.pop()
+			value, err := vm.getAttr(ctx, obj, vm.activeCode.Names[vm.fetch()])
+			if err != nil {
+				return err
+			}
+			vm.push(value)
+		case op.LoadMethod:
+			// Pushes a method and the object it is called on. If the object
+			// has no such method in its method table, the attribute is pushed
+			// instead, followed by nil.
+			offset := vm.ip - 1
+			nameIndex := vm.fetch()
+			obj := vm.pop()
+			if method, ok := vm.lookupMethod(obj, offset, nameIndex); ok {
+				vm.push(method)
+				vm.push(obj)
+				break
+			}
+			value, err := vm.getAttr(ctx, obj, vm.activeCode.Names[nameIndex])
+			if err != nil {
+				return err
+			}
+			vm.push(value)
+			vm.push(object.Nil)
+		case op.LoadAttrOrNil:
 			obj := vm.pop()
 			name := vm.activeCode.Names[vm.fetch()]
 			value, found := obj.GetAttr(name)
 			if !found {
-				return errz.TypeErrorf("type error: attribute %q not found on %s object",
-					name, obj.Type())
+				vm.push(object.Nil)
+				break
 			}
-			switch value := value.(type) {
-			case object.AttrResolver:
-				attr, err := value.ResolveAttr(ctx, name)
+			if resolver, ok := value.(object.AttrResolver); ok {
+				attr, err := resolver.ResolveAttr(ctx, name)
 				if err != nil {
 					return err
 				}
-				vm.push(attr)
-			default:
-				vm.push(value)
+				value = attr
 			}
+			vm.push(value)
 		case op.LoadConst:
 			vm.push(vm.activeCode.Constants[vm.fetch()])
 		case op.LoadFast:
@@ -369,7 +449,7 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			if frameIndex < 0 {
 				return errz.EvalErrorf("eval error: no frame at depth %d", framesBack)
 			}
-			frame := &vm.frames[frameIndex]
+			frame := vm.frames[frameIndex]
 			locals := frame.CaptureLocals()
 			vm.push(object.NewCell(&locals[symbolIndex]))
 		case op.Nil:
@@ -382,7 +462,7 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			opType := op.CompareOpType(vm.fetch())
 			b := vm.pop()
 			a := vm.pop()
-			result, err := object.Compare(opType, a, b)
+			result, err := vm.compare(ctx, opType, a, b)
 			if err != nil {
 				return err
 			}
@@ -391,11 +471,82 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			opType := op.BinaryOpType(vm.fetch())
 			b := vm.pop()
 			a := vm.pop()
-			result, err := object.BinaryOp(opType, a, b)
+			result, err := vm.binaryOp(ctx, opType, a, b)
+			if err != nil {
+				return err
+			}
+			vm.push(result)
+		case op.LoadFastLoadFast:
+			// LOAD_FAST, LOAD_FAST
+			instructions := vm.activeCode.Instructions
+			locals := vm.activeFrame.Locals()
+			vm.push(locals[instructions[vm.ip]])
+			vm.push(locals[instructions[vm.ip+2]])
+			vm.ip += 3
+		case op.BinaryOpFastConst:
+			// LOAD_FAST, LOAD_CONST, BINARY_OP
+			instructions := vm.activeCode.Instructions
+			a := vm.activeFrame.Locals()[instructions[vm.ip]]
+			b := vm.activeCode.Constants[instructions[vm.ip+2]]
+			opType := op.BinaryOpType(instructions[vm.ip+4])
+			// Move past the BINARY_OP first so errors are reported at its location
+			vm.ip += 5
+			result, err := vm.binaryOp(ctx, opType, a, b)
 			if err != nil {
 				return err
 			}
 			vm.push(result)
+		case op.IncrementFast:
+			// LOAD_FAST, LOAD_CONST, BINARY_OP (add), STORE_FAST
+			instructions := vm.activeCode.Instructions
+			idx := instructions[vm.ip]
+			a := vm.activeFrame.Locals()[idx]
+			b := vm.activeCode.Constants[instructions[vm.ip+2]]
+			vm.ip += 5
+			var result object.Object
+			x, xOk := a.(*object.Int)
+			y, yOk := b.(*object.Int)
+			if xOk && yOk {
+				result = object.NewInt(x.Value() + y.Value())
+			} else {
+				var err error
+				if result, err = vm.binaryOp(ctx, op.Add, a, b); err != nil {
+					return err
+				}
+			}
+			vm.ip += 2
+			vm.activeFrame.Locals()[idx] = result
+		case op.CompareJump:
+			// COMPARE_OP, POP_JUMP_FORWARD_IF_FALSE
+			opType := op.CompareOpType(vm.fetch())
+			b := vm.pop()
+			a := vm.pop()
+			result, err := vm.compare(ctx, opType, a, b)
+			if err != nil {
+				return err
+			}
+			// The jump is relative to the POP_JUMP_FORWARD_IF_FALSE
+			if result.IsTruthy() {
+				vm.ip += 2
+			} else {
+				vm.ip += int(vm.activeCode.Instructions[vm.ip+1])
+			}
+		case op.CompareFastConstJump:
+			// LOAD_FAST, LOAD_CONST, COMPARE_OP, POP_JUMP_FORWARD_IF_FALSE
+			instructions := vm.activeCode.Instructions
+			a := vm.activeFrame.Locals()[instructions[vm.ip]]
+			b := vm.activeCode.Constants[instructions[vm.ip+2]]
+			opType := op.CompareOpType(instructions[vm.ip+4])
+			vm.ip += 5
+			result, err := vm.compare(ctx, opType, a, b)
+			if err != nil {
+				return err
+			}
+			if result.IsTruthy() {
+				vm.ip += 2
+			} else {
+				vm.ip += int(instructions[vm.ip+1])
+			}
 		case op.Call:
 			argc := int(vm.fetch())
 			if argc > MaxArgs {
@@ -410,6 +561,74 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			if err := vm.callObject(ctx, obj, args); err != nil {
 				return err
 			}
+		case op.TailCall:
+			argc := int(vm.fetch())
+			if argc > MaxArgs {
+				return errz.EvalErrorf("eval error: max args limit of %d exceeded (got %d)",
+					MaxArgs, argc)
+			}
+			args := make([]object.Object, argc)
+			for argIndex := argc - 1; argIndex >= 0; argIndex-- {
+				args[argIndex] = vm.pop()
+			}
+			obj := vm.pop()
+			// Partials are expanded so that the wrapped function can be
+			// called in place of the active frame too
+			fn, fnArgs := obj, args
+			if partial, ok := obj.(*object.Partial); ok && partial.Kwargs() == nil {
+				fn = partial.Function()
+				fnArgs = append(args, partial.Args()...)
+			}
+			if fn, ok := fn.(*object.Function); ok {
+				called, err := vm.tailCall(fn, fnArgs)
+				if err != nil {
+					return err
+				}
+				if called {
+					break
+				}
+			}
+			// Otherwise this is a regular call followed by a return
+			if err := vm.callObject(ctx, obj, args); err != nil {
+				return err
+			}
+		case op.CallMethod:
+			argc := int(vm.fetch())
+			if argc > MaxArgs {
+				return errz.EvalErrorf("eval error: max args limit of %d exceeded (got %d)",
+					MaxArgs, argc)
+			}
+			args := make([]object.Object, argc)
+			for argIndex := argc - 1; argIndex >= 0; argIndex-- {
+				args[argIndex] = vm.pop()
+			}
+			self := vm.pop()
+			obj := vm.pop()
+			method, ok := obj.(*object.Method)
+			if !ok {
+				if err := vm.callObject(ctx, obj, args); err != nil {
+					return err
+				}
+				break
+			}
+			result := method.Call(ctx, self, args...)
+			if err, ok := result.(*object.Error); ok && err.IsRaised() {
+				return err.Value()
+			}
+			vm.push(result)
+		case op.CallSpread:
+			args := vm.pop().(*object.List).Value()
+			obj := vm.pop()
+			if err := vm.callObject(ctx, obj, args); err != nil {
+				return err
+			}
+		case op.CallKw:
+			kwargs := vm.pop().(*object.Map)
+			args := vm.pop().(*object.List).Value()
+			obj := vm.pop()
+			if err := vm.callObjectWithKwargs(ctx, obj, args, kwargs); err != nil {
+				return err
+			}
 		case op.Partial:
 			argc := int(vm.fetch())
 			args := make([]object.Object, argc)
@@ -419,6 +638,32 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			obj := vm.pop()
 			partial := object.NewPartial(obj, args)
 			vm.push(partial)
+		case op.PartialSpread:
+			args := vm.pop().(*object.List).Value()
+			obj := vm.pop()
+			vm.push(object.NewPartial(obj, args))
+		case op.PartialKw:
+			kwargs := vm.pop().(*object.Map)
+			args := vm.pop().(*object.List).Value()
+			obj := vm.pop()
+			vm.push(object.NewPartialWithKwargs(obj, args, kwargs))
+		case op.SetupCatch, op.SetupFinally:
+			base := vm.ip - 1
+			delta := int(vm.fetch())
+			vm.activeFrame.PushHandler(handler{
+				ip:      base + delta,
+				sp:      vm.sp,
+				isCatch: opcode == op.SetupCatch,
+			})
+		case op.PopExcept:
+			vm.activeFrame.PopHandler()
+		case op.Raise:
+			obj := vm.pop()
+			errObj, ok := obj.(*object.Error)
+			if !ok {
+				return errz.TypeErrorf("type error: cannot raise %s object", obj.Type())
+			}
+			return errObj.Value()
 		case op.ReturnValue:
 			activeFrame := vm.activeFrame
 			returnAddr := activeFrame.returnAddr
@@ -442,6 +687,18 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			if !tos.IsTruthy() {
 				vm.ip += delta
 			}
+		case op.JumpForwardIfNil:
+			base := vm.ip - 1
+			delta := int(vm.fetch())
+			if vm.stack[vm.sp] == object.Nil {
+				vm.ip = base + delta
+			}
+		case op.JumpForwardIfNotNil:
+			base := vm.ip - 1
+			delta := int(vm.fetch())
+			if vm.stack[vm.sp] != object.Nil {
+				vm.ip = base + delta
+			}
 		case op.JumpForward:
 			base := vm.ip - 1
 			delta := int(vm.fetch())
@@ -466,6 +723,39 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				items[k.(*object.String).Value()] = v
 			}
 			vm.push(object.NewMap(items))
+		case op.ListExtend:
+			obj := vm.pop()
+			list := vm.stack[vm.sp].(*object.List)
+			if err := extendList(ctx, list, obj); err != nil {
+				return err
+			}
+		case op.MapMerge:
+			obj := vm.pop()
+			m := vm.stack[vm.sp].(*object.Map)
+			other, ok := obj.(*object.Map)
+			if !ok {
+				return errz.TypeErrorf("type error: cannot spread %s into a map", obj.Type())
+			}
+			for k, v := range other.Value() {
+				m.Set(k, v)
+			}
+		case op.ListAppend:
+			obj := vm.pop()
+			list := vm.stack[vm.sp-int(vm.fetch())].(*object.List)
+			list.Append(obj)
+		case op.MapAdd:
+			value := vm.pop()
+			key := vm.pop()
+			m := vm.stack[vm.sp-int(vm.fetch())].(*object.Map)
+			if err := m.SetItem(key, value); err != nil {
+				return err.Value()
+			}
+		case op.SetAdd:
+			obj := vm.pop()
+			set := vm.stack[vm.sp-int(vm.fetch())].(*object.Set)
+			if err, ok := set.Add(obj).(*object.Error); ok {
+				return err.Value()
+			}
 		case op.BuildSet:
 			count := vm.fetch()
 			items := make([]object.Object, count)
@@ -473,16 +763,47 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				items[i] = vm.pop()
 			}
 			vm.push(object.NewSet(items))
+		case op.BuildStruct:
+			count := vm.fetch()
+			fields := make([]string, count)
+			defaults := make([]object.Object, count)
+			for i := uint16(0); i < count; i++ {
+				defaults[count-1-i] = vm.pop()
+				fields[count-1-i] = vm.pop().(*object.String).Value()
+			}
+			name := vm.pop().(*object.String).Value()
+			vm.push(object.NewStructType(name, fields, defaults))
 		case op.BinarySubscr:
 			idx := vm.pop()
 			lhs := vm.pop()
+			result, err := vm.getItem(ctx, lhs, idx)
+			if err != nil {
+				return err
+			}
+			vm.push(result)
+		case op.BinarySubscrOrNil:
+			idx := vm.pop()
+			lhs := vm.pop()
+			if hook, ok := object.GetHook(lhs, object.HookGetItem); ok {
+				result, err := vm.callHook(ctx, hook, lhs, idx)
+				if err != nil {
+					return err
+				}
+				vm.push(result)
+				break
+			}
 			container, ok := lhs.(object.Container)
 			if !ok {
 				return errz.TypeErrorf("type error: object is not a container (got %s)", lhs.Type())
 			}
 			result, err := container.GetItem(idx)
 			if err != nil {
-				return err.Value()
+				// A missing key or index produces nil, but type errors are raised
+				var typeErr *errz.TypeError
+				if errors.As(err.Value(), &typeErr) {
+					return err.Value()
+				}
+				result = object.Nil
 			}
 			vm.push(result)
 		case op.StoreSubscr:
@@ -503,6 +824,10 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				vm.push(object.NewInt(-obj.Value()))
 			case *object.Float:
 				vm.push(object.NewFloat(-obj.Value()))
+			case *object.BigInt:
+				vm.push(object.NewBigInt(new(big.Int).Neg(obj.Value())))
+			case *object.Decimal:
+				vm.push(object.NewDecimal(new(big.Int).Neg(obj.Unscaled()), obj.Scale()))
 			default:
 				return errz.TypeErrorf("type error: object is not a number (got %s)", obj.Type())
 			}
@@ -517,16 +842,14 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			obj := vm.pop()
 			containerObj := vm.pop()
 			invert := vm.fetch() == 1
-			if container, ok := containerObj.(object.Container); ok {
-				value := container.Contains(obj)
-				if invert {
-					value = object.Not(value)
-				}
-				vm.push(value)
-			} else {
-				return errz.TypeErrorf("type error: object is not a container (got %s)",
-					containerObj.Type())
+			value, err := vm.contains(ctx, containerObj, obj)
+			if err != nil {
+				return err
 			}
+			if invert {
+				value = object.Not(value)
+			}
+			vm.push(value)
 		case op.Swap:
 			vm.swap(int(vm.fetch()))
 		case op.BuildString:
@@ -544,10 +867,29 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				case *object.String:
 					items[dst] = obj.Value()
 				default:
-					items[dst] = obj.Inspect()
+					s, ok, err := object.CallStrHook(ctx, obj)
+					if err != nil {
+						return err
+					}
+					if !ok {
+						s = obj.Inspect()
+					}
+					items[dst] = s
 				}
 			}
 			vm.push(object.NewString(strings.Join(items, "")))
+		case op.FormatValue:
+			conversion := op.FormatConversion(vm.fetch())
+			spec := vm.activeCode.Constants[vm.fetch()].(*object.String).Value()
+			obj := vm.pop()
+			if err, ok := obj.(*object.Error); ok && err.IsRaised() {
+				return err.Value()
+			}
+			result, err := vm.formatValue(ctx, obj, conversion, spec)
+			if err != nil {
+				return err
+			}
+			vm.push(result)
 		case op.Range:
 			iterableObj := vm.pop()
 			iterable, ok := iterableObj.(object.Iterable)
@@ -572,13 +914,11 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 			}
 			vm.push(result)
 		case op.Length:
-			containerObj := vm.pop()
-			container, ok := containerObj.(object.Container)
-			if !ok {
-				return errz.TypeErrorf("type error: object is not a container (got %s)",
-					containerObj.Type())
+			result, err := vm.length(ctx, vm.pop())
+			if err != nil {
+				return err
 			}
-			vm.push(container.Len())
+			vm.push(result)
 		case op.Copy:
 			offset := vm.fetch()
 			vm.push(vm.stack[vm.sp-int(offset)])
@@ -625,6 +965,10 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 					if err != nil {
 						return err
 					}
+					if module.IsPrivateAttr(name) {
+						return fmt.Errorf("import error: cannot import private name %q from %q",
+							name, module.Name())
+					}
 					attr, found := module.GetAttr(name)
 					if !found {
 						return fmt.Errorf("import error: cannot import name %q from %q",
@@ -655,22 +999,51 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				}
 				vm.push(val)
 			}
+		case op.UnpackRest:
+			containerObj := vm.pop()
+			before := int64(vm.fetch())
+			after := int64(vm.fetch())
+			container, ok := containerObj.(object.Container)
+			if !ok {
+				return errz.TypeErrorf("type error: object is not a container (got %s)",
+					containerObj.Type())
+			}
+			containerSize := container.Len().Value()
+			if containerSize < before+after {
+				return fmt.Errorf("unpack count mismatch: %d < %d", containerSize, before+after)
+			}
+			items := make([]object.Object, 0, containerSize)
+			iter := container.Iter()
+			for {
+				val, ok := iter.Next(ctx)
+				if !ok {
+					break
+				}
+				items = append(items, val)
+			}
+			restEnd := int64(len(items)) - after
+			for _, item := range items[:before] {
+				vm.push(item)
+			}
+			vm.push(object.NewList(append([]object.Object{}, items[before:restEnd]...)))
+			for _, item := range items[restEnd:] {
+				vm.push(item)
+			}
 		case op.GetIter:
-			obj := vm.pop()
-			switch obj := obj.(type) {
-			case object.Iterable:
-				vm.push(obj.Iter())
-			case object.Iterator:
-				vm.push(obj)
-			default:
-				return errz.TypeErrorf("type error: object is not iterable (got %s)", obj.Type())
+			iter, err := vm.getIter(ctx, vm.pop())
+			if err != nil {
+				return err
 			}
+			vm.push(iter)
 		case op.ForIter:
 			base := vm.ip - 1
 			jumpAmount := vm.fetch()
 			nameCount := vm.fetch()
 			iter := vm.pop().(object.Iterator)
 			if _, ok := iter.Next(ctx); !ok {
+				if err := object.IteratorErr(iter); err != nil {
+					return err
+				}
 				vm.ip = base + int(jumpAmount)
 			} else {
 				obj, _ := iter.Entry()
@@ -724,6 +1097,26 @@ func (vm *VirtualMachine) eval(ctx context.Context) error {
 				return err
 			}
 			vm.push(value)
+		case op.Select:
+			count := int(vm.fetch())
+			hasDefault := vm.fetch() == 1
+			items := make([]object.Object, count*3)
+			for i := len(items) - 1; i >= 0; i-- {
+				items[i] = vm.pop()
+			}
+			chosen, value, ok, err := selectChannels(ctx, items, hasDefault)
+			if err != nil {
+				return err
+			}
+			vm.push(value)
+			vm.push(object.NewBool(ok))
+			vm.push(object.NewInt(int64(chosen)))
+		case op.MatchType:
+			obj := vm.pop()
+			name := vm.activeCode.Names[vm.fetch()]
+			vm.push(object.NewBool(matchesType(obj, name)))
+		case op.Yield:
+			return vm.suspendGenerator(vm.pop())
 		case op.Halt:
 			return nil
 		default:
@@ -771,9 +1164,42 @@ func (vm *VirtualMachine) pop() object.Object {
 
 func (vm *VirtualMachine) push(obj object.Object) {
 	vm.sp++
+	if vm.sp == len(vm.stack) {
+		vm.growStack()
+	}
 	vm.stack[vm.sp] = obj
 }
 
+// growStack doubles the size of the data stack. The limit on its size is
+// enforced by checkDepth when functions are called rather than on each push.
+func (vm *VirtualMachine) growStack() {
+	stack := make([]object.Object, max(2*len(vm.stack), initialStackSize))
+	copy(stack, vm.stack)
+	vm.stack = stack
+}
+
+// frameAt returns the frame at the given frame pointer, allocating it if
+// the frame stack hasn't been this deep before. Frames are allocated
+// individually so that pointers to them remain valid as the stack grows.
+func (vm *VirtualMachine) frameAt(fp int) *frame {
+	for len(vm.frames) <= fp {
+		vm.frames = append(vm.frames, &frame{})
+	}
+	return vm.frames[fp]
+}
+
+// checkDepth returns an error if activating another frame would exceed the
+// limits on the depth of the frame stack or the size of the data stack.
+func (vm *VirtualMachine) checkDepth() error {
+	if vm.fp+1 >= vm.maxFrames {
+		return errz.EvalErrorf("eval error: recursion limit exceeded (max frame depth %d)", vm.maxFrames)
+	}
+	if vm.sp+1 >= vm.maxStack {
+		return errz.EvalErrorf("eval error: recursion limit exceeded (max stack depth %d)", vm.maxStack)
+	}
+	return nil
+}
+
 func (vm *VirtualMachine) swap(pos int) {
 	otherIndex := vm.sp - pos
 	tos := vm.stack[vm.sp]
@@ -809,6 +1235,38 @@ func (vm *VirtualMachine) Call(
 	return vm.callFunction(vm.initContext(ctx), fn, args)
 }
 
+// addStackFrame appends the active frame to the call stack of the given error,
+// wrapping the error in an errz.RuntimeError if it doesn't have a stack yet.
+// This is called as an error propagates out of each nested eval call, so the
+// stack is built from the innermost call outwards.
+func (vm *VirtualMachine) addStackFrame(err error) error {
+	runtimeErr, ok := err.(*errz.RuntimeError)
+	if !ok {
+		runtimeErr = errz.NewRuntimeError(err, nil)
+	}
+	runtimeErr.Stack = append(runtimeErr.Stack, vm.stackFrame())
+	return runtimeErr
+}
+
+// stackFrame describes the active frame and the source position of the
+// instruction that is currently executing within it.
+func (vm *VirtualMachine) stackFrame() errz.StackFrame {
+	code := vm.activeCode
+	frame := errz.StackFrame{
+		Function: code.CodeName(),
+		File:     code.Filename(),
+	}
+	if frame.Function == "" {
+		frame.Function = "<anonymous>"
+	}
+	// The instruction pointer was advanced past the opcode before dispatch
+	if pos, ok := code.LocationAt(vm.ip - 1); ok {
+		frame.Line = pos.LineNumber()
+		frame.Column = pos.ColumnNumber()
+	}
+	return frame
+}
+
 // Calls a compiled function with the given arguments. This is used internally
 // when a Risor object calls a function, e.g. [1, 2, 3].map(func(x) { x + 1 }).
 func (vm *VirtualMachine) callFunction(
@@ -816,18 +1274,17 @@ func (vm *VirtualMachine) callFunction(
 	fn *object.Function,
 	args []object.Object,
 ) (result object.Object, resultErr error) {
-	// Check that the argument count is appropriate
-	paramsCount := len(fn.Parameters())
-	argc := len(args)
-
-	if argc > MaxArgs {
-		return nil, errz.EvalErrorf("eval error: max args limit of %d exceeded (got %d)",
-			MaxArgs, argc)
-	}
-	if err := checkCallArgs(fn, argc); err != nil {
+	locals, err := vm.bindArgs(fn, args)
+	if err != nil {
 		return nil, err
 	}
 
+	// Calling a generator function doesn't run it. Its body runs as the
+	// returned generator is iterated.
+	if fn.Code().IsGenerator() {
+		return vm.newGenerator(fn, locals), nil
+	}
+
 	baseFP := vm.fp
 	baseIP := vm.ip
 	baseSP := vm.sp
@@ -835,25 +1292,11 @@ func (vm *VirtualMachine) callFunction(
 	// Restore the previous frame when done
 	defer vm.resumeFrame(baseFP, baseIP, baseSP)
 
-	// Assemble frame local variables in vm.tmp. The local variable order is:
-	// 1. Function parameters
-	// 2. Function name (if the function is named)
-	copy(vm.tmp[:argc], args)
-	if argc < paramsCount {
-		defaults := fn.Defaults()
-		for i := argc; i < len(defaults); i++ {
-			vm.tmp[i] = defaults[i]
-		}
-		argc = paramsCount
-	}
-	code := fn.Code()
-	if code.IsNamed() {
-		vm.tmp[paramsCount] = fn
-		argc++
-	}
-
 	// Activate a frame for the function call
-	vm.activateFunction(vm.fp+1, 0, fn, vm.tmp[:argc])
+	if err := vm.checkDepth(); err != nil {
+		return nil, err
+	}
+	vm.activateFunction(vm.fp+1, 0, fn, locals)
 
 	// Setting StopSignal as the return address will cause the eval function to
 	// stop execution when it reaches the end of the active code.
@@ -863,7 +1306,7 @@ func (vm *VirtualMachine) callFunction(
 	callFrame := vm.activeFrame
 	defer func() {
 		for _, partial := range callFrame.defers {
-			if err := vm.callObject(ctx, partial.Function(), partial.Args()); err != nil {
+			if err := vm.callObjectWithKwargs(ctx, partial.Function(), partial.Args(), partial.Kwargs()); err != nil {
 				result = nil
 				resultErr = err
 			} else {
@@ -881,6 +1324,104 @@ func (vm *VirtualMachine) callFunction(
 	return vm.pop(), nil
 }
 
+// bindArgs checks the arguments of a call to the given function and returns
+// the local variables of the call, which are assembled in vm.tmp. The local
+// variable order is:
+// 1. Function parameters
+// 2. Rest parameter (if the function is variadic)
+// 3. Function name (if the function is named)
+func (vm *VirtualMachine) bindArgs(fn *object.Function, args []object.Object) ([]object.Object, error) {
+	// Check that the argument count is appropriate
+	paramsCount := len(fn.Parameters())
+	argc := len(args)
+	isVariadic := fn.RestParameter() != ""
+
+	if argc > MaxArgs && !isVariadic {
+		return nil, errz.EvalErrorf("eval error: max args limit of %d exceeded (got %d)",
+			MaxArgs, argc)
+	}
+	if err := checkCallArgs(fn, argc); err != nil {
+		return nil, err
+	}
+
+	// Extra arguments to a variadic function are collected into a list
+	var rest []object.Object
+	if isVariadic {
+		rest = make([]object.Object, max(argc-paramsCount, 0))
+		if argc > paramsCount {
+			copy(rest, args[paramsCount:])
+			args = args[:paramsCount]
+			argc = paramsCount
+		}
+	}
+
+	copy(vm.tmp[:argc], args)
+	if argc < paramsCount {
+		defaults := fn.Defaults()
+		for i := argc; i < len(defaults); i++ {
+			vm.tmp[i] = defaults[i]
+		}
+		argc = paramsCount
+	}
+	if isVariadic {
+		vm.tmp[argc] = object.NewList(rest)
+		argc++
+	}
+	if fn.Code().IsNamed() {
+		vm.tmp[argc] = fn
+		argc++
+	}
+	return vm.tmp[:argc], nil
+}
+
+// tailCall calls the given function by replacing the active frame, rather
+// than activating a new frame on top of it. The function returns directly to
+// the caller of the active frame. Returns false without calling the function
+// if the active frame can't be replaced.
+func (vm *VirtualMachine) tailCall(fn *object.Function, args []object.Object) (bool, error) {
+	frame := vm.activeFrame
+	// The active frame must belong to a function call that has nothing left
+	// to do once the called function returns
+	if frame.fn == nil || frame.generator != nil || len(frame.handlers) > 0 || len(frame.defers) > 0 {
+		return false, nil
+	}
+	if fn.Code().IsGenerator() {
+		return false, nil
+	}
+	// Closures created by the function may expect the frames of enclosing
+	// functions to be directly below its own
+	code := vm.loadCode(fn.Code())
+	if code.usesOuterFrames {
+		return false, nil
+	}
+	locals, err := vm.bindArgs(fn, args)
+	if err != nil {
+		return false, err
+	}
+	// Discard anything the active function left on the stack
+	for vm.sp > frame.returnSp {
+		vm.pop()
+	}
+	frame.ActivateFunction(fn, code, frame.returnAddr, frame.returnSp, locals)
+	vm.activeCode = code
+	vm.ip = 0
+	return true, nil
+}
+
+// getAttr returns the named attribute of the object, resolving it if the
+// attribute is an object.AttrResolver.
+func (vm *VirtualMachine) getAttr(ctx context.Context, obj object.Object, name string) (object.Object, error) {
+	value, found := obj.GetAttr(name)
+	if !found {
+		return nil, errz.TypeErrorf("type error: attribute %q not found on %s object",
+			name, obj.Type())
+	}
+	if resolver, ok := value.(object.AttrResolver); ok {
+		return resolver.ResolveAttr(ctx, name)
+	}
+	return value, nil
+}
+
 // Call a callable object with the given arguments. Returns an error if the
 // object is not callable. If this call succeeds, the result of the call will
 // have been pushed onto the stack.
@@ -905,6 +1446,9 @@ func (vm *VirtualMachine) callObject(
 		vm.push(result)
 		return nil
 	case *object.Partial:
+		if fn.Kwargs() != nil {
+			return vm.callObjectWithKwargs(ctx, fn, args, nil)
+		}
 		// Combine the current arguments with the partial's arguments
 		argc := len(args)
 		expandedCount := argc + len(fn.Args())
@@ -922,6 +1466,66 @@ func (vm *VirtualMachine) callObject(
 	}
 }
 
+// Call a callable object with the given positional and keyword arguments.
+// Keyword arguments are bound to the parameters of compiled functions by name
+// and passed as a map to callables that implement object.KeywordCallable.
+func (vm *VirtualMachine) callObjectWithKwargs(
+	ctx context.Context,
+	fn object.Object,
+	args []object.Object,
+	kwargs *object.Map,
+) error {
+	switch fn := fn.(type) {
+	case *object.Function:
+		boundArgs, err := bindKwargs(fn, args, kwargs)
+		if err != nil {
+			return err
+		}
+		result, err := vm.callFunction(ctx, fn, boundArgs)
+		if err != nil {
+			return err
+		}
+		vm.push(result)
+		return nil
+	case object.KeywordCallable:
+		if kwargs == nil {
+			kwargs = object.NewMap(nil)
+		}
+		result := fn.CallWithKeywords(ctx, kwargs, args...)
+		if err, ok := result.(*object.Error); ok && err.IsRaised() {
+			return err.Value()
+		}
+		vm.push(result)
+		return nil
+	case *object.Partial:
+		// Combine the current arguments with the partial's arguments. Keyword
+		// arguments given in the call take precedence over the partial's.
+		newArgs := make([]object.Object, 0, len(args)+len(fn.Args()))
+		newArgs = append(newArgs, args...)
+		newArgs = append(newArgs, fn.Args()...)
+		newKwargs := object.NewMap(nil)
+		if partialKwargs := fn.Kwargs(); partialKwargs != nil {
+			for k, v := range partialKwargs.Value() {
+				newKwargs.Set(k, v)
+			}
+		}
+		if kwargs != nil {
+			for k, v := range kwargs.Value() {
+				newKwargs.Set(k, v)
+			}
+		}
+		return vm.callObjectWithKwargs(ctx, fn.Function(), newArgs, newKwargs)
+	default:
+		if kwargs == nil || kwargs.Size() == 0 {
+			return vm.callObject(ctx, fn, args)
+		}
+		if _, ok := fn.(object.Callable); ok {
+			return errz.TypeErrorf("type error: %s object does not accept keyword arguments", fn.Type())
+		}
+		return errz.TypeErrorf("type error: object is not callable (got %s)", fn.Type())
+	}
+}
+
 // Resume the frame at the given frame pointer, restoring the given IP and SP.
 func (vm *VirtualMachine) resumeFrame(fp, ip, sp int) *frame {
 	// The return value of the previous frame is on the top of the stack
@@ -941,7 +1545,7 @@ func (vm *VirtualMachine) resumeFrame(fp, ip, sp int) *frame {
 	// Activate the resumed frame
 	vm.fp = fp
 	vm.ip = ip
-	vm.activeFrame = &vm.frames[fp]
+	vm.activeFrame = vm.frameAt(fp)
 	vm.activeCode = vm.activeFrame.code
 	return vm.activeFrame
 }
@@ -951,7 +1555,7 @@ func (vm *VirtualMachine) resumeFrame(fp, ip, sp int) *frame {
 func (vm *VirtualMachine) activateCode(fp, ip int, code *code) *frame {
 	vm.fp = fp
 	vm.ip = ip
-	vm.activeFrame = &vm.frames[fp]
+	vm.activeFrame = vm.frameAt(fp)
 	vm.activeFrame.ActivateCode(code)
 	vm.activeCode = code
 	return vm.activeFrame
@@ -964,7 +1568,7 @@ func (vm *VirtualMachine) activateFunction(fp, ip int, fn *object.Function, loca
 	returnSp := vm.sp
 	vm.fp = fp
 	vm.ip = ip
-	vm.activeFrame = &vm.frames[fp]
+	vm.activeFrame = vm.frameAt(fp)
 	vm.activeFrame.ActivateFunction(fn, code, returnAddr, returnSp, locals)
 	vm.activeCode = code
 	return vm.activeFrame
@@ -1018,6 +1622,9 @@ func (vm *VirtualMachine) importModule(ctx context.Context, name string) (*objec
 		return nil, err
 	}
 	// Activate a new frame to evaluate the module code
+	if err := vm.checkDepth(); err != nil {
+		return nil, err
+	}
 	baseFP := vm.fp
 	baseIP := vm.ip
 	baseSP := vm.sp
@@ -1088,6 +1695,8 @@ func (vm *VirtualMachine) Clone() (*VirtualMachine, error) {
 		modules:      modules,
 		loadedCode:   loadedCode,
 		concAllowed:  vm.concAllowed,
+		maxStack:     vm.maxStack,
+		maxFrames:    vm.maxFrames,
 	}
 
 	// Only activate main code if it exists
@@ -1130,6 +1739,7 @@ func (vm *VirtualMachine) initContext(ctx context.Context) context.Context {
 	oss := vm.getOS(ctx)
 	ctx = os.WithOS(ctx, oss)
 	ctx = object.WithCallFunc(ctx, vm.callFunction)
+	ctx = object.WithResumeFunc(ctx, vm.resumeGenerator)
 	if vm.concAllowed {
 		ctx = object.WithSpawnFunc(ctx, vm.cloneCallAsync)
 		ctx = object.WithCloneCallFunc(ctx, vm.cloneCallSync)
@@ -2,13 +2,16 @@ package vm
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/risor-io/risor/compiler"
 	"github.com/risor-io/risor/errz"
 	"github.com/risor-io/risor/object"
+	"github.com/risor-io/risor/op"
 	ros "github.com/risor-io/risor/os"
 	"github.com/risor-io/risor/parser"
 	"github.com/stretchr/testify/require"
@@ -621,6 +624,141 @@ func TestConstantFunction(t *testing.T) {
 	require.Equal(t, "compile error: cannot assign to constant \"add\"\n\nlocation: unknown:3:6 (line 3, column 6)", err.Error())
 }
 
+func TestConstantFolding(t *testing.T) {
+	tests := []testCase{
+		{`const HOUR = 60 * 60; HOUR * 24`, object.NewInt(86400)},
+		{`const A = "a"; const B = A + "b"; B + "c"`, object.NewString("abc")},
+		{`const X = 1; func f() { X := 2; X }; [f(), X]`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewInt(1),
+		})},
+		{`const LIMIT = 3; func f(n) { n < LIMIT }; [f(2), f(3)]`, object.NewList([]object.Object{
+			object.True, object.False,
+		})},
+		{`const DEBUG = false; if DEBUG { 1 } else { 2 }`, object.NewInt(2)},
+		{`if !true { 1 }`, object.Nil},
+		{`func f() { return 1; 2 }; f()`, object.NewInt(1)},
+		{`x := 0; for { x++; if x > 3 { break; x = 100 } }; x`, object.NewInt(4)},
+		{`2 ** 10 - 1`, object.NewInt(1023)},
+		{`9223372036854775807 + 1`, object.NewInt(-9223372036854775808)},
+	}
+	runTests(t, tests)
+}
+
+func TestSuperinstructions(t *testing.T) {
+	tests := []testCase{
+		{`func f() { x := 5; y := 7; return x * y }; f()`, object.NewInt(35)},
+		{`func f(x) { return x - 1.5 }; f(4)`, object.NewFloat(2.5)},
+		{`func f() { s := 0; for i := 0; i < 10; i++ { s += i }; return s }; f()`, object.NewInt(45)},
+		{`func f() { n := 10; for n > 0 { n-- }; return n }; f()`, object.NewInt(0)},
+		{`func f() { x := 1.5; x += 2; return x }; f()`, object.NewFloat(3.5)},
+		{`func f() { s := "a"; s += "b"; return s }; f()`, object.NewString("ab")},
+		{`func f(x) { if x == 1 { return "one" }; return "other" }; [f(1), f(2)]`,
+			object.NewList([]object.Object{object.NewString("one"), object.NewString("other")})},
+		{`func f(a, b) { if a < b { return a }; return b }; [f(1, 2), f(4, 3)]`,
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(3)})},
+		{`func f() {
+			m := {value: 1, __add__: func(a, b) { a.value + b }}
+			m += 2
+			return m
+		}; f()`, object.NewInt(3)},
+		{`func f() {
+			m := {__cmp__: func(a, b) { 1 }}
+			if m < 0 { return "less" }
+			return "not less"
+		}; f()`, object.NewString("not less")},
+	}
+	runTests(t, tests)
+}
+
+func TestSuperinstructionErrorLocation(t *testing.T) {
+	code := `
+func f(x) {
+	if x < "a" {
+		return 1
+	}
+}
+f(1)
+`
+	_, err := run(context.Background(), code)
+	require.NotNil(t, err)
+	var runtimeErr *errz.RuntimeError
+	require.True(t, errors.As(err, &runtimeErr))
+	require.Equal(t, errz.StackFrame{Function: "f", Line: 3, Column: 7}, runtimeErr.Stack[0])
+}
+
+func TestStruct(t *testing.T) {
+	tests := []testCase{
+		{`struct Point { x = 0, y = 0 }; p := Point(); [p.x, p.y]`, object.NewList([]object.Object{
+			object.NewInt(0),
+			object.NewInt(0),
+		})},
+		{`struct Point { x = 0, y = 0 }; p := Point(3); [p.x, p.y]`, object.NewList([]object.Object{
+			object.NewInt(3),
+			object.NewInt(0),
+		})},
+		{`struct Point { x, y }; Point(1, 2).y`, object.NewInt(2)},
+		{`struct Point { x, y }; Point().x`, object.Nil},
+		{`struct Point {
+			x = 1
+			y = 2,
+		  }
+		  p := Point()
+		  p.y = p.x + 10
+		  p.y`, object.NewInt(11)},
+		{`struct Point { x, y }; string(Point(1, "a"))`, object.NewString(`Point(x=1, y="a")`)},
+		{`struct Point { x, y }; Point(1, 2) == Point(1, 2)`, object.True},
+		{`struct Point { x, y }; Point(1, 2) == Point(1, 3)`, object.False},
+		{`struct A { x }; struct B { x }; A(1) == B(1)`, object.False},
+		{`struct Point { x, y }; type(Point(1, 2))`, object.NewString("Point")},
+		{`struct Point { x, y }; Point.fields()`, object.NewList([]object.Object{
+			object.NewString("x"),
+			object.NewString("y"),
+		})},
+		{`struct Point { y = 1, x = 2 }; json.marshal(Point())`, object.NewString(`{"y":1,"x":2}`)},
+		{`n := 5; struct Box { size = n * 2 }; Box().size`, object.NewInt(10)},
+		{`func f() { struct Pair { a, b }; return Pair(1, 2).b }; f()`, object.NewInt(2)},
+		{`struct Empty {}; string(Empty())`, object.NewString("Empty()")},
+		{`struct Bag { items = [], tags = {"a": [1]} }
+		  a := Bag(); b := Bag()
+		  a.items.append(1); a.tags["a"].append(2)
+		  [b.items, b.tags, Bag().items]`, object.NewList([]object.Object{
+			object.NewList([]object.Object{}),
+			object.NewMap(map[string]object.Object{
+				"a": object.NewList([]object.Object{object.NewInt(1)}),
+			}),
+			object.NewList([]object.Object{}),
+		})},
+		{`items := [1]; struct Bag { items = items, copy = items }
+		  b := Bag(); b.items.append(2)
+		  [items, b.items, b.copy]`, object.NewList([]object.Object{
+			object.NewList([]object.Object{object.NewInt(1)}),
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)}),
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)}),
+		})},
+	}
+	runTests(t, tests)
+}
+
+func TestStructErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`struct Point { x, y }; Point(1, 2).z`, `type error: attribute "z" not found on Point object`},
+		{`struct Point { x, y }; p := Point(); p.z = 1`, `type error: struct Point has no field "z"`},
+		{`struct Point { x, y }; Point(1, 2, 3)`, `args error: Point() takes between 0 and 2 arguments (3 given)`},
+		{`struct Point { x, y }; Point = 1`, "compile error: cannot assign to constant \"Point\"\n\nlocation: unknown:1:30 (line 1, column 30)"},
+		{`struct Point { x, x }`, "compile error: duplicate field \"x\" in struct \"Point\"\n\nlocation: unknown:1:19 (line 1, column 19)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
 func TestStatementsNilValue(t *testing.T) {
 	// The result value of a statement is always nil
 	tests := []testCase{
@@ -843,7 +981,10 @@ func TestTry(t *testing.T) {
 		{`try(1)`, object.NewInt(1)},
 		{`try(1, 2)`, object.NewInt(1)},
 		{`try(func() { error("oops") }, "nope")`, object.NewString("nope")},
-		{`try(func() { error("oops") }, func(e) { e })`, object.Errorf("oops").WithRaised(false)},
+		{`try(func() { error("oops") }, func(e) { e })`, object.NewError(errz.NewRuntimeError(
+			errors.New("oops"),
+			[]errz.StackFrame{{Function: "<anonymous>", Line: 1, Column: 19}},
+		)).WithRaised(false)},
 		{`try(func() { error("oops") }, func(e) { e.error() })`, object.NewString("oops")},
 		{`try(func() { error("oops") }, func() { error("oops") }, 1)`, object.NewInt(1)},
 		{`x := 0; y := 0; z := try(func() {
@@ -863,6 +1004,285 @@ func TestTry(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestTryErrorStack(t *testing.T) {
+	code := `
+	try(func() { error("oops") }, func(e) { e })
+	`
+	result, err := run(context.Background(), code)
+	require.Nil(t, err)
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.False(t, errObj.IsRaised())
+	require.Equal(t, "oops", errObj.Message().Value())
+	require.Equal(t, []errz.StackFrame{
+		{Function: "<anonymous>", Line: 2, Column: 20},
+	}, errObj.Stack())
+}
+
+func TestRuntimeErrorUnwrap(t *testing.T) {
+	// Errors are wrapped in a RuntimeError, but the original error remains
+	// available through Unwrap and errors.As
+	_, err := run(context.Background(), `1 + "a"`)
+	var typeErr *errz.TypeError
+	require.True(t, errors.As(err, &typeErr))
+	require.Same(t, typeErr, errors.Unwrap(err))
+
+	_, err = run(context.Background(), `len()`)
+	var argsErr *errz.ArgsError
+	require.True(t, errors.As(err, &argsErr))
+	require.Same(t, argsErr, errors.Unwrap(err))
+
+	_, err = run(context.Background(), `func f() { error(errors.eval_error("x")) }; f()`)
+	var evalErr *errz.EvalError
+	require.True(t, errors.As(err, &evalErr))
+	require.Same(t, evalErr, errors.Unwrap(err))
+
+	_, err = run(context.Background(), `error("oops")`)
+	_, ok := err.(*errz.RuntimeError)
+	require.True(t, ok)
+	require.Equal(t, errors.New("oops"), errors.Unwrap(err))
+}
+
+func TestRuntimeErrorStack(t *testing.T) {
+	code := `
+func inner(x) {
+	return x + "a"
+}
+func outer() {
+	result := inner(1)
+	return result
+}
+outer()
+`
+	_, err := run(context.Background(), code)
+	require.NotNil(t, err)
+	var runtimeErr *errz.RuntimeError
+	require.True(t, errors.As(err, &runtimeErr))
+	require.Equal(t, []errz.StackFrame{
+		{Function: "inner", Line: 3, Column: 11},
+		{Function: "outer", Line: 6, Column: 17},
+		{Function: "__main__", Line: 9, Column: 6},
+	}, runtimeErr.Stack)
+}
+
+func TestRuntimeErrorStackTailCall(t *testing.T) {
+	// The frame of outer is replaced by the tail call to inner
+	code := `
+func inner(x) {
+	return x + "a"
+}
+func outer() {
+	return inner(1)
+}
+outer()
+`
+	_, err := run(context.Background(), code)
+	require.NotNil(t, err)
+	var runtimeErr *errz.RuntimeError
+	require.True(t, errors.As(err, &runtimeErr))
+	require.Equal(t, []errz.StackFrame{
+		{Function: "inner", Line: 3, Column: 11},
+		{Function: "__main__", Line: 8, Column: 6},
+	}, runtimeErr.Stack)
+}
+
+func TestRuntimeErrorStackAttr(t *testing.T) {
+	code := `
+func fail() {
+	error("boom")
+}
+e := try(fail, func(e) { e })
+e.stack()
+`
+	result, err := run(context.Background(), code)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewMap(map[string]object.Object{
+			"function": object.NewString("fail"),
+			"file":     object.NewString(""),
+			"line":     object.NewInt(3),
+			"column":   object.NewInt(7),
+		}),
+	}), result)
+}
+
+func TestTryCatchStatement(t *testing.T) {
+	tests := []testCase{
+		{`x := 0
+		  try { x = 1 } catch { x = 2 }
+		  x`, object.NewInt(1)},
+		{`x := 0
+		  try { error("oops"); x = 1 } catch { x = 2 }
+		  x`, object.NewInt(2)},
+		{`msg := ""
+		  try { error("oops") } catch err { msg = err.message() }
+		  msg`, object.NewString("oops")},
+		{`e := nil
+		  try { [1, 2].nope } catch err { e = err }
+		  [type(e), e.message()]`, object.NewList([]object.Object{
+			object.NewString("error"),
+			object.NewString(`type error: attribute "nope" not found on list object`),
+		})},
+		{`steps := []
+		  try { steps.append("try") } catch { steps.append("catch") } finally { steps.append("finally") }
+		  steps`, object.NewList([]object.Object{
+			object.NewString("try"),
+			object.NewString("finally"),
+		})},
+		{`steps := []
+		  try { error("oops") } catch { steps.append("catch") } finally { steps.append("finally") }
+		  steps`, object.NewList([]object.Object{
+			object.NewString("catch"),
+			object.NewString("finally"),
+		})},
+		{`func fail() { error("deep") }
+		  func middle() { fail(); return 1 }
+		  msg := ""
+		  try { middle() } catch err { msg = err.message() }
+		  msg`, object.NewString("deep")},
+		{`x := 0
+		  try {
+			try { error("inner") } catch { error("rethrown") }
+		  } catch err {
+			x = err.message()
+		  }
+		  x`, object.NewString("rethrown")},
+		{`func f() {
+			try { return "try" } finally { print("") }
+		  }
+		  f()`, object.NewString("try")},
+		{`steps := []
+		  func f() {
+			try { return 1 } finally { steps.append("finally") }
+		  }
+		  [f(), steps]`, object.NewList([]object.Object{
+			object.NewInt(1),
+			object.NewList([]object.Object{object.NewString("finally")}),
+		})},
+		{`func f() {
+			try { error("oops") } catch { return "caught" } finally { print("") }
+		  }
+		  f()`, object.NewString("caught")},
+		{`func f() {
+			try { error("oops") } finally { return "finally" }
+		  }
+		  f()`, object.NewString("finally")},
+		{`steps := []
+		  for i := range 3 {
+			try {
+			  if i == 1 { continue }
+			  if i == 2 { break }
+			  steps.append(i)
+			} finally {
+			  steps.append('f{i}')
+			}
+		  }
+		  steps`, object.NewList([]object.Object{
+			object.NewInt(0),
+			object.NewString("f0"),
+			object.NewString("f1"),
+			object.NewString("f2"),
+		})},
+		{`count := 0
+		  for i := 0; i < 5; i++ {
+			try { error("oops") } catch { count++ }
+		  }
+		  count`, object.NewInt(5)},
+		{`steps := []
+		  func f() {
+			defer func() { steps.append("defer") }()
+			error("oops")
+		  }
+		  try { f() } catch { steps.append("catch") }
+		  steps`, object.NewList([]object.Object{
+			object.NewString("defer"),
+			object.NewString("catch"),
+		})},
+		{`sentinel := errors.new("sentinel")
+		  ok := false
+		  try { error(sentinel) } catch err { ok = errors.is(err, sentinel) }
+		  ok`, object.True},
+		{`x := [1, 2, 3]
+		  total := 0
+		  for _, v := range x {
+			try { total += v } catch { }
+		  }
+		  total`, object.NewInt(6)},
+		{`x := 0; try(func() { x = 1 }); x`, object.NewInt(1)},
+	}
+	runTests(t, tests)
+}
+
+func TestTryCatchStatementErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`try { error("oops") } finally { print("") }`, "oops"},
+		{`try { error("oops") } catch { error("again") }`, "again"},
+		{`try { error(errors.eval_error("fatal")) } catch { }`, "fatal"},
+		{`try { 1 } catch e { e.nope }`, ""},
+		{`for i := range 3 { try { } finally { break } }`,
+			"compile error: invalid break statement in finally block\n\nlocation: unknown:1:38 (line 1, column 38)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			if tt.expectedErr == "" {
+				require.Nil(t, err)
+				return
+			}
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
+func TestTryFinallyRunsOnError(t *testing.T) {
+	code := `
+	steps := []
+	func f() {
+		try {
+			error("oops")
+		} finally {
+			steps.append("finally")
+		}
+	}
+	try { f() } catch err { steps.append(err.message()) }
+	steps
+	`
+	result, err := run(context.Background(), code)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewString("finally"),
+		object.NewString("oops"),
+	}), result)
+}
+
+func TestTryCatchStack(t *testing.T) {
+	code := `
+func fail() {
+	error("boom")
+}
+e := nil
+try {
+	fail()
+} catch err {
+	e = err
+}
+e
+`
+	result, err := run(context.Background(), code)
+	require.Nil(t, err)
+	errObj, ok := result.(*object.Error)
+	require.True(t, ok)
+	require.False(t, errObj.IsRaised())
+	require.Equal(t, []errz.StackFrame{
+		{Function: "fail", Line: 3, Column: 7},
+		{Function: "__main__", Line: 7, Column: 6},
+	}, errObj.Stack())
+}
+
 func TestTryEvalError(t *testing.T) {
 	code := `
 	try(func() { error(errors.eval_error("oops")) }, 1)
@@ -870,7 +1290,9 @@ func TestTryEvalError(t *testing.T) {
 	_, err := run(context.Background(), code)
 	require.NotNil(t, err)
 	require.Equal(t, "oops", err.Error())
-	require.Equal(t, errz.EvalErrorf("oops"), err)
+	var evalErr *errz.EvalError
+	require.True(t, errors.As(err, &evalErr))
+	require.Equal(t, errz.EvalErrorf("oops"), evalErr)
 }
 
 func TestTryTypeError(t *testing.T) {
@@ -1000,7 +1422,7 @@ func TestDeferWithError(t *testing.T) {
 	`
 	_, err := run(context.Background(), code)
 	require.Error(t, err)
-	require.Equal(t, fmt.Errorf("AGH"), err)
+	require.Equal(t, fmt.Errorf("AGH"), errors.Unwrap(err))
 }
 
 func TestStringTemplateWithRaisedError(t *testing.T) {
@@ -1017,6 +1439,52 @@ func TestStringTemplateWithNonRaisedError(t *testing.T) {
 	require.Equal(t, object.NewString("the err string is: oops. sad!"), result)
 }
 
+func TestStringTemplateFormatSpecs(t *testing.T) {
+	tests := []testCase{
+		{`value := 3.14159; '{value:.2f}'`, object.NewString("3.14")},
+		{`n := 42; '[{n:08d}]'`, object.NewString("[00000042]")},
+		{`s := "total"; '[{s:>8}|{s:<7}|{s:*^9}]'`, object.NewString("[   total|total  |**total**]")},
+		{`x := "hi"; '{x!r} {x!s} {[1, "a"]!r}'`, object.NewString(`"hi" hi [1, "a"]`)},
+		{`s := true; '{!s}'`, object.NewString("false")},
+		{`x := 1; r := false; '{x > 0 && !r}'`, object.NewString("true")},
+		{`x := "hi"; '{x!r:>6}'`, object.NewString(`  "hi"`)},
+		{`t := time.unix(1700000000, 0).utc(); '{t:%Y-%m-%d}'`, object.NewString("2023-11-14")},
+		{`d := decimal("1234.565"); '{d:,.2f}'`, object.NewString("1,234.56")},
+		{`n := 3; '{n > 2 ? "big" : "small":>6}'`, object.NewString("   big")},
+		{`m := {a: 5}; '{m["a"] * 2:03}'`, object.NewString("010")},
+		{`p := {__str__: func(self) { "point" }}; '{p:>6}|{p!r}'`, object.NewString(` point|{"__str__": func(self) { "point" }}`)},
+	}
+	runTests(t, tests)
+}
+
+func TestStringTemplateFormatSpecErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`'{"a":d}'`, `value error: invalid format specifier "d" for string`},
+		{`'{1.5:x}'`, `value error: invalid format specifier "x" for float`},
+		{`'{time.now():%Q}'`, `value error: invalid time format "%Q": unsupported directive %Q`},
+		{`'{error("oops"):>5}'`, "oops"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := run(context.Background(), tc.input)
+			require.NotNil(t, err)
+			require.Equal(t, tc.expected, err.Error())
+		})
+	}
+}
+
+func TestStringTemplateFormatSpecErrorsAreCaught(t *testing.T) {
+	tests := []testCase{
+		{`x := "abc"; r := nil; try { r = '{x:d}' } catch e { r = e.message() }; r`,
+			object.NewString(`value error: invalid format specifier "d" for string`)},
+		{`x := "abc"; try(func() { '{x:d}' }, "fallback")`, object.NewString("fallback")},
+	}
+	runTests(t, tests)
+}
+
 func TestMultiVarAssignment(t *testing.T) {
 	tests := []testCase{
 		{`a, b := [3, 4]; a`, object.NewInt(3)},
@@ -1034,22 +1502,413 @@ func TestMultiVarAssignment(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestDestructuring(t *testing.T) {
+	tests := []testCase{
+		{`[a, [b, c]] := [1, [2, 3]]; [a, b, c]`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(3),
+		})},
+		{`{name, age} := {name: "ann", age: 30}; '{name} {age}'`, object.NewString("ann 30")},
+		{`first, ...rest := [1, 2, 3]; rest`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewInt(3),
+		})},
+		{`first, ...rest := [1]; rest`, object.NewList([]object.Object{})},
+		{`a, ...mid, z := "abcd"; '{a}{mid}{z}'`, object.NewString(`a["b", "c"]d`)},
+		{`_, [x, _] := [0, [5, 6]]; x`, object.NewInt(5)},
+		{`{user: {name: n, tags: [t, ...more]}} := {user: {name: "z", tags: [1, 2, 3]}}; '{n} {t} {more}'`,
+			object.NewString("z 1 [2, 3]")},
+		{`{"first-name": fn} := {"first-name": "q"}; fn`, object.NewString("q")},
+		{`a := 1; b := 2; [a, b] = [b, a]; [a, b]`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewInt(1),
+		})},
+		{`x := 0; func() { [x, _] = [9, 8] }(); x`, object.NewInt(9)},
+		{`func f(p) { {x, y} := p; return x * y }; f({x: 3, y: 4})`, object.NewInt(12)},
+		{`out := []
+		for k, {id, name} in [{id: 1, name: "a"}, {id: 2, name: "b"}] {
+			out.append('{k}:{id}:{name}')
+		}
+		out`, object.NewList([]object.Object{
+			object.NewString("0:1:a"), object.NewString("1:2:b"),
+		})},
+		{`total := 0; for [a, b] in [[1, 2], [3, 4]] { total += a * b }; total`, object.NewInt(14)},
+		{`total := 0; for k, v in {a: 1, b: 2} { total += v }; total`, object.NewInt(3)},
+		{`total := 0; for i, [a, ...b] := range [[1, 2], [3, 4, 5]] { total += i + a + len(b) }; total`, object.NewInt(8)},
+	}
+	runTests(t, tests)
+}
+
+func TestDestructuringErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[a, b] := [1]`, "unpack count mismatch: 1 != 2"},
+		{`a, ...b, c := [1]`, "unpack count mismatch: 1 < 2"},
+		{`{name} := {age: 1}`, `key error: "name"`},
+		{`[a, b] := 1`, "type error: object is not a container (got int)"},
+		{`a, ...b := 1`, "type error: object is not a container (got int)"},
+		{`const q = 1; [q] = [2]`, "compile error: cannot assign to constant \"q\"\n\nlocation: unknown:1:15 (line 1, column 15)"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := run(context.Background(), tc.input)
+			require.NotNil(t, err)
+			require.Equal(t, tc.expected, err.Error())
+		})
+	}
+}
+
 func TestFunctions(t *testing.T) {
 	tests := []testCase{
-		{`func add(x, y) { x + y }; add(3, 4)`, object.NewInt(7)},
-		{`func add(x, y) { x + y }; add(3, 4) + 5`, object.NewInt(12)},
-		{`func inc(x, amount=1) { x + amount }; inc(3)`, object.NewInt(4)},
-		{`func factorial(n) { if (n == 1) { return 1 } else { return n * factorial(n - 1) } }; factorial(5)`, object.NewInt(120)},
-		{`z := 10; y := func(x, inc=100) { x + z + inc }; y(3)`, object.NewInt(113)},
-		{`func(x="a", y="b") { x + y }()`, object.NewString("ab")},
-		{`func(x="a", y="b") { x + y + "c" }()`, object.NewString("abc")},
-		{`func(x="a", y="b") { x + y + "c" }("W")`, object.NewString("Wbc")},
-		{`func(x="a", y="b") { x + y + "c" }("W", "X")`, object.NewString("WXc")},
-		{`func(x="a", y="b") { return "X"; x + y + "c" }()`, object.NewString("X")},
-		{`x := 1; func() { y := 10; x + y }()`, object.NewInt(11)},
-		{`x := 1; func() { func() { y := 10; x + y } }()()`, object.NewInt(11)},
+		{`func add(x, y) { x + y }; add(3, 4)`, object.NewInt(7)},
+		{`func add(x, y) { x + y }; add(3, 4) + 5`, object.NewInt(12)},
+		{`func inc(x, amount=1) { x + amount }; inc(3)`, object.NewInt(4)},
+		{`func factorial(n) { if (n == 1) { return 1 } else { return n * factorial(n - 1) } }; factorial(5)`, object.NewInt(120)},
+		{`z := 10; y := func(x, inc=100) { x + z + inc }; y(3)`, object.NewInt(113)},
+		{`func(x="a", y="b") { x + y }()`, object.NewString("ab")},
+		{`func(x="a", y="b") { x + y + "c" }()`, object.NewString("abc")},
+		{`func(x="a", y="b") { x + y + "c" }("W")`, object.NewString("Wbc")},
+		{`func(x="a", y="b") { x + y + "c" }("W", "X")`, object.NewString("WXc")},
+		{`func(x="a", y="b") { return "X"; x + y + "c" }()`, object.NewString("X")},
+		{`x := 1; func() { y := 10; x + y }()`, object.NewInt(11)},
+		{`x := 1; func() { func() { y := 10; x + y } }()()`, object.NewInt(11)},
+	}
+	runTests(t, tests)
+}
+
+func TestVariadicFunctions(t *testing.T) {
+	tests := []testCase{
+		{`func f(...args) { args }; f()`, object.NewList([]object.Object{})},
+		{`func f(...args) { args }; f(1, 2)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewInt(2)})},
+		{`func f(a, ...rest) { [a, rest] }; f(1)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewList([]object.Object{})})},
+		{`func f(a, ...rest) { [a, rest] }; f(1, 2, 3)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewList(
+				[]object.Object{object.NewInt(2), object.NewInt(3)})})},
+		{`func f(a, b=2, ...rest) { [a, b, len(rest)] }; f(1)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewInt(2), object.NewInt(0)})},
+		{`func f(a, b=2, ...rest) { [a, b, len(rest)] }; f(1, 5, 6, 7)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewInt(5), object.NewInt(2)})},
+		{`func sum(...n) { if len(n) == 1 { return n[0] }; return n[0] + sum(...n[1:]) }; sum(1, 2, 3)`, object.NewInt(6)},
+		{`x := 10; f := func(...n) { x + len(n) }; f(1, 2)`, object.NewInt(12)},
+		{`func wrap(fn) { return func(...args) { fn(...args) } }; wrap(func(a, b) { a - b })(5, 3)`, object.NewInt(2)},
+		{`"b" | func(...args) { args }("a")`, object.NewList(
+			[]object.Object{object.NewString("b"), object.NewString("a")})},
+	}
+	runTests(t, tests)
+}
+
+func TestMethodCalls(t *testing.T) {
+	tests := []testCase{
+		{`out := []; for i in range(3) { out.append(i * 2) }; out`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewInt(2), object.NewInt(4),
+		})},
+		{`count := 0; for line in ["a b", "c", "d e f"] { count += len(line.split(" ")) }; count`, object.NewInt(6)},
+		// The same instruction is used with receivers of different types
+		{`[x.index("b") for x in ["abc", ["a", "b"], "b"]]`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(1), object.NewInt(0),
+		})},
+		{`m := {"a": 1}; m.get("a") + m.get("b", 10)`, object.NewInt(11)},
+		// Map items are called if there is no method with the name
+		{`m := {"double": x => x * 2}; m.double(4)`, object.NewInt(8)},
+		{`m := {"keys": x => x}; m.keys()`, object.NewList([]object.Object{object.NewString("keys")})},
+		{`struct Point { x, y }; p := Point(1, 2); p.x`, object.NewInt(1)},
+		{`strings.to_upper("abc")`, object.NewString("ABC")},
+		{`func f(s) { s.to_upper() }; [f("a"), f("b")]`, object.NewList([]object.Object{
+			object.NewString("A"), object.NewString("B"),
+		})},
+	}
+	runTests(t, tests)
+}
+
+func TestMethodCacheIsPerInstruction(t *testing.T) {
+	ctx := context.Background()
+	vm, err := newVM(ctx, `[1].index(1); "ab".index("b")`)
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+	// Each call site caches the method table of its own receiver
+	var tables []*object.MethodTable
+	for offset := range vm.activeCode.methodCache {
+		if entry := vm.activeCode.methodCache[offset].Load(); entry != nil {
+			require.Equal(t, op.LoadMethod, vm.activeCode.Instructions[offset])
+			tables = append(tables, entry.table)
+		}
+	}
+	require.Equal(t, []*object.MethodTable{
+		object.NewList(nil).Methods(),
+		object.NewString("").Methods(),
+	}, tables)
+}
+
+func TestMethodCallErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1].append()`, "args error: list.append() takes exactly 1 arguments (0 given)"},
+		{`"abc".nope()`, `type error: attribute "nope" not found on string object`},
+		{`x := 1; x.append(2)`, `type error: attribute "append" not found on int object`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expected, err.Error())
+		})
+	}
+}
+
+func TestArrowFunctions(t *testing.T) {
+	tests := []testCase{
+		{`[1, 2, 3].map(x => x * 2)`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewInt(4), object.NewInt(6),
+		})},
+		{`[1, 2, 3, 4].filter(x => x % 2 == 0)`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewInt(4),
+		})},
+		{`f := (a, b=10) => a + b; [f(1), f(1, 2)]`, object.NewList([]object.Object{
+			object.NewInt(11), object.NewInt(3),
+		})},
+		{`f := (a, ...rest) => len(rest); f(1, 2, 3)`, object.NewInt(2)},
+		{`(() => 42)()`, object.NewInt(42)},
+		{`f := (a, b) => {
+			total := a + b
+			return total * 2
+		}
+		f(2, 3)`, object.NewInt(10)},
+		{`sorted([3, 1, 2], (a, b) => a > b)`, object.NewList([]object.Object{
+			object.NewInt(3), object.NewInt(2), object.NewInt(1),
+		})},
+		{`"abc" | (s => s + "!") | strings.to_upper`, object.NewString("ABC!")},
+		{`add := x => y => x + y; add(3)(4)`, object.NewInt(7)},
+		{`n := 5; [1, 2].map(x =>
+			x + n
+		)`, object.NewList([]object.Object{object.NewInt(6), object.NewInt(7)})},
+	}
+	runTests(t, tests)
+}
+
+func TestComprehensions(t *testing.T) {
+	tests := []testCase{
+		{`[x * 2 for x in [1, 2, 3]]`, object.NewList([]object.Object{
+			object.NewInt(2), object.NewInt(4), object.NewInt(6),
+		})},
+		{`[x for x in [3, -1, 4, -1, 5] if x > 0]`, object.NewList([]object.Object{
+			object.NewInt(3), object.NewInt(4), object.NewInt(5),
+		})},
+		{`[x for x in range(10) if x % 2 == 0 if x > 4]`, object.NewList([]object.Object{
+			object.NewInt(6), object.NewInt(8),
+		})},
+		{`[x for x in []]`, object.NewList([]object.Object{})},
+		{`[i for i, x in ["a", "b"]]`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewInt(1),
+		})},
+		{`[[i, j] for i in range(3) for j in range(i)]`, object.NewList([]object.Object{
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(0)}),
+			object.NewList([]object.Object{object.NewInt(2), object.NewInt(0)}),
+			object.NewList([]object.Object{object.NewInt(2), object.NewInt(1)}),
+		})},
+		{`{k: v * 10 for k, v in {a: 1, b: 2}}`, object.NewMap(map[string]object.Object{
+			"a": object.NewInt(10), "b": object.NewInt(20),
+		})},
+		{`{string(x): x for x in [1, 2] }`, object.NewMap(map[string]object.Object{
+			"1": object.NewInt(1), "2": object.NewInt(2),
+		})},
+		{`{x % 3 for x in range(6)}`, object.NewSet([]object.Object{
+			object.NewInt(0), object.NewInt(1), object.NewInt(2),
+		})},
+		{`x := 10; y := [x for x in [1]]; [x, y]`, object.NewList([]object.Object{
+			object.NewInt(10), object.NewList([]object.Object{object.NewInt(1)}),
+		})},
+		{`func f(n) { return [y + n for y in range(n)] }; f(3)`, object.NewList([]object.Object{
+			object.NewInt(3), object.NewInt(4), object.NewInt(5),
+		})},
+		{`total := 0
+		  for _, row := range [[1, 2], [3]] {
+			total += len([x for x in row if x > 1])
+		  }
+		  total`, object.NewInt(2)},
+	}
+	runTests(t, tests)
+}
+
+func TestComprehensionErrors(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`{x: x for x in [1]}`, "type error: map key must be a string (got int)"},
+		{`{[x] for x in [1]}`, "type error: list object is unhashable"},
+		{`[x for x in 1.5]`, "type error: object is not iterable (got float)"},
+	}
+	for _, tt := range tests {
+		_, err := run(ctx, tt.input)
+		require.NotNil(t, err)
+		require.Equal(t, tt.expectedErr, err.Error())
+	}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []testCase{
+		{`match 2 { case 1: "one"; case 2: "two" }`, object.NewString("two")},
+		{`match 3 { case 1, 2: "small"; default: "big" }`, object.NewString("big")},
+		{`match 3 { case 1: "one" }`, object.Nil},
+		{`v := -1; match v { case -1: "minus one" }`, object.NewString("minus one")},
+		{`match nil { case nil: "nil" }`, object.NewString("nil")},
+		{`match 5 { case x: x * 2 }`, object.NewInt(10)},
+		{`match 5 { case _: "any" }`, object.NewString("any")},
+		{`match "hi" { case int(i): i; case string(s): s + "!" }`, object.NewString("hi!")},
+		{`match 1.5 { case string(): "string"; case float(): "float" }`, object.NewString("float")},
+		{`v := [1, 2]
+		  match v { case [a]: a; case [a, b]: a + b; case [a, b, c]: a + b + c }`, object.NewInt(3)},
+		{`v := [1, 2, 3]; match v { case [first, ...rest]: [first, rest] }`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewList([]object.Object{object.NewInt(2), object.NewInt(3)}),
+		})},
+		{`v := []; match v { case [_, ...rest]: "some"; case [...rest]: rest }`, object.NewList([]object.Object{})},
+		{`v := [[1, 2], 3]; match v { case [[a, b], c]: a + b + c }`, object.NewInt(6)},
+		{`match "ab" { case [a, b]: "list"; default: "other" }`, object.NewString("other")},
+		{`v := [2, 1]; match v { case [a, b] if a < b: "asc"; case [a, b] if a > b: "desc" }`, object.NewString("desc")},
+		{`v := {name: "bob", age: 42}; match v { case {name: n, age: string(a)}: a; case {name: n, "age": int(a)}: n + string(a) }`,
+			object.NewString("bob42")},
+		{`v := {name: "bob"}; match v { case {name: n, age: a}: "both"; case {name: "bob"}: "bob" }`, object.NewString("bob")},
+		{`v := {kind: "circle", r: 2}
+		  match v {
+			case {kind: "square", side: s}: s * s
+			case {kind: "circle", r: r}: 3 * r * r
+		  }`, object.NewInt(12)},
+		{`struct Point { x, y }
+		  match Point(0, 5) { case Point(x=0, y=y): y; case Point(): "other" }`, object.NewInt(5)},
+		{`struct Point { x, y }
+		  match Point(1, 5) { case Point(x=0): "axis"; case Point(p): p.x }`, object.NewInt(1)},
+		{`struct Point { x, y }; v := [1]
+		  match v { case Point(): "point"; case list(l): len(l) }`, object.NewInt(1)},
+		{`obj := {a: 1}
+		  match 1 { case obj.a: "same" }`, object.NewString("same")},
+		{`x := 1
+		  match 2 { case x: x }
+		  x`, object.NewInt(1)},
+		{`func f(v) {
+			return match v {
+			case [a, b]: a * b
+			default: 0
+			}
+		  }
+		  [f([2, 3]), f(1)]`, object.NewList([]object.Object{object.NewInt(6), object.NewInt(0)})},
+		{`n := 0
+		  for i := 0; i < 3000; i++ {
+			match i {
+			case int(v) if v % 2 == 0: continue
+			case 7: break
+			}
+			n++
+		  }
+		  n`, object.NewInt(3)},
+	}
+	runTests(t, tests)
+}
+
+func TestSpread(t *testing.T) {
+	tests := []testCase{
+		{`func add(a, b, c) { a + b + c }; args := [1, 2, 3]; add(...args)`, object.NewInt(6)},
+		{`func add(a, b, c) { a + b + c }; add(1, ...[2, 3])`, object.NewInt(6)},
+		{`func add(a, b, c) { a + b + c }; add(...[1], 2, ...[3])`, object.NewInt(6)},
+		{`m := func(...n) { math.max(...n) }; m(4, 9)`, object.NewFloat(9)},
+		{`strings.join(...[["a", "b"], "-"])`, object.NewString("a-b")},
+		{`"a,b".split(...[","])`, object.NewList(
+			[]object.Object{object.NewString("a"), object.NewString("b")})},
+		{`[...[1, 2], 3, ...[4]]`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewInt(2), object.NewInt(3), object.NewInt(4)})},
+		{`[...{1}]`, object.NewList([]object.Object{object.NewInt(1)})},
+		{`[..."ab"]`, object.NewList(
+			[]object.Object{object.NewString("a"), object.NewString("b")})},
+		{`[...range 3]`, object.NewList(
+			[]object.Object{object.NewInt(0), object.NewInt(1), object.NewInt(2)})},
+		{`a := {x: 1, y: 2}; {...a, y: 3}`, object.NewMap(map[string]object.Object{
+			"x": object.NewInt(1), "y": object.NewInt(3)})},
+		{`a := {x: 1, y: 2}; {y: 3, ...a}`, object.NewMap(map[string]object.Object{
+			"x": object.NewInt(1), "y": object.NewInt(2)})},
+		{`a := {x: 1}; b := {z: 2}; {...a, y: 0, ...b}`, object.NewMap(map[string]object.Object{
+			"x": object.NewInt(1), "y": object.NewInt(0), "z": object.NewInt(2)})},
+		{`a := {x: 1}; b := {...a}; b["x"] = 2; a["x"]`, object.NewInt(1)},
+	}
+	runTests(t, tests)
+}
+
+func TestSpreadErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`func f(...a) { a }; f(...f)`, "type error: cannot spread function object (expected an iterable)"},
+		{`[...nil]`, "type error: cannot spread nil object (expected an iterable)"},
+		{`{...[1, 2]}`, "type error: cannot spread list into a map"},
+	}
+	for _, tt := range tests {
+		_, err := run(context.Background(), tt.input)
+		require.NotNil(t, err, tt.input)
+		require.Equal(t, tt.expectedErr, err.Error())
+	}
+}
+
+func TestKeywordArguments(t *testing.T) {
+	tests := []testCase{
+		{`func f(a, b) { [a, b] }; f(b=2, a=1)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewInt(2)})},
+		{`func f(a, b) { [a, b] }; f(1, b=2)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewInt(2)})},
+		{`func f(a, b=2, c=3) { [a, b, c] }; f(1, c=30)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewInt(2), object.NewInt(30)})},
+		{`func f(a, ...rest) { [a, rest] }; f(a=1)`, object.NewList(
+			[]object.Object{object.NewInt(1), object.NewList([]object.Object{})})},
+		{`func f(a, b=0) { a - b }; f(...[5], b=3)`, object.NewInt(2)},
+		{`m := {f: func(x, y=1) { x * y }}; m.f(3, y=4)`, object.NewInt(12)},
+		{`func f(x, scale=1) { x * scale }; 3 | f(scale=2)`, object.NewInt(6)},
+		{`struct P { x, y = 0 }; P(y=2, x=1).y`, object.NewInt(2)},
+		{`func f(x, suffix="") { return x + suffix }; func g() { defer f("a", suffix="b"); 1 }; g()`, object.NewInt(1)},
+		{`func f(x) { x }; f(x=nil)`, object.Nil},
+	}
+	runTests(t, tests)
+}
+
+func TestKeywordArgumentErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`func f(a) { a }; f(b=1)`, "args error: function \"f\" got an unexpected keyword argument \"b\""},
+		{`func f(a) { a }; f(1, a=1)`, "args error: function \"f\" got multiple values for argument \"a\""},
+		{`func f(a, b) { a }; f(b=1)`, "args error: function \"f\" missing required argument \"a\""},
+		{`func f(a) { a }; f(1, 2, a=1)`, "args error: function \"f\" takes 1 argument (2 given)"},
+		{`func(a) { a }(c=1)`, "args error: function got an unexpected keyword argument \"c\""},
+		{`len("a", x=1)`, "type error: len() does not accept keyword arguments"},
+		{`struct P { x }; P(z=1)`, "type error: struct P has no field \"z\""},
+		{`struct P { x }; P(1, x=2)`, "args error: P() got multiple values for field \"x\""},
+	}
+	for _, tt := range tests {
+		_, err := run(context.Background(), tt.input)
+		require.NotNil(t, err, tt.input)
+		require.Equal(t, tt.expectedErr, err.Error())
+	}
+}
+
+func TestKeywordBuiltin(t *testing.T) {
+	greet := object.NewKeywordBuiltin("greet", func(ctx context.Context, kwargs *object.Map, args ...object.Object) object.Object {
+		greeting := kwargs.GetWithDefault("greeting", object.NewString("hello"))
+		return object.NewString(greeting.(*object.String).Value() + " " + args[0].(*object.String).Value())
+	})
+	tests := []testCase{
+		{`greet("bob")`, object.NewString("hello bob")},
+		{`greet("bob", greeting="hi")`, object.NewString("hi bob")},
+		{`"bob" | greet(greeting="hey")`, object.NewString("hey bob")},
+	}
+	for _, tt := range tests {
+		result, err := run(context.Background(), tt.input, runOpts{
+			Globals: map[string]any{"greet": greet},
+		})
+		require.Nil(t, err, tt.input)
+		require.Equal(t, tt.expected, result, tt.input)
 	}
-	runTests(t, tests)
 }
 
 func TestContainers(t *testing.T) {
@@ -1403,6 +2262,8 @@ func TestIncorrectArgCount(t *testing.T) {
 		{`func ex(x, y) { 1 }; ex(1, 2, 3)`, "args error: function \"ex\" takes 2 arguments (3 given)"},
 		{`func ex() { 1 }; [1, 2].filter(ex)`, "args error: function \"ex\" takes 0 arguments (1 given)"},
 		{`func ex() { 1 }; "foo" | ex`, "args error: function \"ex\" takes 0 arguments (1 given)"},
+		{`func ex(x, ...rest) { 1 }; ex()`, "args error: function \"ex\" takes at least 1 argument (0 given)"},
+		{`func ex(x, y, ...rest) { 1 }; ex(1)`, "args error: function \"ex\" takes at least 2 arguments (1 given)"},
 		{`"foo" | "bar"`, "type error: object is not callable (got string)"},
 	}
 	for _, tt := range tests {
@@ -1790,10 +2651,26 @@ func TestImports(t *testing.T) {
 		{`import data; data.mydata["count"] = 3; data.mydata["count"]`, object.NewInt(3)},
 		{`import data as d; d.mydata["count"]`, object.NewInt(1)},
 		{`import math as m; m.min(3,-7)`, object.NewFloat(-7)},
+		{`import private; private.double(3)`, object.NewInt(6)},
+		{`from private import double; double(4)`, object.NewInt(8)},
 	}
 	runTests(t, tests)
 }
 
+func TestFromImportBuiltinPrivateName(t *testing.T) {
+	// Builtins of a module are importable regardless of their names, the
+	// same as they are accessible as attributes of the module
+	ext := object.NewBuiltinsModule("ext", map[string]object.Object{
+		"_version": object.NewString("1.0"),
+	})
+	result, err := run(context.Background(), `from ext import _version; [_version, ext._version]`,
+		runOpts{Globals: map[string]any{"ext": ext}})
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewString("1.0"), object.NewString("1.0"),
+	}), result)
+}
+
 func TestFromImport(t *testing.T) {
 	tests := []testCase{
 		{`from a.data import mapValue; mapValue["3"]`, object.NewInt(3)},
@@ -1827,6 +2704,8 @@ func TestBadImports(t *testing.T) {
 		{`from a.b import c`, `import error: module "a/b" not found`},
 		{`from a.b import c as d`, `import error: module "a/b" not found`},
 		{`from math import foo`, `import error: cannot import name "foo" from "math"`},
+		{`from private import _helper`, `import error: cannot import private name "_helper" from "private"`},
+		{`import private; private._rate`, `type error: attribute "_rate" not found on module object`},
 		{`from math`, `parse error: from-import is missing import statement`},
 		{`from math import`, `parse error: unexpected end of file while parsing a from-import statement (expected identifier)`},
 		{`from math import min as`, `parse error: unexpected end of file while parsing a from-import statement (expected identifier)`},
@@ -2062,6 +2941,119 @@ func TestChannelErrors(t *testing.T) {
 	}
 }
 
+func TestSelect(t *testing.T) {
+	tests := []testCase{
+		{`c := chan(1); c <- 1
+		  x := 0
+		  select {
+		  case v := <-c:
+			x = v
+		  }
+		  x`, object.NewInt(1)},
+		{`a := chan(1); b := chan(1); b <- "b"
+		  x := ""
+		  select {
+		  case v := <-a:
+			x = "a"
+		  case v := <-b:
+			x = v
+		  }
+		  x`, object.NewString("b")},
+		{`c := chan(1)
+		  select {
+		  case c <- 42:
+		  }
+		  <-c`, object.NewInt(42)},
+		{`c := chan()
+		  x := 0
+		  select {
+		  case v := <-c:
+			x = 1
+		  default:
+			x = 2
+		  }
+		  x`, object.NewInt(2)},
+		{`c := chan(); close(c)
+		  result := nil
+		  select {
+		  case v, ok := <-c:
+			result = [v, ok]
+		  }
+		  result`, object.NewList([]object.Object{object.Nil, object.False})},
+		{`c := chan(1); c <- 3
+		  result := nil
+		  select {
+		  case v, ok := <-c:
+			result = [v, ok]
+		  }
+		  result`, object.NewList([]object.Object{object.NewInt(3), object.True})},
+		{`c := chan(1); c <- 1
+		  x := 0
+		  select {
+		  case <-c:
+			x = 1
+		  }
+		  x`, object.NewInt(1)},
+		{`x := 0
+		  select {
+		  case <-nil:
+			x = 1
+		  default:
+			x = 2
+		  }
+		  x`, object.NewInt(2)},
+		{`func f(c) {
+			select {
+			case v := <-c:
+				return v * 2
+			}
+		  }
+		  c := chan(1); c <- 21
+		  f(c)`, object.NewInt(42)},
+		{`c := chan(); done := chan()
+		  go func() { for i := 0; i < 3; i++ { c <- i }; close(done) }()
+		  results := []
+		  for {
+			select {
+			case v := <-c:
+				results.append(v)
+				continue
+			case <-done:
+			}
+			break
+		  }
+		  results`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewInt(1), object.NewInt(2),
+		})},
+	}
+	runTests(t, tests)
+}
+
+func TestSelectErrors(t *testing.T) {
+	ctx := context.Background()
+	type testCase struct {
+		input     string
+		expectErr string
+	}
+	tests := []testCase{
+		{`c := chan(1); close(c); select { case c <- 1: }`, "exec error: send on closed channel"},
+		{`select { case <-1: }`, "type error: object is not a channel (got int)"},
+	}
+	for _, tt := range tests {
+		_, err := run(ctx, tt.input)
+		require.NotNil(t, err)
+		require.Equal(t, tt.expectErr, err.Error())
+	}
+}
+
+func TestSelectCancellation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	_, err := run(ctx, `c := chan(); select { case <-c: }`)
+	require.NotNil(t, err)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
 func TestGoStatement(t *testing.T) {
 	tests := []testCase{
 		{`go func() { 1 }()`, object.Nil},
@@ -2101,6 +3093,395 @@ func TestSpawn(t *testing.T) {
 	runTests(t, tests)
 }
 
+func TestGenerators(t *testing.T) {
+	tests := []testCase{
+		{`func gen(n) { for i := 0; i < n; i++ { yield i } }
+		  list(gen(3))`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewInt(1), object.NewInt(2),
+		})},
+		{`func gen() { yield "a"; yield; yield "c" }
+		  results := []
+		  for x in gen() { results.append(x) }
+		  results`, object.NewList([]object.Object{
+			object.NewString("a"), object.Nil, object.NewString("c"),
+		})},
+		{`func gen() { yield 1; return 2 }
+		  g := gen()
+		  [g.next(), g.next(), g.next()]`, object.NewList([]object.Object{
+			object.NewInt(1), object.Nil, object.Nil,
+		})},
+		{`func pages() {
+			for page in [[1, 2], [], [3]] {
+				for item in page { yield item }
+			}
+		  }
+		  pages() | list`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(3),
+		})},
+		{`func naturals() { i := 0; for { yield i; i++ } }
+		  func evens(items) { for x in items { if x % 2 == 0 { yield x } } }
+		  g := evens(naturals())
+		  [g.next(), g.next(), g.next()]`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewInt(2), object.NewInt(4),
+		})},
+		{`func gen(n) { for i := 0; i < n; i++ { yield i } }
+		  [x * 10 for x in gen(4) if x > 1]`, object.NewList([]object.Object{
+			object.NewInt(20), object.NewInt(30),
+		})},
+		{`func gen() { yield 1; yield 2 }
+		  [...gen(), ...gen()]`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2), object.NewInt(1), object.NewInt(2),
+		})},
+		{`func gen() { x := 0; inc := func() { x++ }; inc(); yield x; inc(); yield x }
+		  list(gen())`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2),
+		})},
+		{`func gen() {
+			try { yield 1; error("oops") } catch e { yield e.message() }
+			yield 3
+		  }
+		  list(gen())`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewString("oops"), object.NewInt(3),
+		})},
+		{`log := []
+		  func gen() { defer log.append("done"); yield 1; yield 2 }
+		  log.append(list(gen()))
+		  log`, object.NewList([]object.Object{
+			object.NewString("done"),
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)}),
+		})},
+		{`func gen() { yield 1; yield 2 }
+		  g := gen()
+		  for x in g { break }
+		  list(g)`, object.NewList([]object.Object{object.NewInt(2)})},
+		{`func gen() { yield 1 }
+		  type(gen())`, object.NewString("generator")},
+		{`func gen() { yield 1; yield 2 }
+		  g := gen()
+		  message := try(func() { error("%v", g) }, func(e) { e.message() })
+		  [message, list(g)]`, object.NewList([]object.Object{
+			object.NewString("<nil>"),
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)}),
+		})},
+		{`func gen() { if false { yield 1 } }
+		  type(gen())`, object.NewString("generator")},
+		{`func gen() { if false { yield 1 } }
+		  list(gen())`, object.NewList(nil)},
+		{`func gen() { return 5; yield 1 }
+		  list(gen())`, object.NewList(nil)},
+		{`func gen(n) { for i := 0; i < n; i++ { yield i } }
+		  list(spawn(gen, 2).wait())`, object.NewList([]object.Object{
+			object.NewInt(0), object.NewInt(1),
+		})},
+		{`func gen() { yield 1; yield 2 }
+		  g := gen()
+		  spawn(func() { list(g) }).wait()`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2),
+		})},
+	}
+	runTests(t, tests)
+}
+
+func TestGeneratorErrors(t *testing.T) {
+	ctx := context.Background()
+	type testCase struct {
+		input     string
+		expectErr string
+	}
+	tests := []testCase{
+		{`func gen() { yield 1; error("boom") }; list(gen())`, "boom"},
+		{`func gen() { yield 1; error("boom") }; for x in gen() {}`, "boom"},
+		{`var g = nil; func gen() { for x in g { yield x } }; g = gen(); list(g)`,
+			"eval error: generator is already running"},
+	}
+	for _, tt := range tests {
+		_, err := run(ctx, tt.input)
+		require.NotNil(t, err)
+		require.Equal(t, tt.expectErr, err.Error())
+	}
+	result, err := run(ctx, `func gen() { yield 1; error("boom") }
+	  r := []
+	  try { for x in gen() { r.append(x) } } catch e { r.append(e.message()) }
+	  r`)
+	require.Nil(t, err)
+	require.Equal(t, object.NewList([]object.Object{
+		object.NewInt(1), object.NewString("boom"),
+	}), result)
+}
+
+func TestOptionalChaining(t *testing.T) {
+	tests := []testCase{
+		{`a := {b: {c: 1}}; a?.b?.c`, object.NewInt(1)},
+		{`a := {b: {c: 1}}; a?.x?.c`, object.Nil},
+		{`a := nil; a?.b`, object.Nil},
+		{`a := nil; a?.b.c.d`, object.Nil},
+		{`a := nil; a?.b(1, 2)`, object.Nil},
+		{`"abc"?.to_upper()`, object.NewString("ABC")},
+		{`"abc"?.missing()`, object.Nil},
+		{`a := {b: [1, 2]}; a?.["b"]?.[1]`, object.NewInt(2)},
+		{`a := {b: [1, 2]}; a?.["b"]?.[5]`, object.Nil},
+		{`a := {}; a?.["b"]`, object.Nil},
+		{`a := nil; a?.[0]`, object.Nil},
+		{`a := {b: nil}; a?.b?.to_upper() ?? "default"`, object.NewString("default")},
+		{`calls := 0
+		  func f() { calls++; return 0 }
+		  a := nil
+		  a?.[f()]
+		  a?.b(f())
+		  calls`, object.NewInt(0)},
+	}
+	runTests(t, tests)
+}
+
+func TestOptionalChainingErrors(t *testing.T) {
+	ctx := context.Background()
+	type testCase struct {
+		input     string
+		expectErr string
+	}
+	tests := []testCase{
+		{`a := {b: nil}; a?.b.c`, "type error: attribute \"c\" not found on nil object"},
+		{`a := [1]; a?.["x"]`, "type error: list index must be an int (got string)"},
+		{`a := 1; a?.[0]`, "type error: object is not a container (got int)"},
+	}
+	for _, tt := range tests {
+		_, err := run(ctx, tt.input)
+		require.NotNil(t, err)
+		require.Equal(t, tt.expectErr, err.Error())
+	}
+}
+
+func TestNilCoalescing(t *testing.T) {
+	tests := []testCase{
+		{`nil ?? 5`, object.NewInt(5)},
+		{`0 ?? 5`, object.NewInt(0)},
+		{`false ?? 5`, object.False},
+		{`"" ?? 5`, object.NewString("")},
+		{`nil ?? nil ?? 3`, object.NewInt(3)},
+		{`x := 0
+		  func f() { x = 1; return 2 }
+		  [1 ?? f(), x]`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(0)})},
+		{`m := {}; m?.name ?? "anonymous"`, object.NewString("anonymous")},
+	}
+	runTests(t, tests)
+}
+
+func TestTypeAnnotationsAreIgnored(t *testing.T) {
+	tests := []testCase{
+		{`func add(x: int, y: int = 2) -> int { x + y }; add(1)`, object.NewInt(3)},
+		{`func f(x: int) -> int { x }; f("not checked")`, object.NewString("not checked")},
+		{`var x: int | nil = nil; x`, object.Nil},
+		{`const y: string = "a"; y`, object.NewString("a")},
+		{`func(...rest: list) -> int { len(rest) }(1, 2)`, object.NewInt(2)},
+	}
+	runTests(t, tests)
+}
+
+func TestLabeledBreakContinue(t *testing.T) {
+	tests := []testCase{
+		{`found := []
+		outer: for i := 0; i < 3; i++ {
+			for j := 0; j < 3; j++ {
+				if j > i { continue outer }
+				if i == 2 { break outer }
+				found.append([i, j])
+			}
+		}
+		found`, object.NewList([]object.Object{
+			object.NewList([]object.Object{object.NewInt(0), object.NewInt(0)}),
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(0)}),
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(1)}),
+		})},
+		{`found := []
+		outer: for a in [1, 2, 3] {
+			for _, b := range [0, 1, 2] {
+				if a == b { continue outer }
+				if a == 3 { break outer }
+				found.append(a * 10 + b)
+			}
+		}
+		found`, object.NewList([]object.Object{
+			object.NewInt(10),
+			object.NewInt(20), object.NewInt(21),
+		})},
+		{`n := 0
+		outer: for i in range(2000) {
+			for j in range(3) {
+				switch i { case i: n++; continue outer }
+			}
+		}
+		n`, object.NewInt(2000)},
+		{`n := 0
+		outer: for i in range(2000) {
+			for j in range(3) {
+				match i { case _: n++; continue outer }
+			}
+		}
+		n`, object.NewInt(2000)},
+		{`finals := 0
+		outer: for i in range(3) {
+			for j in range(3) {
+				try { continue outer } finally { finals++ }
+			}
+		}
+		finals`, object.NewInt(3)},
+		{`func f() {
+			outer: for i in range(3) {
+				for j in range(3) {
+					if i * j == 2 { return [i, j] }
+				}
+			}
+		}
+		f()`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)})},
+	}
+	runTests(t, tests)
+}
+
+func TestBigIntAndDecimal(t *testing.T) {
+	tests := []testCase{
+		{`string(bigint(9223372036854775807) + 1)`, object.NewString("9223372036854775808")},
+		{`string(2 ** bigint(100))`, object.NewString("1267650600228229401496703205376")},
+		{`string(-bigint("123456789012345678901234567890"))`, object.NewString("-123456789012345678901234567890")},
+		{`string(decimal("0.1") + decimal("0.2"))`, object.NewString("0.3")},
+		{`decimal("0.1") + decimal("0.2") == decimal("0.3")`, object.True},
+		{`string(-decimal("12.50") * 3)`, object.NewString("-37.50")},
+		{`string(decimal("100.00") / 3)`, object.NewString("33.3333333333333333")},
+		{`string(decimal(0.1))`, object.NewString("0.1")},
+		{`string(decimal("2.675").round(2))`, object.NewString("2.68")},
+		{`int(decimal("-7.9"))`, object.NewInt(-7)},
+		{`float(decimal("7.25"))`, object.NewFloat(7.25)},
+		{`int(bigint("42"))`, object.NewInt(42)},
+		{`bigint(3) > 2.5 && decimal("2.5") < 3`, object.True},
+		{`type(bigint()) + " " + type(decimal())`, object.NewString("bigint decimal")},
+		{`total := decimal(0)
+		for _, price := range ["19.99", "5.01", "0.10"] { total += decimal(price) }
+		string(total)`, object.NewString("25.10")},
+		{`json.marshal([decimal("12.50"), bigint("123456789012345678901234567890")])`,
+			object.NewString("[12.50,123456789012345678901234567890]")},
+		{`type(json.unmarshal("123456789012345678901234567890"))`, object.NewString("bigint")},
+		{`value := "[1234567890.123456789012,98765432109876543210]"
+		json.marshal(json.unmarshal(value)) == value`, object.True},
+	}
+	runTests(t, tests)
+}
+
+func TestBigIntAndDecimalErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`bigint(1) / 0`, "value error: division by zero"},
+		{`decimal("1.5") + 1.5`, "type error: unsupported operation for decimal: + on type float (use decimal() to convert the float)"},
+		{`decimal("abc")`, "value error: invalid literal for decimal(): \"abc\""},
+		{`bigint("1.5")`, "value error: invalid literal for bigint(): \"1.5\""},
+		{`int(bigint(2) ** 64)`, "value error: int() argument is out of range (18446744073709551616 given)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
+const hooksPrelude = `
+func money(cents) {
+	return {
+		cents: cents,
+		__add__: func(a, b) { money(a.cents + b.cents) },
+		__mul__: func(a, n) { money(a.cents * n) },
+		__eq__: func(a, b) { type(b) == "map" && a.cents == b.cents },
+		__cmp__: func(a, b) { a.cents - b.cents },
+		__str__: func(self) { sprintf("$%d.%02d", self.cents / 100, self.cents % 100) },
+	}
+}
+func vec(...elems) {
+	return {
+		elems: elems,
+		__len__: func(self) { len(self.elems) },
+		__getitem__: func(self, i) { self.elems[i] },
+		__iter__: func(self) { self.elems },
+	}
+}
+`
+
+func TestOperatorHooks(t *testing.T) {
+	tests := []testCase{
+		{`string(money(150) + money(275))`, object.NewString("$4.25")},
+		{`(money(150) * 3).cents`, object.NewInt(450)},
+		{`money(1) == money(1)`, object.True},
+		{`money(1) == money(2)`, object.False},
+		{`money(1) != money(2)`, object.True},
+		{`money(1) == 1`, object.False},
+		{`money(1) < money(2)`, object.True},
+		{`money(3) <= money(2)`, object.False},
+		{`money(3) > money(2)`, object.True},
+		{`'total: {money(1999)}'`, object.NewString("total: $19.99")},
+		{`len(vec(1, 2, 3))`, object.NewInt(3)},
+		{`vec(1, 2, 3)[1]`, object.NewInt(2)},
+		{`vec(1, 2, 3)?.[2]`, object.NewInt(3)},
+		{`result := []; for v in vec(4, 5) { result.append(v) }; result`,
+			object.NewList([]object.Object{object.NewInt(4), object.NewInt(5)})},
+		{`[v * 2 for v in vec(1, 2)]`,
+			object.NewList([]object.Object{object.NewInt(2), object.NewInt(4)})},
+		{`struct Interval { lo, hi, __len__ = func(self) { self.hi - self.lo } }
+		len(Interval(2, 7))`, object.NewInt(5)},
+		{`struct Point { x, y, __add__ = func(a, b) { [a.x + b.x, a.y + b.y] } }
+		Point(1, 2) + Point(3, 4)`,
+			object.NewList([]object.Object{object.NewInt(4), object.NewInt(6)})},
+		// A hook is used when its value is on the right of the operator
+		{`({cents: 5} + money(1)).cents`, object.NewInt(6)},
+		// Values that aren't callable are not hooks
+		{`m := {__len__: 5}; len(m)`, object.NewInt(1)},
+		{`list(vec(1, 2))`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)})},
+		{`struct Pair { a, b, __iter__ = func(self) { [self.a, self.b] } }
+		list(Pair(1, 2))`, object.NewList([]object.Object{object.NewInt(1), object.NewInt(2)})},
+		{`2 in vec(1, 2)`, object.True},
+		{`3 not in vec(1, 2)`, object.True},
+		{`money(5) in [money(1), money(5)]`, object.True},
+		{`money(5) in [money(1), 5]`, object.False},
+		{`money(5) in vec(money(5))`, object.True},
+		{`{cents: 1} in [money(1)]`, object.True},
+		{`"elems" in {elems: 1}`, object.True},
+		{`[m.cents for m in sorted([money(3), money(1), money(2)])]`,
+			object.NewList([]object.Object{object.NewInt(1), object.NewInt(2), object.NewInt(3)})},
+		{`l := [money(2), money(1)]; l.sort(); l[0].cents`, object.NewInt(1)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			result, err := run(context.Background(), hooksPrelude+tt.input)
+			require.Nil(t, err)
+			require.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestOperatorHookErrors(t *testing.T) {
+	tests := []struct {
+		input       string
+		expectedErr string
+	}{
+		{`m := {__len__: func(self) { "x" }}; len(m)`, "type error: __len__ must return an int (got string)"},
+		{`m := {__cmp__: func(a, b) { nil }}; m < m`, "type error: __cmp__ must return an int (got nil)"},
+		{`m := {__str__: func(self) { 1 }}; string(m)`, "type error: __str__ must return a string (got int)"},
+		{`m := {__add__: func(a, b) { error("nope") }}; m + 1`, "nope"},
+		{`m := {__iter__: func(self) { error("nope") }}; list(m)`, "nope"},
+		{`m := {__iter__: func(self) { 1.5 }}; 1 in m`, "type error: object is not iterable (got float)"},
+		{`m := {__eq__: func(a, b) { error("nope") }}; m in [1]`, "nope"},
+		{`sorted([{a: 1}, {b: 2}])`, "type error: sorted() encountered a non-comparable item (map)"},
+		{`m := {__cmp__: func(a, b) { "x" }}; sorted([m, m])`, "type error: __cmp__ must return an int (got string)"},
+		{`{a: 1} + {b: 2}`, "type error: unsupported operation for map: +"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := run(context.Background(), tt.input)
+			require.NotNil(t, err)
+			require.Equal(t, tt.expectedErr, err.Error())
+		})
+	}
+}
+
 func TestMaps(t *testing.T) {
 	tests := []testCase{
 		{`{"a": 1}`, object.NewMap(map[string]object.Object{
@@ -2406,6 +3787,152 @@ func TestDeeplyNestedRangeLoopBreak(t *testing.T) {
 	require.Equal(t, object.NewInt(45), result)
 }
 
+func TestTailCalls(t *testing.T) {
+	// Each of these recurses well beyond MaxFrameDepth
+	tests := []testCase{
+		{`func count(n, acc) {
+			if n == 0 { return acc }
+			return count(n - 1, acc + 1)
+		  }
+		  count(100000, 0)`, object.NewInt(100000)},
+		{`func is_even(n) { if n == 0 { return true }; return is_odd(n - 1) }
+		  func is_odd(n) { if n == 0 { return false }; return is_even(n - 1) }
+		  [is_even(100000), is_odd(100001)]`, object.NewList([]object.Object{
+			object.True, object.True,
+		})},
+		{`func sum_by(step) {
+			func loop(n, acc) {
+				if n == 0 { return acc }
+				return loop(n - 1, acc + step)
+			}
+			return loop(50000, 0)
+		  }
+		  sum_by(2)`, object.NewInt(100000)},
+		{`func count(n, acc) {
+			if n == 0 { return acc }
+			return (n - 1) | count(acc + 1)
+		  }
+		  count(50000, 0)`, object.NewInt(50000)},
+		{`func walk(node, depth=0) {
+			if node == nil { return depth }
+			return walk(node.get("child"), depth + 1)
+		  }
+		  tree := nil
+		  for i := 0; i < 20000; i++ { tree = {child: tree} }
+		  walk(tree)`, object.NewInt(20000)},
+		{`func first(...items) { return items[0] }
+		  func f(x) { return first(x, 2, 3) }
+		  f(1)`, object.NewInt(1)},
+		{`func f(s) { return len(s) }; f("abc")`, object.NewInt(3)},
+		{`func f(n) { return n == 0 ? 0 : f(n-1) }; f(5000)`, object.NewInt(0)},
+		{`func f(n, acc) { n == 0 ? acc : f(n - 1, acc + 1) }; f(100000, 0)`, object.NewInt(100000)},
+		{`func f(n) {
+			return if n == 0 { "done" } else if n % 2 == 0 { f(n - 1) } else { (n - 1) | f }
+		  }
+		  f(100000)`, object.NewString("done")},
+		{`func f(n) { if n > 0 { f(n - 1) } else { len("abc") } }; f(100000)`, object.NewInt(3)},
+	}
+	runTests(t, tests)
+}
+
+func TestTailCallsKeepFrames(t *testing.T) {
+	// Calls in tail position that have to return to their caller's frame
+	tests := []testCase{
+		{`steps := []
+		  func g() { steps.append("g"); return 1 }
+		  func f() {
+			defer func() { steps.append("deferred") }()
+			return g()
+		  }
+		  f()
+		  steps`, object.NewList([]object.Object{
+			object.NewString("g"), object.NewString("deferred"),
+		})},
+		{`func g() { error("oops") }
+		  func f() {
+			try { return g() } catch { return "caught" }
+		  }
+		  f()`, object.NewString("caught")},
+		{`func gen() { yield 1; yield 2 }
+		  func f() { return gen() }
+		  result := []
+		  for x in f() { result.append(x) }
+		  result`, object.NewList([]object.Object{
+			object.NewInt(1), object.NewInt(2),
+		})},
+		{`func f() {
+			x := 42
+			g := func() { return func() { x }() }
+			return g()
+		  }
+		  func other() { y := 7; return f() }
+		  other()`, object.NewInt(42)},
+	}
+	runTests(t, tests)
+}
+
+func TestRecursionLimit(t *testing.T) {
+	ctx := context.Background()
+	// Each call leaves a value on the stack until the next call returns
+	ast, err := parser.Parse(ctx, `func f(n) { return 1 + f(n + 1) }; f(0)`)
+	require.Nil(t, err)
+	main, err := compiler.Compile(ast)
+	require.Nil(t, err)
+
+	_, err = Run(ctx, main)
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "recursion limit exceeded (max frame depth 1024)")
+
+	_, err = Run(ctx, main, WithMaxFrameDepth(50))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "recursion limit exceeded (max frame depth 50)")
+
+	_, err = Run(ctx, main, WithMaxStackDepth(100))
+	require.NotNil(t, err)
+	require.Contains(t, err.Error(), "recursion limit exceeded (max stack depth 100)")
+}
+
+func TestDeepRecursion(t *testing.T) {
+	ctx := context.Background()
+	ast, err := parser.Parse(ctx, `
+	func sum(n) {
+		if n == 0 { return 0 }
+		return n + sum(n - 1)
+	}
+	sum(20000)
+	`)
+	require.Nil(t, err)
+	main, err := compiler.Compile(ast)
+	require.Nil(t, err)
+	result, err := Run(ctx, main, WithMaxFrameDepth(30000), WithMaxStackDepth(100000))
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(200010000), result)
+}
+
+func TestStackGrowth(t *testing.T) {
+	// A list literal pushes all of its items onto the stack before building
+	items := make([]string, 5000)
+	for i := range items {
+		items[i] = fmt.Sprintf("%d", i)
+	}
+	result, err := run(context.Background(), fmt.Sprintf("len([%s])", strings.Join(items, ", ")))
+	require.Nil(t, err)
+	require.Equal(t, object.NewInt(5000), result)
+}
+
+func TestCloneStacksStartSmall(t *testing.T) {
+	ctx := context.Background()
+	vm, err := newVM(ctx, `func f(n) { return n < 1 ? 0 : 1 + f(n - 1) }; f(500)`)
+	require.Nil(t, err)
+	require.Nil(t, vm.Run(ctx))
+	require.Greater(t, len(vm.frames), 500)
+
+	clone, err := vm.Clone()
+	require.Nil(t, err)
+	require.Len(t, clone.frames, 1)
+	require.Len(t, clone.stack, 0)
+}
+
 func TestClonedVMOS(t *testing.T) {
 	code := `os.stdout.write("hello\n")`
 	ctx := context.Background()